@@ -138,6 +138,25 @@ func (ab *ActivityBuilder) RegisterModels(models ...interface{}) *ActivityBuilde
 	return ab
 }
 
+// RegisterAllModels registers every model of the presets builder that is not registered yet,
+// so all the creates, updates and deletes made in the admin are logged.
+// Call it after the models are configured, as it wraps their current saver and deleter.
+func (ab *ActivityBuilder) RegisterAllModels(b *presets.Builder, excludes ...interface{}) *ActivityBuilder {
+	excluded := map[reflect.Type]bool{
+		reflect.Indirect(reflect.ValueOf(ab.logModel)).Type(): true,
+	}
+	for _, e := range excludes {
+		excluded[reflect.Indirect(reflect.ValueOf(getBasicModel(e))).Type()] = true
+	}
+	for _, m := range b.GetModels() {
+		if excluded[reflect.Indirect(reflect.ValueOf(m.NewModel())).Type()] {
+			continue
+		}
+		ab.RegisterModel(m)
+	}
+	return ab
+}
+
 // Model register a model and return model builder
 func (ab *ActivityBuilder) RegisterModel(m interface{}) (mb *ModelBuilder) {
 	if m, exist := ab.GetModelBuilder(m); exist {
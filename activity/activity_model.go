@@ -26,10 +26,11 @@ type ModelBuilder struct {
 	presetModel *presets.ModelBuilder // preset model builder
 	skip        uint8                 // skip the prefined data operator of the presetModel
 
-	keys          []string                     // primary keys
-	ignoredFields []string                     // ignored fields
-	typeHanders   map[reflect.Type]TypeHandler // type handlers
-	link          func(interface{}) string     // display the model link on the admin detail page
+	keys           []string                     // primary keys
+	ignoredFields  []string                     // ignored fields
+	redactedFields []string                     // fields logged without values
+	typeHanders    map[reflect.Type]TypeHandler // type handlers
+	link           func(interface{}) string     // display the model link on the admin detail page
 }
 
 // @snippet_end
@@ -104,7 +105,6 @@ func (mb *ModelBuilder) SkipDelete() *ModelBuilder {
 	return mb
 }
 
-
 // EnableActivityInfoTab enable activity info tab on the given model's editing page
 func (mb *ModelBuilder) EnableActivityInfoTab() *ModelBuilder {
 	if mb.presetModel == nil {
@@ -157,6 +157,30 @@ func (mb *ModelBuilder) SetIgnoredFields(fields ...string) *ModelBuilder {
 	return mb
 }
 
+// AddRedactedFields adds the names of the fields that are logged as changed without their values, besides DefaultRedactedFields
+func (mb *ModelBuilder) AddRedactedFields(fields ...string) *ModelBuilder {
+	mb.redactedFields = append(mb.redactedFields, fields...)
+	return mb
+}
+
+func (mb *ModelBuilder) isRedacted(field reflect.StructField) bool {
+	if field.Tag.Get("activity") == "redact" {
+		return true
+	}
+	name := strings.ToLower(field.Name)
+	for _, f := range DefaultRedactedFields {
+		if strings.Contains(name, strings.ToLower(f)) {
+			return true
+		}
+	}
+	for _, f := range mb.redactedFields {
+		if f == field.Name {
+			return true
+		}
+	}
+	return false
+}
+
 // AddTypeHanders add type handers for the model builder
 func (mb *ModelBuilder) AddTypeHanders(v interface{}, f TypeHandler) *ModelBuilder {
 	if mb.typeHanders == nil {
@@ -260,7 +284,7 @@ func (mb *ModelBuilder) AddViewRecord(creator interface{}, v interface{}, db *go
 
 // AddDeleteRecord	add delete record
 func (mb *ModelBuilder) AddDeleteRecord(creator interface{}, v interface{}, db *gorm.DB) error {
-	return mb.save(creator, ActivityDelete, v, db, "")
+	return mb.save(creator, ActivityDelete, v, db, mb.valuesDiffs(v, false))
 }
 
 // AddSaverRecord will save a create log or a edit log
@@ -274,7 +298,25 @@ func (mb *ModelBuilder) AddSaveRecord(creator interface{}, now interface{}, db *
 
 // AddCreateRecord add create record
 func (mb *ModelBuilder) AddCreateRecord(creator interface{}, v interface{}, db *gorm.DB) error {
-	return mb.save(creator, ActivityCreate, v, db, "")
+	return mb.save(creator, ActivityCreate, v, db, mb.valuesDiffs(v, true))
+}
+
+// valuesDiffs are the diffs of the values of a created record from the zero value, or of a deleted one to it
+func (mb *ModelBuilder) valuesDiffs(v interface{}, created bool) string {
+	zero := reflect.New(mb.typ).Interface()
+	old, now := zero, v
+	if !created {
+		old, now = v, zero
+	}
+	diffs, err := mb.Diff(old, now)
+	if err != nil || len(diffs) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(diffs)
+	if err != nil {
+		return ""
+	}
+	return string(b)
 }
 
 // AddEditRecord add edit record
@@ -351,7 +393,7 @@ func (mb *ModelBuilder) save(creator interface{}, action string, v interface{},
 		return nil
 	}
 
-	if action == ActivityEdit {
+	if diffs != "" {
 		log.SetModelDiffs(diffs)
 	}
 
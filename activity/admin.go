@@ -38,7 +38,7 @@ func (ab *ActivityBuilder) configureAdmin(b *presets.Builder) {
 	ab.lmb = mb
 	listing.Field("CreatedAt").Label(Messages_en_US.ModelCreatedAt).ComponentFunc(
 		func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
-			return h.Td(h.Text(obj.(*ActivityLog).CreatedAt.Format("2006-01-02 15:04:05 MST")))
+			return h.Td(h.Text(presets.FormatTime(ctx.R, obj.(*ActivityLog).CreatedAt)))
 		},
 	)
 	listing.Field("ModelKeys").Label(Messages_en_US.ModelKeys)
@@ -167,7 +167,7 @@ func (ab *ActivityBuilder) configureAdmin(b *presets.Builder) {
 						h.Tr(h.Td(h.Text(msgr.ModelLabel)), h.Td(h.Text(record.GetModelLabel()))),
 						h.Tr(h.Td(h.Text(msgr.ModelKeys)), h.Td(h.Text(record.GetModelKeys()))),
 						h.If(record.GetModelLink() != "", h.Tr(h.Td(h.Text(msgr.ModelLink)), h.Td(h.Text(record.GetModelLink())))),
-						h.Tr(h.Td(h.Text(msgr.ModelCreatedAt)), h.Td(h.Text(record.GetCreatedAt().Format("2006-01-02 15:04:05 MST")))),
+						h.Tr(h.Td(h.Text(msgr.ModelCreatedAt)), h.Td(h.Text(presets.FormatTime(ctx.R, record.GetCreatedAt())))),
 					),
 				),
 			).Attr("style", "margin-top:15px;margin-bottom:15px;"))
@@ -14,6 +14,10 @@ var (
 	DefaultIgnoredFields = []string{"ID", "UpdatedAt", "DeletedAt", "CreatedAt"}
 	// @snippet_end
 
+	// DefaultRedactedFields are logged as changed without their values, they match the fields whose names contain them
+	// ignoring the case, like PasswordHash and TOTPSecret. Fields tagged with `activity:"redact"` are redacted too.
+	DefaultRedactedFields = []string{"password", "secret", "token", "totp"}
+
 	// @snippet_begin(ActivityDefaultTypeHandles)
 	DefaultTypeHandles = map[reflect.Type]TypeHandler{
 		reflect.TypeOf(time.Time{}): func(old, now interface{}, prefixField string) []Diff {
@@ -53,6 +57,8 @@ type TypeHandler func(old, now interface{}, prefixField string) []Diff
 
 // @snippet_end
 
+const redactedValue = "[REDACTED]"
+
 type Diff struct {
 	Field string
 	Old   string
@@ -133,6 +139,12 @@ func (db *DiffBuilder) diffLoop(old, now reflect.Value, prefixField string) erro
 			}
 
 			newPrefixField := formatFieldByDot(prefixField, field.Name)
+			if db.mb.isRedacted(field) {
+				if !reflect.DeepEqual(old.Field(i).Interface(), now.Field(i).Interface()) {
+					db.diffs = append(db.diffs, Diff{Field: newPrefixField, Old: redactedValue, Now: redactedValue})
+				}
+				continue
+			}
 			if f := DefaultTypeHandles[field.Type]; f != nil {
 				db.diffs = append(db.diffs, f(old.Field(i).Interface(), now.Field(i).Interface(), newPrefixField)...)
 				continue
@@ -356,3 +356,31 @@ func BenchmarkComplexDiff(b *testing.B) {
 // cpu: Intel(R) Core(TM) i5-6267U CPU @ 2.90GHz
 // BenchmarkSimpleDiff-4    	  669444	      1869 ns/op
 // BenchmarkComplexDiff-4   	    1381	    729444 ns/op
+
+func TestDiffRedactedFields(t *testing.T) {
+	type User struct {
+		Name         string
+		PasswordHash string
+		TOTPSecret   string
+		Pin          string `activity:"redact"`
+		APIKey       string
+	}
+	mb := (&ModelBuilder{}).AddRedactedFields("APIKey")
+	diffs, err := mb.Diff(
+		User{Name: "a", PasswordHash: "old", TOTPSecret: "s1", Pin: "1", APIKey: "k1"},
+		User{Name: "b", PasswordHash: "new", TOTPSecret: "s2", Pin: "2", APIKey: "k2"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Diff{
+		{Field: "Name", Old: "a", Now: "b"},
+		{Field: "PasswordHash", Old: redactedValue, Now: redactedValue},
+		{Field: "TOTPSecret", Old: redactedValue, Now: redactedValue},
+		{Field: "Pin", Old: redactedValue, Now: redactedValue},
+		{Field: "APIKey", Old: redactedValue, Now: redactedValue},
+	}
+	if fmt.Sprint(diffs) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", diffs, want)
+	}
+}
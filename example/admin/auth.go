@@ -2,6 +2,7 @@ package admin
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -22,8 +23,11 @@ import (
 )
 
 var (
-	loginBuilder *login.Builder
-	vh           *login.ViewHelper
+	loginBuilder      *login.Builder
+	adminLoginBuilder *plogin.Builder
+	loginRateLimiter  = plogin.NewIPRateLimiter()
+	rememberMe        *plogin.RememberMe
+	vh                *login.ViewHelper
 )
 
 func getCurrentUser(r *http.Request) (u *models.User) {
@@ -37,7 +41,34 @@ func getCurrentUser(r *http.Request) (u *models.User) {
 
 func initLoginBuilder(db *gorm.DB, pb *presets.Builder, ab *activity.ActivityBuilder) {
 	ab.RegisterModel(&models.User{})
-	loginBuilder = plogin.New(pb).
+	adminLoginBuilder = plogin.New(pb).
+		// the reset password and the email verification links are printed instead of being emailed
+		Notifier(plogin.ConsoleNotifier(nil)).
+		// the users change their email after entering their password, and opening the link sent to the new one
+		EmailChange(db, &models.User{}).
+		EmailChangeVerification(os.Getenv("LOGIN_SECRET"), os.Getenv("BASE_URL")+verifyEmailURL).
+		// chained after the disabled account check and the login metrics
+		AfterLogin(plogin.RehashHook(db, &models.User{}, func(r *http.Request, user interface{}, _ ...interface{}) error {
+			if err := ab.AddCustomizedRecord("log-in", false, r.Context(), user); err != nil {
+				return err
+			}
+
+			if err := addSessionLogByUserID(r, user.(*models.User).ID); err != nil {
+				return err
+			}
+
+			return nil
+		})).
+		AfterFailedToLogin(loginRateLimiter.Hook(func(r *http.Request, user interface{}, _ ...interface{}) error {
+			if user != nil {
+				return ab.AddCustomizedRecord("login-failed", false, r.Context(), user)
+			}
+			return nil
+		})).
+		AfterUserLocked(func(r *http.Request, user interface{}, _ ...interface{}) error {
+			return ab.AddCustomizedRecord("locked", false, r.Context(), user)
+		})
+	loginBuilder = adminLoginBuilder.
 		DB(db).
 		UserModel(&models.User{}).
 		Secret(os.Getenv("LOGIN_SECRET")).
@@ -86,17 +117,6 @@ func initLoginBuilder(db *gorm.DB, pb *presets.Builder, ab *activity.ActivityBui
 			}
 			return nil
 		}).
-		AfterLogin(func(r *http.Request, user interface{}, _ ...interface{}) error {
-			if err := ab.AddCustomizedRecord("log-in", false, r.Context(), user); err != nil {
-				return err
-			}
-
-			if err := addSessionLogByUserID(r, user.(*models.User).ID); err != nil {
-				return err
-			}
-
-			return nil
-		}).
 		AfterOAuthComplete(func(r *http.Request, user interface{}, _ ...interface{}) error {
 			u := user.(goth.User)
 			if u.Email == "" {
@@ -134,15 +154,6 @@ func initLoginBuilder(db *gorm.DB, pb *presets.Builder, ab *activity.ActivityBui
 
 			return nil
 		}).
-		AfterFailedToLogin(func(r *http.Request, user interface{}, _ ...interface{}) error {
-			if user != nil {
-				return ab.AddCustomizedRecord("login-failed", false, r.Context(), user)
-			}
-			return nil
-		}).
-		AfterUserLocked(func(r *http.Request, user interface{}, _ ...interface{}) error {
-			return ab.AddCustomizedRecord("locked", false, r.Context(), user)
-		}).
 		AfterLogout(func(r *http.Request, user interface{}, _ ...interface{}) error {
 			if err := ab.AddCustomizedRecord("log-out", false, r.Context(), user); err != nil {
 				return err
@@ -154,21 +165,25 @@ func initLoginBuilder(db *gorm.DB, pb *presets.Builder, ab *activity.ActivityBui
 
 			return nil
 		}).
-		AfterConfirmSendResetPasswordLink(func(r *http.Request, user interface{}, extraVals ...interface{}) error {
-			resetLink := extraVals[0]
-			_ = resetLink
+		AfterConfirmSendResetPasswordLink(adminLoginBuilder.ResetPasswordLinkHook(func(r *http.Request, user interface{}, extraVals ...interface{}) error {
 			return ab.AddCustomizedRecord("send-reset-password-link", false, r.Context(), user)
-		}).
+		})).
 		AfterResetPassword(func(r *http.Request, user interface{}, _ ...interface{}) error {
 			if err := expireAllSessionLogs(user.(*models.User).ID); err != nil {
 				return err
 			}
+			if err := rememberMe.RevokeAll(fmt.Sprint(user.(*models.User).ID)); err != nil {
+				return err
+			}
 			return ab.AddCustomizedRecord("reset-password", false, r.Context(), user)
 		}).
 		AfterChangePassword(func(r *http.Request, user interface{}, _ ...interface{}) error {
 			if err := expireAllSessionLogs(user.(*models.User).ID); err != nil {
 				return err
 			}
+			if err := rememberMe.RevokeAll(fmt.Sprint(user.(*models.User).ID)); err != nil {
+				return err
+			}
 
 			return ab.AddCustomizedRecord("change-password", false, r.Context(), user)
 		}).
@@ -184,6 +199,19 @@ func initLoginBuilder(db *gorm.DB, pb *presets.Builder, ab *activity.ActivityBui
 			return nil
 		}).TOTP(false).MaxRetryCount(0)
 
+	// new passwords are hashed with argon2id, bcrypt hashes are upgraded on the next login
+	plogin.PasswordHasher = plogin.NewArgon2idHasher()
+	// e.g. LOGIN_MAX_PASSWORD_AGE=2160h for 90 days, the public user can't change its password so it's off by default
+	if v, err := time.ParseDuration(os.Getenv("LOGIN_MAX_PASSWORD_AGE")); err == nil {
+		adminLoginBuilder.MaxPasswordAge(v)
+	}
+	rememberMe = plogin.NewRememberMe(loginBuilder, db).
+		Secret(os.Getenv("LOGIN_SECRET")).
+		UserModel(&models.User{}).
+		AfterRestore(func(r *http.Request, user interface{}, _ ...interface{}) error {
+			return addSessionLogByUserID(r, user.(*models.User).ID)
+		})
+
 	vh = loginBuilder.ViewHelper()
 	loginBuilder.LoginPageFunc(loginPage(vh, pb))
 
@@ -211,8 +239,10 @@ func GenInitialUser() {
 
 	user := &models.User{
 		Name: email,
-		UserPass: login.UserPass{
-			Account:  email,
+		UserPass: plogin.UserPass{
+			UserPass: login.UserPass{
+				Account: email,
+			},
 			Password: password,
 		},
 	}
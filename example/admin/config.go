@@ -23,6 +23,7 @@ import (
 	"github.com/qor5/admin/media/media_library"
 	media_oss "github.com/qor5/admin/media/oss"
 	media_view "github.com/qor5/admin/media/views"
+	"github.com/qor5/admin/microsite"
 	microsite_utils "github.com/qor5/admin/microsite/utils"
 	microsite_views "github.com/qor5/admin/microsite/views"
 	"github.com/qor5/admin/note"
@@ -57,8 +58,14 @@ var (
 
 type Config struct {
 	pb          *presets.Builder
-	pageBuilder *pagebuilder.Builder
+	PageBuilder *pagebuilder.Builder
+	sitemap     *pagebuilder.SitemapBuilder
+	live        http.Handler
 	Publisher   *publish.Builder
+	mediaViews  *media_view.Builder
+	worker      *worker.Builder
+	// micrositePreview serves the previews of the microsites
+	micrositePreview *microsite.PreviewBuilder
 }
 
 func NewConfig() Config {
@@ -179,8 +186,15 @@ func NewConfig() Config {
 
 	utils.Configure(b)
 
-	media_view.Configure(b, db)
+	mediaViews := media_view.New(db).DownloadPath(mediaDownloadsURL).MaxUploadSize(50 << 20)
+	mediaViews.Configure(b)
 	// media_view.MediaLibraryPerPage = 3
+	media_view.TrackRecentlyUsed(func(r *http.Request) string {
+		if u := getCurrentUser(r); u != nil {
+			return fmt.Sprint(u.ID)
+		}
+		return ""
+	})
 	// vips.UseVips(vips.Config{EnableGenerateWebp: true})
 	ConfigureSeo(b, db, l10nBuilder.GetSupportLocaleCodes()...)
 
@@ -300,27 +314,26 @@ func NewConfig() Config {
 		}
 	})
 
-	w := worker.New(db)
+	// the log lines pruned from the database are kept in the storage of the media
+	w := worker.New(db).LogStorage(media_oss.Storage).LogDownloadPath(workerLogsURL)
 	defer w.Listen()
-	addJobs(w)
 
 	ed := m.Editing("StatusBar", "ScheduleBar", "Title", "TitleWithSlug", "Seo", "HeroImage", "Body", "BodyImage")
-	ed.Field("HeroImage").
-		WithContextValue(
-			media_view.MediaBoxConfig,
-			&media_library.MediaBoxConfig{
-				AllowType: "image",
-				Sizes: map[string]*media.Size{
-					"thumb": {
-						Width:  400,
-						Height: 300,
-					},
-					"main": {
-						Width:  800,
-						Height: 500,
-					},
+	media_view.WithMediaBoxConfig(ed.Field("HeroImage"),
+		&media_library.MediaBoxConfig{
+			AllowType: "image",
+			Sizes: map[string]*media.Size{
+				"thumb": {
+					Width:  400,
+					Height: 300,
+				},
+				"main": {
+					Width:  800,
+					Height: 500,
 				},
-			})
+			},
+		})
+	addJobs(w, db, mediaViews, ed.Field("HeroImage"))
 	ed.Field("BodyImage").
 		WithContextValue(
 			media_view.MediaBoxConfig,
@@ -331,6 +344,7 @@ func NewConfig() Config {
 	})
 
 	roleBuilder := role.New(db).
+		UserModel(&models.User{}).
 		Resources([]*v.DefaultOptionItem{
 			{Text: "All", Value: "*"},
 			{Text: "InputHarnesses", Value: "*:input_harnesses:*"},
@@ -396,11 +410,23 @@ func NewConfig() Config {
 	// ab.Model(l).SkipDelete().SkipCreate()
 	// @snippet_end
 
-	w.Activity(ab).Configure(b)
+	wmb := w.Activity(ab).Configure(b)
+	media_view.CropInBackground(20_000_000, func(r *http.Request, mediaID uint) (string, error) {
+		jobID, err := w.EnqueueJobWithContext(r.Context(), "cropMedia", &CropMediaResource{MediaID: mediaID})
+		if err != nil {
+			return "", err
+		}
+		return wmb.Info().DetailingHref(fmt.Sprint(jobID)), nil
+	})
 	publisher := publish.New(db, PublishStorage).WithL10nBuilder(l10nBuilder)
 
 	pageBuilder := example.ConfigPageBuilder(db, "/page_builder", ``, b.I18n())
 	pm := pageBuilder.Configure(b, db, l10nBuilder, ab, publisher, seoBuilder)
+	addRepublishCategoryPagesJob(w, pageBuilder)
+	pageBuilder.CategoryRepublisher(func(r *http.Request, categoryID uint, localeCode string) error {
+		_, err := w.EnqueueJobWithContext(r.Context(), "republishCategoryPages", &RepublishCategoryPagesResource{CategoryID: categoryID, LocaleCode: localeCode})
+		return err
+	})
 	pmListing := pm.Listing()
 	pmListing.FilterDataFunc(func(ctx *web.EventContext) vx.FilterData {
 		u := getCurrentUser(ctx.R)
@@ -515,6 +541,10 @@ func NewConfig() Config {
 		PerPage(10)
 	mm.Editing("StatusBar", "ScheduleBar", "Name", "Description", "PrePath", "FilesList", "Package")
 	microsite_views.Configure(b, db, ab, PublishStorage, publisher, mm)
+	// previews of unpublished microsites, the links expire after a day
+	micrositePreview := microsite.NewPreviewBuilder(micrositePreviewURL, db, PublishStorage, &models.MicrositeModel{}).
+		Secret([]byte(os.Getenv("MICROSITE_PREVIEW_SECRET")))
+	microsite_views.ConfigurePreview(b, db, PublishStorage, micrositePreview, mm)
 	l10nM, l10nVM := configL10nModel(b)
 	_ = l10nM
 	publish_view.Configure(b, db, ab, publisher, m, l, product, category, l10nVM)
@@ -526,11 +556,14 @@ func NewConfig() Config {
 	configOrder(b, db)
 	configECDashboard(b, db)
 
-	configUser(b, db)
+	configUser(b, db, ab, roleBuilder)
 	configProfile(b, db)
 
 	l10n_view.Configure(b, db, l10nBuilder, ab, l10nM, l10nVM)
 
+	// audit the models that are not registered above
+	ab.RegisterAllModels(b)
+
 	if os.Getenv("RESET_AND_IMPORT_INITIAL_DATA") == "true" {
 		tbs := GetNonIgnoredTableNames()
 		EmptyDB(db, tbs)
@@ -538,9 +571,22 @@ func NewConfig() Config {
 	}
 
 	return Config{
-		pb:          b,
-		pageBuilder: pageBuilder,
-		Publisher:   publisher,
+		pb:               b,
+		worker:           w,
+		micrositePreview: micrositePreview,
+		PageBuilder:      pageBuilder,
+		sitemap: pageBuilder.Sitemap(PublishStorage.GetEndpoint()).L10n(l10nBuilder).HreflangFunc(func(localeCode string) string {
+			switch localeCode {
+			case "China":
+				return "zh-CN"
+			case "Japan":
+				return "ja-JP"
+			}
+			return "x-default"
+		}),
+		live:       pageBuilder.LiveHandler(PublishStorage, l10nBuilder),
+		Publisher:  publisher,
+		mediaViews: mediaViews,
 	}
 }
 
@@ -552,8 +598,16 @@ func notifierCount(db *gorm.DB) func(ctx *web.EventContext) int {
 		}
 
 		a, b, c := data["Pages"], data["Posts"], data["Users"]
-		return a + b + c
+		return a + b + c + len(passwordNotices(ctx.R))
+	}
+}
+
+// passwordNotices are the notices about the password of the current user, listed before the unread notes
+func passwordNotices(r *http.Request) (notices []string) {
+	if notice := adminLoginBuilder.PasswordExpiryNotice(r); notice != "" {
+		notices = append(notices, notice)
 	}
+	return
 }
 
 func notifierComponent(db *gorm.DB) func(ctx *web.EventContext) h.HTMLComponent {
@@ -565,7 +619,18 @@ func notifierComponent(db *gorm.DB) func(ctx *web.EventContext) h.HTMLComponent
 
 		a, b, c := data["Pages"], data["Posts"], data["Users"]
 
+		var notices []h.HTMLComponent
+		for _, notice := range passwordNotices(ctx.R) {
+			notices = append(notices, v.VListItem(
+				v.VListItemContent(
+					v.VListItemTitle(h.Text("Password")),
+					v.VListItemSubtitle(h.Text(notice)),
+				),
+			).TwoLine(true).Href("/auth/change-password"))
+		}
+
 		return v.VList(
+			h.Components(notices...),
 			v.VListItem(
 				v.VListItemContent(
 					v.VListItemTitle(h.Text("Pages")),
@@ -27,6 +27,7 @@ func ConnectDB() *gorm.DB {
 		&models.InputDemo{},
 		&models.User{},
 		&models.LoginSession{},
+		&models.Impersonation{},
 		&models.ListModel{},
 		&role.Role{},
 		&perm.DefaultDBPolicy{},
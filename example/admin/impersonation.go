@@ -0,0 +1,239 @@
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qor5/admin/activity"
+	"github.com/qor5/admin/example/models"
+	plogin "github.com/qor5/admin/login"
+	"github.com/qor5/admin/presets"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	"github.com/qor5/x/login"
+	"github.com/qor5/x/perm"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+)
+
+const (
+	PermImpersonate = "perm_impersonate"
+
+	impersonateUserEvent     = "impersonateUserEvent"
+	stopImpersonationEvent   = "stopImpersonationEvent"
+	impersonationCookieName  = "qor5_impersonation"
+	impersonationMaxAge      = time.Hour
+	impersonationTokenHashLn = 32
+)
+
+type impersonatorKey int
+
+const impersonatorContextKey impersonatorKey = iota
+
+var errNestedImpersonation = errors.New("can not impersonate while impersonating")
+
+// getImpersonator returns the admin behind the current user if it is an impersonation session
+func getImpersonator(r *http.Request) *models.User {
+	u, _ := r.Context().Value(impersonatorContextKey).(*models.User)
+	return u
+}
+
+// forbiddenWhileImpersonating are the requests acting on the credentials or sessions of the impersonated user
+func forbiddenWhileImpersonating(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/auth/change-password", vh.ChangePasswordURL():
+		return true
+	}
+	switch r.URL.Query().Get(web.EventFuncIDName) {
+	case plogin.OpenChangePasswordDialogEvent, "login_changePassword", plogin.OpenChangeEmailDialogEvent, plogin.ChangeEmailEvent,
+		signOutAllSessionEvent, impersonateUserEvent:
+		return true
+	}
+	return false
+}
+
+// withImpersonation swaps the current user with the impersonated one,
+// it must be used before withRoles so the roles of the impersonated user apply.
+func withImpersonation(db *gorm.DB) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u := getCurrentUser(r)
+			c, err := r.Cookie(impersonationCookieName)
+			if u == nil || err != nil || c.Value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			imp, target, err := findImpersonation(db, u.ID, c.Value)
+			if err != nil {
+				setImpersonationCookie(w, "", -1)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.URL.Path == logoutURL {
+				if err := endImpersonation(db, imp); err != nil {
+					panic(err)
+				}
+				setImpersonationCookie(w, "", -1)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if forbiddenWhileImpersonating(r) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), impersonatorContextKey, u)
+			ctx = context.WithValue(ctx, login.UserKey, target)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func findImpersonation(db *gorm.DB, impersonatorID uint, token string) (imp *models.Impersonation, target *models.User, err error) {
+	imp = &models.Impersonation{}
+	if err = db.Where("impersonator_id = ? AND token_hash = ? AND ended_at IS NULL", impersonatorID, getStringHash(token, impersonationTokenHashLn)).
+		First(imp).Error; err != nil {
+		return
+	}
+	if time.Since(imp.StartedAt) > impersonationMaxAge {
+		if err = endImpersonation(db, imp); err != nil {
+			return
+		}
+		return nil, nil, errors.New("impersonation expired")
+	}
+	target = &models.User{}
+	if err = db.Where("id = ?", imp.UserID).First(target).Error; err != nil {
+		return
+	}
+	return
+}
+
+func endImpersonation(db *gorm.DB, imp *models.Impersonation) error {
+	now := time.Now()
+	imp.EndedAt = &now
+	return db.Model(imp).Update("ended_at", now).Error
+}
+
+func setImpersonationCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     impersonationCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func canImpersonate(ctx *web.EventContext, mb *presets.ModelBuilder, target *models.User) bool {
+	u := getCurrentUser(ctx.R)
+	if u == nil || getImpersonator(ctx.R) != nil || u.ID == target.ID || target.ID == 0 {
+		return false
+	}
+	return mb.Info().Verifier().Do(PermImpersonate).ObjectOn(target).WithReq(ctx.R).IsAllowed() == nil
+}
+
+func configImpersonation(b *presets.Builder, db *gorm.DB, ab *activity.ActivityBuilder, user *presets.ModelBuilder) {
+	user.RegisterEventFunc(impersonateUserEvent, func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		if getImpersonator(ctx.R) != nil {
+			return r, errNestedImpersonation
+		}
+		target := &models.User{}
+		if err = db.Where("id = ?", ctx.R.FormValue("id")).First(target).Error; err != nil {
+			return r, err
+		}
+		if !canImpersonate(ctx, user, target) {
+			return r, perm.PermissionDenied
+		}
+
+		u := getCurrentUser(ctx.R)
+		raw := make([]byte, 32)
+		if _, err = rand.Read(raw); err != nil {
+			return r, err
+		}
+		token := hex.EncodeToString(raw)
+		if err = db.Create(&models.Impersonation{
+			ImpersonatorID:   u.ID,
+			ImpersonatorName: u.Name,
+			UserID:           target.ID,
+			UserName:         target.Name,
+			IP:               ip(ctx.R),
+			TokenHash:        getStringHash(token, impersonationTokenHashLn),
+			StartedAt:        time.Now(),
+		}).Error; err != nil {
+			return r, err
+		}
+		if err = ab.AddCustomizedRecord("impersonate", false, ctx.R.Context(), target); err != nil {
+			return r, err
+		}
+
+		setImpersonationCookie(ctx.W, token, int(impersonationMaxAge.Seconds()))
+		r.PushState = web.Location(nil).URL("/admin")
+		return
+	})
+
+	b.GetWebBuilder().RegisterEventFunc(stopImpersonationEvent, func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		impersonator := getImpersonator(ctx.R)
+		if impersonator == nil {
+			r.Reload = true
+			return
+		}
+		c, err := ctx.R.Cookie(impersonationCookieName)
+		if err != nil {
+			return r, err
+		}
+		imp, target, err := findImpersonation(db, impersonator.ID, c.Value)
+		if err != nil {
+			return r, err
+		}
+		if err = endImpersonation(db, imp); err != nil {
+			return r, err
+		}
+		// logged as the admin who stopped it, not the impersonated user
+		if err = ab.AddCustomizedRecord("stop-impersonation", false, context.WithValue(ctx.R.Context(), login.UserKey, impersonator), target); err != nil {
+			return r, err
+		}
+
+		setImpersonationCookie(ctx.W, "", -1)
+		r.PushState = web.Location(nil).URL("/admin/users")
+		return
+	})
+
+	mb := b.Model(&models.Impersonation{}).MenuIcon("manage_accounts")
+	lb := mb.Listing("ID", "ImpersonatorName", "UserName", "IP", "StartedAt", "EndedAt").
+		SearchColumns("impersonator_name", "user_name")
+	lb.Field("ImpersonatorName").Label("Impersonator")
+	lb.Field("UserName").Label("User")
+	lb.Field("EndedAt").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
+		imp := obj.(*models.Impersonation)
+		if imp.EndedAt == nil {
+			return h.Td(h.Text("-"))
+		}
+		return h.Td(h.Text(imp.EndedAt.Format("2006-01-02 15:04:05")))
+	})
+	lb.NewButtonFunc(func(ctx *web.EventContext) h.HTMLComponent { return nil })
+	lb.RowMenu().Empty()
+}
+
+// impersonationBanner shows who is impersonated with a button to go back to the admin's own view
+func impersonationBanner(ctx *web.EventContext) h.HTMLComponent {
+	impersonator := getImpersonator(ctx.R)
+	if impersonator == nil {
+		return nil
+	}
+	u := getCurrentUser(ctx.R)
+	return VAlert(
+		h.Div(
+			h.Text(fmt.Sprintf("Viewing as %s", u.Name)),
+			VBtn("Stop").Small(true).Text(true).Class("ml-2").
+				Attr("@click", web.Plaid().EventFunc(stopImpersonationEvent).Go()),
+		).Class("d-flex align-center justify-space-between"),
+	).Type("warning").Dense(true).Class("mb-6")
+}
@@ -4,15 +4,54 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/qor5/admin/media/media_library"
+	media_view "github.com/qor5/admin/media/views"
+	"github.com/qor5/admin/pagebuilder"
 	"github.com/qor5/admin/presets"
 	"github.com/qor5/admin/worker"
 	. "github.com/qor5/ui/vuetify"
 	"github.com/qor5/web"
 	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
 )
 
-func addJobs(w *worker.Builder) {
+type CropMediaResource struct {
+	MediaID uint
+}
+
+type RepublishCategoryPagesResource struct {
+	CategoryID uint
+	LocaleCode string
+}
+
+func addRepublishCategoryPagesJob(w *worker.Builder, pb *pagebuilder.Builder) {
+	w.NewJob("republishCategoryPages").
+		Resource(&RepublishCategoryPagesResource{}).
+		Handler(func(ctx context.Context, job worker.QorJobInterface) error {
+			jobInfo, err := job.GetJobInfo()
+			if err != nil {
+				return err
+			}
+			args := jobInfo.Argument.(*RepublishCategoryPagesResource)
+			return pb.RepublishCategoryPages(args.CategoryID, args.LocaleCode, job.SetProgress)
+		})
+}
+
+// addJobs adds the jobs of the example, warmThumbnails generates the sizes of the heroImage field
+func addJobs(w *worker.Builder, db *gorm.DB, mediaViews *media_view.Builder, heroImage *presets.FieldBuilder) {
+	w.NewJob("cropMedia").
+		Resource(&CropMediaResource{}).
+		Handler(func(ctx context.Context, job worker.QorJobInterface) error {
+			jobInfo, err := job.GetJobInfo()
+			if err != nil {
+				return err
+			}
+			return media_view.GenerateCrop(db, jobInfo.Argument.(*CropMediaResource).MediaID, job.SetProgress)
+		})
+
 	w.NewJob("noArgJob").
 		Handler(func(ctx context.Context, job worker.QorJobInterface) error {
 			job.AddLog("hoho1")
@@ -69,6 +108,58 @@ func addJobs(w *worker.Builder) {
 			return nil
 		})
 
+	type WarmThumbnailsResource struct {
+		// MediaIDs are separated by commas, all the files are warmed when empty
+		MediaIDs string
+	}
+	w.NewJob("warmThumbnails").
+		Resource(&WarmThumbnailsResource{}).
+		Handler(func(ctx context.Context, job worker.QorJobInterface) error {
+			jobInfo, err := job.GetJobInfo()
+			if err != nil {
+				return err
+			}
+			var ids []uint
+			for _, s := range strings.Split(jobInfo.Argument.(*WarmThumbnailsResource).MediaIDs, ",") {
+				if s = strings.TrimSpace(s); s == "" {
+					continue
+				}
+				id, err := strconv.ParseUint(s, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid media id %q", s)
+				}
+				ids = append(ids, uint(id))
+			}
+			if len(ids) == 0 {
+				if err = db.Model(&media_library.MediaLibrary{}).Order("id").Pluck("id", &ids).Error; err != nil {
+					return err
+				}
+			}
+
+			var warmed, skipped, failed int
+			err = media_view.WarmThumbnails(ctx, db, ids, media_view.FieldMediaBoxConfig(heroImage).Sizes, func(r media_view.WarmThumbnailResult) {
+				switch {
+				case r.Err != nil:
+					failed++
+					job.AddLogf("%d: %v", r.ID, r.Err)
+				case r.Skipped:
+					skipped++
+				default:
+					warmed++
+				}
+				job.SetProgress(uint(r.Done * 100 / r.Total))
+			})
+			job.SetProgressText(fmt.Sprintf("%d warmed, %d already generated, %d failed", warmed, skipped, failed))
+			return err
+		})
+
+	w.NewJob("purgeMediaTrash").
+		Handler(func(ctx context.Context, job worker.QorJobInterface) error {
+			count, err := mediaViews.PurgeTrash(ctx)
+			job.AddLogf("%d files are deleted permanently", count)
+			return err
+		})
+
 	w.NewJob("errorJob").
 		Handler(func(ctx context.Context, job worker.QorJobInterface) error {
 			job.AddLog("=====perform error job")
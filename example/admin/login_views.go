@@ -103,6 +103,7 @@ func loginPage(vh *login.ViewHelper, pb *presets.Builder) web.PageFunc {
 						Label(loginMsgr.PasswordLabel).Class(plogin.DefaultViewCommon.LabelClass).For("password"),
 						plogin.DefaultViewCommon.PasswordInput("password", loginMsgr.PasswordPlaceholder, wIn.Password, true),
 					).Class("mt-6"),
+					plogin.DefaultViewCommon.RememberMeCheckbox(ctx.R),
 					If(isRecaptchaEnabled,
 						// recaptcha response token
 						Input("token").Id("token").Type("hidden"),
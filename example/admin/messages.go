@@ -28,6 +28,7 @@ type Messages struct {
 	Role                           string
 	Status                         string
 	ChangePassword                 string
+	ChangeEmail                    string
 	LoginSessions                  string
 	LoginSessionsTips              string
 	SignOutAllOtherSessions        string
@@ -63,6 +64,7 @@ var Messages_en_US = &Messages{
 	Role:                           "Role",
 	Status:                         "Status",
 	ChangePassword:                 "Change Password",
+	ChangeEmail:                    "Change Email",
 	LoginSessions:                  "Login Sessions",
 	LoginSessionsTips:              "Places where you're logged into QOR5 admin.",
 	SignOutAllOtherSessions:        "Sign out all other sessions",
@@ -98,6 +100,7 @@ var Messages_ja_JP = &Messages{
 	Role:                           "役割",
 	Status:                         "ステータス",
 	ChangePassword:                 "パスワードを変更する",
+	ChangeEmail:                    "メールアドレスを変更する",
 	LoginSessions:                  "ログインセッション",
 	LoginSessionsTips:              "QOR5管理者にログインしている場所。",
 	SignOutAllOtherSessions:        "他のすべてのセッションをサインアウトする",
@@ -133,6 +136,7 @@ var Messages_zh_CN = &Messages{
 	Role:                           "角色",
 	Status:                         "状态",
 	ChangePassword:                 "修改密码",
+	ChangeEmail:                    "修改邮箱",
 	LoginSessions:                  "登录会话",
 	LoginSessionsTips:              "您在QOR5管理中登录的地方。",
 	SignOutAllOtherSessions:        "退出所有其他会话",
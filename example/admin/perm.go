@@ -6,6 +6,7 @@ import (
 	"github.com/ory/ladon"
 	"github.com/qor5/admin/activity"
 	"github.com/qor5/admin/example/models"
+	media_view "github.com/qor5/admin/media/views"
 	"github.com/qor5/admin/presets"
 	"github.com/qor5/x/perm"
 	"gorm.io/gorm"
@@ -23,7 +24,20 @@ func initPermission(b *presets.Builder, db *gorm.DB) {
 				models.RoleEditor,
 				models.RoleManager,
 			).WhoAre(perm.Denied).ToDo(presets.PermCreate, presets.PermUpdate, presets.PermDelete).On("*:roles:*", "*:users:*"),
+			perm.PolicyFor(
+				models.RoleViewer,
+				models.RoleEditor,
+				models.RoleManager,
+			).WhoAre(perm.Denied).ToDo(PermImpersonate).On("*:users:*"),
+			perm.PolicyFor(
+				models.RoleViewer,
+				models.RoleEditor,
+				models.RoleManager,
+			).WhoAre(perm.Denied).ToDo(perm.Anything).On("*:impersonations", "*:impersonations:*"),
 			perm.PolicyFor(models.RoleViewer).WhoAre(perm.Denied).ToDo(presets.PermCreate, presets.PermUpdate, presets.PermDelete).On(perm.Anything),
+			perm.PolicyFor(models.RoleViewer).WhoAre(perm.Denied).
+				ToDo(media_view.PermUpload, media_view.PermDelete, media_view.PermUpdateDesc, media_view.PermConvert).
+				On("*:media_libraries", "*:media_libraries:*"),
 
 			perm.PolicyFor(models.RoleManager).WhoAre(perm.Denied).ToDo(perm.Anything).
 				On("*:activity_logs").On("*:activity_logs:*").
@@ -29,10 +29,11 @@ func configProduct(b *presets.Builder, db *gorm.DB, wb *worker.Builder) *presets
 		"No parameters",
 		p,
 		func(ctx context.Context, job worker.QorJobInterface) error {
+			logger := worker.Logger(job)
 			for i := 1; i <= 10; i++ {
 				select {
 				case <-ctx.Done():
-					job.AddLog("job aborted")
+					logger.Warn("job aborted", "step", i)
 					return nil
 				default:
 					job.SetProgress(uint(i * 10))
@@ -43,7 +43,7 @@ func profile(ctx *web.EventContext) h.HTMLComponent {
 		account = u.OAuthIdentifier
 	}
 
-	return VMenu().OffsetY(true).Children(
+	return h.Components(impersonationBanner(ctx), VMenu().OffsetY(true).Children(
 		h.Template().Attr("v-slot:activator", "{on, attrs}").Children(
 			VList(
 				VListItem(
@@ -72,7 +72,7 @@ func profile(ctx *web.EventContext) h.HTMLComponent {
 				).Class("pa-0 my-n4 ml-1").Dense(true),
 			).Class("pa-0 ma-n4"),
 		),
-	)
+	))
 }
 
 type Profile struct{}
@@ -170,6 +170,11 @@ func configProfile(b *presets.Builder, db *gorm.DB) {
 					Children(VIcon("lock_outline").Small(true), h.Text(msgr.ChangePassword)).
 					Class("mr-2").
 					OnClick(plogin.OpenChangePasswordDialogEvent),
+				VBtn("").
+					Outlined(true).Color("primary").
+					Children(VIcon("mail_outline").Small(true), h.Text(msgr.ChangeEmail)).
+					Class("mr-2").
+					OnClick(plogin.OpenChangeEmailDialogEvent),
 			)
 		}
 
@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/qor5/admin/example/models"
+	plogin "github.com/qor5/admin/login"
+	"github.com/qor5/admin/metrics"
 	"github.com/qor5/x/sitemap"
 )
 
@@ -16,7 +18,12 @@ var favicon []byte
 const (
 	logoutURL = "/auth/logout"
 
-	exportOrdersURL = "/export-orders"
+	exportOrdersURL   = "/export-orders"
+	mediaDownloadsURL = "/media-downloads"
+	workerLogsURL     = "/worker-logs"
+	verifyEmailURL    = "/auth/verify-email"
+
+	micrositePreviewURL = "/microsite-preview"
 )
 
 func Router() http.Handler {
@@ -24,7 +31,8 @@ func Router() http.Handler {
 	c := NewConfig()
 
 	mux := http.NewServeMux()
-	loginBuilder.Mount(mux)
+	adminLoginBuilder.Mount(mux)
+	mux.Handle(verifyEmailURL, adminLoginBuilder.VerifyEmailHandler())
 	//	mux.Handle("/frontstyle.css", c.pb.GetWebBuilder().PacksHandler("text/css", web.ComponentsPack(`
 	// :host {
 	//	all: initial;
@@ -34,7 +42,7 @@ func Router() http.Handler {
 	// }
 	// `)))
 
-	mux.Handle("/page_builder/", c.pageBuilder)
+	mux.Handle("/page_builder/", c.PageBuilder)
 	// example of seo
 	mux.Handle("/posts/first", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var post models.Post
@@ -51,18 +59,38 @@ func Router() http.Handler {
 	})
 
 	mux.Handle(exportOrdersURL, exportOrders(db))
+	mux.Handle(mediaDownloadsURL, c.mediaViews.Downloads())
+	mux.Handle(workerLogsURL, c.worker.LogDownloads())
+
+	metricsRegistry := metrics.NewRegistry()
+	metrics.SetRecorder(metricsRegistry)
+	mux.Handle("/metrics", metricsRegistry)
+
+	// sitemap of the online pages
+	c.sitemap.MountTo(mux)
+	// published pages with the 404 page of the locale for unmatched paths
+	mux.Handle("/live/", http.StripPrefix("/live", c.live))
+	// previews of unpublished microsites
+	mux.Handle(micrositePreviewURL+"/", c.micrositePreview)
 
 	// example of sitemap and robot
 	sitemap.SiteMap("product").RegisterRawString("https://dev.qor5.com/admin", "/product").MountTo(mux)
 	robot := sitemap.Robots()
 	robot.Agent(sitemap.AlexaAgent).Allow("/product1", "/product2").Disallow("/admin")
 	robot.Agent(sitemap.GoogleAgent).Disallow("/admin")
+	robot.Agent(sitemap.AllAgents).Disallow("/admin").AddSitemapUrl(c.sitemap.URL())
 	robot.MountTo(mux)
 
 	cr := chi.NewRouter()
 	cr.Use(
-		loginBuilder.Middleware(),
+		loginRateLimiter.Middleware("/auth/userpass/login"),
+		plogin.NewResetPasswordProtection().Middleware("/auth/send-reset-password-link"),
+		rememberMe.Middleware(),
+		adminLoginBuilder.Middleware(),
+		plogin.DisabledAccountMiddleware(loginBuilder),
+		adminLoginBuilder.MustChangePasswordMiddleware("/auth/change-password"),
 		validateSessionToken(),
+		withImpersonation(db),
 		withRoles(db),
 		withNoteContext(),
 		securityMiddleware(),
@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/url"
 	"os"
@@ -8,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/qor5/admin/activity"
 	"github.com/qor5/admin/example/models"
 	"github.com/qor5/admin/note"
 	"github.com/qor5/admin/presets"
@@ -28,10 +31,11 @@ import (
 	"gorm.io/gorm"
 )
 
-func configUser(b *presets.Builder, db *gorm.DB) {
+func configUser(b *presets.Builder, db *gorm.DB, ab *activity.ActivityBuilder, rb *role.Builder) {
 	user := b.Model(&models.User{})
 	// MenuIcon("people")
 	note.Configure(db, b, user)
+	configImpersonation(b, db, ab, user)
 
 	user.Listing().Searcher = func(model interface{}, params *presets.SearchParams, ctx *web.EventContext) (r interface{}, totalCount int, err error) {
 		u := getCurrentUser(ctx.R)
@@ -57,6 +61,7 @@ func configUser(b *presets.Builder, db *gorm.DB) {
 		"Account",
 		"Company",
 		"Roles",
+		"EffectivePermissions",
 		"Status",
 		"FavorPostID",
 	)
@@ -82,6 +87,28 @@ func configUser(b *presets.Builder, db *gorm.DB) {
 		return r, nil
 	})
 
+	user.RegisterEventFunc("eventToggleUserDisabled", func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		uid := ctx.R.FormValue("id")
+		u := &models.User{}
+		if err = db.Where("id = ?", uid).First(u).Error; err != nil {
+			return r, err
+		}
+		if u.GetAccountName() == os.Getenv("LOGIN_INITIAL_USER_EMAIL") || u.ID == getCurrentUser(ctx.R).ID {
+			return r, perm.PermissionDenied
+		}
+		if u.GetDisabled() {
+			err = u.Enable(db, u)
+		} else if err = u.Disable(db, u); err == nil {
+			err = expireAllSessionLogs(u.ID)
+		}
+		if err != nil {
+			return r, err
+		}
+		presets.ShowMessage(&r, "success", "")
+		ed.UpdateOverlayContent(ctx, &r, u, "", nil)
+		return r, nil
+	})
+
 	user.RegisterEventFunc("eventSendResetPasswordEmail", func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		uid := ctx.R.FormValue("id")
 		u := models.User{}
@@ -92,7 +119,32 @@ func configUser(b *presets.Builder, db *gorm.DB) {
 		if err != nil {
 			return r, err
 		}
-		r.VarsScript = fmt.Sprintf(`alert("http://localhost:9500/auth/reset-password?id=%s&token=%s")`, uid, token)
+		link := fmt.Sprintf("http://localhost:9500/auth/reset-password?id=%s&token=%s", uid, token)
+		if err = adminLoginBuilder.SendResetPasswordLink(ctx.R.Context(), &u, link); err != nil {
+			return r, err
+		}
+		r.VarsScript = fmt.Sprintf(`alert(%q)`, link)
+		return r, nil
+	})
+
+	user.RegisterEventFunc("eventSetTemporaryPassword", func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		uid := ctx.R.FormValue("id")
+		u := &models.User{}
+		if err = db.Where("id = ?", uid).First(u).Error; err != nil {
+			return r, err
+		}
+		raw := make([]byte, 8)
+		if _, err = rand.Read(raw); err != nil {
+			return r, err
+		}
+		password := hex.EncodeToString(raw)
+		if err = u.SetPasswordByAdmin(db, &models.User{}, password); err != nil {
+			return r, err
+		}
+		if err = expireAllSessionLogs(u.ID); err != nil {
+			return r, err
+		}
+		r.VarsScript = fmt.Sprintf(`alert("Temporary password: %s, the user must change it on the next login")`, password)
 		return r, nil
 	})
 
@@ -150,6 +202,15 @@ func configUser(b *presets.Builder, db *gorm.DB) {
 			)
 		}
 
+		if !u.IsOAuthUser() && u.Account != "" {
+			actionBtns = append(actionBtns,
+				VBtn("Set Temporary Password").
+					Color("primary").
+					Attr("@click", web.Plaid().EventFunc("eventSetTemporaryPassword").
+						Query("id", u.ID).Go()),
+			)
+		}
+
 		if u.GetLocked() {
 			actionBtns = append(actionBtns,
 				VBtn("Unlock").Color("primary").
@@ -159,6 +220,19 @@ func configUser(b *presets.Builder, db *gorm.DB) {
 			)
 		}
 
+		if u.ID != getCurrentUser(ctx.R).ID {
+			label := "Disable"
+			if u.GetDisabled() {
+				label = "Enable"
+			}
+			actionBtns = append(actionBtns,
+				VBtn(label).
+					Color("primary").
+					Attr("@click", web.Plaid().EventFunc("eventToggleUserDisabled").
+						Query("id", u.ID).Go()),
+			)
+		}
+
 		if u.GetIsTOTPSetup() {
 			actionBtns = append(actionBtns,
 				VBtn("Revoke TOTP").
@@ -168,6 +242,15 @@ func configUser(b *presets.Builder, db *gorm.DB) {
 			)
 		}
 
+		if canImpersonate(ctx, user, u) {
+			actionBtns = append(actionBtns,
+				VBtn("Login As User").
+					Color("primary").
+					Attr("@click", web.Plaid().EventFunc(impersonateUserEvent).
+						Query("id", u.ID).Go()),
+			)
+		}
+
 		if len(actionBtns) == 0 {
 			return nil
 		}
@@ -273,6 +356,39 @@ func configUser(b *presets.Builder, db *gorm.DB) {
 			return
 		})
 
+	ed.Field("EffectivePermissions").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
+		u := obj.(*models.User)
+		if u.ID == 0 {
+			return nil
+		}
+		perms, err := rb.EffectivePermissions(ctx.R, u.ID)
+		if err != nil {
+			return h.Div(h.Text(err.Error())).Class("red--text mb-4")
+		}
+		var rows h.HTMLComponents
+		for _, p := range perms {
+			color := "green--text"
+			if p.Effect == perm.Denied {
+				color = "red--text"
+			}
+			rows = append(rows, h.Tr(
+				h.Td(h.Text(p.Effect)).Class(color),
+				h.Td(h.Text(p.Action)),
+				h.Td(h.Text(p.Resource)),
+				h.Td(h.Text(strings.Join(p.Roles, ", "))),
+			))
+		}
+		return h.Div(
+			h.Div(h.Text("Effective Permissions")).Class("text-subtitle-2 mb-2"),
+			VSimpleTable(
+				h.Thead(h.Tr(h.Th("Effect"), h.Th("Action"), h.Th("Resource"), h.Th("Roles"))),
+				h.Tbody(rows...),
+			).Dense(true),
+		).Class("mb-4")
+	}).SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
+		return nil
+	})
+
 	ed.Field("Status").
 		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
 			return VSelect().FieldName(field.Name).
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"log"
+	"time"
+
 	"github.com/qor5/admin/example/admin"
 	"github.com/qor5/admin/publish"
 )
@@ -10,5 +13,11 @@ func main() {
 	config := admin.NewConfig()
 	storage := admin.PublishStorage
 	publish.RunPublisher(db, storage, config.Publisher)
+	// the pages using a shared container that has been saved
+	go publish.RunJob("list-updated-pages-publisher", time.Minute, time.Minute*5, func() {
+		if err := config.PageBuilder.RepublishListUpdatedPages(); err != nil {
+			log.Printf("list updated pages publisher error: %v\n", err)
+		}
+	})
 	select {}
 }
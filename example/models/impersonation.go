@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Impersonation is the audit log of an admin viewing the admin as another user
+type Impersonation struct {
+	gorm.Model
+
+	ImpersonatorID   uint `sql:"index"`
+	ImpersonatorName string
+	UserID           uint `sql:"index"`
+	UserName         string
+	IP               string
+	TokenHash        string `sql:"index"`
+	StartedAt        time.Time
+	EndedAt          *time.Time
+}
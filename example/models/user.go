@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	plogin "github.com/qor5/admin/login"
 	"github.com/qor5/admin/role"
 	"github.com/qor5/x/login"
 	"gorm.io/gorm"
@@ -45,7 +46,7 @@ type User struct {
 	RegistrationDate time.Time `gorm:"type:date"`
 
 	// Username is email
-	login.UserPass
+	plogin.UserPass
 	login.OAuthInfo
 	login.SessionSecure
 }
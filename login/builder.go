@@ -2,33 +2,75 @@ package login
 
 import (
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/qor5/admin/presets"
 	"github.com/qor5/web"
 	"github.com/qor5/x/i18n"
 	"github.com/qor5/x/login"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
 )
 
 const (
 	OpenChangePasswordDialogEvent = "login_openChangePasswordDialog"
 )
 
-func New(pb *presets.Builder) *login.Builder {
-	r := login.New()
+// Builder is the login.Builder of x/login set up for the admin, with the settings of this package.
+// Set them before the ones of login.Builder, whose setters return the login.Builder.
+// Mount it with Mount or MountAPI of Builder, so the disabled account check and the login metrics are kept
+// for the hooks set with the setters of login.Builder too.
+type Builder struct {
+	*login.Builder
+	pb                    *presets.Builder
+	alternateVerification func(r *http.Request, user interface{}, proof string) error
+	emailChangeDB         *gorm.DB
+	emailChangeUserModel  interface{}
+	emailChangeSecret     string
+	emailChangeVerifyURL  string
+	notifier              Notifier
+	maxPasswordAge        time.Duration
+	passwordExpiryWarning time.Duration
+	afterLogin            *chainedHook
+	afterFailedToLogin    *chainedHook
+	afterUserLocked       *chainedHook
+}
+
+func New(pb *presets.Builder) *Builder {
+	b := &Builder{Builder: login.New(), pb: pb, notifier: NoopNotifier, passwordExpiryWarning: 7 * 24 * time.Hour}
+	r := b.Builder
 	r.I18n(pb.I18n())
+	pb.I18n().
+		RegisterForModule(language.English, I18nAdminLoginKey, Messages_en_US).
+		RegisterForModule(language.SimplifiedChinese, I18nAdminLoginKey, Messages_zh_CN).
+		RegisterForModule(language.Japanese, I18nAdminLoginKey, Messages_ja_JP)
 
 	vh := r.ViewHelper()
 	r.LoginPageFunc(defaultLoginPage(vh, pb))
 	r.ForgetPasswordPageFunc(defaultForgetPasswordPage(vh, pb))
 	r.ResetPasswordLinkSentPageFunc(defaultResetPasswordLinkSentPage(vh, pb))
 	r.ResetPasswordPageFunc(defaultResetPasswordPage(vh, pb))
-	r.ChangePasswordPageFunc(defaultChangePasswordPage(vh, pb))
+	r.ChangePasswordPageFunc(defaultChangePasswordPage(vh, pb, b.passwordExpiredNotice))
 	r.TOTPSetupPageFunc(defaultTOTPSetupPage(vh, pb))
 	r.TOTPValidatePageFunc(defaultTOTPValidatePage(vh, pb))
 
+	b.afterLogin = &chainedHook{field: "afterLoginHook", pre: func(h login.HookFunc) login.HookFunc {
+		return DisabledAccountHook(MetricsHook(LoginResultSuccess, h))
+	}}
+	b.afterFailedToLogin = &chainedHook{field: "afterFailedToLoginHook", pre: func(h login.HookFunc) login.HookFunc {
+		return MetricsHook(LoginResultFailure, h)
+	}}
+	b.afterUserLocked = &chainedHook{field: "afterUserLockedHook", pre: func(h login.HookFunc) login.HookFunc {
+		return MetricsHook(LoginResultLocked, h)
+	}}
+	b.installHooks()
+	r.AfterConfirmSendResetPasswordLink(b.ResetPasswordLinkHook(nil))
+
 	registerChangePasswordEvents(r, pb)
+	b.registerChangeEmailEvents()
 
-	return r
+	return b
 }
 
 func registerChangePasswordEvents(b *login.Builder, pb *presets.Builder) {
@@ -0,0 +1,265 @@
+package login
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qor5/admin/presets"
+	v "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	"github.com/qor5/x/login"
+	. "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+)
+
+const (
+	OpenChangeEmailDialogEvent = "login_openChangeEmailDialog"
+	ChangeEmailEvent           = "login_changeEmail"
+)
+
+var (
+	ErrEmailChangeDisabled = errors.New("email change is not enabled")
+	ErrInvalidEmail        = errors.New("invalid email")
+	ErrEmailTaken          = errors.New("email is used by another account")
+	ErrInvalidEmailLink    = errors.New("invalid or expired email verification link")
+)
+
+// EmailLinkTTL is how long the links verifying the new emails are valid
+const EmailLinkTTL = 24 * time.Hour
+
+// EmailChange lets the users change their email, the account of the users of userModel,
+// after entering their current password in the dialog of OpenChangeEmailDialogEvent
+func (b *Builder) EmailChange(db *gorm.DB, userModel interface{}) (r *Builder) {
+	b.emailChangeDB = db
+	b.emailChangeUserModel = userModel
+	return b
+}
+
+// EmailChangeVerification sends a link verifying the new email to it with the Notifier instead of changing the email
+// right away, the email is changed when the link is opened. verifyURL is the absolute URL VerifyEmailHandler is mounted at,
+// the links are signed with secret.
+func (b *Builder) EmailChangeVerification(secret string, verifyURL string) (r *Builder) {
+	b.emailChangeSecret = secret
+	b.emailChangeVerifyURL = verifyURL
+	return b
+}
+
+// ChangeEmail changes the email of the current user once VerifyCurrentPassword accepts proof,
+// or sends the link verifying it with EmailChangeVerification
+func (b *Builder) ChangeEmail(r *http.Request, proof string, email string) error {
+	if b.emailChangeDB == nil {
+		return ErrEmailChangeDisabled
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return ErrInvalidEmail
+	}
+	if err = b.VerifyCurrentPassword(r, proof); err != nil {
+		return err
+	}
+	up, ok := login.GetCurrentUser(r).(interface{ GetAccountName() string })
+	if !ok {
+		return login.ErrUserNotFound
+	}
+	if up.GetAccountName() == addr.Address {
+		return nil
+	}
+
+	if b.emailChangeVerifyURL == "" {
+		return b.updateEmail(up.GetAccountName(), addr.Address)
+	}
+	if err = b.checkEmailTaken(b.emailChangeDB, addr.Address); err != nil {
+		return err
+	}
+	token := b.signEmailLink(emailLink{Account: up.GetAccountName(), Email: addr.Address, ExpiresAt: time.Now().Add(EmailLinkTTL).Unix()})
+	link := b.emailChangeVerifyURL + "?token=" + url.QueryEscape(token)
+	return b.SendVerifyEmailLink(r.Context(), up, addr.Address, link)
+}
+
+// VerifyEmail changes the email of the link a token was sent with by ChangeEmail,
+// the link is invalid once the account has changed
+func (b *Builder) VerifyEmail(token string) error {
+	if b.emailChangeDB == nil {
+		return ErrEmailChangeDisabled
+	}
+	l, err := b.parseEmailLink(token)
+	if err != nil {
+		return err
+	}
+	if err = b.updateEmail(l.Account, l.Email); err == login.ErrUserNotFound {
+		return ErrInvalidEmailLink
+	}
+	return err
+}
+
+// VerifyEmailHandler verifies the token of the links of EmailChangeVerification
+func (b *Builder) VerifyEmailHandler() http.Handler {
+	return b.pb.I18n().EnsureLanguage(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lmsgr := i18n.MustGetModuleMessages(r, login.I18nLoginKey, login.Messages_en_US).(*login.Messages)
+		msgr := i18n.MustGetModuleMessages(r, I18nAdminLoginKey, Messages_en_US).(*Messages)
+		switch err := b.VerifyEmail(r.FormValue("token")); err {
+		case nil:
+			fmt.Fprintln(w, msgr.InfoEmailChanged)
+		case ErrInvalidEmailLink:
+			http.Error(w, msgr.ErrorInvalidEmailLink, http.StatusBadRequest)
+		case ErrEmailTaken:
+			http.Error(w, msgr.ErrorEmailTaken, http.StatusConflict)
+		default:
+			http.Error(w, lmsgr.ErrorSystemError, http.StatusInternalServerError)
+		}
+	}))
+}
+
+func (b *Builder) checkEmailTaken(db *gorm.DB, email string) error {
+	var count int64
+	if err := db.Model(b.emailChangeUserModel).Where("account = ?", email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+func (b *Builder) updateEmail(account string, email string) error {
+	return b.emailChangeDB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(b.emailChangeUserModel).Where("account = ?", account).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return login.ErrUserNotFound
+		}
+		if err := b.checkEmailTaken(tx, email); err != nil {
+			return err
+		}
+		return tx.Model(b.emailChangeUserModel).Where("account = ?", account).Update("account", email).Error
+	})
+}
+
+// emailLink is the content of the token of a link verifying a new email
+type emailLink struct {
+	Account   string `json:"a"`
+	Email     string `json:"e"`
+	ExpiresAt int64  `json:"x"`
+}
+
+func (b *Builder) signEmailLink(l emailLink) string {
+	body, _ := json.Marshal(l)
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	return payload + "." + b.emailLinkMAC(payload)
+}
+
+func (b *Builder) parseEmailLink(token string) (l emailLink, err error) {
+	payload, mac, ok := strings.Cut(token, ".")
+	if !ok || b.emailChangeSecret == "" || !hmac.Equal([]byte(mac), []byte(b.emailLinkMAC(payload))) {
+		return l, ErrInvalidEmailLink
+	}
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || json.Unmarshal(body, &l) != nil || time.Now().Unix() > l.ExpiresAt {
+		return l, ErrInvalidEmailLink
+	}
+	return l, nil
+}
+
+func (b *Builder) emailLinkMAC(payload string) string {
+	h := hmac.New(sha256.New, []byte(b.emailChangeSecret))
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (b *Builder) registerChangeEmailEvents() {
+	showVar := "showChangeEmailDialog"
+	b.pb.GetWebBuilder().RegisterEventFunc(OpenChangeEmailDialogEvent, func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+			Name: presets.DialogPortalName,
+			Body: changeEmailDialog(ctx, showVar),
+		})
+		web.AppendVarsScripts(&r, fmt.Sprintf("setTimeout(function(){ vars.%s = true }, 100)", showVar))
+		return
+	})
+
+	b.pb.GetWebBuilder().RegisterEventFunc(ChangeEmailEvent, func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		lmsgr := i18n.MustGetModuleMessages(ctx.R, login.I18nLoginKey, login.Messages_en_US).(*login.Messages)
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nAdminLoginKey, Messages_en_US).(*Messages)
+
+		err = b.ChangeEmail(ctx.R, ctx.R.FormValue("current_password"), ctx.R.FormValue("email"))
+		if err != nil {
+			msg := lmsgr.ErrorSystemError
+			switch err {
+			case login.ErrWrongPassword:
+				msg = lmsgr.ErrorIncorrectPassword
+			case ErrPasswordNotSet:
+				msg = msgr.ErrorPasswordNotSet
+			case ErrInvalidEmail:
+				msg = msgr.ErrorInvalidEmail
+			case ErrEmailTaken:
+				msg = msgr.ErrorEmailTaken
+			}
+			if ne, ok := err.(*login.NoticeError); ok {
+				msg = ne.Message
+			}
+			presets.ShowMessage(&r, msg, "error")
+			return r, nil
+		}
+
+		web.AppendVarsScripts(&r, fmt.Sprintf("vars.%s = false", showVar))
+		if b.emailChangeVerifyURL != "" {
+			presets.ShowMessage(&r, strings.ReplaceAll(msgr.InfoEmailVerificationSent, "{Email}", strings.TrimSpace(ctx.R.FormValue("email"))), "info")
+			return r, nil
+		}
+		presets.ShowMessage(&r, msgr.InfoEmailChanged, "info")
+		r.Reload = true
+		return r, nil
+	})
+}
+
+func changeEmailDialog(ctx *web.EventContext, showVar string) HTMLComponent {
+	pmsgr := presets.MustGetMessages(ctx.R)
+	lmsgr := i18n.MustGetModuleMessages(ctx.R, login.I18nLoginKey, login.Messages_en_US).(*login.Messages)
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nAdminLoginKey, Messages_en_US).(*Messages)
+	return v.VDialog(
+		v.VCard(
+			v.VCardTitle(Text(msgr.ChangeEmailTitle)),
+			v.VCardText(
+				Div(
+					DefaultViewCommon.Input("email", msgr.ChangeEmailNewPlaceholder, "").
+						Outlined(false).
+						Label(msgr.ChangeEmailNewLabel).
+						FieldName("email"),
+				),
+				Div(
+					DefaultViewCommon.PasswordInput("current_password", lmsgr.ChangePasswordOldPlaceholder, "", true).
+						Outlined(false).
+						Label(lmsgr.ChangePasswordOldLabel).
+						FieldName("current_password"),
+				).Class("mt-12"),
+			),
+			v.VCardActions(
+				v.VSpacer(),
+				v.VBtn(pmsgr.Cancel).
+					Depressed(true).
+					Class("ml-2").
+					On("click", fmt.Sprintf("vars.%s = false", showVar)),
+
+				v.VBtn(pmsgr.OK).
+					Color("primary").
+					Depressed(true).
+					Dark(true).
+					Attr("@click", web.Plaid().EventFunc(ChangeEmailEvent).Go()),
+			),
+		),
+	).MaxWidth("600px").
+		Attr("v-model", fmt.Sprintf("vars.%s", showVar)).
+		Attr(web.InitContextVars, fmt.Sprintf(`{%s: false}`, showVar))
+}
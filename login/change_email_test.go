@@ -0,0 +1,137 @@
+package login
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/x/login"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestChangeEmail(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&rehashUser{}); err != nil {
+		t.Fatal(err)
+	}
+	u := &rehashUser{UserPass: UserPass{UserPass: login.UserPass{Account: "a@example.com"}, Password: "secret"}}
+	u.EncryptPassword()
+	other := &rehashUser{UserPass: UserPass{UserPass: login.UserPass{Account: "b@example.com"}}}
+	oauth := &rehashUser{UserPass: UserPass{UserPass: login.UserPass{Account: "c@example.com"}}}
+	for _, m := range []*rehashUser{u, other} {
+		if err = db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	request := func(user interface{}) *http.Request {
+		r := httptest.NewRequest("POST", "/", nil)
+		return r.WithContext(context.WithValue(r.Context(), login.UserKey, user))
+	}
+
+	b := New(presets.New())
+	if err = b.ChangeEmail(request(u), "secret", "new@example.com"); err != ErrEmailChangeDisabled {
+		t.Errorf("err = %v, want %v", err, ErrEmailChangeDisabled)
+	}
+	b.EmailChange(db, &rehashUser{})
+
+	for _, c := range []struct {
+		user  interface{}
+		proof string
+		email string
+		want  error
+	}{
+		{u, "secret", "not an email", ErrInvalidEmail},
+		{u, "wrong", "new@example.com", login.ErrWrongPassword},
+		{u, "secret", "b@example.com", ErrEmailTaken},
+		{oauth, "", "new@example.com", ErrPasswordNotSet},
+	} {
+		if err = b.ChangeEmail(request(c.user), c.proof, c.email); err != c.want {
+			t.Errorf("ChangeEmail(%q, %q) = %v, want %v", c.proof, c.email, err, c.want)
+		}
+	}
+
+	if err = b.ChangeEmail(request(u), "secret", " new@example.com "); err != nil {
+		t.Fatal(err)
+	}
+	var got rehashUser
+	db.First(&got, u.ID)
+	if got.Account != "new@example.com" {
+		t.Errorf("account = %q, want new@example.com", got.Account)
+	}
+
+	b.AlternateVerification(func(r *http.Request, user interface{}, proof string) error {
+		if proof != "123456" {
+			return login.ErrWrongPassword
+		}
+		return nil
+	})
+	if err = b.VerifyCurrentPassword(request(oauth), "000000"); err != login.ErrWrongPassword {
+		t.Errorf("err = %v, want %v", err, login.ErrWrongPassword)
+	}
+	if err = b.VerifyCurrentPassword(request(oauth), "123456"); err != nil {
+		t.Errorf("the alternate verification isn't used: %v", err)
+	}
+}
+
+func TestChangeEmailVerification(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&rehashUser{}); err != nil {
+		t.Fatal(err)
+	}
+	u := &rehashUser{UserPass: UserPass{UserPass: login.UserPass{Account: "a@example.com"}, Password: "secret"}}
+	u.EncryptPassword()
+	if err = db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), login.UserKey, u))
+
+	var sent *Notification
+	b := New(presets.New()).
+		Notifier(NotifierFunc(func(ctx context.Context, n *Notification) error {
+			sent = n
+			return nil
+		})).
+		EmailChange(db, &rehashUser{}).
+		EmailChangeVerification("secret", "http://localhost/auth/verify-email")
+	if err = b.ChangeEmail(r, "secret", "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	var got rehashUser
+	db.First(&got, u.ID)
+	if got.Account != "a@example.com" {
+		t.Errorf("the email is changed before it's verified: %q", got.Account)
+	}
+	if sent == nil || sent.Kind != NotificationVerifyEmail || sent.To != "new@example.com" {
+		t.Fatalf("no verification link sent to the new email: %+v", sent)
+	}
+	link, err := url.Parse(sent.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := link.Query().Get("token")
+
+	if err = b.VerifyEmail(token + "x"); err != ErrInvalidEmailLink {
+		t.Errorf("err = %v, want %v", err, ErrInvalidEmailLink)
+	}
+	if err = b.VerifyEmail(token); err != nil {
+		t.Fatal(err)
+	}
+	db.First(&got, u.ID)
+	if got.Account != "new@example.com" {
+		t.Errorf("account = %q, want new@example.com", got.Account)
+	}
+	if err = b.VerifyEmail(token); err != ErrInvalidEmailLink {
+		t.Errorf("the link is used twice: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package login
+
+import (
+	"net/http"
+
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	"github.com/qor5/x/login"
+	"gorm.io/gorm"
+)
+
+const accountDisabledFlashCookieName = "qor5_account_disabled_flash"
+
+// GetDisabled reports whether the account is disabled by an admin, unlike a lockout it doesn't expire
+// and is only lifted by Enable
+func (up *UserPass) GetDisabled() bool {
+	return up.DisabledAt != nil
+}
+
+// Disable disables the account of the user, DisabledAccountMiddleware signs out all its sessions on their next request.
+// Users without an account, like the OAuth ones, are found by the primary key of model.
+func (up *UserPass) Disable(db *gorm.DB, model interface{}) error {
+	now := db.NowFunc()
+	if err := up.whereAccount(db, model).Update("disabled_at", now).Error; err != nil {
+		return err
+	}
+	up.DisabledAt = &now
+	return nil
+}
+
+// Enable lets the user log in again
+func (up *UserPass) Enable(db *gorm.DB, model interface{}) error {
+	if err := up.whereAccount(db, model).Update("disabled_at", nil).Error; err != nil {
+		return err
+	}
+	up.DisabledAt = nil
+	return nil
+}
+
+func (up *UserPass) whereAccount(db *gorm.DB, model interface{}) *gorm.DB {
+	q := db.Model(model)
+	if up.Account != "" {
+		q = q.Where("account = ?", up.Account)
+	}
+	return q
+}
+
+// DisabledAccountHook rejects a disabled user logging in before the session is issued, the login page tells the account is disabled.
+// Builder calls it before the AfterLogin hook.
+// The accounts disabled while they are logged in are signed out by DisabledAccountMiddleware.
+func DisabledAccountHook(h login.HookFunc) login.HookFunc {
+	return func(r *http.Request, user interface{}, extraVals ...interface{}) error {
+		if isDisabled(user) {
+			setRequestLoginError(r, ErrAccountDisabled)
+			return &login.NoticeError{
+				Level:   login.NoticeLevel_Error,
+				Message: i18n.MustGetModuleMessages(r, I18nAdminLoginKey, Messages_en_US).(*Messages).ErrorAccountDisabled,
+			}
+		}
+		if h == nil {
+			return nil
+		}
+		return h(r, user, extraVals...)
+	}
+}
+
+// DisabledAccountMiddleware signs out the users whose account is disabled, it must be used after the middleware of the login builder.
+// Pages are redirected to the logout URL and the login page tells the account is disabled, event requests are rejected.
+func DisabledAccountMiddleware(lb *login.Builder) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isDisabled(login.GetCurrentUser(r)) || r.URL.Path == lb.LogoutURL {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// the refused login is counted by the AfterFailedToLogin hook, signing out a session isn't a login
+			http.SetCookie(w, &http.Cookie{
+				Name:     accountDisabledFlashCookieName,
+				Value:    "1",
+				Path:     "/",
+				HttpOnly: true,
+			})
+			if r.URL.Query().Get(web.EventFuncIDName) != "" {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			http.Redirect(w, r, lb.LogoutURL, http.StatusFound)
+		})
+	}
+}
+
+// accountDisabledFlashMessage returns the message set by DisabledAccountMiddleware once
+func accountDisabledFlashMessage(w http.ResponseWriter, r *http.Request) string {
+	if _, err := r.Cookie(accountDisabledFlashCookieName); err != nil {
+		return ""
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:   accountDisabledFlashCookieName,
+		Path:   "/",
+		MaxAge: -1,
+	})
+	return i18n.MustGetModuleMessages(r, I18nAdminLoginKey, Messages_en_US).(*Messages).ErrorAccountDisabled
+}
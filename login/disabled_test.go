@@ -0,0 +1,76 @@
+package login
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/login"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDisabledAccountHook(t *testing.T) {
+	r := httptest.NewRequest("POST", "/auth/userpass/login", nil)
+	called := false
+	hook := DisabledAccountHook(func(r *http.Request, user interface{}, _ ...interface{}) error {
+		called = true
+		return nil
+	})
+
+	now := time.Now()
+	err := hook(r, &UserPass{DisabledAt: &now})
+	if _, ok := err.(*login.NoticeError); !ok {
+		t.Fatalf("expected a notice for the disabled user, got %v", err)
+	}
+	if called {
+		t.Error("the chained hook is called for the disabled user")
+	}
+
+	if err = hook(r, &UserPass{}); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("the chained hook isn't called")
+	}
+}
+
+func TestRememberMeRefusesDisabledUsers(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&rehashUser{}); err != nil {
+		t.Fatal(err)
+	}
+	u := &rehashUser{UserPass: UserPass{UserPass: login.UserPass{Account: "a@example.com", PassUpdatedAt: "1"}}}
+	if err = db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	rm := NewRememberMe(nil, db).UserModel(&rehashUser{})
+	if err = db.Create(&RememberToken{
+		UserID:        fmt.Sprint(u.ID),
+		TokenHash:     hashRememberToken("before"),
+		PassUpdatedAt: "1",
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = rm.lookup("before"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = u.Disable(db, &rehashUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = rm.lookup("before"); err == nil {
+		t.Fatal("the session of a disabled user is restored")
+	}
+	var count int64
+	db.Model(&RememberToken{}).Count(&count)
+	if count != 0 {
+		t.Error("the remember token of the disabled user isn't revoked")
+	}
+}
@@ -0,0 +1,105 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/qor5/x/login"
+)
+
+// The errors of a failed login, they tell the reason for logging and metrics only,
+// the login page keeps showing generic messages so accounts can't be enumerated with them.
+var (
+	ErrWrongPassword   = login.ErrWrongPassword
+	ErrAccountLocked   = login.ErrUserLocked
+	ErrAccountDisabled = errors.New("account disabled")
+	ErrPasswordExpired = errors.New("password expired")
+)
+
+// The reasons of LoginFailureReason
+const (
+	FailureReasonWrongPassword   = "wrong_password"
+	FailureReasonUserNotFound    = "user_not_found"
+	FailureReasonAccountLocked   = "account_locked"
+	FailureReasonAccountDisabled = "account_disabled"
+	FailureReasonPasswordExpired = "password_expired"
+	FailureReasonWrongTOTPCode   = "wrong_totp_code"
+	FailureReasonOther           = "other"
+)
+
+// AccountError returns ErrAccountDisabled for a user disabled by Disable and ErrPasswordExpired for a user
+// whose password is older than MaxPasswordAge, or nil. DisabledAccountHook refuses the login with the first one,
+// the change password page tells the password expired with the second one.
+func (b *Builder) AccountError(user interface{}) error {
+	if isDisabled(user) {
+		return ErrAccountDisabled
+	}
+	if b.PasswordExpired(user) {
+		return ErrPasswordExpired
+	}
+	return nil
+}
+
+func isDisabled(user interface{}) bool {
+	u, ok := user.(interface{ GetDisabled() bool })
+	return ok && u.GetDisabled()
+}
+
+// LoginError returns the error of a failed login from the extra values of the AfterFailedToLogin hooks,
+// the errors of the login builder meaning the same are unified, like login.ErrUserGetLocked to ErrAccountLocked
+func LoginError(extraVals ...interface{}) error {
+	for _, v := range extraVals {
+		err, ok := v.(error)
+		if !ok {
+			continue
+		}
+		switch {
+		case errors.Is(err, login.ErrUserGetLocked):
+			return ErrAccountLocked
+		case errors.Is(err, login.ErrPasswordNotMatch), errors.Is(err, login.ErrEmptyPassword):
+			return ErrWrongPassword
+		}
+		return err
+	}
+	return nil
+}
+
+// loginErrorKey keeps the error of a login refused by a hook of this package in the context of the request
+type loginErrorKey struct{}
+
+// setRequestLoginError keeps err in r itself, the login builder passes the same request to the AfterFailedToLogin hook
+func setRequestLoginError(r *http.Request, err error) {
+	*r = *r.WithContext(context.WithValue(r.Context(), loginErrorKey{}, err))
+}
+
+// requestLoginError is LoginError with the notices of the hooks of this package turned back into their errors,
+// the login builder only shows a *login.NoticeError returned by a hook, so the notice is all AfterFailedToLogin gets
+func requestLoginError(r *http.Request, extraVals ...interface{}) error {
+	err := LoginError(extraVals...)
+	if _, ok := err.(*login.NoticeError); ok {
+		if v, ok := r.Context().Value(loginErrorKey{}).(error); ok {
+			return v
+		}
+	}
+	return err
+}
+
+// LoginFailureReason returns a short reason of the login error to label metrics or logs with
+func LoginFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrWrongPassword):
+		return FailureReasonWrongPassword
+	case errors.Is(err, login.ErrUserNotFound):
+		return FailureReasonUserNotFound
+	case errors.Is(err, ErrAccountLocked), errors.Is(err, login.ErrUserGetLocked):
+		return FailureReasonAccountLocked
+	case errors.Is(err, ErrAccountDisabled):
+		return FailureReasonAccountDisabled
+	case errors.Is(err, ErrPasswordExpired):
+		return FailureReasonPasswordExpired
+	case errors.Is(err, login.ErrWrongTOTPCode), errors.Is(err, login.ErrTOTPCodeHasBeenUsed):
+		return FailureReasonWrongTOTPCode
+	}
+	return FailureReasonOther
+}
@@ -0,0 +1,78 @@
+package login
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qor5/admin/metrics"
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/x/login"
+)
+
+func TestLoginFailureReason(t *testing.T) {
+	cases := []struct {
+		extraVals []interface{}
+		want      string
+	}{
+		{[]interface{}{login.ErrWrongPassword}, FailureReasonWrongPassword},
+		{[]interface{}{login.ErrPasswordNotMatch}, FailureReasonWrongPassword},
+		{[]interface{}{login.ErrUserGetLocked}, FailureReasonAccountLocked},
+		{[]interface{}{"extra", fmt.Errorf("login: %w", ErrAccountDisabled)}, FailureReasonAccountDisabled},
+		{[]interface{}{ErrPasswordExpired}, FailureReasonPasswordExpired},
+		{[]interface{}{login.ErrUserNotFound}, FailureReasonUserNotFound},
+		{nil, FailureReasonOther},
+	}
+	for _, c := range cases {
+		if got := LoginFailureReason(LoginError(c.extraVals...)); got != c.want {
+			t.Errorf("LoginFailureReason(%v) = %q, want %q", c.extraVals, got, c.want)
+		}
+	}
+}
+
+func TestAccountError(t *testing.T) {
+	b := New(presets.New()).MaxPasswordAge(time.Hour)
+
+	now := time.Now()
+	old := fmt.Sprint(now.Add(-2 * time.Hour).UnixNano())
+	cases := []struct {
+		user interface{}
+		want error
+	}{
+		{&UserPass{DisabledAt: &now}, ErrAccountDisabled},
+		{&UserPass{UserPass: login.UserPass{PassUpdatedAt: old}, Password: "hash"}, ErrPasswordExpired},
+		{&UserPass{UserPass: login.UserPass{PassUpdatedAt: fmt.Sprint(now.UnixNano())}, Password: "hash"}, nil},
+		{nil, nil},
+	}
+	for _, c := range cases {
+		if got := b.AccountError(c.user); got != c.want {
+			t.Errorf("AccountError(%#v) = %v, want %v", c.user, got, c.want)
+		}
+	}
+}
+
+func TestMetricsHookCountsDisabledLogins(t *testing.T) {
+	reg := metrics.NewRegistry()
+	metrics.SetRecorder(reg)
+	defer metrics.SetRecorder(nil)
+
+	r := httptest.NewRequest("POST", "/auth/userpass/login", nil)
+	now := time.Now()
+	// the login builder passes the notice of the AfterLogin hook to AfterFailedToLogin
+	notice := DisabledAccountHook(nil)(r, &UserPass{DisabledAt: &now})
+	if err := MetricsHook(LoginResultFailure, nil)(r, nil, notice); err != nil {
+		t.Fatal(err)
+	}
+	// the reason isn't told by the text of the notice
+	if got := LoginFailureReason(requestLoginError(httptest.NewRequest("POST", "/auth/userpass/login", nil), notice)); got != FailureReasonOther {
+		t.Errorf("a notice of another request is %s", got)
+	}
+
+	w := httptest.NewRecorder()
+	reg.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if want := `qor5_login_total{reason="account_disabled",result="failure"} 1`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("missing %q in:\n%s", want, w.Body.String())
+	}
+}
@@ -0,0 +1,79 @@
+package login
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qor5/x/i18n"
+	"github.com/qor5/x/login"
+)
+
+// MaxPasswordAge sets how long a password lasts, the users with an older one must change it on the next login
+// like with MustChangePassword. Zero, the default, means passwords don't expire.
+func (b *Builder) MaxPasswordAge(v time.Duration) (r *Builder) {
+	b.maxPasswordAge = v
+	return b
+}
+
+// PasswordExpiryWarning sets how long before the expiry PasswordExpiryNotice starts warning the users, 7 days by default
+func (b *Builder) PasswordExpiryWarning(v time.Duration) (r *Builder) {
+	b.passwordExpiryWarning = v
+	return b
+}
+
+// PasswordUpdatedTime returns when the password was set, false for the users without a password like the OAuth ones
+func (up *UserPass) PasswordUpdatedTime() (time.Time, bool) {
+	if up.Password == "" || up.PassUpdatedAt == "" {
+		return time.Time{}, false
+	}
+	ns, err := strconv.ParseInt(up.PassUpdatedAt, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ns), true
+}
+
+// PasswordExpiresAt returns when the password of the user expires by MaxPasswordAge, false if it doesn't
+func (b *Builder) PasswordExpiresAt(user interface{}) (time.Time, bool) {
+	u, ok := user.(interface{ PasswordUpdatedTime() (time.Time, bool) })
+	if !ok || b.maxPasswordAge <= 0 {
+		return time.Time{}, false
+	}
+	t, ok := u.PasswordUpdatedTime()
+	if !ok {
+		return time.Time{}, false
+	}
+	return t.Add(b.maxPasswordAge), true
+}
+
+// PasswordExpired reports whether the password of the user is older than MaxPasswordAge
+func (b *Builder) PasswordExpired(user interface{}) bool {
+	t, ok := b.PasswordExpiresAt(user)
+	return ok && !time.Now().Before(t)
+}
+
+// PasswordExpiryNotice returns a warning for the current user whose password expires within PasswordExpiryWarning,
+// or an empty string
+func (b *Builder) PasswordExpiryNotice(r *http.Request) string {
+	t, ok := b.PasswordExpiresAt(login.GetCurrentUser(r))
+	left := time.Until(t)
+	if !ok || left <= 0 || left > b.passwordExpiryWarning {
+		return ""
+	}
+	days := int(left.Hours()/24) + 1
+	msgr := i18n.MustGetModuleMessages(r, I18nAdminLoginKey, Messages_en_US).(*Messages)
+	if days == 1 {
+		return msgr.PasswordExpiresInOneDay
+	}
+	return strings.ReplaceAll(msgr.PasswordExpiresInDays, "{Days}", strconv.Itoa(days))
+}
+
+// passwordExpiredNotice returns the reason of the change password page for the current user whose password expired
+func (b *Builder) passwordExpiredNotice(r *http.Request) string {
+	if !b.PasswordExpired(login.GetCurrentUser(r)) {
+		return ""
+	}
+	return i18n.MustGetModuleMessages(r, I18nAdminLoginKey, Messages_en_US).(*Messages).PasswordExpired
+}
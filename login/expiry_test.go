@@ -0,0 +1,82 @@
+package login
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/x/login"
+)
+
+func TestPasswordExpired(t *testing.T) {
+	b := New(presets.New())
+	up := &UserPass{Password: "hash"}
+	up.PassUpdatedAt = fmt.Sprint(time.Now().Add(-100 * 24 * time.Hour).UnixNano())
+	if b.PasswordExpired(up) {
+		t.Errorf("passwords should not expire without MaxPasswordAge")
+	}
+
+	b.MaxPasswordAge(90 * 24 * time.Hour)
+	if !b.PasswordExpired(up) || !b.mustChangePassword(up) {
+		t.Errorf("a password of 100 days should be expired")
+	}
+	up.PassUpdatedAt = fmt.Sprint(time.Now().UnixNano())
+	if b.PasswordExpired(up) {
+		t.Errorf("a new password should not be expired")
+	}
+
+	oauth := &UserPass{}
+	if b.PasswordExpired(oauth) {
+		t.Errorf("users without a password should not expire")
+	}
+}
+
+func TestPasswordExpiryNotice(t *testing.T) {
+	b := New(presets.New()).MaxPasswordAge(10 * 24 * time.Hour)
+	request := func(age time.Duration) *http.Request {
+		up := &UserPass{Password: "hash"}
+		up.PassUpdatedAt = fmt.Sprint(time.Now().Add(-age).UnixNano())
+		r := httptest.NewRequest("GET", "/", nil)
+		return r.WithContext(context.WithValue(r.Context(), login.UserKey, up))
+	}
+	for age, want := range map[time.Duration]string{
+		time.Hour:                   "",
+		5*24*time.Hour + time.Hour:  "Your password expires in 5 days, please change it",
+		9*24*time.Hour + time.Hour:  "Your password expires in 1 day, please change it",
+		10*24*time.Hour + time.Hour: "",
+	} {
+		if got := b.PasswordExpiryNotice(request(age)); got != want {
+			t.Errorf("PasswordExpiryNotice(%v) = %q, want %q", age, got, want)
+		}
+	}
+}
+
+func TestMustChangePasswordMiddleware(t *testing.T) {
+	b := New(presets.New())
+	h := b.MustChangePasswordMiddleware("/change-password")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, c := range []struct {
+		method, target, accept string
+		want                   int
+	}{
+		{"GET", "/admin/pages", "text/html", http.StatusFound},
+		{"GET", "/change-password", "text/html", http.StatusOK},
+		{"POST", "/change-password?__execute_event__=save", "", http.StatusOK},
+		{"POST", "/admin/pages?__execute_event__=presets_Update", "", http.StatusForbidden},
+		{"POST", "/admin/api/pages", "", http.StatusForbidden},
+		{"DELETE", "/admin/api/pages/1", "", http.StatusForbidden},
+		{"GET", "/admin/assets/main.js", "*/*", http.StatusOK},
+	} {
+		r := httptest.NewRequest(c.method, c.target, nil)
+		r.Header.Set("Accept", c.accept)
+		r = r.WithContext(context.WithValue(r.Context(), login.UserKey, &UserPass{Password: "hash", MustChangePassword: true}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.want {
+			t.Errorf("%v %v = %v, want %v", c.method, c.target, w.Code, c.want)
+		}
+	}
+}
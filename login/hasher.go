@@ -0,0 +1,160 @@
+package login
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher hashes passwords into a self-describing string, the prefix of the hash tells which hasher made it
+// so hashes of different hashers can coexist in the user table.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(hash string, password string) bool
+	// Identify reports whether the hash was made by this hasher
+	Identify(hash string) bool
+	// NeedsRehash reports whether the hash was made with weaker parameters than the current ones
+	NeedsRehash(hash string) bool
+}
+
+// PasswordHasher hashes new passwords, existing hashes of the other PasswordHashers still verify
+// and are upgraded on the next login with RehashHook.
+var PasswordHasher Hasher = &BcryptHasher{}
+
+// PasswordHashers are all the hashers whose hashes can be verified
+var PasswordHashers = []Hasher{&BcryptHasher{}, NewArgon2idHasher()}
+
+var errUnknownHash = errors.New("unknown password hash")
+
+// BcryptHasher uses BcryptCost when Cost is 0
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) cost() int {
+	if h.Cost == 0 {
+		return BcryptCost
+	}
+	return h.Cost
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost())
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (h *BcryptHasher) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < h.cost()
+}
+
+// Argon2idHasher encodes hashes in the PHC string format $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// NewArgon2idHasher uses the parameters recommended by RFC 9106 for memory constrained environments
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+		KeyLen:  32,
+		SaltLen: 16,
+	}
+}
+
+const argon2idPrefix = "$argon2id$"
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(hash string, password string) bool {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+func (h *Argon2idHasher) Identify(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	return p.Time < h.Time || p.Memory < h.Memory || p.Threads < h.Threads ||
+		uint32(len(key)) < h.KeyLen || uint32(len(salt)) < h.SaltLen
+}
+
+func decodeArgon2id(hash string) (p Argon2idHasher, salt []byte, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, key
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errUnknownHash
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errUnknownHash
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, errUnknownHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, errUnknownHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return p, nil, nil, errUnknownHash
+	}
+	return p, salt, key, nil
+}
+
+func identifyHasher(hash string) Hasher {
+	if PasswordHasher.Identify(hash) {
+		return PasswordHasher
+	}
+	for _, h := range PasswordHashers {
+		if h.Identify(hash) {
+			return h
+		}
+	}
+	return nil
+}
+
+// VerifyPassword verifies the password with the hasher that made the hash
+func VerifyPassword(hash string, password string) bool {
+	h := identifyHasher(hash)
+	return h != nil && h.Verify(hash, password)
+}
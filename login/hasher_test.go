@@ -0,0 +1,48 @@
+package login
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasherMigration(t *testing.T) {
+	defer func(h Hasher) { PasswordHasher = h }(PasswordHasher)
+
+	PasswordHasher = &BcryptHasher{Cost: bcrypt.MinCost}
+	old, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	argon2id := &Argon2idHasher{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}
+	PasswordHasher = argon2id
+	if !VerifyPassword(old, "secret") {
+		t.Error("bcrypt hash should still verify")
+	}
+	if !NeedsRehash(old) {
+		t.Error("bcrypt hash should be rehashed with argon2id")
+	}
+
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !argon2id.Identify(hash) {
+		t.Fatalf("not an argon2id hash: %s", hash)
+	}
+	if !VerifyPassword(hash, "secret") || VerifyPassword(hash, "wrong") {
+		t.Error("argon2id hash should only verify the right password")
+	}
+	if NeedsRehash(hash) {
+		t.Error("argon2id hash of the current parameters needs no rehash")
+	}
+
+	argon2id.Time = 2
+	if !NeedsRehash(hash) {
+		t.Error("argon2id hash of a lower time should be rehashed")
+	}
+	if VerifyPassword("plain", "plain") {
+		t.Error("unknown hash should never verify")
+	}
+}
@@ -0,0 +1,96 @@
+package login
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"unsafe"
+
+	"github.com/qor5/x/login"
+)
+
+// chainedHook is a hook of login.Builder calling the checks of this package before the hook registered by the user
+type chainedHook struct {
+	// field is the field of login.Builder keeping the hook
+	field string
+	// pre wraps the registered hook with the checks of this package
+	pre func(h login.HookFunc) login.HookFunc
+	h   login.HookFunc
+}
+
+func (c *chainedHook) run(r *http.Request, user interface{}, extraVals ...interface{}) error {
+	h := c.h
+	if h != nil {
+		h = withCurrentUser(h)
+	}
+	return c.pre(h)(r, user, extraVals...)
+}
+
+// withCurrentUser sets the user of the hook as the current user of the request like the setters of login.Builder
+func withCurrentUser(h login.HookFunc) login.HookFunc {
+	return func(r *http.Request, user interface{}, extraVals ...interface{}) error {
+		if user != nil && login.GetCurrentUser(r) == nil {
+			r = r.WithContext(context.WithValue(r.Context(), login.UserKey, user))
+		}
+		return h(r, user, extraVals...)
+	}
+}
+
+// installHooks puts the chained hooks into login.Builder. A hook set with the setter of the embedded login.Builder
+// replaces the chained one, it's taken as the registered hook of the chain, so the checks are kept whatever the
+// order of the setters. It's called by the setters of the hooks, Mount, MountAPI and Middleware.
+func (b *Builder) installHooks() {
+	v := reflect.ValueOf(b.Builder).Elem()
+	for _, c := range []*chainedHook{b.afterLogin, b.afterFailedToLogin, b.afterUserLocked} {
+		f := v.FieldByName(c.field)
+		if !f.IsValid() || f.Type() != reflect.TypeOf(login.HookFunc(nil)) {
+			panic(fmt.Sprintf("login: login.Builder has no hook %s", c.field))
+		}
+		p := (*login.HookFunc)(unsafe.Pointer(f.UnsafeAddr()))
+		// the setters of login.Builder keep a closure of the hook, never the method value of a chained hook
+		if *p == nil || reflect.ValueOf(*p).Pointer() != reflect.ValueOf(c.run).Pointer() {
+			c.h = *p
+		}
+		*p = c.run
+	}
+}
+
+// AfterLogin sets the hook called after a user logs in, DisabledAccountHook and MetricsHook are called before it
+func (b *Builder) AfterLogin(v login.HookFunc) (r *Builder) {
+	b.installHooks()
+	b.afterLogin.h = v
+	return b
+}
+
+// AfterFailedToLogin sets the hook called after a failed login, MetricsHook is called before it
+func (b *Builder) AfterFailedToLogin(v login.HookFunc) (r *Builder) {
+	b.installHooks()
+	b.afterFailedToLogin.h = v
+	return b
+}
+
+// AfterUserLocked sets the hook called after a user gets locked, MetricsHook is called before it
+func (b *Builder) AfterUserLocked(v login.HookFunc) (r *Builder) {
+	b.installHooks()
+	b.afterUserLocked.h = v
+	return b
+}
+
+// Mount mounts the pages and the API of login.Builder, with the hooks set with the setters of login.Builder chained
+func (b *Builder) Mount(mux *http.ServeMux) {
+	b.installHooks()
+	b.Builder.Mount(mux)
+}
+
+// MountAPI mounts the API of login.Builder, with the hooks set with the setters of login.Builder chained
+func (b *Builder) MountAPI(mux *http.ServeMux) {
+	b.installHooks()
+	b.Builder.MountAPI(mux)
+}
+
+// Middleware is the middleware of login.Builder, with the hooks set with the setters of login.Builder chained
+func (b *Builder) Middleware(cfgs ...login.MiddlewareConfig) func(next http.Handler) http.Handler {
+	b.installHooks()
+	return b.Builder.Middleware(cfgs...)
+}
@@ -0,0 +1,101 @@
+package login
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qor5/admin/metrics"
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/x/login"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newHooksTestBuilder mounts the API of a Builder whose hooks are set with the setters of the embedded login.Builder
+func newHooksTestBuilder(t *testing.T, afterLogin, afterFailedToLogin login.HookFunc) (*gorm.DB, *http.ServeMux) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&rehashUser{}); err != nil {
+		t.Fatal(err)
+	}
+	b := New(presets.New())
+	b.DB(db).
+		UserModel(&rehashUser{}).
+		Secret("secret").
+		TOTP(false).
+		AfterLogin(afterLogin).
+		AfterFailedToLogin(afterFailedToLogin)
+	mux := http.NewServeMux()
+	b.MountAPI(mux)
+	return db, mux
+}
+
+func postLogin(mux *http.ServeMux, account string, password string) {
+	r := httptest.NewRequest("POST", "/auth/userpass/login", strings.NewReader(url.Values{"account": {account}, "password": {password}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func loginMetrics(reg *metrics.Registry) string {
+	w := httptest.NewRecorder()
+	reg.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	return w.Body.String()
+}
+
+func TestHooksOfLoginBuilderKeepTheMetrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	metrics.SetRecorder(reg)
+	defer metrics.SetRecorder(nil)
+
+	called := false
+	_, mux := newHooksTestBuilder(t, nil, func(r *http.Request, user interface{}, _ ...interface{}) error {
+		called = true
+		return nil
+	})
+	postLogin(mux, "nobody@example.com", "secret")
+	if !called {
+		t.Error("the registered hook isn't called")
+	}
+	if want := `result="failure"} 1`; !strings.Contains(loginMetrics(reg), want) {
+		t.Errorf("missing %q in:\n%s", want, loginMetrics(reg))
+	}
+}
+
+func TestHooksOfLoginBuilderKeepTheDisabledAccountCheck(t *testing.T) {
+	reg := metrics.NewRegistry()
+	metrics.SetRecorder(reg)
+	defer metrics.SetRecorder(nil)
+
+	called := false
+	db, mux := newHooksTestBuilder(t, func(r *http.Request, user interface{}, _ ...interface{}) error {
+		called = true
+		return nil
+	}, nil)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	now := time.Now()
+	u := &rehashUser{UserPass: UserPass{UserPass: login.UserPass{Account: "a@example.com"}, Password: string(hash), DisabledAt: &now}}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	postLogin(mux, "a@example.com", "secret")
+	if called {
+		t.Error("the disabled user logged in")
+	}
+	if want := `qor5_login_total{reason="account_disabled",result="failure"} 1`; !strings.Contains(loginMetrics(reg), want) {
+		t.Errorf("missing %q in:\n%s", want, loginMetrics(reg))
+	}
+
+	db.Model(u).Update("disabled_at", nil)
+	postLogin(mux, "a@example.com", "secret")
+	if !called {
+		t.Error("the registered hook isn't called")
+	}
+}
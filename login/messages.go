@@ -0,0 +1,75 @@
+package login
+
+import (
+	"github.com/qor5/x/i18n"
+)
+
+const I18nAdminLoginKey i18n.ModuleKey = "I18nAdminLoginKey"
+
+type Messages struct {
+	RememberMe                string
+	ErrorPasswordNotSet       string
+	ErrorAccountDisabled      string
+	PasswordExpired           string
+	PasswordExpiresInDays     string
+	PasswordExpiresInOneDay   string
+	ChangeEmailTitle          string
+	ChangeEmailNewLabel       string
+	ChangeEmailNewPlaceholder string
+	ErrorInvalidEmail         string
+	ErrorEmailTaken           string
+	InfoEmailChanged          string
+	InfoEmailVerificationSent string
+	ErrorInvalidEmailLink     string
+}
+
+var Messages_en_US = &Messages{
+	RememberMe:                "Remember me",
+	ErrorPasswordNotSet:       "Your account has no password to verify",
+	ErrorAccountDisabled:      "Your account has been disabled, please contact an administrator",
+	PasswordExpired:           "Your password has expired, please change it to continue",
+	PasswordExpiresInDays:     "Your password expires in {Days} days, please change it",
+	PasswordExpiresInOneDay:   "Your password expires in 1 day, please change it",
+	ChangeEmailTitle:          "Change Email",
+	ChangeEmailNewLabel:       "New email",
+	ChangeEmailNewPlaceholder: "Enter your new email",
+	ErrorInvalidEmail:         "Please enter a valid email",
+	ErrorEmailTaken:           "The email is used by another account",
+	InfoEmailChanged:          "Your email has been changed",
+	InfoEmailVerificationSent: "A link to verify it has been sent to {Email}, your email is changed once you open it",
+	ErrorInvalidEmailLink:     "The link is invalid or has expired",
+}
+
+var Messages_zh_CN = &Messages{
+	RememberMe:                "记住我",
+	ErrorPasswordNotSet:       "您的账号没有可验证的密码",
+	ErrorAccountDisabled:      "您的账号已被停用，请联系管理员",
+	PasswordExpired:           "您的密码已过期，请修改密码后继续",
+	PasswordExpiresInDays:     "您的密码将在 {Days} 天后过期，请及时修改",
+	PasswordExpiresInOneDay:   "您的密码将在 1 天后过期，请及时修改",
+	ChangeEmailTitle:          "修改邮箱",
+	ChangeEmailNewLabel:       "新邮箱",
+	ChangeEmailNewPlaceholder: "请输入新邮箱",
+	ErrorInvalidEmail:         "请输入有效的邮箱",
+	ErrorEmailTaken:           "该邮箱已被其他账号使用",
+	InfoEmailChanged:          "您的邮箱已修改",
+	InfoEmailVerificationSent: "验证链接已发送至 {Email}，打开链接后邮箱即修改",
+	ErrorInvalidEmailLink:     "链接无效或已过期",
+}
+
+var Messages_ja_JP = &Messages{
+	RememberMe:                "ログイン状態を保持する",
+	ErrorPasswordNotSet:       "アカウントに確認できるパスワードがありません",
+	ErrorAccountDisabled:      "アカウントは無効化されています。管理者にお問い合わせください",
+	PasswordExpired:           "パスワードの有効期限が切れました。続行するにはパスワードを変更してください",
+	PasswordExpiresInDays:     "パスワードの有効期限はあと {Days} 日です。変更してください",
+	PasswordExpiresInOneDay:   "パスワードの有効期限はあと 1 日です。変更してください",
+	ChangeEmailTitle:          "メールアドレスを変更する",
+	ChangeEmailNewLabel:       "新しいメールアドレス",
+	ChangeEmailNewPlaceholder: "新しいメールアドレスを入力してください",
+	ErrorInvalidEmail:         "有効なメールアドレスを入力してください",
+	ErrorEmailTaken:           "このメールアドレスは他のアカウントで使用されています",
+	InfoEmailChanged:          "メールアドレスが変更されました",
+	InfoEmailVerificationSent: "確認用のリンクを {Email} に送信しました。リンクを開くとメールアドレスが変更されます",
+	ErrorInvalidEmailLink:     "リンクが無効か、有効期限が切れています",
+}
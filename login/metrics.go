@@ -0,0 +1,31 @@
+package login
+
+import (
+	"net/http"
+
+	"github.com/qor5/admin/metrics"
+	"github.com/qor5/x/login"
+)
+
+const (
+	LoginResultSuccess = "success"
+	LoginResultFailure = "failure"
+	LoginResultLocked  = "locked"
+)
+
+// MetricsHook counts qor5_login_total with the result label before calling h,
+// Builder calls it before the AfterLogin, AfterFailedToLogin and AfterUserLocked hooks.
+// Failures are labeled with the reason of LoginFailureReason too.
+func MetricsHook(result string, h login.HookFunc) login.HookFunc {
+	return func(r *http.Request, user interface{}, extraVals ...interface{}) error {
+		labels := metrics.Labels{"result": result}
+		if result == LoginResultFailure {
+			labels["reason"] = LoginFailureReason(requestLoginError(r, extraVals...))
+		}
+		metrics.Inc("qor5_login_total", labels)
+		if h == nil {
+			return nil
+		}
+		return h(r, user, extraVals...)
+	}
+}
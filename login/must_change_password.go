@@ -0,0 +1,46 @@
+package login
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/qor5/web"
+	"github.com/qor5/x/login"
+)
+
+// MustChangePasswordMiddleware redirects users whose MustChangePassword is set or password expired by MaxPasswordAge
+// to changePasswordPageURL, it must be used after the middleware of the login builder.
+// Pages are redirected, event and other non GET requests are rejected, assets are still served for the change password page.
+func (b *Builder) MustChangePasswordMiddleware(changePasswordPageURL string) func(next http.Handler) http.Handler {
+	lb := b.Builder
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !b.mustChangePassword(login.GetCurrentUser(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch r.URL.Path {
+			case changePasswordPageURL, lb.ViewHelper().ChangePasswordURL(), lb.LogoutURL:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.URL.Query().Get(web.EventFuncIDName) != "" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			if r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+				http.Redirect(w, r, changePasswordPageURL, http.StatusFound)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (b *Builder) mustChangePassword(user interface{}) bool {
+	if u, ok := user.(interface{ GetMustChangePassword() bool }); ok && u.GetMustChangePassword() {
+		return true
+	}
+	return b.PasswordExpired(user)
+}
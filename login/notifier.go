@@ -0,0 +1,183 @@
+package login
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+
+	"github.com/qor5/x/login"
+)
+
+const (
+	NotificationResetPassword = "reset_password"
+	NotificationVerifyEmail   = "verify_email"
+)
+
+// Notification is a link sent to a user, like the reset password link
+type Notification struct {
+	Kind string
+	// To is the account name of the user, their email
+	To   string
+	Link string
+	User interface{}
+}
+
+// Notifier delivers the notifications of the login flow
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// NotifierFunc is a func as a Notifier
+type NotifierFunc func(ctx context.Context, n *Notification) error
+
+func (f NotifierFunc) Notify(ctx context.Context, n *Notification) error {
+	return f(ctx, n)
+}
+
+// Notifier sets the Notifier sending the reset password and the email verification links, nothing is sent by default.
+// Use ConsoleNotifier in development and SMTPNotifier or your own one otherwise.
+func (b *Builder) Notifier(v Notifier) (r *Builder) {
+	if v == nil {
+		v = NoopNotifier
+	}
+	b.notifier = v
+	return b
+}
+
+// NoopNotifier drops the notifications
+var NoopNotifier Notifier = NotifierFunc(func(ctx context.Context, n *Notification) error { return nil })
+
+// ConsoleNotifier prints the notifications with their links to w, os.Stdout if nil, for development only
+func ConsoleNotifier(w io.Writer) Notifier {
+	if w == nil {
+		w = os.Stdout
+	}
+	return NotifierFunc(func(ctx context.Context, n *Notification) error {
+		_, err := fmt.Fprintf(w, "[login] %s to %s: %s\n", n.Kind, n.To, n.Link)
+		return err
+	})
+}
+
+// SMTPNotifier sends the notifications as plain text emails from the address from,
+// subject returns the subject and the body of the email of a notification.
+// The connection is closed when the context of the notification is done.
+func SMTPNotifier(addr string, auth smtp.Auth, from string, subject func(n *Notification) (subject string, body string)) Notifier {
+	return NotifierFunc(func(ctx context.Context, n *Notification) error {
+		to := headerValue(n.To)
+		s, body := subject(n)
+		msg := strings.Join([]string{
+			"From: " + headerValue(from),
+			"To: " + to,
+			"Subject: " + headerValue(s),
+			"MIME-Version: 1.0",
+			"Content-Type: text/plain; charset=UTF-8",
+			"",
+			body,
+		}, "\r\n")
+		return sendMail(ctx, addr, auth, headerValue(from), to, []byte(msg))
+	})
+}
+
+// headerValue removes the line breaks, so a value can't add headers to an email
+func headerValue(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
+
+// sendMail is smtp.SendMail with a context
+func sendMail(ctx context.Context, addr string, auth smtp.Auth, from string, to string, msg []byte) (err error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	host, _, _ := net.SplitHostPort(addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err = c.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err = c.Mail(from); err != nil {
+		return err
+	}
+	if err = c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// SendResetPasswordLink sends the reset password link to the user with the Notifier
+func (b *Builder) SendResetPasswordLink(ctx context.Context, user interface{}, link string) error {
+	n := &Notification{Kind: NotificationResetPassword, Link: link, User: user}
+	if u, ok := user.(interface{ GetAccountName() string }); ok {
+		n.To = u.GetAccountName()
+	}
+	return b.notifier.Notify(ctx, n)
+}
+
+// SendVerifyEmailLink sends the link verifying the email of the user with the Notifier, to the email
+func (b *Builder) SendVerifyEmailLink(ctx context.Context, user interface{}, email string, link string) error {
+	return b.notifier.Notify(ctx, &Notification{Kind: NotificationVerifyEmail, To: email, Link: link, User: user})
+}
+
+// ResetPasswordLinkHook sends the reset password link with the Notifier before calling h,
+// New registers it for AfterConfirmSendResetPasswordLink, wrap your own hook with it to keep sending the links,
+// e.g. AfterConfirmSendResetPasswordLink(b.ResetPasswordLinkHook(hook)).
+// A link failing to be sent is only logged, the answer is the same as for the accounts that don't exist.
+func (b *Builder) ResetPasswordLinkHook(h login.HookFunc) login.HookFunc {
+	return func(r *http.Request, user interface{}, extraVals ...interface{}) error {
+		link, _ := extraVals[0].(string)
+		if err := b.SendResetPasswordLink(r.Context(), user, link); err != nil {
+			log.Printf("login: failed to send the reset password link: %v", err)
+		}
+		if h == nil {
+			return nil
+		}
+		return h(r, user, extraVals...)
+	}
+}
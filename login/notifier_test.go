@@ -0,0 +1,61 @@
+package login
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/x/login"
+)
+
+func TestSendResetPasswordLink(t *testing.T) {
+	var buf bytes.Buffer
+	b := New(presets.New()).Notifier(ConsoleNotifier(&buf))
+	u := &UserPass{UserPass: login.UserPass{Account: "a@example.com"}}
+	if err := b.SendResetPasswordLink(context.Background(), u, "http://localhost/auth/reset-password?id=1&token=t"); err != nil {
+		t.Fatal(err)
+	}
+	want := "[login] reset_password to a@example.com: http://localhost/auth/reset-password?id=1&token=t\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestResetPasswordLinkHookHidesFailures(t *testing.T) {
+	b := New(presets.New()).Notifier(NotifierFunc(func(ctx context.Context, n *Notification) error {
+		return errors.New("smtp is down")
+	}))
+	called := false
+	hook := b.ResetPasswordLinkHook(func(r *http.Request, user interface{}, extraVals ...interface{}) error {
+		called = true
+		return nil
+	})
+	u := &UserPass{UserPass: login.UserPass{Account: "a@example.com"}}
+	if err := hook(httptest.NewRequest("POST", "/", nil), u, "http://localhost/link"); err != nil {
+		t.Errorf("the failure tells the account exists: %v", err)
+	}
+	if !called {
+		t.Error("the wrapped hook isn't called")
+	}
+}
+
+func TestHeaderValue(t *testing.T) {
+	if got := headerValue("a@example.com\r\nBcc: b@example.com"); got != "a@example.comBcc: b@example.com" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSMTPNotifierHonoursContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n := SMTPNotifier("127.0.0.1:25", nil, "admin@example.com", func(n *Notification) (string, string) {
+		return "Reset your password", n.Link
+	})
+	if err := n.Notify(ctx, &Notification{To: "a@example.com"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,145 @@
+package login
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/qor5/x/login"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// BcryptCost is the cost of new bcrypt password hashes,
+// raising it upgrades the stored hashes as users log in with RehashHook.
+var BcryptCost = bcrypt.DefaultCost
+
+// UserPass replaces login.UserPass in the user model to hash passwords with PasswordHasher,
+// Password shadows the one of login.UserPass for a column wide enough for argon2id hashes.
+type UserPass struct {
+	login.UserPass
+	Password string `gorm:"size:255"`
+	// MustChangePassword is set by SetPasswordByAdmin, MustChangePasswordMiddleware
+	// keeps the user on the change password page until they set their own.
+	MustChangePassword bool
+	// DisabledAt is set by Disable, a disabled user can't log in even with the correct password
+	DisabledAt *time.Time
+}
+
+var _ login.UserPasser = (*UserPass)(nil)
+
+func (up *UserPass) EncryptPassword() {
+	if up.Password == "" {
+		return
+	}
+	hash, err := HashPassword(up.Password)
+	if err != nil {
+		panic(err)
+	}
+	up.Password = hash
+	up.PassUpdatedAt = fmt.Sprint(time.Now().UnixNano())
+}
+
+// SetPassword is called when users change or reset their own password, so it clears MustChangePassword in the same update
+func (up *UserPass) SetPassword(db *gorm.DB, model interface{}, password string) error {
+	return up.setPassword(db, model, password, false)
+}
+
+// SetPasswordByAdmin sets a password for the user and requires them to change it on the next login
+func (up *UserPass) SetPasswordByAdmin(db *gorm.DB, model interface{}, password string) error {
+	return up.setPassword(db, model, password, true)
+}
+
+func (up *UserPass) setPassword(db *gorm.DB, model interface{}, password string, mustChange bool) error {
+	up.Password = password
+	up.EncryptPassword()
+	if err := db.Model(model).
+		Where("account = ?", up.Account).
+		Updates(map[string]interface{}{
+			"password":             up.Password,
+			"pass_updated_at":      up.PassUpdatedAt,
+			"must_change_password": mustChange,
+		}).
+		Error; err != nil {
+		return err
+	}
+	up.MustChangePassword = mustChange
+	return nil
+}
+
+// GetMustChangePassword is true when MustChangePassword is set, MustChangePasswordMiddleware also checks MaxPasswordAge
+func (up *UserPass) GetMustChangePassword() bool {
+	return up.MustChangePassword
+}
+
+func (up *UserPass) IsPasswordCorrect(password string) bool {
+	return VerifyPassword(up.Password, password)
+}
+
+func HashPassword(password string) (string, error) {
+	return PasswordHasher.Hash(password)
+}
+
+// NeedsRehash reports whether the hash was not made by PasswordHasher or with weaker parameters
+func NeedsRehash(hash string) bool {
+	h := identifyHasher(hash)
+	if h == nil {
+		return false
+	}
+	return h != PasswordHasher || h.NeedsRehash(hash)
+}
+
+// RehashHook upgrades the password hash of a user logging in with the password, chain it into AfterLogin.
+// The hook runs right after the login builder verified the posted password, so the hash is made from it without verifying
+// it again. Unlike SetPassword it keeps PassUpdatedAt, otherwise the session just issued would be invalidated,
+// and it only replaces the verified hash so a concurrent password change wins.
+// With TOTP the hook runs once the code is validated, the password isn't posted then and the hash is kept.
+func RehashHook(db *gorm.DB, userModel interface{}, h login.HookFunc) login.HookFunc {
+	return func(r *http.Request, user interface{}, extraVals ...interface{}) error {
+		if password := r.FormValue("password"); password != "" {
+			rehash(db, userModel, user, password)
+		}
+		if h == nil {
+			return nil
+		}
+		return h(r, user, extraVals...)
+	}
+}
+
+// rehash doesn't fail the login, the old hash still verifies
+func rehash(db *gorm.DB, userModel interface{}, user interface{}, password string) {
+	up, ok := user.(interface {
+		GetPassword() string
+		GetAccountName() string
+	})
+	if !ok || !NeedsRehash(up.GetPassword()) {
+		return
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return
+	}
+	db.Model(newObject(userModel)).
+		Where("account = ? AND password = ?", up.GetAccountName(), up.GetPassword()).
+		Update("password", hash)
+}
+
+// GetPassword returns the stored password hash
+func (up *UserPass) GetPassword() string {
+	return up.Password
+}
+
+func newObject(m interface{}) interface{} {
+	return reflect.New(reflect.TypeOf(m).Elem()).Interface()
+}
+
+// responseCookie returns the non-empty cookie of the name set by the response
+func responseCookie(w http.ResponseWriter, name string) *http.Cookie {
+	for _, c := range (&http.Response{Header: w.Header()}).Cookies() {
+		if c.Name == name && c.Value != "" {
+			return c
+		}
+	}
+	return nil
+}
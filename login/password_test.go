@@ -0,0 +1,33 @@
+package login
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNeedsRehash(t *testing.T) {
+	defer func(v int) { BcryptCost = v }(BcryptCost)
+
+	BcryptCost = bcrypt.MinCost
+	up := &UserPass{}
+	up.Password = "secret"
+	up.EncryptPassword()
+	if cost, _ := bcrypt.Cost([]byte(up.Password)); cost != bcrypt.MinCost {
+		t.Fatalf("cost = %d, want %d", cost, bcrypt.MinCost)
+	}
+	if up.PassUpdatedAt == "" {
+		t.Error("PassUpdatedAt not set")
+	}
+	if NeedsRehash(up.Password) {
+		t.Error("hash of the current cost needs no rehash")
+	}
+
+	BcryptCost = bcrypt.MinCost + 1
+	if !NeedsRehash(up.Password) {
+		t.Error("hash of a lower cost needs rehash")
+	}
+	if NeedsRehash("not a hash") {
+		t.Error("invalid hash can not be rehashed")
+	}
+}
@@ -0,0 +1,144 @@
+package login
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/qor5/x/login"
+)
+
+// IPRateLimiter throttles password logins of a client IP across all accounts,
+// an IP having Burst failed logins within Window is rejected until the oldest one leaves the window.
+// Successful logins don't reset the count, otherwise an attacker could reset it with an account of their own.
+type IPRateLimiter struct {
+	window       time.Duration
+	burst        int
+	clientIPFunc func(r *http.Request) string
+
+	mutex     sync.Mutex
+	failures  map[string][]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewIPRateLimiter allows 20 failed logins per IP in 10 minutes by default,
+// loose enough for offices sharing one NAT IP. Use Burst and Window to tune it.
+func NewIPRateLimiter() *IPRateLimiter {
+	return &IPRateLimiter{
+		window:       10 * time.Minute,
+		burst:        20,
+		clientIPFunc: RemoteIP,
+		failures:     make(map[string][]time.Time),
+		now:          time.Now,
+	}
+}
+
+func (l *IPRateLimiter) Window(v time.Duration) *IPRateLimiter {
+	l.window = v
+	return l
+}
+
+func (l *IPRateLimiter) Burst(v int) *IPRateLimiter {
+	l.burst = v
+	return l
+}
+
+// ClientIPFunc sets how the client IP is resolved, RemoteIP by default,
+// behind a reverse proxy use the header it sets, e.g. X-Real-IP
+func (l *IPRateLimiter) ClientIPFunc(v func(r *http.Request) string) *IPRateLimiter {
+	l.clientIPFunc = v
+	return l
+}
+
+// RemoteIP return the IP of r.RemoteAddr
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Allow reports whether the IP of r can try to log in, and if not, how long it has to wait
+func (l *IPRateLimiter) Allow(r *http.Request) (ok bool, retryAfter time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.burst <= 0 {
+		return true, 0
+	}
+	now := l.now()
+	ip := l.clientIPFunc(r)
+	failures := l.prune(ip, now)
+	if len(failures) < l.burst {
+		return true, 0
+	}
+	return false, failures[len(failures)-l.burst].Add(l.window).Sub(now)
+}
+
+// AddFailure counts a failed login of the IP of r
+func (l *IPRateLimiter) AddFailure(r *http.Request) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	ip := l.clientIPFunc(r)
+	l.failures[ip] = append(l.prune(ip, now), now)
+	if now.Sub(l.lastSweep) > l.window {
+		for k := range l.failures {
+			l.prune(k, now)
+		}
+		l.lastSweep = now
+	}
+}
+
+// prune drops the failures out of the window, must be called with the mutex held
+func (l *IPRateLimiter) prune(ip string, now time.Time) []time.Time {
+	failures := l.failures[ip]
+	i := 0
+	for i < len(failures) && now.Sub(failures[i]) >= l.window {
+		i++
+	}
+	failures = failures[i:]
+	if len(failures) == 0 {
+		delete(l.failures, ip)
+		return nil
+	}
+	l.failures[ip] = failures
+	return failures
+}
+
+// Hook counts the failed logins before calling h, register it for AfterFailedToLogin,
+// e.g. AfterFailedToLogin(limiter.Hook(hook))
+func (l *IPRateLimiter) Hook(h login.HookFunc) login.HookFunc {
+	return func(r *http.Request, user interface{}, extraVals ...interface{}) error {
+		l.AddFailure(r)
+		if h == nil {
+			return nil
+		}
+		return h(r, user, extraVals...)
+	}
+}
+
+// Middleware rejects the login posts to loginURL of throttled IPs with 429 Too Many Requests,
+// loginURL is the password login endpoint, /auth/userpass/login by default
+func (l *IPRateLimiter) Middleware(loginURL string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != loginURL {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if ok, retryAfter := l.Allow(r); !ok {
+				w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, "Too many failed login attempts, please try again later", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package login
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIPRateLimiter(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewIPRateLimiter().Burst(3).Window(time.Minute)
+	l.now = func() time.Time { return now }
+
+	r := httptest.NewRequest("POST", "/auth/userpass/login", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	other := httptest.NewRequest("POST", "/auth/userpass/login", nil)
+	other.RemoteAddr = "10.0.0.2:1234"
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow(r); !ok {
+			t.Fatalf("attempt %d should be allowed", i)
+		}
+		l.AddFailure(r)
+		now = now.Add(10 * time.Second)
+	}
+	if ok, retryAfter := l.Allow(r); ok || retryAfter != 30*time.Second {
+		t.Errorf("Allow = %v, %v, want throttled for 30s", ok, retryAfter)
+	}
+	if ok, _ := l.Allow(other); !ok {
+		t.Errorf("other IPs should not be throttled")
+	}
+
+	now = now.Add(30 * time.Second)
+	if ok, _ := l.Allow(r); !ok {
+		t.Errorf("should be allowed once the oldest failure left the window")
+	}
+}
+
+func TestResetPasswordProtectionAllow(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewResetPasswordProtection().AccountBurst(2).IPBurst(3).Window(time.Minute)
+	p.now = func() time.Time { return now }
+
+	r := httptest.NewRequest("POST", "/auth/send-reset-password-link", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := p.Allow(r, "a@example.com"); !ok {
+			t.Fatalf("request %d should be allowed", i)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	if ok, retryAfter := p.Allow(r, "A@example.com"); ok || retryAfter != 40*time.Second {
+		t.Errorf("allow = %v, %v, want the account throttled for 40s", ok, retryAfter)
+	}
+	if ok, _ := p.Allow(r, "b@example.com"); !ok {
+		t.Errorf("other accounts should be allowed")
+	}
+	if ok, _ := p.Allow(r, "c@example.com"); ok {
+		t.Errorf("the IP should be throttled after 3 requests")
+	}
+}
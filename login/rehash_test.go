@@ -0,0 +1,56 @@
+package login
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/qor5/x/login"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type rehashUser struct {
+	gorm.Model
+	UserPass
+}
+
+func TestRehashHook(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&rehashUser{}); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	u := &rehashUser{UserPass: UserPass{UserPass: login.UserPass{Account: "a@example.com", PassUpdatedAt: "1"}, Password: string(old)}}
+	if err = db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("POST", "/auth/userpass/login", strings.NewReader(url.Values{"password": {"secret"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	called := false
+	if err = RehashHook(db, &rehashUser{}, func(r *http.Request, user interface{}, _ ...interface{}) error {
+		called = true
+		return nil
+	})(r, u); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("the chained hook isn't called")
+	}
+
+	var got rehashUser
+	db.First(&got, u.ID)
+	if got.Password == string(old) || NeedsRehash(got.Password) || !VerifyPassword(got.Password, "secret") {
+		t.Errorf("the hash %q isn't upgraded", got.Password)
+	}
+	if got.PassUpdatedAt != "1" {
+		t.Errorf("PassUpdatedAt = %q, the session would be invalidated", got.PassUpdatedAt)
+	}
+}
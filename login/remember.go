@@ -0,0 +1,413 @@
+package login
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/qor5/x/login"
+	"gorm.io/gorm"
+)
+
+const rememberMeFormName = "remember_me"
+
+// errRememberTokenRevoked is returned by lookup for an expired or revoked token, its cookie is cleared
+var errRememberTokenRevoked = errors.New("remember token revoked")
+
+// RememberToken is a long-lived login of a device, only the hash of the token is stored.
+// PassUpdatedAt binds the token to the password it was issued with,
+// so setting a new password in any way (e.g. UserPasser.SetPassword) revokes it.
+type RememberToken struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	UserID        string `gorm:"size:64;index;not null"`
+	TokenHash     string `gorm:"size:64;uniqueIndex;not null"`
+	DeviceLabel   string `gorm:"size:255"`
+	PassUpdatedAt string
+	TOTPValidated bool
+	ExpiresAt     time.Time
+	LastUsedAt    time.Time
+}
+
+// RememberMe issues a separately revocable token when "remember me" is checked on the login page,
+// and restores the session from it after the short session of login.Builder expires.
+type RememberMe struct {
+	lb             *login.Builder
+	db             *gorm.DB
+	secret         string
+	userModel      interface{}
+	maxAge         time.Duration
+	cookieName     string
+	authCookieName string
+	secureCookie   string
+	cookieConfig   login.CookieConfig
+	afterRestore   login.HookFunc
+}
+
+type rememberMeContextKey int
+
+const rememberMeKey rememberMeContextKey = iota
+
+// NewRememberMe keeps remembered users logged in for 30 days by default,
+// secret and user model must be the same ones as the login builder.
+func NewRememberMe(lb *login.Builder, db *gorm.DB) *RememberMe {
+	if err := db.AutoMigrate(&RememberToken{}); err != nil {
+		panic(err)
+	}
+	return &RememberMe{
+		lb:             lb,
+		db:             db,
+		maxAge:         30 * 24 * time.Hour,
+		cookieName:     "qor5_remember_me",
+		authCookieName: "auth",
+		secureCookie:   "qor5_auth_secure",
+		cookieConfig: login.CookieConfig{
+			Path:     "/",
+			SameSite: http.SameSiteStrictMode,
+		},
+	}
+}
+
+func (rm *RememberMe) Secret(v string) *RememberMe {
+	rm.secret = v
+	return rm
+}
+
+func (rm *RememberMe) UserModel(m interface{}) *RememberMe {
+	rm.userModel = m
+	return rm
+}
+
+// MaxAge sets how long a remembered device stays logged in
+func (rm *RememberMe) MaxAge(v time.Duration) *RememberMe {
+	rm.maxAge = v
+	return rm
+}
+
+func (rm *RememberMe) CookieName(v string) *RememberMe {
+	rm.cookieName = v
+	return rm
+}
+
+// AuthCookieName must be set if it is changed on the login builder
+func (rm *RememberMe) AuthCookieName(v string) *RememberMe {
+	rm.authCookieName = v
+	return rm
+}
+
+// CookieConfig must be set if it is changed on the login builder
+func (rm *RememberMe) CookieConfig(v login.CookieConfig) *RememberMe {
+	rm.cookieConfig = v
+	return rm
+}
+
+// AfterRestore is called after a session is restored from a remember token,
+// the new session token can be read with login.GetSessionToken.
+func (rm *RememberMe) AfterRestore(v login.HookFunc) *RememberMe {
+	rm.afterRestore = v
+	return rm
+}
+
+// Middleware must be used before the middleware of the login builder
+func (rm *RememberMe) Middleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(context.WithValue(r.Context(), rememberMeKey, true))
+
+			switch r.URL.Path {
+			case rm.lb.ViewHelper().PasswordLoginURL():
+				if r.Method == http.MethodPost && r.FormValue(rememberMeFormName) != "" {
+					w = &rememberMeWriter{ResponseWriter: w, rm: rm, r: r}
+				}
+			case rm.lb.LogoutURL:
+				rm.revokeCurrent(w, r)
+			default:
+				rm.restore(w, r)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rememberMeEnabled(r *http.Request) bool {
+	v, _ := r.Context().Value(rememberMeKey).(bool)
+	return v
+}
+
+// RememberedDevices lists the unexpired remember tokens of the user for management
+func (rm *RememberMe) RememberedDevices(userID string) (tokens []*RememberToken, err error) {
+	err = rm.db.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&tokens).Error
+	return
+}
+
+// Revoke logs out one remembered device of the user
+func (rm *RememberMe) Revoke(userID string, id uint) error {
+	return rm.db.Where("user_id = ? AND id = ?", userID, id).Delete(&RememberToken{}).Error
+}
+
+// RevokeAll logs out all remembered devices of the user,
+// tokens issued before a password change are rejected on use even without calling it.
+func (rm *RememberMe) RevokeAll(userID string) error {
+	return rm.db.Where("user_id = ?", userID).Delete(&RememberToken{}).Error
+}
+
+type rememberMeWriter struct {
+	http.ResponseWriter
+	rm          *RememberMe
+	r           *http.Request
+	wroteHeader bool
+}
+
+func (w *rememberMeWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.rm.issue(w.ResponseWriter, w.r)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *rememberMeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// issue creates a remember token if the login response sets a session,
+// the login goes on without the remember cookie when the token can't be created
+func (rm *RememberMe) issue(w http.ResponseWriter, r *http.Request) {
+	c := responseCookie(w, rm.authCookieName)
+	if c == nil {
+		return
+	}
+	claims, err := rm.parseClaims(c.Value)
+	if err != nil || claims.Provider != "" {
+		return
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		log.Printf("login: failed to generate the remember token: %v", err)
+		return
+	}
+	now := time.Now()
+	rt := RememberToken{
+		UserID:        claims.UserID,
+		TokenHash:     hashRememberToken(token),
+		DeviceLabel:   deviceLabel(r),
+		PassUpdatedAt: claims.PassUpdatedAt,
+		TOTPValidated: claims.TOTPValidated,
+		ExpiresAt:     now.Add(rm.maxAge),
+		LastUsedAt:    now,
+	}
+	if err := rm.db.Create(&rt).Error; err != nil {
+		log.Printf("login: failed to save the remember token: %v", err)
+		return
+	}
+	rm.setCookie(w, token, int(rm.maxAge.Seconds()))
+}
+
+// restore signs a new session when the session cookie is gone but a valid remember cookie is present,
+// the request goes on without a session when it can't be restored, the remember cookie is only cleared for a bad token
+func (rm *RememberMe) restore(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(rm.authCookieName); err == nil && c.Value != "" {
+		if _, err := rm.parseClaims(c.Value); err == nil {
+			return
+		}
+	}
+	c, err := r.Cookie(rm.cookieName)
+	if err != nil || c.Value == "" {
+		return
+	}
+
+	rt, user, err := rm.lookup(c.Value)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, errRememberTokenRevoked) {
+			rm.setCookie(w, "", -1)
+			return
+		}
+		log.Printf("login: failed to restore the session from the remember token: %v", err)
+		return
+	}
+
+	claims := login.UserClaims{
+		UserID:        rt.UserID,
+		PassUpdatedAt: rt.PassUpdatedAt,
+		TOTPValidated: rt.TOTPValidated,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(rm.lb.GetSessionMaxAge()) * time.Second)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Subject:   rt.UserID,
+			ID:        uuid.New().String(),
+		},
+	}
+	token, err := rm.sign(claims, rm.secret)
+	if err != nil {
+		log.Printf("login: failed to sign the restored session: %v", err)
+		return
+	}
+	var secure string
+	if ss, ok := user.(login.SessionSecurer); ok && ss.GetSecure() != "" {
+		if secure, err = rm.sign(&claims.RegisteredClaims, rm.secret+ss.GetSecure()); err != nil {
+			log.Printf("login: failed to sign the restored session: %v", err)
+			return
+		}
+	}
+	rm.setSessionCookie(w, r, rm.authCookieName, token)
+	if secure != "" {
+		rm.setSessionCookie(w, r, rm.secureCookie, secure)
+	}
+
+	rm.db.Model(&RememberToken{}).Where("id = ?", rt.ID).Update("last_used_at", time.Now())
+	if rm.afterRestore != nil {
+		if err := rm.afterRestore(r, user); err != nil {
+			log.Printf("login: the hook after restoring the session failed: %v", err)
+		}
+	}
+}
+
+func (rm *RememberMe) lookup(token string) (rt RememberToken, user interface{}, err error) {
+	if err = rm.db.Where("token_hash = ?", hashRememberToken(token)).First(&rt).Error; err != nil {
+		return
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		rm.db.Delete(&rt)
+		return rt, nil, errRememberTokenRevoked
+	}
+
+	user = newObject(rm.userModel)
+	if err = rm.db.Where("id = ?", rt.UserID).First(user).Error; err != nil {
+		return
+	}
+	up, ok := user.(login.UserPasser)
+	if !ok || up.GetPasswordUpdatedAt() != rt.PassUpdatedAt || up.GetLocked() {
+		rm.db.Delete(&rt)
+		return rt, nil, errRememberTokenRevoked
+	}
+	if u, ok := user.(interface{ GetDisabled() bool }); ok && u.GetDisabled() {
+		rm.db.Delete(&rt)
+		return rt, nil, errRememberTokenRevoked
+	}
+	return
+}
+
+func (rm *RememberMe) revokeCurrent(w http.ResponseWriter, r *http.Request) {
+	c, err := r.Cookie(rm.cookieName)
+	if err != nil || c.Value == "" {
+		return
+	}
+	rm.db.Where("token_hash = ?", hashRememberToken(c.Value)).Delete(&RememberToken{})
+	rm.setCookie(w, "", -1)
+}
+
+func (rm *RememberMe) parseClaims(v string) (*login.UserClaims, error) {
+	claims := &login.UserClaims{}
+	token, err := jwt.ParseWithClaims(v, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(rm.secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+func (rm *RememberMe) sign(claims jwt.Claims, secret string) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+func (rm *RememberMe) setCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     rm.cookieName,
+		Value:    value,
+		Path:     rm.cookieConfig.Path,
+		Domain:   rm.cookieConfig.Domain,
+		MaxAge:   maxAge,
+		Expires:  time.Now().Add(time.Duration(maxAge) * time.Second),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: rm.cookieConfig.SameSite,
+	})
+}
+
+// setSessionCookie sets the cookie on both the response and the request,
+// so the login middleware after it sees the restored session.
+func (rm *RememberMe) setSessionCookie(w http.ResponseWriter, r *http.Request, name string, value string) {
+	maxAge := rm.lb.GetSessionMaxAge()
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     rm.cookieConfig.Path,
+		Domain:   rm.cookieConfig.Domain,
+		MaxAge:   maxAge,
+		Expires:  time.Now().Add(time.Duration(maxAge) * time.Second),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: rm.cookieConfig.SameSite,
+	})
+
+	var cookies []string
+	for _, c := range r.Cookies() {
+		if c.Name != name {
+			cookies = append(cookies, c.String())
+		}
+	}
+	cookies = append(cookies, (&http.Cookie{Name: name, Value: value}).String())
+	r.Header.Set("Cookie", strings.Join(cookies, "; "))
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashRememberToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// deviceLabel describes the browser and OS of the request for the device list
+func deviceLabel(r *http.Request) string {
+	ua := r.UserAgent()
+	if ua == "" {
+		return "Unknown device"
+	}
+	var browser, system string
+	for _, b := range [][2]string{{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"}} {
+		if strings.Contains(ua, b[0]) {
+			browser = b[1]
+			break
+		}
+	}
+	for _, o := range [][2]string{{"Windows", "Windows"}, {"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"}} {
+		if strings.Contains(ua, o[0]) {
+			system = o[1]
+			break
+		}
+	}
+	if browser != "" && system != "" {
+		return fmt.Sprintf("%s - %s", browser, system)
+	}
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	return ua
+}
@@ -0,0 +1,65 @@
+package login
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qor5/x/login"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDeviceLabel(t *testing.T) {
+	cases := []struct {
+		ua   string
+		want string
+	}{
+		{"", "Unknown device"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36", "Chrome - macOS"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36 Edg/118.0.2088.46", "Edge - Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", "Safari - iOS"},
+		{"curl/8.1.2", "curl/8.1.2"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", c.ua)
+		if got := deviceLabel(r); got != c.want {
+			t.Errorf("deviceLabel(%q) = %q, want %q", c.ua, got, c.want)
+		}
+	}
+}
+
+func TestRememberMeSkipsTheCookieOnDBErrors(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&rehashUser{}); err != nil {
+		t.Fatal(err)
+	}
+	rm := NewRememberMe(login.New().Secret("secret"), db).Secret("secret").UserModel(&rehashUser{})
+	if err = db.Migrator().DropTable(&RememberToken{}); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := rm.sign(login.UserClaims{UserID: "1"}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	http.SetCookie(w, &http.Cookie{Name: "auth", Value: session})
+	rm.issue(w, httptest.NewRequest("POST", "/auth/userpass/login", nil))
+	if c := responseCookie(w, "qor5_remember_me"); c != nil {
+		t.Errorf("a remember cookie is set without its token: %v", c)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "qor5_remember_me", Value: "token"})
+	w = httptest.NewRecorder()
+	rm.restore(w, r)
+	if v := w.Header().Get("Set-Cookie"); strings.Contains(v, "qor5_remember_me") || strings.Contains(v, "auth=") {
+		t.Errorf("the cookies are changed by a failed restore: %s", v)
+	}
+}
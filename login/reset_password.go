@@ -0,0 +1,192 @@
+package login
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResetPasswordLimiter counts the requests of reset password links. The built in one of ResetPasswordProtection
+// keeps them in the memory of the process, so with several replicas each replica has its own limits,
+// set a limiter with a shared store like Redis to limit them across the replicas.
+type ResetPasswordLimiter interface {
+	// Allow counts the request of the account from the IP of r unless one of them is throttled
+	Allow(r *http.Request, account string) (ok bool, retryAfter time.Duration)
+}
+
+// ResetPasswordProtection throttles the requests of reset password links per account and per IP, and answers them
+// the same way whether the account exists or not: with the link sent page, after at least MinDuration.
+// The link is still only sent to existing accounts. The accounts with TOTP get no link, as asking for their code
+// would tell the account exists, their passwords have to be reset by an admin.
+type ResetPasswordProtection struct {
+	window          time.Duration
+	accountBurst    int
+	ipBurst         int
+	minDuration     time.Duration
+	clientIPFunc    func(r *http.Request) string
+	linkSentPageURL string
+	limiter         ResetPasswordLimiter
+
+	mutex     sync.Mutex
+	requests  map[string][]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewResetPasswordProtection allows 3 requests per account and 10 per IP in an hour by default
+func NewResetPasswordProtection() *ResetPasswordProtection {
+	return &ResetPasswordProtection{
+		window:          time.Hour,
+		accountBurst:    3,
+		ipBurst:         10,
+		minDuration:     time.Second,
+		clientIPFunc:    RemoteIP,
+		linkSentPageURL: "/auth/reset-password-link-sent",
+		requests:        make(map[string][]time.Time),
+		now:             time.Now,
+	}
+}
+
+func (p *ResetPasswordProtection) Window(v time.Duration) *ResetPasswordProtection {
+	p.window = v
+	return p
+}
+
+func (p *ResetPasswordProtection) AccountBurst(v int) *ResetPasswordProtection {
+	p.accountBurst = v
+	return p
+}
+
+func (p *ResetPasswordProtection) IPBurst(v int) *ResetPasswordProtection {
+	p.ipBurst = v
+	return p
+}
+
+// MinDuration is how long every response takes at least, so sending the link doesn't take longer than not sending it
+func (p *ResetPasswordProtection) MinDuration(v time.Duration) *ResetPasswordProtection {
+	p.minDuration = v
+	return p
+}
+
+func (p *ResetPasswordProtection) ClientIPFunc(v func(r *http.Request) string) *ResetPasswordProtection {
+	p.clientIPFunc = v
+	return p
+}
+
+// LinkSentPageURL must be the ResetPasswordLinkSentPageURL of the login builder
+func (p *ResetPasswordProtection) LinkSentPageURL(v string) *ResetPasswordProtection {
+	p.linkSentPageURL = v
+	return p
+}
+
+// Limiter replaces the per process limits of the protection, the bursts and the window are then up to the limiter
+func (p *ResetPasswordProtection) Limiter(v ResetPasswordLimiter) *ResetPasswordProtection {
+	p.limiter = v
+	return p
+}
+
+// Allow counts the request of the account from the IP of r in memory unless one of them is throttled
+func (p *ResetPasswordProtection) Allow(r *http.Request, account string) (ok bool, retryAfter time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := p.now()
+	keys := []string{"account:" + strings.ToLower(account), "ip:" + p.clientIPFunc(r)}
+	for i, burst := range []int{p.accountBurst, p.ipBurst} {
+		if burst <= 0 {
+			continue
+		}
+		if reqs := p.prune(keys[i], now); len(reqs) >= burst {
+			if d := reqs[len(reqs)-burst].Add(p.window).Sub(now); d > retryAfter {
+				retryAfter = d
+			}
+		}
+	}
+	if retryAfter > 0 {
+		return false, retryAfter
+	}
+	for _, k := range keys {
+		p.requests[k] = append(p.requests[k], now)
+	}
+	if now.Sub(p.lastSweep) > p.window {
+		for k := range p.requests {
+			p.prune(k, now)
+		}
+		p.lastSweep = now
+	}
+	return true, 0
+}
+
+// prune drops the requests out of the window, must be called with the mutex held
+func (p *ResetPasswordProtection) prune(key string, now time.Time) []time.Time {
+	reqs := p.requests[key]
+	i := 0
+	for i < len(reqs) && now.Sub(reqs[i]) >= p.window {
+		i++
+	}
+	reqs = reqs[i:]
+	if len(reqs) == 0 {
+		delete(p.requests, key)
+		return nil
+	}
+	p.requests[key] = reqs
+	return reqs
+}
+
+// Middleware protects the posts to sendURL, the SendResetPasswordLinkURL of the login builder,
+// /auth/send-reset-password-link by default
+func (p *ResetPasswordProtection) Middleware(sendURL string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			account := strings.TrimSpace(r.FormValue("account"))
+			if r.Method != http.MethodPost || r.URL.Path != sendURL || account == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := p.now()
+			defer func() {
+				if d := p.minDuration - p.now().Sub(start); d > 0 {
+					time.Sleep(d)
+				}
+			}()
+
+			var limiter ResetPasswordLimiter = p
+			if p.limiter != nil {
+				limiter = p.limiter
+			}
+			if ok, retryAfter := limiter.Allow(r, account); !ok {
+				w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, "Too many reset password requests, please try again later", http.StatusTooManyRequests)
+				return
+			}
+
+			// the response of the login builder is dropped, its errors, like a link sent less than a minute ago
+			// or the TOTP step, would tell the account exists
+			next.ServeHTTP(&bufferedResponseWriter{header: make(http.Header), code: http.StatusOK}, r)
+			http.Redirect(w, r, fmt.Sprintf("%s?a=%s", p.linkSentPageURL, url.QueryEscape(account)), http.StatusFound)
+		})
+	}
+}
+
+type bufferedResponseWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (bw *bufferedResponseWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return bw.body.Write(b)
+}
+
+func (bw *bufferedResponseWriter) WriteHeader(code int) {
+	bw.code = code
+}
@@ -0,0 +1,39 @@
+package login
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/qor5/x/login"
+)
+
+// ErrPasswordNotSet is returned by VerifyCurrentPassword for the users without a password, like the OAuth only ones,
+// unless AlternateVerification is set
+var ErrPasswordNotSet = errors.New("user has no password")
+
+// AlternateVerification verifies the users without a password in VerifyCurrentPassword,
+// proof is what they entered in place of the password, like a code sent to their email
+func (b *Builder) AlternateVerification(v func(r *http.Request, user interface{}, proof string) error) (r *Builder) {
+	b.alternateVerification = v
+	return b
+}
+
+// VerifyCurrentPassword checks the password entered by the current user before a sensitive change, like of their email,
+// and returns login.ErrWrongPassword when it's wrong. The change password flow checks the old password itself.
+func (b *Builder) VerifyCurrentPassword(r *http.Request, password string) error {
+	user := login.GetCurrentUser(r)
+	if user == nil {
+		return login.ErrUserNotFound
+	}
+	up, ok := user.(login.UserPasser)
+	if p, hasPassword := user.(interface{ GetPassword() string }); ok && hasPassword && p.GetPassword() != "" {
+		if !up.IsPasswordCorrect(password) {
+			return login.ErrWrongPassword
+		}
+		return nil
+	}
+	if b.alternateVerification == nil {
+		return ErrPasswordNotSet
+	}
+	return b.alternateVerification(r, user, password)
+}
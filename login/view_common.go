@@ -6,6 +6,7 @@ import (
 
 	v "github.com/qor5/ui/vuetify"
 	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
 	"github.com/qor5/x/login"
 	. "github.com/theplant/htmlgo"
 )
@@ -38,6 +39,7 @@ func (vc *ViewCommon) Notice(vh *login.ViewHelper, msgr *login.Messages, w http.
 	}
 	return Components(
 		vc.ErrNotice(vh.GetFailFlashMessage(msgr, w, r)),
+		vc.ErrNotice(accountDisabledFlashMessage(w, r)),
 		vc.WarnNotice(vh.GetWarnFlashMessage(msgr, w, r)),
 		vc.InfoNotice(vh.GetInfoFlashMessage(msgr, w, r)),
 		nn,
@@ -157,6 +159,18 @@ func (vc *ViewCommon) PasswordInputWithStrengthMeter(in *v.VTextFieldBuilder, id
 	).Attr(web.InitContextVars, fmt.Sprintf(`{%s: "%s", %s: "%s" ? zxcvbn("%s").score + 1 : 0}`, passVar, val, meterScoreVar, val, val))
 }
 
+// RememberMeCheckbox is only rendered when the RememberMe middleware is used
+func (vc *ViewCommon) RememberMeCheckbox(r *http.Request) HTMLComponent {
+	if !rememberMeEnabled(r) {
+		return nil
+	}
+	msgr := i18n.MustGetModuleMessages(r, I18nAdminLoginKey, Messages_en_US).(*Messages)
+	return Div(
+		Input(rememberMeFormName).Id(rememberMeFormName).Type("checkbox").Value("true").Class("mr-2"),
+		Label(msgr.RememberMe).For(rememberMeFormName),
+	).Class("d-flex align-center mt-4")
+}
+
 func (vc *ViewCommon) FormSubmitBtn(
 	label string,
 ) *v.VBtnBuilder {
@@ -98,6 +98,7 @@ func defaultLoginPage(vh *login.ViewHelper, pb *presets.Builder) web.PageFunc {
 						Label(msgr.PasswordLabel).Class(DefaultViewCommon.LabelClass).For("password"),
 						DefaultViewCommon.PasswordInput("password", msgr.PasswordPlaceholder, wIn.Password, true),
 					).Class("mt-6"),
+					DefaultViewCommon.RememberMeCheckbox(ctx.R),
 					If(isRecaptchaEnabled,
 						// recaptcha response token
 						Input("token").Id("token").Type("hidden"),
@@ -325,7 +326,7 @@ func defaultResetPasswordPage(vh *login.ViewHelper, pb *presets.Builder) web.Pag
 	})
 }
 
-func defaultChangePasswordPage(vh *login.ViewHelper, pb *presets.Builder) web.PageFunc {
+func defaultChangePasswordPage(vh *login.ViewHelper, pb *presets.Builder, expiredNotice func(r *http.Request) string) web.PageFunc {
 	return pb.PlainLayout(func(ctx *web.EventContext) (r web.PageResponse, err error) {
 		msgr := i18n.MustGetModuleMessages(ctx.R, login.I18nLoginKey, login.Messages_en_US).(*login.Messages)
 
@@ -337,6 +338,7 @@ func defaultChangePasswordPage(vh *login.ViewHelper, pb *presets.Builder) web.Pa
 
 		r.Body = Div(
 			DefaultViewCommon.Notice(vh, msgr, ctx.W, ctx.R),
+			DefaultViewCommon.WarnNotice(expiredNotice(ctx.R)),
 			Div(
 				H1(msgr.ChangePasswordTitle).Class(DefaultViewCommon.TitleClass),
 				Form(
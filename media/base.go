@@ -56,6 +56,12 @@ type Base struct {
 	Width       int            `json:",omitempty"`
 	Height      int            `json:",omitempty"`
 	FileSizes   map[string]int `json:",omitempty"`
+	// SeparateDerivatives is true if the sizes are stored in the derivative storage
+	SeparateDerivatives bool `json:",omitempty"`
+	// SizeURL is the URL of the sizes with a {{size}} placeholder, empty if the sizes are named after Url
+	SizeURL string `json:",omitempty"`
+	// KeyScheme is the key scheme of a new upload, files already stored keep the keys they were stored with
+	KeyScheme KeyScheme `json:"-"`
 }
 
 // Scan scan files, crop options, db values into struct
@@ -128,8 +134,7 @@ func (b Base) Ext() string {
 // URL return file's url with given style
 func (b Base) URL(styles ...string) string {
 	if b.Url != "" && len(styles) > 0 {
-		ext := path.Ext(b.Url)
-		return fmt.Sprintf("%v.%v%v", strings.TrimSuffix(b.Url, ext), styles[0], ext)
+		return SizedURL(b.Url, b.SizeURL, styles[0])
 	}
 	return b.Url
 }
@@ -150,6 +155,16 @@ func (b Base) GetFileName() string {
 	return ""
 }
 
+// GetSizeURL get the URL of the sizes with a {{size}} placeholder
+func (b Base) GetSizeURL() string {
+	return b.SizeURL
+}
+
+// GetKeyScheme get the key scheme of a new upload
+func (b Base) GetKeyScheme() KeyScheme {
+	return b.KeyScheme
+}
+
 // GetFileHeader get file's header, this value only exists when saving files
 func (b Base) GetFileHeader() FileHeader {
 	return b.FileHeader
@@ -166,6 +181,7 @@ func (b Base) GetURLTemplate(option *Option) (path string) {
 var urlReplacer = regexp.MustCompile("(\\s|\\+)+")
 
 func getFuncMap(db *gorm.DB, field *schema.Field, filename string) template.FuncMap {
+	filename = SanitizeFileName(filename)
 	hash := func() string { return strings.Replace(time.Now().Format("20060102150405.000000"), ".", "", -1) }
 	shortHash := func() string { return time.Now().Format("20060102150405") }
 
@@ -191,6 +207,7 @@ func getFuncMap(db *gorm.DB, field *schema.Field, filename string) template.Func
 			return urlReplacer.ReplaceAllString(fmt.Sprintf("%s.%v%v", slug.Make(strings.TrimSuffix(path.Base(filename), path.Ext(filename))), shortHash(), path.Ext(filename)), "-")
 		},
 		"extension": func() string { return strings.TrimPrefix(path.Ext(filename), ".") },
+		"size":      func() string { return sizePlaceholder },
 	}
 }
 
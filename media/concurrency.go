@@ -0,0 +1,47 @@
+package media
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ThumbnailConcurrency caps the sizes of images generated at the same time across all the uploads and crops,
+// each one holding a resized copy of its decoded image in memory. Set it before the first image is handled,
+// 1 generates the sizes one after another.
+var ThumbnailConcurrency = runtime.GOMAXPROCS(0)
+
+var (
+	thumbnailSlots     chan struct{}
+	thumbnailSlotsOnce sync.Once
+)
+
+func acquireThumbnailSlot() (release func()) {
+	thumbnailSlotsOnce.Do(func() {
+		n := ThumbnailConcurrency
+		if n < 1 {
+			n = 1
+		}
+		thumbnailSlots = make(chan struct{}, n)
+	})
+	thumbnailSlots <- struct{}{}
+	return func() { <-thumbnailSlots }
+}
+
+// generateSizes calls gen concurrently for the sizes other than the default one and waits for them to be done
+func generateSizes(sizes map[string]*Size, gen func(key string, size *Size)) {
+	var wg sync.WaitGroup
+	for key, size := range sizes {
+		if key == DefaultSizeKey {
+			continue
+		}
+		// acquired before starting the goroutine so the waiting sizes don't pile up
+		release := acquireThumbnailSlot()
+		wg.Add(1)
+		go func(key string, size *Size) {
+			defer wg.Done()
+			defer release()
+			gen(key, size)
+		}(key, size)
+	}
+	wg.Wait()
+}
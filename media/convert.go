@@ -0,0 +1,67 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// ConvertFormats are the extensions images can be converted to
+var ConvertFormats = []string{"jpg", "png", "gif"}
+
+// ConvertJPEGQuality is the quality of images converted to JPEG
+var ConvertJPEGQuality = 85
+
+// ConvertImage re-encodes the image to the format of ext, and return the file name with the new extension.
+// Transparent pixels are flattened onto white when converting to JPEG, animated GIFs keep the first frame only.
+func ConvertImage(file io.Reader, fileName string, ext string) (newFileName string, data []byte, err error) {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	newFileName = strings.TrimSuffix(fileName, path.Ext(fileName)) + "." + ext
+	format, err := GetImageFormat(newFileName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var opts []imaging.EncodeOption
+	if *format == imaging.JPEG {
+		background := imaging.New(img.Bounds().Dx(), img.Bounds().Dy(), color.White)
+		img = imaging.Overlay(background, img, image.Pt(0, 0), 1)
+		opts = append(opts, imaging.JPEGQuality(ConvertJPEGQuality))
+	}
+
+	var buffer bytes.Buffer
+	if err = imaging.Encode(&buffer, img, *format, opts...); err != nil {
+		return "", nil, err
+	}
+	return newFileName, buffer.Bytes(), nil
+}
+
+// NewBytesFileHeader return a FileHeader of the data, to save generated content as an upload
+func NewBytesFileHeader(data []byte) FileHeader {
+	return bytesFileHeader(data)
+}
+
+type bytesFileHeader []byte
+
+func (b bytesFileHeader) Open() (multipart.File, error) {
+	return bytesFile{bytes.NewReader(b)}, nil
+}
+
+type bytesFile struct {
+	*bytes.Reader
+}
+
+func (bytesFile) Close() error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestConvertImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	name, data, err := ConvertImage(&buf, "photo.PNG", "jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "photo.jpg" {
+		t.Errorf("file name = %q", name)
+	}
+	converted, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted.Bounds().Dx() != 4 || converted.Bounds().Dy() != 2 {
+		t.Errorf("dimensions changed to %v", converted.Bounds())
+	}
+	// transparent pixels are flattened onto white
+	if r, g, b, _ := converted.At(3, 1).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("transparent pixel converted to %v", converted.At(3, 1))
+	}
+
+	if _, _, err := ConvertImage(bytes.NewReader(data), "photo.jpg", "webp"); err == nil {
+		t.Errorf("unsupported format should fail")
+	}
+}
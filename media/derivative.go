@@ -0,0 +1,18 @@
+package media
+
+// DerivativeURLHandler converts the URL of a size image to the derivative storage,
+// set by the storage package when a separate derivative storage is configured
+var DerivativeURLHandler func(url string) string
+
+// DerivativeURL returns the URL of a size image in the derivative storage
+func DerivativeURL(url string) string {
+	if DerivativeURLHandler == nil {
+		return url
+	}
+	return DerivativeURLHandler(url)
+}
+
+// IsDerivativeStyle reports whether the style is a generated size rather than the original file
+func IsDerivativeStyle(styles ...string) bool {
+	return len(styles) > 0 && styles[0] != "" && styles[0] != "original"
+}
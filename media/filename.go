@@ -0,0 +1,84 @@
+package media
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gosimple/unidecode"
+)
+
+// LowercaseFileName makes the sanitized file names used in storage keys lowercase
+var LowercaseFileName = false
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// SanitizeFileName return a file name that is safe to be used in storage keys and URLs,
+// path separators are stripped and unicode characters are transliterated to ASCII.
+// The original file name is still kept in FileName for displaying.
+func SanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	base = strings.Trim(unsafeFileNameChars.ReplaceAllString(unidecode.Unidecode(base), "-"), "-")
+	if base == "" {
+		base = "file"
+	}
+	ext = unsafeFileNameChars.ReplaceAllString(strings.TrimPrefix(ext, "."), "")
+	if ext != "" {
+		ext = "." + ext
+	}
+
+	if LowercaseFileName {
+		return strings.ToLower(base + ext)
+	}
+	return base + ext
+}
+
+// StoredFilesLister is implemented by the media that can list the stored files,
+// uniqueURL lists the folder once with it instead of retrieving every candidate
+type StoredFilesLister interface {
+	// StoredFiles returns the names of the files stored in the folder of url
+	StoredFiles(url string) ([]string, error)
+}
+
+// uniqueURL appends a -1, -2... suffix to the url until it doesn't collide with an existing file
+func uniqueURL(media Media, url string) string {
+	ext := path.Ext(url)
+	base := strings.TrimSuffix(url, ext)
+	exists := fileExistsFunc(media, url)
+	candidate := url
+	for i := 1; exists(candidate); i++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	return candidate
+}
+
+// fileExistsFunc returns the existence check of the files in the folder of url
+func fileExistsFunc(media Media, url string) func(url string) bool {
+	if lister, ok := media.(StoredFilesLister); ok {
+		if names, err := lister.StoredFiles(url); err == nil {
+			stored := map[string]bool{}
+			for _, name := range names {
+				stored[name] = true
+			}
+			return func(url string) bool {
+				return stored[path.Base(url)]
+			}
+		}
+	}
+	return func(url string) bool {
+		return fileExists(media, url)
+	}
+}
+
+func fileExists(media Media, url string) bool {
+	f, err := media.Retrieve(url)
+	if err != nil || f == nil {
+		return false
+	}
+	f.Close()
+	return true
+}
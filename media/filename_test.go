@@ -0,0 +1,64 @@
+package media
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	cases := map[string]string{
+		"My Résumé (1).PDF": "My-Resume-1.PDF",
+		"../../etc/passwd":  "passwd",
+		`C:\tmp\photo.png`:  "photo.png",
+		".png":              "file.png",
+	}
+	for in, want := range cases {
+		if got := SanitizeFileName(in); got != want {
+			t.Errorf("SanitizeFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	LowercaseFileName = true
+	defer func() { LowercaseFileName = false }()
+	if got := SanitizeFileName("My Résumé (1).PDF"); got != "my-resume-1.pdf" {
+		t.Errorf("lowercase SanitizeFileName = %q", got)
+	}
+}
+
+func TestSizedURL(t *testing.T) {
+	if got := SizedURL("/system/media_libraries/1/file/hero.123.jpg", "", "thumb"); got != "/system/media_libraries/1/file/hero.123.thumb.jpg" {
+		t.Errorf("legacy size url = %q", got)
+	}
+	if got := SizedURL("/media/1/file/hero.jpg", "/media/1/{{size}}/hero.jpg", "thumb"); got != "/media/1/thumb/hero.jpg" {
+		t.Errorf("deterministic size url = %q", got)
+	}
+}
+
+// listedMedia lists the files of stored and counts its retrieves
+type listedMedia struct {
+	memMedia
+	stored    []string
+	retrieved int
+}
+
+func (m *listedMedia) Retrieve(url string) (FileInterface, error) {
+	m.retrieved++
+	return nil, os.ErrNotExist
+}
+
+func (m *listedMedia) StoredFiles(url string) ([]string, error) {
+	return m.stored, nil
+}
+
+func TestUniqueURL(t *testing.T) {
+	m := &listedMedia{stored: []string{"a.jpg", "a-1.jpg", "b.jpg"}}
+	if got, want := uniqueURL(m, "/records/1/a.jpg"), "/records/1/a-2.jpg"; got != want {
+		t.Errorf("uniqueURL = %q, want %q", got, want)
+	}
+	if got, want := uniqueURL(m, "/records/1/c.jpg"), "/records/1/c.jpg"; got != want {
+		t.Errorf("uniqueURL = %q, want %q", got, want)
+	}
+	if m.retrieved != 0 {
+		t.Errorf("retrieved %v files, the listed media shouldn't be retrieved", m.retrieved)
+	}
+}
@@ -3,6 +3,7 @@ package media
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
@@ -10,8 +11,13 @@ import (
 	_ "image/jpeg"
 	"io/ioutil"
 	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/disintegration/imaging"
+	"github.com/qor5/admin/metrics"
 )
 
 var mediaHandlers = make(map[string]MediaHandler)
@@ -106,9 +112,8 @@ func (imageHandler) Handle(media Media, file FileInterface, option *Option) (err
 	originalFileSize := len(fileBytes)
 	fileSizes["original"] = originalFileSize
 	file.Seek(0, 0)
-	err = media.Store(media.URL("original"), option, file)
-	if err != nil {
-		return
+	if err = media.Store(media.URL("original"), option, file); err != nil {
+		return &DerivativeError{Failed: map[string]error{"original": err}}
 	}
 	file.Seek(0, 0)
 
@@ -133,36 +138,53 @@ func (imageHandler) Handle(media Media, file FileInterface, option *Option) (err
 	}
 
 	SetWeightHeight(media, img.Bounds().Dx(), img.Bounds().Dy())
+	derr := &DerivativeError{Stored: []string{"original"}}
 	// Save cropped default image
 	if cropOption := media.GetCropOption(DefaultSizeKey); cropOption != nil {
 		var buffer bytes.Buffer
 		imaging.Encode(&buffer, imaging.Crop(img, *cropOption), *format)
 		fileSizes[DefaultSizeKey] = buffer.Len()
-		media.Store(media.URL(), option, &buffer)
+		derr.add(DefaultSizeKey, media.Store(media.URL(), option, &buffer))
 	} else {
 		file.Seek(0, 0)
 		// Save default image
 		fileSizes[DefaultSizeKey] = originalFileSize
-		media.Store(media.URL(), option, file)
+		derr.add(DefaultSizeKey, media.Store(media.URL(), option, file))
 	}
 
 	// save sizes image
-	for key, size := range media.GetSizes() {
-		if key == DefaultSizeKey {
-			continue
-		}
-
+	var mu sync.Mutex
+	generateSizes(media.GetSizes(), func(key string, size *Size) {
+		start := time.Now()
 		newImage := img
 		if cropOption := media.GetCropOption(key); cropOption != nil {
 			newImage = imaging.Crop(newImage, *cropOption)
+		} else if size.SmartCrop && !size.Padding && size.Width > 0 && size.Height > 0 {
+			newImage = imaging.Crop(newImage, SmartCropRect(newImage, size.Width, size.Height))
+		}
+		newImage = resizeImageTo(newImage, size, *format)
+		if size.Sharpen > 0 {
+			newImage = imaging.Sharpen(newImage, size.Sharpen)
 		}
 		var buffer bytes.Buffer
-		imaging.Encode(&buffer, resizeImageTo(newImage, size, *format), *format)
+		imaging.Encode(&buffer, newImage, *format, encodeOptions(size)...)
+		storeErr := media.Store(media.URL(key), option, &buffer)
+		metrics.ObserveSince("qor5_media_thumbnail_duration_seconds", metrics.Labels{"size": key}, start)
+
+		mu.Lock()
+		defer mu.Unlock()
 		fileSizes[key] = buffer.Len()
-		media.Store(media.URL(key), option, &buffer)
-	}
+		derr.add(key, storeErr)
+	})
 	SetFileSizes(media, fileSizes)
 
+	return derr.errOrNil()
+}
+
+func encodeOptions(size *Size) (opts []imaging.EncodeOption) {
+	if quality := size.GetQuality(); quality > 0 {
+		opts = append(opts, imaging.JPEGQuality(quality))
+	}
 	return
 }
 
@@ -188,7 +210,8 @@ func handleGIF(media Media, file FileInterface, option *Option, fileSizes map[st
 
 	gif.EncodeAll(&buffer, g)
 	fileSizes[DefaultSizeKey] = buffer.Len()
-	media.Store(media.URL(), option, &buffer)
+	derr := &DerivativeError{Stored: []string{"original"}}
+	derr.add(DefaultSizeKey, media.Store(media.URL(), option, &buffer))
 
 	// save sizes image
 	for key, size := range media.GetSizes() {
@@ -196,6 +219,7 @@ func handleGIF(media Media, file FileInterface, option *Option, fileSizes map[st
 			continue
 		}
 
+		start := time.Now()
 		file.Seek(0, 0)
 		g, err := gif.DecodeAll(file)
 		if err != nil {
@@ -217,9 +241,47 @@ func handleGIF(media Media, file FileInterface, option *Option, fileSizes map[st
 		g.Config.Height = size.Height
 		gif.EncodeAll(&buffer, g)
 		fileSizes[key] = buffer.Len()
-		media.Store(media.URL(key), option, &buffer)
+		derr.add(key, media.Store(media.URL(key), option, &buffer))
+		metrics.ObserveSince("qor5_media_thumbnail_duration_seconds", metrics.Labels{"size": key}, start)
+	}
+	return derr.errOrNil()
+}
+
+// DerivativeError is returned by the handlers when some files of a media couldn't be stored,
+// Stored are the sizes that were stored before or despite the failures
+type DerivativeError struct {
+	Stored []string
+	Failed map[string]error
+}
+
+func (e *DerivativeError) Error() string {
+	var failed []string
+	for k := range e.Failed {
+		failed = append(failed, k)
+	}
+	sort.Strings(failed)
+	stored := append([]string{}, e.Stored...)
+	sort.Strings(stored)
+	return fmt.Sprintf("media: failed to store %s (stored %s): %v",
+		strings.Join(failed, ", "), strings.Join(stored, ", "), e.Failed[failed[0]])
+}
+
+func (e *DerivativeError) add(key string, err error) {
+	if err == nil {
+		e.Stored = append(e.Stored, key)
+		return
+	}
+	if e.Failed == nil {
+		e.Failed = make(map[string]error)
+	}
+	e.Failed[key] = err
+}
+
+func (e *DerivativeError) errOrNil() error {
+	if len(e.Failed) == 0 {
+		return nil
 	}
-	return nil
+	return e
 }
 
 func SetWeightHeight(media Media, width, height int) {
@@ -0,0 +1,71 @@
+package media
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// KeyScheme is how the storage keys of new uploads are generated
+type KeyScheme int
+
+const (
+	// KeySchemeDefault stores sizes next to the file with a timestamped name,
+	// like /system/media_libraries/12/file/hero.20231120150405000000.thumb.jpg
+	KeySchemeDefault KeyScheme = iota
+	// KeySchemeDeterministic stores each size in a folder of the record, like /media/12/thumb/hero.jpg
+	KeySchemeDeterministic
+)
+
+// DeterministicURLTemplate is the URL template of KeySchemeDeterministic, {{size}} is kept in the generated URL
+var DeterministicURLTemplate = "/media/{{primary_key}}/{{size}}/{{filename}}"
+
+const (
+	sizePlaceholder = "{{size}}"
+	// mainSizeKey is the folder of the file itself in KeySchemeDeterministic
+	mainSizeKey = "file"
+)
+
+// KeySchemer is implemented by the media choosing the key scheme of their new uploads, like Base
+type KeySchemer interface {
+	GetKeyScheme() KeyScheme
+}
+
+// SizeURLer is implemented by the media able to store their sizes with KeySchemeDeterministic, like Base
+type SizeURLer interface {
+	GetSizeURL() string
+}
+
+// deterministicKeys reports whether the file is stored with KeySchemeDeterministic,
+// new uploads follow the scheme of the media and crops of existing files keep theirs
+func deterministicKeys(media Media) bool {
+	if _, ok := media.(SizeURLer); !ok {
+		return false
+	}
+	if media.GetFileHeader() != nil {
+		ks, ok := media.(KeySchemer)
+		return ok && ks.GetKeyScheme() == KeySchemeDeterministic
+	}
+	return media.(SizeURLer).GetSizeURL() != ""
+}
+
+// SizedURL returns the URL of a size of the file stored at url,
+// sizeURL is the URL with the {{size}} placeholder of KeySchemeDeterministic, empty for KeySchemeDefault
+func SizedURL(url string, sizeURL string, size string) string {
+	if sizeURL != "" {
+		return strings.Replace(sizeURL, sizePlaceholder, size, 1)
+	}
+	ext := path.Ext(url)
+	return fmt.Sprintf("%v.%v%v", strings.TrimSuffix(url, ext), size, ext)
+}
+
+// uniqueSizeURL renames the file of sizeURL like uniqueURL if its main file already exists
+func uniqueSizeURL(media Media, u string) string {
+	main := SizedURL("", u, mainSizeKey)
+	unique := uniqueURL(media, main)
+	if unique == main {
+		return u
+	}
+	ext := path.Ext(main)
+	return strings.TrimSuffix(u, ext) + strings.TrimPrefix(unique, strings.TrimSuffix(main, ext))
+}
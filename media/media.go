@@ -2,8 +2,11 @@ package media
 
 import (
 	"database/sql/driver"
+	"errors"
+	"fmt"
 	"image"
 	"io"
+	"log"
 	"strings"
 
 	"gorm.io/gorm"
@@ -48,6 +51,40 @@ type Size struct {
 	Width   int
 	Height  int
 	Padding bool
+	// Quality is the encoding quality (1-100) used when generating this size, 0 means the handler's default
+	Quality int `json:",omitempty"`
+	// Sharpen is the sigma of the sharpen filter applied after resizing, 0 means no sharpening
+	Sharpen float64 `json:",omitempty"`
+	// SmartCrop crops the images without a crop option of this size around their most detailed part
+	// instead of their center, a crop option set by the editors always wins
+	SmartCrop bool `json:",omitempty"`
+}
+
+// Validate rejects negative dimensions and a size without any, one of them can be 0 to keep the image proportions
+func (size *Size) Validate() error {
+	if size == nil {
+		return errors.New("size is nil")
+	}
+	if size.Width < 0 || size.Height < 0 || (size.Width == 0 && size.Height == 0) {
+		return fmt.Errorf("invalid dimensions %dx%d, both must be positive or one 0 to keep the proportions", size.Width, size.Height)
+	}
+	return nil
+}
+
+// GetQuality return the configured quality clamped into 1-100, 0 if not configured
+func (size *Size) GetQuality() int {
+	if size == nil || size.Quality == 0 {
+		return 0
+	}
+	if size.Quality < 1 {
+		log.Printf("media: size quality %d is out of range 1-100, clamped to 1", size.Quality)
+		return 1
+	}
+	if size.Quality > 100 {
+		log.Printf("media: size quality %d is out of range 1-100, clamped to 100", size.Quality)
+		return 100
+	}
+	return size.Quality
 }
 
 // URLTemplater is a interface to return url template
@@ -0,0 +1,62 @@
+package media_library
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+var (
+	descriptionLinkRe   = regexp.MustCompile(`\[([^\]\n]+)\]\(([^)\s]+)\)`)
+	descriptionBoldRe   = regexp.MustCompile(`\*\*(\S(?:[^\n]*?\S)?)\*\*`)
+	descriptionItalicRe = regexp.MustCompile(`\*(\S(?:[^\n*]*?\S)?)\*`)
+)
+
+// descriptionPolicy removes all the HTML of the descriptions, with the content of the elements like script
+var descriptionPolicy = bluemonday.StrictPolicy()
+
+// SanitizeDescription removes the HTML tags of a formatted description, which is written in markdown.
+// The text is kept as it is, like "1 < 2", it's escaped by DescriptionHTML.
+func SanitizeDescription(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.TrimSpace(html.UnescapeString(descriptionPolicy.Sanitize(s)))
+}
+
+// DescriptionHTML renders the basic markdown of a description: **bold**, *italic*, [links](https://...) and line breaks.
+// Everything else is escaped, links other than http, https, mailto and relative ones are left as text.
+func DescriptionHTML(s string) template.HTML {
+	s = html.EscapeString(s)
+	s = descriptionLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := descriptionLinkRe.FindStringSubmatch(m)
+		if !safeDescriptionURL(html.UnescapeString(sub[2])) {
+			return sub[1]
+		}
+		return fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener nofollow">%s</a>`, sub[2], sub[1])
+	})
+	s = descriptionBoldRe.ReplaceAllString(s, "<strong>$1</strong>")
+	s = descriptionItalicRe.ReplaceAllString(s, "<em>$1</em>")
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return template.HTML(s)
+}
+
+func safeDescriptionURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// DescriptionHTML renders the description of the media box, see DescriptionHTML
+func (mediaBox *MediaBox) DescriptionHTML() template.HTML {
+	return DescriptionHTML(mediaBox.Description)
+}
@@ -4,7 +4,10 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/qor5/admin/media"
@@ -26,13 +29,105 @@ type MediaBox struct {
 	// for default image
 	Width  int `json:",omitempty"`
 	Height int `json:",omitempty"`
+	// SeparateDerivatives is true if the sizes are stored in the derivative storage
+	SeparateDerivatives bool `json:",omitempty"`
+	// SizeURL is the URL of the sizes with a {{size}} placeholder, see media.SizedURL
+	SizeURL string `json:",omitempty"`
+	// Version changes whenever the files are regenerated, it is the UpdatedAt of the media library file
+	Version int64 `json:",omitempty"`
+	// Locales are the variants chosen for locale codes, see ForLocale
+	Locales map[string]*MediaBox `json:",omitempty"`
 }
 
 // MediaBoxConfig configure MediaBox metas
 type MediaBoxConfig struct {
-	Sizes     map[string]*media.Size
-	Max       uint
-	AllowType string
+	Sizes map[string]*media.Size
+	// SizeOrder is the order the thumbnails of the sizes are shown in, sizes not in it follow alphabetically
+	SizeOrder []string `json:",omitempty"`
+	// ThumbnailSizes are the sizes shown as thumbnails in the media box, media.DefaultSizeKey for the original.
+	// By default the sizes of Sizes are shown, or the original without Sizes.
+	ThumbnailSizes []string `json:",omitempty"`
+	Max            uint
+	AllowType      string
+	// PerPage overrides the global page size of the file chooser
+	PerPage int
+	// RequireDescription rejects saving a chosen image without a description
+	RequireDescription bool
+	// RichDescription allows the basic markdown of DescriptionHTML in the description, the HTML tags are removed on save
+	RichDescription bool `json:",omitempty"`
+	// InfiniteScroll loads the next page of the file chooser when scrolled to the bottom instead of paginating
+	InfiniteScroll bool
+	// Locales are the locale codes that can have their own variant of the file, like an image with text
+	Locales []string `json:",omitempty"`
+	// StripMetadata removes the EXIF, IPTC and XMP of uploaded images, like the GPS coordinates, it's on when nil
+	StripMetadata *bool `json:",omitempty"`
+	// KeepCopyright and KeepICCProfile keep them when stripping the metadata
+	KeepCopyright  bool `json:",omitempty"`
+	KeepICCProfile bool `json:",omitempty"`
+}
+
+// Validate checks the dimensions of Sizes
+func (cfg *MediaBoxConfig) Validate() error {
+	if cfg == nil {
+		return nil
+	}
+	for _, k := range cfg.SizeKeys() {
+		if err := cfg.Sizes[k].Validate(); err != nil {
+			return fmt.Errorf("media box size %q: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// SizeKeys returns the keys of Sizes in the order of SizeOrder, then the others alphabetically
+func (cfg *MediaBoxConfig) SizeKeys() []string {
+	order := make(map[string]int, len(cfg.SizeOrder))
+	for i, k := range cfg.SizeOrder {
+		if _, ok := order[k]; !ok {
+			order[k] = i
+		}
+	}
+	keys := make([]string, 0, len(cfg.Sizes))
+	for k := range cfg.Sizes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		oi, iok := order[keys[i]]
+		oj, jok := order[keys[j]]
+		if iok != jok {
+			return iok
+		}
+		if iok {
+			return oi < oj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// ThumbnailKeys returns the sizes of ThumbnailSizes that exist, falling back to the default ones when none does
+func (cfg *MediaBoxConfig) ThumbnailKeys() []string {
+	defaults := cfg.SizeKeys()
+	if len(defaults) == 0 {
+		defaults = []string{media.DefaultSizeKey}
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, k := range cfg.ThumbnailSizes {
+		if seen[k] || (k != media.DefaultSizeKey && cfg.Sizes[k] == nil) {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return defaults
+	}
+	return keys
+}
+
+func (cfg *MediaBoxConfig) ShouldStripMetadata() bool {
+	return cfg == nil || cfg.StripMetadata == nil || *cfg.StripMetadata
 }
 
 func (mediaBox *MediaBox) Scan(data interface{}) (err error) {
@@ -48,13 +143,45 @@ func (mediaBox *MediaBox) Scan(data interface{}) (err error) {
 }
 
 func (mediaBox MediaBox) Value() (driver.Value, error) {
-	if mediaBox.ID.String() == "0" || mediaBox.ID.String() == "" {
+	if mediaBox.IsEmpty() && len(mediaBox.Locales) == 0 {
 		return nil, nil
 	}
 	results, err := json.Marshal(mediaBox)
 	return string(results), err
 }
 
+// CacheToken return a token that only changes when the files change, to be appended to the URLs
+// so browsers and CDNs can cache them. Boxes saved without Version fall back to a hash of the files.
+func (mediaBox *MediaBox) CacheToken() string {
+	if mediaBox.Version != 0 {
+		return strconv.FormatInt(mediaBox.Version, 36)
+	}
+	keys := make([]string, 0, len(mediaBox.FileSizes))
+	for k := range mediaBox.FileSizes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s|%dx%d", mediaBox.Url, mediaBox.Width, mediaBox.Height)
+	for _, k := range keys {
+		fmt.Fprintf(h, "|%s=%d", k, mediaBox.FileSizes[k])
+	}
+	return strconv.FormatUint(uint64(h.Sum32()), 36)
+}
+
+// IsEmpty reports whether neither a file nor a video link is chosen
+func (mediaBox *MediaBox) IsEmpty() bool {
+	return (mediaBox.ID.String() == "0" || mediaBox.ID.String() == "") && mediaBox.VideoLink == ""
+}
+
+// ForLocale returns the variant of the locale code, or the box itself when the locale has none
+func (mediaBox *MediaBox) ForLocale(localeCode string) *MediaBox {
+	if v, ok := mediaBox.Locales[localeCode]; ok && v != nil && !v.IsEmpty() {
+		return v
+	}
+	return mediaBox
+}
+
 // IsImage return if it is an image
 func (mediaBox *MediaBox) IsImage() bool {
 	return media.IsImageFormat(mediaBox.Url)
@@ -64,14 +191,22 @@ func (mediaBox *MediaBox) IsVideo() bool {
 	return media.IsVideoFormat(mediaBox.Url)
 }
 
+// IsVideoLink return if it is an external video link instead of a media library file
+func (mediaBox *MediaBox) IsVideoLink() bool {
+	return mediaBox.VideoLink != "" && (mediaBox.ID.String() == "" || mediaBox.ID.String() == "0")
+}
+
 func (mediaBox *MediaBox) IsSVG() bool {
 	return media.IsSVGFormat(mediaBox.Url)
 }
 
 func (mediaBox *MediaBox) URL(styles ...string) string {
 	if mediaBox.Url != "" && len(styles) > 0 {
-		ext := path.Ext(mediaBox.Url)
-		return fmt.Sprintf("%v.%v%v", strings.TrimSuffix(mediaBox.Url, ext), styles[0], ext)
+		url := media.SizedURL(mediaBox.Url, mediaBox.SizeURL, styles[0])
+		if mediaBox.SeparateDerivatives && media.IsDerivativeStyle(styles...) {
+			return media.DerivativeURL(url)
+		}
+		return url
 	}
 	return mediaBox.Url
 }
@@ -0,0 +1,145 @@
+package media_library
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qor5/admin/media"
+)
+
+func TestMediaBoxCacheToken(t *testing.T) {
+	mb := MediaBox{Url: "/system/media_libraries/1/file.png", FileSizes: map[string]int{"original": 100, "thumb": 10}}
+	token := mb.CacheToken()
+	if token != mb.CacheToken() {
+		t.Errorf("token is not stable")
+	}
+	mb.FileSizes["thumb"] = 11
+	if token == mb.CacheToken() {
+		t.Errorf("token should change when the files change")
+	}
+	mb.Version = 36
+	if mb.CacheToken() != "10" {
+		t.Errorf("token = %q, want the version", mb.CacheToken())
+	}
+}
+
+func TestMediaBoxForLocale(t *testing.T) {
+	var mb MediaBox
+	if err := mb.Scan(`{"ID":"1","Url":"/default.png"}`); err != nil {
+		t.Fatal(err)
+	}
+	if mb.ForLocale("Japan").Url != "/default.png" {
+		t.Errorf("box without locales should fall back to itself")
+	}
+
+	mb.Locales = map[string]*MediaBox{"Japan": {ID: "2", Url: "/ja.png"}, "China": {}}
+	if mb.ForLocale("Japan").Url != "/ja.png" {
+		t.Errorf("got %q, want the Japan variant", mb.ForLocale("Japan").Url)
+	}
+	if mb.ForLocale("China").Url != "/default.png" {
+		t.Errorf("empty variant should fall back to the default")
+	}
+
+	v, err := mb.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back MediaBox
+	if err := back.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+	if back.ForLocale("Japan").Url != "/ja.png" {
+		t.Errorf("locales are lost after serialization: %v", v)
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	for in, want := range map[string]string{
+		"Hero":          "hero",
+		"  HERO  ":      "hero",
+		"Summer  Sale ": "summer sale",
+		"":              "",
+	} {
+		if got := NormalizeTag(in); got != want {
+			t.Errorf("NormalizeTag(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMediaBoxConfigSizeKeys(t *testing.T) {
+	cfg := MediaBoxConfig{
+		Sizes:     map[string]*media.Size{"small": {}, "medium": {}, "large": {}, "banner": {}, "avatar": {}},
+		SizeOrder: []string{"large", "medium", "small", "missing"},
+	}
+	got := strings.Join(cfg.SizeKeys(), ",")
+	if got != "large,medium,small,avatar,banner" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestMediaBoxConfigThumbnailKeys(t *testing.T) {
+	sizes := map[string]*media.Size{"thumb": {Width: 10}, "large": {Width: 100}, "medium": {Width: 50}}
+	cases := []struct {
+		cfg  MediaBoxConfig
+		want string
+	}{
+		{MediaBoxConfig{}, media.DefaultSizeKey},
+		{MediaBoxConfig{Sizes: sizes}, "large,medium,thumb"},
+		{MediaBoxConfig{Sizes: sizes, ThumbnailSizes: []string{"thumb", "large"}}, "thumb,large"},
+		{MediaBoxConfig{Sizes: sizes, ThumbnailSizes: []string{media.DefaultSizeKey}}, media.DefaultSizeKey},
+		{MediaBoxConfig{Sizes: sizes, ThumbnailSizes: []string{"missing"}}, "large,medium,thumb"},
+		{MediaBoxConfig{ThumbnailSizes: []string{"thumb"}}, media.DefaultSizeKey},
+	}
+	for i, c := range cases {
+		if got := strings.Join(c.cfg.ThumbnailKeys(), ","); got != c.want {
+			t.Errorf("%d: got %q, want %q", i, got, c.want)
+		}
+	}
+}
+
+func TestDescriptionHTML(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"**Sunset** at *the* beach\nby [me](https://example.com/?a=1&b=2)",
+			`<strong>Sunset</strong> at <em>the</em> beach<br>by <a href="https://example.com/?a=1&amp;b=2" target="_blank" rel="noopener nofollow">me</a>`},
+		{`<script>alert(1)</script>`, `&lt;script&gt;alert(1)&lt;/script&gt;`},
+		{`[x](javascript:alert(1))`, `x)`},
+		{`[x](JavaScript:alert&#40;1&#41;)`, `x`},
+		{`[x"><img>](/a"onmouseover=1)`, `<a href="/a&#34;onmouseover=1" target="_blank" rel="noopener nofollow">x&#34;&gt;&lt;img&gt;</a>`},
+		{"2 * 3 * 4", "2 * 3 * 4"},
+	}
+	for _, c := range cases {
+		if got := string(DescriptionHTML(c.in)); got != c.want {
+			t.Errorf("DescriptionHTML(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	for in, want := range map[string]string{
+		" <b>a</b>\r\nb ":                       "a\nb",
+		"1 < 2 and 3 > 2":                       "1 < 2 and 3 > 2",
+		"Tom & Jerry <script>alert(1)</script>": "Tom & Jerry",
+		"[site](https://example.com/?a=1&b=2)":  "[site](https://example.com/?a=1&b=2)",
+	} {
+		if got := SanitizeDescription(in); got != want {
+			t.Errorf("SanitizeDescription(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMediaBoxConfigValidate(t *testing.T) {
+	cfg := &MediaBoxConfig{Sizes: map[string]*media.Size{
+		"thumb": {Width: 100, Height: 100},
+		"wide":  {Width: 800},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("proportional sizes should be valid: %v", err)
+	}
+	cfg.Sizes["broken"] = &media.Size{}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `"broken"`) {
+		t.Errorf("Validate = %v, want the 0x0 size rejected", err)
+	}
+	cfg.Sizes["broken"] = &media.Size{Width: -1, Height: 100}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("negative sizes should be rejected")
+	}
+}
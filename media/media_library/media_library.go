@@ -3,10 +3,7 @@ package media_library
 import (
 	"database/sql/driver"
 	"encoding/json"
-	"fmt"
 	"math"
-	"path"
-	"strings"
 
 	"github.com/qor5/admin/media"
 	"github.com/qor5/admin/media/oss"
@@ -161,8 +158,7 @@ func (mediaLibraryStorage MediaLibraryStorage) Value() (driver.Value, error) {
 
 func (mediaLibraryStorage MediaLibraryStorage) URL(styles ...string) string {
 	if mediaLibraryStorage.Url != "" && len(styles) > 0 {
-		ext := path.Ext(mediaLibraryStorage.Url)
-		return fmt.Sprintf("%v.%v%v", strings.TrimSuffix(mediaLibraryStorage.Url, ext), styles[0], ext)
+		return media.SizedURL(mediaLibraryStorage.Url, mediaLibraryStorage.SizeURL, styles[0])
 	}
 	return mediaLibraryStorage.Url
 }
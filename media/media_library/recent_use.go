@@ -0,0 +1,13 @@
+package media_library
+
+import (
+	"time"
+)
+
+// MediaLibraryRecentUse records when a user last chose a media library file
+type MediaLibraryRecentUse struct {
+	ID             uint   `gorm:"primarykey"`
+	UserID         string `gorm:"uniqueIndex:idx_media_library_recent_uses_user_media;index"`
+	MediaLibraryID uint   `gorm:"uniqueIndex:idx_media_library_recent_uses_user_media"`
+	UsedAt         time.Time
+}
@@ -0,0 +1,15 @@
+package media_library
+
+import "strings"
+
+// MediaLibraryTag is a free-form tag of a media library file, names are stored normalized by NormalizeTag
+type MediaLibraryTag struct {
+	ID             uint   `gorm:"primarykey"`
+	MediaLibraryID uint   `gorm:"uniqueIndex:idx_media_library_tags_media_name"`
+	Name           string `gorm:"uniqueIndex:idx_media_library_tags_media_name;index"`
+}
+
+// NormalizeTag trims and lowercases a tag name so tags match case-insensitively
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,36 @@
+package media_library
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrConflict is returned by ClaimVersion when the media has been changed since the editor loaded it
+var ErrConflict = errors.New("the media has been changed by someone else")
+
+// ClaimVersion makes sure the media in tx is still at version, the UnixNano of the UpdatedAt the editor loaded,
+// and holds it until tx ends, so saving it after doesn't overwrite a concurrent change. A zero version is not checked.
+func ClaimVersion(tx *gorm.DB, id uint, version int64) error {
+	if version == 0 {
+		return nil
+	}
+	var current MediaLibrary
+	if err := tx.Select("id", "updated_at").First(&current, id).Error; err != nil {
+		return err
+	}
+	if current.UpdatedAt.UnixNano() != version {
+		return ErrConflict
+	}
+	res := tx.Model(&MediaLibrary{}).
+		Where("id = ? AND updated_at = ?", id, current.UpdatedAt).
+		UpdateColumn("updated_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
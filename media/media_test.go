@@ -0,0 +1,53 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestSizeGetQuality(t *testing.T) {
+	cases := []struct {
+		size *Size
+		want int
+	}{
+		{nil, 0},
+		{&Size{}, 0},
+		{&Size{Quality: 75}, 75},
+		{&Size{Quality: -5}, 1},
+		{&Size{Quality: 120}, 100},
+	}
+	for _, c := range cases {
+		if got := c.size.GetQuality(); got != c.want {
+			t.Errorf("GetQuality(%+v) = %d, want %d", c.size, got, c.want)
+		}
+	}
+}
+
+func TestEncodeOptionsUseTheSizeQuality(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 4), uint8(x * y), 255})
+		}
+	}
+	encode := func(size *Size) int {
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, img, imaging.JPEG, encodeOptions(size)...); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Len()
+	}
+
+	low, high := encode(&Size{Quality: 10}), encode(&Size{Quality: 100})
+	if low >= high {
+		t.Errorf("quality 10 encodes %d bytes, quality 100 encodes %d bytes", low, high)
+	}
+	// the default quality of imaging is 95
+	if def, q95 := encode(&Size{}), encode(&Size{Quality: 95}); def != q95 {
+		t.Errorf("the default encodes %d bytes, quality 95 encodes %d bytes", def, q95)
+	}
+}
@@ -0,0 +1,276 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+// StripMetadataOptions are the metadata kept by StripMetadata
+type StripMetadataOptions struct {
+	KeepCopyright  bool
+	KeepICCProfile bool
+}
+
+var (
+	jpegExifPrefix = []byte("Exif\x00\x00")
+	jpegICCPrefix  = []byte("ICC_PROFILE\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+const (
+	exifTagOrientation = 0x0112
+	exifTagCopyright   = 0x8298
+)
+
+// StripMetadata removes EXIF, IPTC, XMP and comments from a JPEG, PNG or TIFF image, other formats are returned as they are.
+// The EXIF orientation of a JPEG is applied to the pixels before it's removed, which re-encodes the image.
+// A TIFF keeps its metadata in the tags of the image itself, so it's always encoded again with its orientation applied,
+// keeping only its first page and none of its metadata whatever the options.
+func StripMetadata(data []byte, format imaging.Format, opts StripMetadataOptions) ([]byte, error) {
+	switch format {
+	case imaging.JPEG:
+		return stripJPEGMetadata(data, opts)
+	case imaging.PNG:
+		return stripPNGMetadata(data, opts), nil
+	case imaging.TIFF:
+		return stripTIFFMetadata(data)
+	}
+	return data, nil
+}
+
+type jpegSegment struct {
+	marker  byte
+	payload []byte
+}
+
+func stripJPEGMetadata(data []byte, opts StripMetadataOptions) ([]byte, error) {
+	segments, rest, ok := splitJPEG(data)
+	if !ok {
+		return data, nil
+	}
+
+	var orientation int
+	var copyright string
+	var icc []jpegSegment
+	for _, s := range segments {
+		switch {
+		case s.marker == 0xE1 && bytes.HasPrefix(s.payload, jpegExifPrefix):
+			tiff := s.payload[len(jpegExifPrefix):]
+			orientation, copyright = readExifTags(tiff)
+		case s.marker == 0xE2 && bytes.HasPrefix(s.payload, jpegICCPrefix):
+			icc = append(icc, s)
+		}
+	}
+
+	var metadata []jpegSegment
+	if opts.KeepCopyright && copyright != "" {
+		metadata = append(metadata, jpegSegment{0xE1, append(append([]byte{}, jpegExifPrefix...), copyrightExif(copyright)...)})
+	}
+	if opts.KeepICCProfile {
+		metadata = append(metadata, icc...)
+	}
+
+	reencoded := orientation > 1
+	if reencoded {
+		img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err = imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(95)); err != nil {
+			return nil, err
+		}
+		if segments, rest, ok = splitJPEG(buf.Bytes()); !ok {
+			return buf.Bytes(), nil
+		}
+	}
+	// the metadata kept takes the place of the first metadata segment, or follows the APPn segments of a
+	// re-encoded image, so the order of the segments is the one of the image
+	var kept []jpegSegment
+	placed := false
+	place := func() {
+		if !placed {
+			kept = append(kept, metadata...)
+			placed = true
+		}
+	}
+	for _, s := range segments {
+		switch {
+		// APP0 (JFIF) and APP14 (Adobe) describe the pixels, the other APPn and comments are metadata
+		case s.marker == 0xE0 || s.marker == 0xEE:
+			kept = append(kept, s)
+		case s.marker < 0xE0 && s.marker != 0xFE:
+			place()
+			kept = append(kept, s)
+		case !reencoded && (s.marker == 0xE1 && bytes.HasPrefix(s.payload, jpegExifPrefix) ||
+			s.marker == 0xE2 && bytes.HasPrefix(s.payload, jpegICCPrefix)):
+			place()
+		}
+	}
+	place()
+
+	out := bytes.NewBuffer([]byte{0xFF, 0xD8})
+	for _, s := range kept {
+		out.Write([]byte{0xFF, s.marker})
+		binary.Write(out, binary.BigEndian, uint16(len(s.payload)+2))
+		out.Write(s.payload)
+	}
+	out.Write(rest)
+	return out.Bytes(), nil
+}
+
+// splitJPEG returns the segments before the image data, and the image data starting at the SOS marker
+func splitJPEG(data []byte) (segments []jpegSegment, rest []byte, ok bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, nil, false
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil, nil, false
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return segments, data[i:], true
+		}
+		l := int(binary.BigEndian.Uint16(data[i+2:]))
+		if l < 2 || i+2+l > len(data) {
+			return nil, nil, false
+		}
+		segments = append(segments, jpegSegment{marker, data[i+4 : i+2+l]})
+		i += 2 + l
+	}
+	return nil, nil, false
+}
+
+// readExifTags reads the orientation and the copyright of the first IFD of a TIFF structure
+func readExifTags(tiff []byte) (orientation int, copyright string) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		e := ifd + 2 + i*12
+		if e+12 > len(tiff) {
+			return
+		}
+		switch order.Uint16(tiff[e:]) {
+		case exifTagOrientation:
+			orientation = int(order.Uint16(tiff[e+8:]))
+		case exifTagCopyright:
+			count := int(order.Uint32(tiff[e+4:]))
+			value := tiff[e+8 : e+12]
+			if count > 4 {
+				offset := int(order.Uint32(tiff[e+8:]))
+				if offset+count > len(tiff) {
+					continue
+				}
+				value = tiff[offset : offset+count]
+			} else {
+				value = value[:count]
+			}
+			copyright = string(bytes.TrimRight(value, "\x00"))
+		}
+	}
+	return
+}
+
+// copyrightExif makes a TIFF structure with only the copyright tag
+func copyrightExif(copyright string) []byte {
+	value := append([]byte(copyright), 0)
+	buf := bytes.NewBuffer([]byte("MM\x00\x2a"))
+	binary.Write(buf, binary.BigEndian, uint32(8))
+	binary.Write(buf, binary.BigEndian, uint16(1))
+	binary.Write(buf, binary.BigEndian, []uint16{exifTagCopyright, 2})
+	binary.Write(buf, binary.BigEndian, uint32(len(value)))
+	if len(value) <= 4 {
+		buf.Write(append(value, make([]byte, 4-len(value))...))
+		binary.Write(buf, binary.BigEndian, uint32(0))
+		return buf.Bytes()
+	}
+	binary.Write(buf, binary.BigEndian, uint32(8+2+12+4))
+	binary.Write(buf, binary.BigEndian, uint32(0))
+	buf.Write(value)
+	return buf.Bytes()
+}
+
+func stripTIFFMetadata(data []byte) ([]byte, error) {
+	img, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	// a TIFF is a TIFF structure, its orientation tag is the one of the EXIF of a JPEG
+	orientation, _ := readExifTags(data)
+	img = applyOrientation(img, orientation)
+	var buf bytes.Buffer
+	if err = imaging.Encode(&buf, img, imaging.TIFF); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// applyOrientation transforms img like a viewer honouring the EXIF orientation would
+func applyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
+
+func stripPNGMetadata(data []byte, opts StripMetadataOptions) []byte {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return data
+	}
+	out := bytes.NewBuffer(append([]byte{}, pngSignature...))
+	for i := len(pngSignature); i+12 <= len(data); {
+		l := int(binary.BigEndian.Uint32(data[i:]))
+		if i+12+l > len(data) {
+			return data
+		}
+		chunk := data[i : i+12+l]
+		i += 12 + l
+
+		switch string(chunk[4:8]) {
+		case "eXIf", "zTXt", "iTXt", "tIME":
+			continue
+		case "tEXt":
+			if !opts.KeepCopyright || !bytes.HasPrefix(chunk[8:], []byte("Copyright\x00")) {
+				continue
+			}
+		case "iCCP":
+			if !opts.KeepICCProfile {
+				continue
+			}
+		}
+		out.Write(chunk)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,170 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func exifSegment(orientation uint16, copyright string) []byte {
+	value := append([]byte(copyright), 0)
+	tiff := bytes.NewBuffer([]byte("MM\x00\x2a"))
+	binary.Write(tiff, binary.BigEndian, uint32(8))
+	binary.Write(tiff, binary.BigEndian, uint16(2))
+	binary.Write(tiff, binary.BigEndian, []uint16{exifTagOrientation, 3, 0, 1, orientation, 0})
+	binary.Write(tiff, binary.BigEndian, []uint16{exifTagCopyright, 2})
+	binary.Write(tiff, binary.BigEndian, []uint32{uint32(len(value)), 8 + 2 + 24 + 4, 0})
+	tiff.Write(value)
+
+	payload := append(append([]byte{}, jpegExifPrefix...), tiff.Bytes()...)
+	seg := bytes.NewBuffer([]byte{0xFF, 0xE1})
+	binary.Write(seg, binary.BigEndian, uint16(len(payload)+2))
+	seg.Write(payload)
+	return seg.Bytes()
+}
+
+func testJPEG(t *testing.T, orientation uint16) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	return append(append([]byte{0xFF, 0xD8}, exifSegment(orientation, "ACME Photos")...), data[2:]...)
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	data, err := StripMetadata(testJPEG(t, 1), imaging.JPEG, StripMetadataOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, jpegExifPrefix) {
+		t.Errorf("exif is not stripped")
+	}
+	if _, err = jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("stripped image is broken: %v", err)
+	}
+
+	data, err = StripMetadata(testJPEG(t, 1), imaging.JPEG, StripMetadataOptions{KeepCopyright: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments, _, _ := splitJPEG(data)
+	if len(segments) == 0 || segments[0].marker != 0xE1 {
+		t.Fatalf("copyright is not kept")
+	}
+	if o, c := readExifTags(segments[0].payload[len(jpegExifPrefix):]); o != 0 || c != "ACME Photos" {
+		t.Errorf("got orientation %d, copyright %q", o, c)
+	}
+
+	data, err = StripMetadata(testJPEG(t, 6), imaging.JPEG, StripMetadataOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 2 || cfg.Height != 4 || bytes.Contains(data, jpegExifPrefix) {
+		t.Errorf("orientation is not applied, got %dx%d", cfg.Width, cfg.Height)
+	}
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	chunk := func(typ, data string) []byte {
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.BigEndian, uint32(len(data)))
+		buf.WriteString(typ + data + "crc_")
+		return buf.Bytes()
+	}
+	var png []byte
+	png = append(png, pngSignature...)
+	for _, c := range [][]byte{
+		chunk("IHDR", "0123456789abc"),
+		chunk("tEXt", "Copyright\x00ACME"),
+		chunk("tEXt", "GPS\x0035.6,139.7"),
+		chunk("iCCP", "icc"),
+		chunk("IEND", ""),
+	} {
+		png = append(png, c...)
+	}
+
+	data, _ := StripMetadata(png, imaging.PNG, StripMetadataOptions{KeepICCProfile: true})
+	if bytes.Contains(data, []byte("GPS")) || bytes.Contains(data, []byte("ACME")) || !bytes.Contains(data, []byte("iCCP")) {
+		t.Errorf("unexpected chunks %q", data)
+	}
+	if !bytes.HasSuffix(data, chunk("IEND", "")) {
+		t.Errorf("image chunks are dropped")
+	}
+}
+
+func TestStripJPEGMetadataKeepsTheSegmentOrder(t *testing.T) {
+	segment := func(marker byte, payload string) []byte {
+		seg := bytes.NewBuffer([]byte{0xFF, marker})
+		binary.Write(seg, binary.BigEndian, uint16(len(payload)+2))
+		seg.WriteString(payload)
+		return seg.Bytes()
+	}
+	data := testJPEG(t, 1)
+	var in []byte
+	in = append(in, 0xFF, 0xD8)
+	in = append(in, segment(0xE0, "JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")...)
+	in = append(in, data[2:2+len(exifSegment(1, "ACME Photos"))]...)
+	in = append(in, segment(0xFE, "a comment")...)
+	in = append(in, segment(0xE2, string(jpegICCPrefix)+"\x01\x01icc")...)
+	in = append(in, data[2+len(exifSegment(1, "ACME Photos")):]...)
+
+	out, err := StripMetadata(in, imaging.JPEG, StripMetadataOptions{KeepCopyright: true, KeepICCProfile: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments, _, _ := splitJPEG(out)
+	var markers []byte
+	for _, s := range segments {
+		markers = append(markers, s.marker)
+	}
+	if len(markers) < 3 || markers[0] != 0xE0 || markers[1] != 0xE1 || markers[2] != 0xE2 || bytes.IndexByte(markers, 0xFE) >= 0 {
+		t.Errorf("got the markers % X", markers)
+	}
+	if _, err = jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("stripped image is broken: %v", err)
+	}
+}
+
+func TestStripTIFFMetadata(t *testing.T) {
+	// a 2x1 grey TIFF rotated by its orientation tag, with a copyright
+	copyright := []byte("ACME Photos\x00")
+	tiff := bytes.NewBuffer([]byte("II\x2a\x00"))
+	binary.Write(tiff, binary.LittleEndian, uint32(8))
+	entries := [][3]uint32{
+		{256, 3, 2}, {257, 3, 1}, {258, 3, 8}, {259, 3, 1}, {262, 3, 1},
+		{273, 4, 8 + 2 + 10*12 + 4 + uint32(len(copyright))}, {274, 3, 6}, {278, 3, 1}, {279, 4, 2},
+	}
+	binary.Write(tiff, binary.LittleEndian, uint16(len(entries)+1))
+	for _, e := range entries {
+		binary.Write(tiff, binary.LittleEndian, []uint16{uint16(e[0]), uint16(e[1])})
+		binary.Write(tiff, binary.LittleEndian, []uint32{1, e[2]})
+	}
+	binary.Write(tiff, binary.LittleEndian, []uint16{exifTagCopyright, 2})
+	binary.Write(tiff, binary.LittleEndian, []uint32{uint32(len(copyright)), 8 + 2 + 10*12 + 4, 0})
+	tiff.Write(copyright)
+	tiff.Write([]byte{0x00, 0xFF})
+
+	out, err := StripMetadata(tiff.Bytes(), imaging.TIFF, StripMetadataOptions{KeepCopyright: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out, []byte("ACME")) {
+		t.Error("the copyright is not stripped")
+	}
+	img, err := imaging.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 1 || b.Dy() != 2 {
+		t.Errorf("orientation is not applied, got %dx%d", b.Dx(), b.Dy())
+	}
+}
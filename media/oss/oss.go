@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"io/ioutil"
+	"path/filepath"
 	"strings"
 
 	"github.com/qor/oss"
@@ -16,21 +17,26 @@ var (
 	URLTemplate = "/system/{{class}}/{{primary_key}}/{{column}}/{{filename_with_hash}}"
 	// Storage the storage used to save medias
 	Storage oss.StorageInterface = filesystem.New("public")
-	_       media.Media          = &OSS{}
+	// DerivativeStorage the storage used to save sizes of images, same as Storage if nil
+	DerivativeStorage oss.StorageInterface
+	_                 media.Media             = &OSS{}
+	_                 media.StoredFilesLister = &OSS{}
 )
 
-// OSS common storage interface
-type OSS struct {
-	media.Base
-}
-
-// DefaultURLTemplateHandler used to generate URL and save into database
-var DefaultURLTemplateHandler = func(oss OSS, option *media.Option) (url string) {
-	if url = option.Get("URL"); url == "" {
-		url = URLTemplate
+// SetDerivativeStorage stores sizes of new uploads to s while originals stay in Storage,
+// existing sizes are still resolved against Storage
+func SetDerivativeStorage(s oss.StorageInterface) {
+	DerivativeStorage = s
+	media.DerivativeURLHandler = nil
+	if s != nil {
+		media.DerivativeURLHandler = func(url string) string {
+			return joinEndpoint(DerivativeStorage.GetEndpoint(), relativePath(url))
+		}
 	}
+}
 
-	url = strings.Join([]string{strings.TrimSuffix(Storage.GetEndpoint(), "/"), strings.TrimPrefix(url, "/")}, "/")
+func joinEndpoint(endpoint string, url string) string {
+	url = strings.Join([]string{strings.TrimSuffix(endpoint, "/"), strings.TrimPrefix(url, "/")}, "/")
 	if strings.HasPrefix(url, "/") {
 		return url
 	}
@@ -43,6 +49,51 @@ var DefaultURLTemplateHandler = func(oss OSS, option *media.Option) (url string)
 	return "//" + url
 }
 
+// relativePath trims the endpoint of Storage or DerivativeStorage from url
+func relativePath(url string) string {
+	storages := []oss.StorageInterface{Storage}
+	if DerivativeStorage != nil {
+		storages = append(storages, DerivativeStorage)
+	}
+	for _, s := range storages {
+		endpoint := joinEndpoint(s.GetEndpoint(), "")
+		if endpoint != "/" && strings.HasPrefix(url, endpoint) {
+			return "/" + strings.TrimPrefix(url, endpoint)
+		}
+	}
+	return url
+}
+
+// storageFor returns the storage of path, sizes go to DerivativeStorage if the media separates derivatives
+func (o OSS) storageFor(path string) (s oss.StorageInterface, p string) {
+	if DerivativeStorage == nil || !o.SeparateDerivatives {
+		return Storage, path
+	}
+	trimExt := func(v string) string {
+		return strings.TrimSuffix(v, filepath.Ext(v))
+	}
+	for _, original := range []string{o.Base.URL(), o.Base.URL("original")} {
+		if trimExt(path) == trimExt(original) {
+			return Storage, path
+		}
+	}
+	return DerivativeStorage, relativePath(path)
+}
+
+// OSS common storage interface
+type OSS struct {
+	media.Base
+}
+
+// DefaultURLTemplateHandler used to generate URL and save into database
+var DefaultURLTemplateHandler = func(oss OSS, option *media.Option) (url string) {
+	if url = option.Get("URL"); url == "" {
+		url = URLTemplate
+	}
+
+	return joinEndpoint(Storage.GetEndpoint(), url)
+}
+
 // GetURLTemplate URL's template
 func (o OSS) GetURLTemplate(option *media.Option) (url string) {
 	return DefaultURLTemplateHandler(o, option)
@@ -50,7 +101,8 @@ func (o OSS) GetURLTemplate(option *media.Option) (url string) {
 
 // DefaultStoreHandler used to store reader with default Storage
 var DefaultStoreHandler = func(oss OSS, path string, option *media.Option, reader io.Reader) error {
-	_, err := Storage.Put(path, reader)
+	storage, path := oss.storageFor(path)
+	_, err := storage.Put(path, reader)
 	return err
 }
 
@@ -61,7 +113,8 @@ func (o OSS) Store(path string, option *media.Option, reader io.Reader) error {
 
 // DefaultRetrieveHandler used to retrieve file
 var DefaultRetrieveHandler = func(oss OSS, path string) (media.FileInterface, error) {
-	result, err := Storage.GetStream(path)
+	storage, path := oss.storageFor(path)
+	result, err := storage.GetStream(path)
 	if f, ok := result.(media.FileInterface); ok {
 		return f, err
 	}
@@ -82,11 +135,41 @@ func (o OSS) Retrieve(path string) (media.FileInterface, error) {
 	return DefaultRetrieveHandler(o, path)
 }
 
+// StoredFiles returns the names of the files stored in the folder of path
+func (o OSS) StoredFiles(path string) ([]string, error) {
+	storage, path := o.storageFor(path)
+	objects, err := storage.List(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(objects))
+	for _, object := range objects {
+		names = append(names, object.Name)
+	}
+	return names, nil
+}
+
+// DefaultRemoveHandler used to delete a stored file
+var DefaultRemoveHandler = func(oss OSS, path string) error {
+	storage, path := oss.storageFor(path)
+	return storage.Delete(path)
+}
+
+// Remove deletes the stored file of url
+func (o OSS) Remove(path string) error {
+	return DefaultRemoveHandler(o, path)
+}
+
 // URL return file's url with given style
 func (o OSS) URL(styles ...string) string {
 	url := o.Base.URL(styles...)
 
-	newurl, err := Storage.GetURL(url)
+	storage := Storage
+	if DerivativeStorage != nil && o.SeparateDerivatives && media.IsDerivativeStyle(styles...) {
+		storage = DerivativeStorage
+		url = media.DerivativeURL(url)
+	}
+	newurl, err := storage.GetURL(url)
 	if err != nil || len(newurl) == 0 {
 		return url
 	}
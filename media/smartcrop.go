@@ -0,0 +1,87 @@
+package media
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// smartCropAnalysisSize is the longest side of the copy of an image analyzed by smartCropRect
+const smartCropAnalysisSize = 128
+
+// SmartCropRect returns the largest crop of img with the aspect ratio of width x height that keeps its most detailed part,
+// scored by the edges of a small grayscale copy, so flat backgrounds are cropped out before the subject.
+// Crops scoring the same are chosen closest to the center.
+func SmartCropRect(img image.Image, width, height int) image.Rectangle {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	cw, ch := w, h
+	if float64(w)*float64(height) > float64(h)*float64(width) {
+		cw = int(math.Round(float64(h) * float64(width) / float64(height)))
+	} else {
+		ch = int(math.Round(float64(w) * float64(height) / float64(width)))
+	}
+	if cw <= 0 || ch <= 0 || (cw == w && ch == h) {
+		return b
+	}
+
+	scale := math.Min(1, float64(smartCropAnalysisSize)/math.Max(float64(w), float64(h)))
+	sw, sh := int(math.Max(1, math.Round(float64(w)*scale))), int(math.Max(1, math.Round(float64(h)*scale)))
+	gray := imaging.Grayscale(imaging.Resize(img, sw, sh, imaging.Box))
+
+	// integral of the edges along the axis the crop slides on
+	horizontal := cw < w
+	n := sh
+	if horizontal {
+		n = sw
+	}
+	sums := make([]float64, n+1)
+	lum := func(x, y int) float64 { return float64(gray.Pix[y*gray.Stride+x*4]) }
+	for y := 0; y < sh; y++ {
+		for x := 0; x < sw; x++ {
+			var e float64
+			if x+1 < sw {
+				e += math.Abs(lum(x+1, y) - lum(x, y))
+			}
+			if y+1 < sh {
+				e += math.Abs(lum(x, y+1) - lum(x, y))
+			}
+			if horizontal {
+				sums[x+1] += e
+			} else {
+				sums[y+1] += e
+			}
+		}
+	}
+	for i := 1; i <= n; i++ {
+		sums[i] += sums[i-1]
+	}
+
+	window := int(math.Round(float64(ch) * scale))
+	free := h - ch
+	if horizontal {
+		window = int(math.Round(float64(cw) * scale))
+		free = w - cw
+	}
+	if window > n {
+		window = n
+	}
+	best, bestScore, bestDist := (n-window)/2, -1.0, math.MaxFloat64
+	for i := 0; i+window <= n; i++ {
+		score := sums[i+window] - sums[i]
+		dist := math.Abs(float64(i) - float64(n-window)/2)
+		if score > bestScore || (score == bestScore && dist < bestDist) {
+			best, bestScore, bestDist = i, score, dist
+		}
+	}
+
+	offset := int(math.Round(float64(best) / scale))
+	if offset > free {
+		offset = free
+	}
+	if horizontal {
+		return image.Rect(b.Min.X+offset, b.Min.Y, b.Min.X+offset+cw, b.Min.Y+ch)
+	}
+	return image.Rect(b.Min.X, b.Min.Y+offset, b.Min.X+cw, b.Min.Y+offset+ch)
+}
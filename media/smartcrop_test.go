@@ -0,0 +1,37 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestSmartCropRect(t *testing.T) {
+	// a flat 400x100 image with a detailed square on its right
+	img := imaging.New(400, 100, color.White)
+	for y := 20; y < 80; y++ {
+		for x := 320; x < 380; x++ {
+			if (x/4+y/4)%2 == 0 {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	r := SmartCropRect(img, 100, 100)
+	if r.Dx() != 100 || r.Dy() != 100 {
+		t.Fatalf("crop %v should be 100x100", r)
+	}
+	if !image.Rect(320, 20, 380, 80).In(r) {
+		t.Errorf("crop %v should keep the detailed square", r)
+	}
+
+	flat := imaging.New(400, 100, color.White)
+	if r := SmartCropRect(flat, 100, 100); r != image.Rect(150, 0, 250, 100) {
+		t.Errorf("crop of a flat image %v should be centered", r)
+	}
+	if r := SmartCropRect(flat, 400, 100); r != flat.Bounds() {
+		t.Errorf("crop with the same ratio %v should be the whole image", r)
+	}
+}
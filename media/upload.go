@@ -13,13 +13,17 @@ var (
 	MediaLibraryURL = ""
 )
 
-func cropField(field *schema.Field, db *gorm.DB) (cropped bool, err error) {
-
+// fieldMedia gets the media of field of the saved record
+func fieldMedia(field *schema.Field, db *gorm.DB) (media Media, ok bool) {
 	if !field.ReflectValueOf(db.Statement.Context, db.Statement.ReflectValue).CanAddr() {
 		return
 	}
+	media, ok = field.ReflectValueOf(db.Statement.Context, db.Statement.ReflectValue).Addr().Interface().(Media)
+	return
+}
 
-	media, ok := field.ReflectValueOf(db.Statement.Context, db.Statement.ReflectValue).Addr().Interface().(Media)
+func cropField(field *schema.Field, db *gorm.DB) (cropped bool, err error) {
+	media, ok := fieldMedia(field, db)
 	if !ok {
 		return
 	}
@@ -54,10 +58,28 @@ func cropField(field *schema.Field, db *gorm.DB) (cropped bool, err error) {
 	defer mediaFile.Close()
 	media.Cropped(true)
 
+	deterministic := deterministicKeys(media)
+	if deterministic {
+		option.Set("URL", DeterministicURLTemplate)
+	}
 	if url := media.GetURL(option, db, field, media); url == "" {
 		return false, errors.New("invalid URL")
 	} else {
-		result, _ := json.Marshal(map[string]string{"Url": url})
+		var sizeURLTemplate string
+		if deterministic {
+			// never overwrite an existing file with a new upload
+			if media.GetFileHeader() != nil {
+				url = uniqueSizeURL(media, url)
+			}
+			sizeURLTemplate, url = url, SizedURL("", url, mainSizeKey)
+		} else if media.GetFileHeader() != nil {
+			url = uniqueURL(media, url)
+		}
+		result, _ := json.Marshal(map[string]interface{}{
+			"Url":                 url,
+			"SizeURL":             sizeURLTemplate,
+			"SeparateDerivatives": DerivativeURLHandler != nil,
+		})
 		media.Scan(string(result))
 	}
 
@@ -69,8 +91,15 @@ func cropField(field *schema.Field, db *gorm.DB) (cropped bool, err error) {
 		}
 
 		mediaFile.Seek(0, 0)
-		if handler.Handle(media, mediaFile, option) == nil {
+		herr := handler.Handle(media, mediaFile, option)
+		if herr == nil {
 			handled = true
+			continue
+		}
+		// storing the file as is would leave the sizes out of sync with the crop options
+		var derr *DerivativeError
+		if errors.As(herr, &derr) || media.GetFileHeader() == nil {
+			return false, herr
 		}
 	}
 
@@ -83,7 +112,38 @@ func cropField(field *schema.Field, db *gorm.DB) (cropped bool, err error) {
 	return true, nil
 }
 
+// SaveUploadAndCropImage saves obj and generates the files of its media fields in a transaction,
+// the record is rolled back if any file failed to be stored, and the files stored for the new uploads are removed
+// if the media can remove them
 func SaveUploadAndCropImage(db *gorm.DB, obj interface{}) (err error) {
+	var uploaded []Media
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return saveUploadAndCropImage(tx, obj, &uploaded)
+	})
+	if err != nil {
+		for _, m := range uploaded {
+			removeStored(m)
+		}
+	}
+	return
+}
+
+// removeStored removes the file and the sizes of a new upload, they are stored under a new URL and belong to no record
+func removeStored(media Media) {
+	r, ok := media.(interface{ Remove(url string) error })
+	if !ok {
+		return
+	}
+	urls := []string{media.URL(), media.URL("original")}
+	for k := range media.GetSizes() {
+		urls = append(urls, media.URL(k))
+	}
+	for _, url := range urls {
+		r.Remove(url)
+	}
+}
+
+func saveUploadAndCropImage(db *gorm.DB, obj interface{}, uploaded *[]Media) (err error) {
 	db = db.Model(obj).Save(obj)
 	err = db.Error
 	if err != nil {
@@ -93,7 +153,15 @@ func SaveUploadAndCropImage(db *gorm.DB, obj interface{}) (err error) {
 	var updateColumns = map[string]interface{}{}
 
 	for _, field := range db.Statement.Schema.Fields {
+		var before string
+		m, isMedia := fieldMedia(field, db)
+		if isMedia {
+			before = m.URL()
+		}
 		ok, err := cropField(field, db)
+		if isMedia && m.GetFileHeader() != nil && m.URL() != "" && m.URL() != before {
+			*uploaded = append(*uploaded, m)
+		}
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,110 @@
+package media
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// memMedia stores the files in memStored
+type memMedia struct {
+	Base
+}
+
+var memStored = map[string]bool{}
+
+func (m *memMedia) Store(url string, option *Option, reader io.Reader) error {
+	memStored[url] = true
+	return nil
+}
+
+func (m *memMedia) Retrieve(url string) (FileInterface, error) {
+	return nil, os.ErrNotExist
+}
+
+func (m *memMedia) Remove(url string) error {
+	delete(memStored, url)
+	return nil
+}
+
+type uploadRecord struct {
+	ID   uint
+	File memMedia `gorm:"type:text" mediaLibrary:"url:/records/{{primary_key}}/{{filename_with_hash}}"`
+}
+
+// newUploadRecord opens the database of uploadRecord and makes a record uploading a.txt
+func newUploadRecord(t *testing.T) (*gorm.DB, *uploadRecord) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&uploadRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "a.txt")
+	if err = os.WriteFile(name, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	r := &uploadRecord{}
+	if err = r.File.Scan(f); err != nil {
+		t.Fatal(err)
+	}
+	return db, r
+}
+
+func TestSaveUploadAndCropImageRemovesFilesOfFailedSave(t *testing.T) {
+	db, r := newUploadRecord(t)
+	errFail := errors.New("update failed")
+	db.Callback().Update().Register("test:fail", func(tx *gorm.DB) { tx.AddError(errFail) })
+
+	if err := SaveUploadAndCropImage(db, r); !errors.Is(err, errFail) {
+		t.Fatalf("err = %v, want %v", err, errFail)
+	}
+	if r.File.URL() == "" {
+		t.Fatal("the file isn't stored")
+	}
+	if len(memStored) != 0 {
+		t.Errorf("the files of the failed save are left: %v", memStored)
+	}
+}
+
+func TestKeyScheme(t *testing.T) {
+	defer func() { memStored = map[string]bool{} }()
+
+	db, r := newUploadRecord(t)
+	if err := SaveUploadAndCropImage(db, r); err != nil {
+		t.Fatal(err)
+	}
+	if r.File.SizeURL != "" || !memStored[r.File.URL()] {
+		t.Errorf("the default scheme stores %v, size url %q", memStored, r.File.SizeURL)
+	}
+
+	db, r = newUploadRecord(t)
+	r.File.KeyScheme = KeySchemeDeterministic
+	if err := SaveUploadAndCropImage(db, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/media/1/file/a.txt"; r.File.URL() != want || !memStored[want] {
+		t.Errorf("url = %q, want %q", r.File.URL(), want)
+	}
+	if want := "/media/1/thumb/a.txt"; r.File.URL("thumb") != want {
+		t.Errorf("size url = %q, want %q", r.File.URL("thumb"), want)
+	}
+
+	// the crops keep the scheme the file is stored with
+	r.File.FileHeader = nil
+	r.File.KeyScheme = KeySchemeDefault
+	if !deterministicKeys(&r.File) {
+		t.Error("the stored file doesn't keep its scheme")
+	}
+}
@@ -1,7 +1,10 @@
 package media
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net/http"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -63,6 +66,21 @@ func IsSVGFormat(name string) bool {
 	return false
 }
 
+// DetectContentType sniffs the content type of a file by its first bytes, regardless of its filename.
+// It adds TIFF, which http.DetectContentType doesn't know, and returns "application/octet-stream" for unknown content.
+func DetectContentType(r io.Reader) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	buf = buf[:n]
+	if bytes.HasPrefix(buf, []byte("II*\x00")) || bytes.HasPrefix(buf, []byte("MM\x00*")) {
+		return "image/tiff", nil
+	}
+	return http.DetectContentType(buf), nil
+}
+
 func parseTagOption(str string) *Option {
 	option := Option(utils.ParseTagOption(str))
 	return &option
@@ -0,0 +1,66 @@
+package media
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var ErrUnsupportedVideoLink = errors.New("only YouTube and Vimeo video links are supported")
+
+var (
+	youtubeIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	vimeoIDRegexp   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// NormalizeVideoLink validates a YouTube or Vimeo link and returns its https embed URL,
+// e.g. https://youtu.be/<id> becomes https://www.youtube.com/embed/<id>
+func NormalizeVideoLink(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", ErrUnsupportedVideoLink
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	var id string
+	switch host {
+	case "youtube.com", "m.youtube.com", "youtube-nocookie.com":
+		if len(segments) == 1 && segments[0] == "watch" {
+			id = u.Query().Get("v")
+		} else if len(segments) == 2 && (segments[0] == "embed" || segments[0] == "shorts" || segments[0] == "v") {
+			id = segments[1]
+		}
+		if youtubeIDRegexp.MatchString(id) {
+			return "https://www.youtube.com/embed/" + id, nil
+		}
+	case "youtu.be":
+		if len(segments) == 1 {
+			id = segments[0]
+		}
+		if youtubeIDRegexp.MatchString(id) {
+			return "https://www.youtube.com/embed/" + id, nil
+		}
+	case "vimeo.com":
+		if len(segments) > 0 {
+			id = segments[len(segments)-1]
+		}
+		if vimeoIDRegexp.MatchString(id) {
+			return "https://player.vimeo.com/video/" + id, nil
+		}
+	case "player.vimeo.com":
+		if len(segments) == 2 && segments[0] == "video" {
+			id = segments[1]
+		}
+		if vimeoIDRegexp.MatchString(id) {
+			return "https://player.vimeo.com/video/" + id, nil
+		}
+	}
+	return "", ErrUnsupportedVideoLink
+}
@@ -0,0 +1,26 @@
+package media
+
+import (
+	"testing"
+)
+
+func TestNormalizeVideoLink(t *testing.T) {
+	cases := map[string]string{
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=10": "https://www.youtube.com/embed/dQw4w9WgXcQ",
+		"youtu.be/dQw4w9WgXcQ":                             "https://www.youtube.com/embed/dQw4w9WgXcQ",
+		"https://m.youtube.com/shorts/dQw4w9WgXcQ":         "https://www.youtube.com/embed/dQw4w9WgXcQ",
+		"https://vimeo.com/channels/staffpicks/76979871":   "https://player.vimeo.com/video/76979871",
+		"https://player.vimeo.com/video/76979871":          "https://player.vimeo.com/video/76979871",
+	}
+	for in, want := range cases {
+		if got, err := NormalizeVideoLink(in); err != nil || got != want {
+			t.Errorf("NormalizeVideoLink(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "https://example.com/watch?v=dQw4w9WgXcQ", "https://www.youtube.com/watch?v=bad", "javascript://vimeo.com/1"} {
+		if _, err := NormalizeVideoLink(in); err != ErrUnsupportedVideoLink {
+			t.Errorf("NormalizeVideoLink(%q) should fail", in)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package views
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/utils"
+	"gorm.io/gorm"
+)
+
+// APIConfig configures the media API
+type APIConfig struct {
+	// Authorize checks the request, like its bearer token, the PermList permission is checked when it's nil
+	Authorize func(r *http.Request) error
+	// AllowType restricts the API to the files of a type like the AllowType of a media box
+	AllowType string
+}
+
+type apiList struct {
+	Items      []MediaMetadata `json:"items"`
+	Page       int             `json:"page"`
+	PerPage    int             `json:"per_page"`
+	Total      int64           `json:"total"`
+	PagesCount int             `json:"pages_count"`
+}
+
+type apiCursorList struct {
+	Items []MediaMetadata `json:"items"`
+	utils.CursorPage
+}
+
+// API returns a read-only JSON API of the media library for front end apps.
+// GET ?id=1 returns a file, otherwise the files are listed newest first with the page, per_page, type, tag and keyword
+// parameters. With the cursor or before parameter, empty for the first page, pages are loaded by the next_cursor
+// and prev_cursor of the response instead of page, which stays fast deep into large libraries, the files matching
+// a keyword are then listed newest first too. Files hidden by Authorize are returned locked without their URLs, like in the admin.
+func API(db *gorm.DB, cfg APIConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		authorize := cfg.Authorize
+		if authorize == nil {
+			authorize = listIsAllowed
+		}
+		if err := authorize(r); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		wh := db.WithContext(r.Context()).Model(&media_library.MediaLibrary{})
+		if cfg.AllowType != "" {
+			wh = wh.Where("selected_type = ?", cfg.AllowType)
+		}
+
+		if id := r.FormValue("id"); id != "" {
+			var m media_library.MediaLibrary
+			if err := wh.First(&m, "id = ?", id).Error; err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			items, err := apiItems(db, r, []*media_library.MediaLibrary{&m})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, items[0])
+			return
+		}
+
+		if t := r.FormValue("type"); t != "" {
+			wh = wh.Where("selected_type = ?", t)
+		}
+		if tag := media_library.NormalizeTag(r.FormValue("tag")); tag != "" {
+			wh = wh.Where("id IN (SELECT media_library_id FROM media_library_tags WHERE name = ?)", tag)
+		}
+		if keyword := r.FormValue("keyword"); keyword != "" {
+			wh = SearchFunc(wh, keyword)
+		}
+		page, _ := strconv.Atoi(r.FormValue("page"))
+		perPage, _ := strconv.Atoi(r.FormValue("per_page"))
+		if perPage > MediaLibraryMaxPerPage {
+			perPage = MediaLibraryMaxPerPage
+		}
+
+		var files []*media_library.MediaLibrary
+		if _, ok := r.Form["cursor"]; ok || r.FormValue("before") != "" {
+			if perPage <= 0 {
+				perPage = MediaLibraryPerPage
+			}
+			cp, err := utils.CursorPaginate(wh, r.FormValue("cursor"), r.FormValue("before"), perPage, true, &files)
+			if err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, utils.ErrInvalidCursor) {
+					status = http.StatusBadRequest
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			items, err := apiItems(db, r, files)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, apiCursorList{Items: items, CursorPage: cp})
+			return
+		}
+		pg, err := paginate(wh.Order("created_at DESC"), page, perPage, &files)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		items, err := apiItems(db, r, files)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, apiList{Items: items, Page: pg.Page, PerPage: pg.PerPage, Total: pg.Total, PagesCount: pg.PagesCount})
+	})
+}
+
+func apiItems(db *gorm.DB, r *http.Request, files []*media_library.MediaLibrary) ([]MediaMetadata, error) {
+	tagsByID, err := filesTags(db.WithContext(r.Context()), files)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]MediaMetadata, 0, len(files))
+	for _, f := range files {
+		md := newMediaMetadata(f)
+		if !viewIsAllowed(r, f) {
+			md.URL, md.SizeURLs, md.URLExpiresAt, md.Locked = "", nil, nil, true
+		}
+		md.Tags = tagsByID[f.ID]
+		items = append(items, md)
+	}
+	return items, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,65 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/presets"
+	"gorm.io/gorm"
+)
+
+// Builder holds the settings of the media library of a presets builder,
+// create it with New, set it up with the options and apply it with Configure.
+type Builder struct {
+	db            *gorm.DB
+	downloadPath  string
+	maxUploadSize int64
+	dbTimeout     time.Duration
+	keyScheme     media.KeyScheme
+	uploadHooks   []MediaLibraryHook
+	chooseHooks   []MediaLibraryHook
+
+	webhooks       []Webhook
+	webhookClient  *http.Client
+	webhookRetries int
+	webhookBackoff time.Duration
+}
+
+func New(db *gorm.DB) *Builder {
+	return &Builder{
+		db:             db,
+		webhookClient:  &http.Client{Timeout: 10 * time.Second},
+		webhookRetries: 3,
+		webhookBackoff: time.Second,
+	}
+}
+
+// DownloadPath is the path the Downloads handler is mounted at, the download links are only shown once it's set
+func (b *Builder) DownloadPath(v string) *Builder {
+	b.downloadPath = v
+	return b
+}
+
+// MaxUploadSize refuses the uploaded files larger than v bytes, 0 doesn't limit them
+func (b *Builder) MaxUploadSize(v int64) *Builder {
+	b.maxUploadSize = v
+	return b
+}
+
+// DBTimeout cancels the queries of the event funcs taking longer, they are always cancelled with their request
+func (b *Builder) DBTimeout(v time.Duration) *Builder {
+	b.dbTimeout = v
+	return b
+}
+
+// KeyScheme sets the key scheme of new uploads, files already stored keep the keys they were stored with
+func (b *Builder) KeyScheme(v media.KeyScheme) *Builder {
+	b.keyScheme = v
+	return b
+}
+
+// Configure sets up the media library with the default settings
+func Configure(pb *presets.Builder, db *gorm.DB) {
+	New(db).Configure(pb)
+}
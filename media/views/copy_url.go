@@ -0,0 +1,129 @@
+package views
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/media/media_library"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	h "github.com/theplant/htmlgo"
+)
+
+const copiedVarName = "mediaURLCopied"
+
+type copyableURL struct {
+	Label string
+	URL   string
+}
+
+// mediaLibraryURLs return the original and size urls of the file
+func mediaLibraryURLs(f *media_library.MediaLibrary) (urls []copyableURL) {
+	urls = append(urls, copyableURL{Label: "original", URL: f.File.URL()})
+	if !media.IsImageFormat(f.File.FileName) {
+		return
+	}
+	var keys []string
+	for k := range f.File.Sizes {
+		if k == media_library.QorPreviewSizeName {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		urls = append(urls, copyableURL{Label: k, URL: f.File.URL(k)})
+	}
+	return
+}
+
+func embedSnippet(u string, fileName string, description string) string {
+	switch {
+	case media.IsImageFormat(fileName):
+		return fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(u), html.EscapeString(description))
+	case media.IsVideoFormat(fileName):
+		return fmt.Sprintf(`<video src="%s" controls></video>`, html.EscapeString(u))
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(u), html.EscapeString(fileName))
+}
+
+// signedURLExpiresAt return the expiry of a presigned url, both the AWS signature v4
+// (X-Amz-Date and X-Amz-Expires) and the Expires timestamp of v2 style signatures are supported
+func signedURLExpiresAt(u string) (t time.Time, ok bool) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return
+	}
+	q := pu.Query()
+	if date, expires := q.Get("X-Amz-Date"), q.Get("X-Amz-Expires"); date != "" && expires != "" {
+		start, err := time.Parse("20060102T150405Z", date)
+		if err != nil {
+			return
+		}
+		seconds, err := strconv.Atoi(expires)
+		if err != nil {
+			return
+		}
+		return start.Add(time.Duration(seconds) * time.Second), true
+	}
+	if expires := q.Get("Expires"); expires != "" {
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil {
+			return
+		}
+		return time.Unix(unix, 0), true
+	}
+	return
+}
+
+func copyScript(text string) string {
+	return fmt.Sprintf("navigator.clipboard.writeText(%s).then(function(){ vars.%s = true })", h.JSONString(text), copiedVarName)
+}
+
+func copyURLMenu(msgr *Messages, fileName string, description string, urls []copyableURL) h.HTMLComponent {
+	var items []h.HTMLComponent
+	for _, u := range urls {
+		subtitle := u.URL
+		if t, ok := signedURLExpiresAt(u.URL); ok {
+			subtitle = msgr.URLExpiresAt(t.Local().Format("2006-01-02 15:04"))
+		}
+		items = append(items,
+			VListItem(
+				VListItemContent(
+					VListItemTitle(h.Text(fmt.Sprintf("%s: %s", msgr.CopyURL, u.Label))),
+					VListItemSubtitle(h.Text(subtitle)),
+				),
+			).Attr("@click", copyScript(u.URL)),
+			VListItem(
+				VListItemContent(
+					VListItemTitle(h.Text(fmt.Sprintf("%s: %s", msgr.CopyEmbedHTML, u.Label))),
+				),
+			).Attr("@click", copyScript(embedSnippet(u.URL, fileName, description))),
+		)
+	}
+
+	return VMenu(
+		web.Slot(
+			VBtn("").Icon(true).Small(true).
+				Attr("v-bind", "attrs").
+				Attr("v-on", "on").
+				Attr("title", msgr.CopyURL).
+				Children(VIcon("content_copy").Small(true)),
+		).Name("activator").Scope("{ on, attrs }"),
+		VList(items...).Dense(true),
+	).OffsetY(true)
+}
+
+func copiedSnackbar(msgr *Messages) h.HTMLComponent {
+	return VSnackbar(h.Text(msgr.URLCopied)).
+		Attr("v-model", "vars."+copiedVarName).
+		Attr(web.InitContextVars, fmt.Sprintf("{%s: false}", copiedVarName)).
+		Top(true).
+		Color("primary").
+		Timeout(2000)
+}
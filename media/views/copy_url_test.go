@@ -0,0 +1,31 @@
+package views
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignedURLExpiresAt(t *testing.T) {
+	v4 := "https://bucket.s3.amazonaws.com/a.png?X-Amz-Date=20231120T010000Z&X-Amz-Expires=3600&X-Amz-Signature=x"
+	if got, ok := signedURLExpiresAt(v4); !ok || !got.Equal(time.Date(2023, 11, 20, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("v4 expiry = %v, %v", got, ok)
+	}
+	if got, ok := signedURLExpiresAt("https://x.oss.com/a.png?Expires=1700000000&Signature=x"); !ok || got.Unix() != 1700000000 {
+		t.Errorf("v2 expiry = %v, %v", got, ok)
+	}
+	if _, ok := signedURLExpiresAt("/system/media_libraries/1/file.png"); ok {
+		t.Errorf("unsigned url should not expire")
+	}
+}
+
+func TestDownloadFileName(t *testing.T) {
+	if got := downloadFileName("my photo 写真.jpg", "thumb"); got != "my photo 写真-thumb.jpg" {
+		t.Errorf("got %q", got)
+	}
+	if got := downloadFileName("hero.jpg", downloadOriginal); got != "hero.jpg" {
+		t.Errorf("got %q", got)
+	}
+	if got := contentDisposition("my photo 写真.jpg"); got != "attachment; filename*=utf-8''my%20photo%20%E5%86%99%E7%9C%9F.jpg" {
+		t.Errorf("got %q", got)
+	}
+}
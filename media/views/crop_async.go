@@ -0,0 +1,54 @@
+package views
+
+import (
+	"net/http"
+
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/media/media_library"
+	"gorm.io/gorm"
+)
+
+// CropEnqueuer adds a job generating the derivatives of the media with GenerateCrop,
+// progressURL is where the user follows the progress of the job, like its page in the worker admin.
+type CropEnqueuer func(r *http.Request, mediaID uint) (progressURL string, err error)
+
+var (
+	asyncCropPixels int
+	cropEnqueuer    CropEnqueuer
+)
+
+// CropInBackground makes cropping images of more than pixels run in the queue of enqueue instead of the request,
+// smaller images are still cropped inline.
+func CropInBackground(pixels int, enqueue CropEnqueuer) {
+	asyncCropPixels = pixels
+	cropEnqueuer = enqueue
+}
+
+func cropsInBackground(m *media_library.MediaLibrary) bool {
+	return cropEnqueuer != nil && m.File.Width*m.File.Height > asyncCropPixels
+}
+
+// GenerateCrop generates the derivatives of the media with the crop options saved by the cropper,
+// progress is called with the percent done, like the SetProgress of a worker job.
+func GenerateCrop(db *gorm.DB, mediaID uint, progress func(percent uint) error) (err error) {
+	if progress == nil {
+		progress = func(uint) error { return nil }
+	}
+	var m media_library.MediaLibrary
+	if err = db.First(&m, mediaID).Error; err != nil {
+		return
+	}
+	if err = progress(10); err != nil {
+		return
+	}
+
+	moption := m.GetMediaOption()
+	moption.Crop = true
+	if err = m.ScanMediaOptions(moption); err != nil {
+		return
+	}
+	if err = media.SaveUploadAndCropImage(db, &m); err != nil {
+		return
+	}
+	return progress(100)
+}
@@ -2,9 +2,12 @@ package views
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+	"image"
+	"strconv"
 
+	"github.com/disintegration/imaging"
 	"github.com/qor5/admin/media"
 	"github.com/qor5/admin/media/media_library"
 	"github.com/qor5/admin/presets"
@@ -25,7 +28,7 @@ func getParams(ctx *web.EventContext) (field string, id int, thumb string, cfg *
 	return
 }
 
-func loadImageCropper(db *gorm.DB) web.EventFunc {
+func loadImageCropper(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
 		field, id, thumb, cfg := getParams(ctx)
@@ -44,13 +47,14 @@ func loadImageCropper(db *gorm.DB) web.EventFunc {
 		}
 
 		c := cropper.Cropper().
-			Src(m.File.URL("original")+"?"+fmt.Sprint(time.Now().Nanosecond())).
+			Src(fmt.Sprintf("%s?v=%s", m.File.URL("original"), strconv.FormatInt(m.UpdatedAt.UnixNano(), 36))).
 			ViewMode(cropper.VIEW_MODE_FILL_FIT_CONTAINER).
 			AutoCropArea(1).
 			Attr("@input", web.Plaid().
 				FieldValue("CropOption", web.Var("JSON.stringify($event)")).
 				String())
-		if size != nil {
+		// a size with a 0 dimension keeps the proportions of the crop
+		if size != nil && size.Width > 0 && size.Height > 0 {
 			c.AspectRatio(float64(size.Width), float64(size.Height))
 		}
 		// Attr("style", "max-width: 800px; max-height: 600px;")
@@ -63,6 +67,8 @@ func loadImageCropper(db *gorm.DB) web.EventFunc {
 				Width:  float64(cropOption.Width),
 				Height: float64(cropOption.Height),
 			})
+		} else if v, ok := smartCropValue(&m, size); ok {
+			c.Value(v)
 		}
 
 		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
@@ -81,6 +87,7 @@ func loadImageCropper(db *gorm.DB) web.EventFunc {
 									Query("field", field).
 									Query("id", fmt.Sprint(id)).
 									Query("thumb", thumb).
+									Query(versionParam, fmt.Sprint(m.UpdatedAt.UnixNano())).
 									FieldValue("cfg", h.JSONString(cfg)).
 									Go()),
 						).Class("pl-2 pr-2"),
@@ -97,8 +104,37 @@ func loadImageCropper(db *gorm.DB) web.EventFunc {
 	}
 
 }
-func cropImage(db *gorm.DB) web.EventFunc {
+
+// smartCropValue returns the crop the image handlers make for a smart cropped size without a crop option,
+// so the cropper starts from it instead of the center
+func smartCropValue(m *media_library.MediaLibrary, size *media.Size) (v cropper.Value, ok bool) {
+	if size == nil || !size.SmartCrop || size.Padding || size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+	if format, err := media.GetImageFormat(m.File.URL()); err != nil || *format == imaging.GIF {
+		return
+	}
+	f, err := m.File.Retrieve(m.File.URL("original"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return
+	}
+	rect := media.SmartCropRect(img, size.Width, size.Height)
+	return cropper.Value{
+		X:      float64(rect.Min.X),
+		Y:      float64(rect.Min.Y),
+		Width:  float64(rect.Dx()),
+		Height: float64(rect.Dy()),
+	}, true
+}
+
+func cropImage(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
 		cropOption := ctx.R.FormValue("CropOption")
 		// log.Println(cropOption, ctx.Event.Params)
 		field, id, thumb, cfg := getParams(ctx)
@@ -131,20 +167,54 @@ func cropImage(db *gorm.DB) web.EventFunc {
 				Width:  int(cropValue.Width),
 				Height: int(cropValue.Height),
 			}
-			moption.Crop = true
+			// big images only save the crop options here, the derivatives are generated by the enqueued job
+			background := cropsInBackground(&m)
+			moption.Crop = !background
 			err = m.ScanMediaOptions(moption)
 			if err != nil {
 				return
 			}
 
-			err = media.SaveUploadAndCropImage(db, &m)
+			version, _ := strconv.ParseInt(ctx.R.FormValue(versionParam), 10, 64)
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := media_library.ClaimVersion(tx, m.ID, version); err != nil {
+					return err
+				}
+				if background {
+					return tx.Save(&m).Error
+				}
+				return media.SaveUploadAndCropImage(tx, &m)
+			})
+			if errors.Is(err, media_library.ErrConflict) {
+				// reload the cropper with the latest crop options
+				if r, err = loadImageCropper(b, db)(ctx); err != nil {
+					return
+				}
+				presets.ShowMessage(&r, msgr.MediaConflict, "warning")
+				return r, nil
+			}
 			if err != nil {
-				presets.ShowMessage(&r, err.Error(), "error")
+				presets.ShowMessage(&r, derivativeErrorMessage(ctx, err), "error")
+				return r, nil
+			}
+			if background {
+				progressURL, err := cropEnqueuer(ctx.R, m.ID)
+				if err != nil {
+					presets.ShowMessage(&r, err.Error(), "error")
+					return r, nil
+				}
+				r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+					Name: cropperPortalName(field),
+					Body: backgroundCropDialog(msgr, progressURL),
+				})
 				return r, nil
 			}
 
 			mb.Url = m.File.Url
 			mb.FileSizes = m.File.FileSizes
+			mb.SeparateDerivatives = m.File.SeparateDerivatives
+			mb.SizeURL = m.File.SizeURL
+			mb.Version = m.UpdatedAt.UnixNano()
 			if thumb == media.DefaultSizeKey {
 				mb.Width = int(cropValue.Width)
 				mb.Height = int(cropValue.Height)
@@ -153,8 +223,29 @@ func cropImage(db *gorm.DB) web.EventFunc {
 
 		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
 			Name: mediaBoxThumbnailsPortalName(field),
-			Body: mediaBoxThumbnails(ctx, mb, field, cfg, false),
+			Body: b.mediaBoxThumbnails(ctx, mb, field, cfg, false),
 		})
 		return
 	}
 }
+
+// backgroundCropDialog replaces the cropper while the derivatives are generated in the background
+func backgroundCropDialog(msgr *Messages, progressURL string) h.HTMLComponent {
+	return web.Scope(
+		VDialog(
+			VCard(
+				VCardTitle(h.Text(msgr.CroppingInBackground)),
+				VCardText(
+					VProgressLinear().Indeterminate(true).Attr("v-if", "locals.cropping"),
+					h.If(progressURL != "",
+						h.A().Text(msgr.ViewCropProgress).Href(progressURL).Target("_blank"),
+					),
+				),
+				VCardActions(
+					VSpacer(),
+					VBtn(msgr.Close).Text(true).Attr("@click", "locals.dialog = false"),
+				),
+			),
+		).Attr("v-model", "locals.dialog").MaxWidth("500px"),
+	).Init(`{cropping: true, dialog: true}`).VSlot("{ locals }")
+}
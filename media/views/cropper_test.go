@@ -0,0 +1,46 @@
+package views
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/media/oss"
+)
+
+func TestSmartCropValue(t *testing.T) {
+	useTestStorage(t)
+
+	// a flat 400x100 image with a detailed square on its right
+	img := imaging.New(400, 100, color.White)
+	for y := 20; y < 80; y++ {
+		for x := 320; x < 380; x++ {
+			if (x/4+y/4)%2 == 0 {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, imaging.PNG); err != nil {
+		t.Fatal(err)
+	}
+	m := &media_library.MediaLibrary{}
+	m.File.Url = "/system/media_libraries/1/file.png"
+	if _, err := oss.Storage.Put(m.File.URL("original"), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := smartCropValue(m, &media.Size{Width: 100, Height: 100}); ok {
+		t.Error("a size without SmartCrop has no smart crop")
+	}
+	v, ok := smartCropValue(m, &media.Size{Width: 100, Height: 100, SmartCrop: true})
+	if !ok {
+		t.Fatal("the cropper should start from the smart crop")
+	}
+	if v.Width != 100 || v.Height != 100 || v.X > 320 || v.X+v.Width < 380 {
+		t.Errorf("the smart crop %+v should keep the detailed square", v)
+	}
+}
@@ -0,0 +1,86 @@
+package views
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/qor5/admin/media/media_library"
+)
+
+// downloadOriginal is the size of the uncropped original upload of an image
+const downloadOriginal = "original"
+
+// Downloads returns the handler of the download links of the media boxes, mount it at the DownloadPath.
+// The file is sent as an attachment named after the uploaded file.
+func (b *Builder) Downloads() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		var m media_library.MediaLibrary
+		if err = b.db.Where("id = ?", id).First(&m).Error; err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if !viewIsAllowed(r, &m) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		size := r.FormValue("size")
+		u := m.File.URL()
+		if size != "" {
+			if _, ok := m.File.GetSizes()[size]; !ok && size != downloadOriginal {
+				http.NotFound(w, r)
+				return
+			}
+			u = m.File.URL(size)
+		}
+		f, err := m.File.Retrieve(u)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+
+		name := downloadFileName(m.File.FileName, size)
+		w.Header().Set("Content-Disposition", contentDisposition(name))
+		http.ServeContent(w, r, name, m.UpdatedAt, f)
+	})
+}
+
+// downloadURL returns the link downloading the size of the media, "" without a DownloadPath
+func (b *Builder) downloadURL(id string, size string) string {
+	if b.downloadPath == "" {
+		return ""
+	}
+	q := url.Values{"id": {id}}
+	if size != "" {
+		q.Set("size", size)
+	}
+	return b.downloadPath + "?" + q.Encode()
+}
+
+// downloadFileName names a size after the uploaded file, like hero-thumb.jpg
+func downloadFileName(fileName string, size string) string {
+	if size == "" || size == downloadOriginal {
+		return fileName
+	}
+	ext := path.Ext(fileName)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(fileName, ext), size, ext)
+}
+
+// contentDisposition quotes the name and falls back to the RFC 2231 encoding for non ASCII names
+func contentDisposition(name string) string {
+	if v := mime.FormatMediaType("attachment", map[string]string{"filename": name}); v != "" {
+		return v
+	}
+	return "attachment"
+}
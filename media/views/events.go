@@ -1,6 +1,7 @@
 package views
 
 import (
+	"github.com/qor5/admin/utils"
 	"github.com/qor5/web"
 	"gorm.io/gorm"
 )
@@ -12,23 +13,46 @@ const (
 	loadImageCropperEvent   = "mediaLibrary_LoadImageCropperEvent"
 	imageSearchEvent        = "mediaLibrary_ImageSearchEvent"
 	imageJumpPageEvent      = "mediaLibrary_ImageJumpPageEvent"
+	loadMoreFilesEvent      = "mediaLibrary_LoadMoreFilesEvent"
 	uploadFileEvent         = "mediaLibrary_UploadFileEvent"
 	chooseFileEvent         = "mediaLibrary_ChooseFileEvent"
 	updateDescriptionEvent  = "mediaLibrary_UpdateDescriptionEvent"
 	deleteConfirmationEvent = "mediaLibrary_DeleteConfirmationEvent"
 	doDeleteEvent           = "mediaLibrary_DoDelete"
+	chooseVideoLinkEvent    = "mediaLibrary_ChooseVideoLinkEvent"
+	convertFormatEvent      = "mediaLibrary_ConvertFormatEvent"
+	restoreFileEvent        = "mediaLibrary_RestoreFileEvent"
+	purgeFileEvent          = "mediaLibrary_PurgeFileEvent"
+	bulkTagEvent            = "mediaLibrary_BulkTagEvent"
+	uploadAndChooseEvent    = "mediaLibrary_UploadAndChooseEvent"
 )
 
-func registerEventFuncs(hub web.EventFuncHub, db *gorm.DB) {
-	hub.RegisterEventFunc(openFileChooserEvent, fileChooser(db))
-	hub.RegisterEventFunc(deleteFileEvent, deleteFileField())
-	hub.RegisterEventFunc(cropImageEvent, cropImage(db))
-	hub.RegisterEventFunc(loadImageCropperEvent, loadImageCropper(db))
-	hub.RegisterEventFunc(imageSearchEvent, searchFile(db))
-	hub.RegisterEventFunc(imageJumpPageEvent, jumpPage(db))
-	hub.RegisterEventFunc(uploadFileEvent, uploadFile(db))
-	hub.RegisterEventFunc(chooseFileEvent, chooseFile(db))
-	hub.RegisterEventFunc(updateDescriptionEvent, updateDescription(db))
-	hub.RegisterEventFunc(deleteConfirmationEvent, deleteConfirmation(db))
-	hub.RegisterEventFunc(doDeleteEvent, doDelete(db))
+// withRequestDB runs the event func with db bound to the request
+func withRequestDB(b *Builder, f func(b *Builder, db *gorm.DB) web.EventFunc) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		rdb, cancel := utils.RequestDB(b.db, ctx.R, b.dbTimeout)
+		defer cancel()
+		return f(b, rdb)(ctx)
+	}
+}
+
+func registerEventFuncs(hub web.EventFuncHub, b *Builder) {
+	hub.RegisterEventFunc(openFileChooserEvent, withRequestDB(b, fileChooser))
+	hub.RegisterEventFunc(deleteFileEvent, deleteFileField(b))
+	hub.RegisterEventFunc(cropImageEvent, withRequestDB(b, cropImage))
+	hub.RegisterEventFunc(loadImageCropperEvent, withRequestDB(b, loadImageCropper))
+	hub.RegisterEventFunc(imageSearchEvent, withRequestDB(b, searchFile))
+	hub.RegisterEventFunc(imageJumpPageEvent, withRequestDB(b, jumpPage))
+	hub.RegisterEventFunc(loadMoreFilesEvent, withRequestDB(b, loadMoreFiles))
+	hub.RegisterEventFunc(uploadFileEvent, withRequestDB(b, uploadFile))
+	hub.RegisterEventFunc(chooseFileEvent, withRequestDB(b, chooseFile))
+	hub.RegisterEventFunc(updateDescriptionEvent, withRequestDB(b, updateDescription))
+	hub.RegisterEventFunc(deleteConfirmationEvent, withRequestDB(b, deleteConfirmation))
+	hub.RegisterEventFunc(doDeleteEvent, withRequestDB(b, doDelete))
+	hub.RegisterEventFunc(chooseVideoLinkEvent, chooseVideoLink(b))
+	hub.RegisterEventFunc(convertFormatEvent, withRequestDB(b, convertFormat))
+	hub.RegisterEventFunc(restoreFileEvent, withRequestDB(b, restoreFile))
+	hub.RegisterEventFunc(purgeFileEvent, withRequestDB(b, purgeFile))
+	hub.RegisterEventFunc(bulkTagEvent, withRequestDB(b, bulkTag))
+	hub.RegisterEventFunc(uploadAndChooseEvent, withRequestDB(b, uploadAndChoose))
 }
@@ -2,14 +2,19 @@ package views
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/qor5/admin/presets"
+	"io"
 	"mime/multipart"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/qor5/admin/media"
 	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/metrics"
+	"github.com/qor5/admin/utils"
 	. "github.com/qor5/ui/vuetify"
 	"github.com/qor5/web"
 	"github.com/qor5/x/i18n"
@@ -17,11 +22,14 @@ import (
 	"gorm.io/gorm"
 )
 
-func fileChooser(db *gorm.DB) web.EventFunc {
+func fileChooser(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
 		field := ctx.R.FormValue("field")
 		cfg := stringToCfg(ctx.R.FormValue("cfg"))
+		if err = listIsAllowed(ctx.R); err != nil {
+			return
+		}
 
 		portalName := mainPortalName(field)
 		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
@@ -59,9 +67,14 @@ func fileChooser(db *gorm.DB) web.EventFunc {
 						Flat(true).
 						Dark(true),
 					web.Portal().Name(deleteConfirmPortalName(field)),
-					web.Portal(
-						fileChooserDialogContent(db, field, ctx, cfg),
-					).Name(dialogContentPortalName(field)),
+					h.Input("").Type("hidden").
+						Value(ctx.R.FormValue("replace")).
+						Attr(web.VFieldName(replaceFromName(field))...),
+					fileChooserTabs(ctx, field, cfg,
+						web.Portal(
+							fileChooserDialogContent(db, field, ctx, cfg),
+						).Name(dialogContentPortalName(field)),
+					),
 				).Tile(true),
 			).
 				Fullscreen(true).
@@ -75,31 +88,65 @@ func fileChooser(db *gorm.DB) web.EventFunc {
 	}
 }
 
-func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext, cfg *media_library.MediaBoxConfig) h.HTMLComponent {
+// fileChooserTabs adds the external video tab next to the media library for the media boxes that accept videos
+func fileChooserTabs(ctx *web.EventContext, field string, cfg *media_library.MediaBoxConfig, library h.HTMLComponent) h.HTMLComponent {
+	if len(cfg.Sizes) > 0 || (cfg.AllowType != "" && cfg.AllowType != media_library.ALLOW_TYPE_VIDEO) {
+		return library
+	}
 	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
 
-	keyword := ctx.R.FormValue(searchKeywordName(field))
+	return VTabs(
+		VTab(h.Text(msgr.MediaLibrary)),
+		VTabItem(library),
+		VTab(h.Text(msgr.ExternalVideo)),
+		VTabItem(
+			VContainer(
+				VRow(
+					VCol(
+						VTextField().
+							Label(msgr.VideoLink).
+							Placeholder("https://www.youtube.com/watch?v=...").
+							Outlined(true).
+							Dense(true).
+							Attr(web.VFieldName(videoLinkName(field))...),
+					).Cols(9),
+					VCol(
+						VBtn(msgr.UseVideoLink).
+							Color("primary").
+							Depressed(true).
+							Attr("@click", web.Plaid().EventFunc(chooseVideoLinkEvent).
+								Query("field", field).
+								FieldValue("cfg", h.JSONString(cfg)).
+								Go()),
+					).Cols(3),
+				),
+			).Fluid(true),
+		),
+	)
+}
 
-	type selectItem struct {
-		Text  string
-		Value string
-	}
-	const (
-		orderByKey           = "order_by"
-		orderByCreatedAt     = "created_at"
-		orderByCreatedAtDESC = "created_at_desc"
+const (
+	orderByKey           = "order_by"
+	orderByCreatedAt     = "created_at"
+	orderByCreatedAtDESC = "created_at_desc"
 
-		typeKey   = "type"
-		typeAll   = "all"
-		typeImage = "image"
-		typeVideo = "video"
-		typeFile  = "file"
-	)
-	orderByVal := ctx.R.URL.Query().Get(orderByKey)
-	typeVal := ctx.R.URL.Query().Get(typeKey)
+	typeKey   = "type"
+	typeAll   = "all"
+	typeImage = "image"
+	typeVideo = "video"
+	typeFile  = "file"
+)
 
-	var files []*media_library.MediaLibrary
-	wh := db.Model(&media_library.MediaLibrary{})
+// fileChooserQuery filters and orders the files of the chooser by its search, tag, type and order inputs
+func fileChooserQuery(db *gorm.DB, ctx *web.EventContext, field string, cfg *media_library.MediaBoxConfig) (wh *gorm.DB, orderByVal, typeVal, keyword, tag string) {
+	keyword = ctx.R.FormValue(searchKeywordName(field))
+	orderByVal = ctx.R.URL.Query().Get(orderByKey)
+	typeVal = ctx.R.URL.Query().Get(typeKey)
+
+	wh = db.Model(&media_library.MediaLibrary{})
+	if len(keyword) > 0 {
+		wh = SearchFunc(wh, keyword)
+	}
 
 	switch orderByVal {
 	case orderByCreatedAt:
@@ -120,11 +167,6 @@ func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext,
 		typeVal = typeAll
 	}
 
-	currentPageInt, _ := strconv.Atoi(ctx.R.FormValue(currentPageName(field)))
-	if currentPageInt == 0 {
-		currentPageInt = 1
-	}
-
 	if len(cfg.Sizes) > 0 {
 		cfg.AllowType = media_library.ALLOW_TYPE_IMAGE
 	}
@@ -133,26 +175,54 @@ func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext,
 		wh = wh.Where("selected_type = ?", cfg.AllowType)
 	}
 
-	if len(keyword) > 0 {
-		wh = wh.Where("file ILIKE ?", fmt.Sprintf("%%%s%%", keyword))
+	tag = media_library.NormalizeTag(ctx.R.FormValue(tagFilterName(field)))
+	if len(tag) > 0 {
+		wh = wh.Where("id IN (SELECT media_library_id FROM media_library_tags WHERE name = ?)", tag)
 	}
+	return
+}
+
+func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext, cfg *media_library.MediaBoxConfig) h.HTMLComponent {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
 
-	var count int64
-	err := wh.Count(&count).Error
+	type selectItem struct {
+		Text  string
+		Value string
+	}
+	wh, orderByVal, typeVal, keyword, tag := fileChooserQuery(db, ctx, field, cfg)
+
+	perPage := chooserPerPage(ctx, field, cfg)
+	// the pages are loaded after or before the cursors of the previous ones, so paging deep into a large library stays fast,
+	// the infinite scroll starts from the first page and appends the next ones with loadMoreFiles
+	cursor, before := ctx.R.FormValue(cursorName(field)), ctx.R.FormValue(beforeName(field))
+	if cfg.InfiniteScroll {
+		cursor, before = "", ""
+	}
+	var files []*media_library.MediaLibrary
+	cp, err := utils.CursorPaginate(wh, cursor, before, perPage, orderByVal != orderByCreatedAt, &files)
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		cp, err = utils.CursorPaginate(wh, "", "", perPage, orderByVal != orderByCreatedAt, &files)
+	}
 	if err != nil {
 		panic(err)
 	}
-	perPage := MediaLibraryPerPage
-	pagesCount := int(count/int64(perPage) + 1)
-	if count%int64(perPage) == 0 {
-		pagesCount--
-	}
+	firstPage := cp.PrevCursor == ""
 
-	wh = wh.Limit(perPage).Offset((currentPageInt - 1) * perPage)
-	err = wh.Find(&files).Error
+	tagNames, err := allTagNames(db)
 	if err != nil {
 		panic(err)
 	}
+	tagsByID, err := filesTags(db, files)
+	if err != nil {
+		panic(err)
+	}
+
+	var recents []*media_library.MediaLibrary
+	if field != mediaLibraryListField && firstPage && len(keyword) == 0 && len(tag) == 0 {
+		if recents, err = recentlyUsedFiles(db, ctx.R, cfg); err != nil {
+			panic(err)
+		}
+	}
 
 	fileAccept := "*/*"
 	if cfg.AllowType == media_library.ALLOW_TYPE_IMAGE {
@@ -203,18 +273,141 @@ func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext,
 	)
 
 	var initCroppingVars = []string{fileCroppingVarName(0) + ": false"}
+	cards, croppingVars := fileChooserCards(ctx, msgr, field, cfg, files, tagsByID)
+	row.AppendChildren(cards...)
+	initCroppingVars = append(initCroppingVars, croppingVars...)
 
+	return h.Div(
+		copiedSnackbar(msgr),
+		VSnackbar(h.Text(msgr.DescriptionUpdated)).
+			Attr("v-model", "vars.snackbarShow").
+			Top(true).
+			Color("primary").
+			Timeout(5000),
+		web.Scope(
+			VContainer(
+				h.If(field != mediaLibraryListField && len(tagNames) > 0,
+					VRow(
+						VCol(
+							tagFilter(ctx, field, cfg, tagNames, tag),
+						).Cols(3),
+					).Justify("end"),
+				),
+				h.If(field == mediaLibraryListField,
+					VRow(
+						VCol(
+							tagFilter(ctx, field, cfg, tagNames, tag),
+						).Cols(3),
+						VCol(
+							VSelect().Items([]selectItem{
+								{Text: msgr.All, Value: typeAll},
+								{Text: msgr.Images, Value: typeImage},
+								{Text: msgr.Videos, Value: typeVideo},
+								{Text: msgr.Files, Value: typeFile},
+							}).ItemText("Text").ItemValue("Value").
+								FieldName(typeKey).Value(typeVal).
+								Attr("@change",
+									web.GET().PushState(true).
+										Query(typeKey, web.Var("$event")).
+										MergeQuery(true).Go(),
+								).
+								Dense(true).Solo(true).Class("mb-n8"),
+						).Cols(3),
+						VCol(
+							VSelect().Items([]selectItem{
+								{Text: msgr.UploadedAtDESC, Value: orderByCreatedAtDESC},
+								{Text: msgr.UploadedAt, Value: orderByCreatedAt},
+							}).ItemText("Text").ItemValue("Value").
+								FieldName(orderByKey).Value(orderByVal).
+								Attr("@change",
+									web.GET().PushState(true).
+										Query(orderByKey, web.Var("$event")).
+										MergeQuery(true).Go(),
+								).
+								Dense(true).Solo(true).Class("mb-n8"),
+						).Cols(3),
+					).Justify("end"),
+					bulkTagBar(ctx, cfg, tagNames),
+				),
+				h.If(len(recents) > 0, recentlyUsedRow(msgr, ctx.R, recents, field, cfg)),
+				row,
+				h.If(cfg.InfiniteScroll && cp.NextCursor != "",
+					moreFiles(field, cfg, perPage, cp.NextCursor, 1),
+				),
+				h.If(!cfg.InfiniteScroll, VRow(
+					VCol().Cols(1),
+					VCol(
+						VBtn("").Icon(true).Disabled(cp.PrevCursor == "").
+							Children(VIcon("chevron_left")).
+							Attr("@click", web.Plaid().
+								FieldValue(beforeName(field), cp.PrevCursor).
+								FieldValue(perPageName(field), perPage).
+								EventFunc(imageJumpPageEvent).
+								Query("field", field).
+								FieldValue("cfg", h.JSONString(cfg)).
+								Go()),
+						VBtn("").Icon(true).Disabled(cp.NextCursor == "").
+							Children(VIcon("chevron_right")).
+							Attr("@click", web.Plaid().
+								FieldValue(cursorName(field), cp.NextCursor).
+								FieldValue(perPageName(field), perPage).
+								EventFunc(imageJumpPageEvent).
+								Query("field", field).
+								FieldValue("cfg", h.JSONString(cfg)).
+								Go()),
+					).Class("d-flex justify-center").Cols(9),
+					VCol(
+						VSelect().Items(perPageOptions(perPage)).
+							Label(msgr.PerPage).
+							Value(perPage).
+							Attr("@change", web.Plaid().
+								FieldValue(perPageName(field), web.Var("$event")).
+								FieldValue(cursorName(field), "").
+								FieldValue(beforeName(field), "").
+								EventFunc(imageJumpPageEvent).
+								Query("field", field).
+								FieldValue("cfg", h.JSONString(cfg)).
+								Go()).
+							Dense(true).HideDetails(true),
+					).Cols(2),
+				)),
+				VCol().Cols(1),
+			).Fluid(true),
+		).Init(fmt.Sprintf(`{fileChooserUploadingFiles: [], selectedMediaIDs: [], bulkTag: "", %s}`, strings.Join(initCroppingVars, ", "))).VSlot("{ locals }"),
+		VOverlay(
+			h.Img("").Attr(":src", "vars.isImage? vars.mediaShow: ''").
+				Style("max-height: 80vh; max-width: 80vw; background: rgba(0, 0, 0, 0.5)"),
+			h.Div(
+				h.A(
+					VIcon("info").Small(true).Class("mb-1"),
+					h.Text("{{vars.mediaName}}"),
+				).Attr(":href", "vars.mediaShow? vars.mediaShow: ''").Target("_blank").
+					Class("white--text").Style("text-decoration: none;"),
+			).Class("d-flex align-center justify-center pt-2"),
+		).Attr("v-if", "vars.mediaName").Attr("@click", "vars.mediaName = null").ZIndex(10),
+	).Attr(web.InitContextVars, `{snackbarShow: false, mediaShow: null, mediaName: null, isImage: false}`)
+}
+
+// fileChooserCards renders a card for each of the files, croppingVars are the locals of their cropping spinners
+func fileChooserCards(ctx *web.EventContext, msgr *Messages, field string, cfg *media_library.MediaBoxConfig,
+	files []*media_library.MediaLibrary, tagsByID map[uint][]string) (cards []h.HTMLComponent, croppingVars []string) {
 	for i, f := range files {
 		_, needCrop := mergeNewSizes(f, cfg)
 		croppingVar := fileCroppingVarName(f.ID)
-		initCroppingVars = append(initCroppingVars, fmt.Sprintf("%s: false", croppingVar))
+		croppingVars = append(croppingVars, fmt.Sprintf("%s: false", croppingVar))
+		canView := viewIsAllowed(ctx.R, f)
 		imgClickVars := fmt.Sprintf("vars.mediaShow = '%s'; vars.mediaName = '%s'; vars.isImage = %s", f.File.URL(), f.File.FileName, strconv.FormatBool(media.IsImageFormat(f.File.FileName)))
+		if !canView {
+			imgClickVars = ""
+		}
 
-		row.AppendChildren(
+		cards = append(cards,
 			VCol(
 				VCard(
 					h.Div(
-						h.If(
+						h.If(!canView,
+							lockedThumb(),
+						).ElseIf(
 							media.IsImageFormat(f.File.FileName),
 							VImg(
 								h.If(needCrop,
@@ -225,7 +418,8 @@ func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext,
 										Style("height: 100%; background: rgba(0, 0, 0, 0.5)").
 										Attr("v-if", fmt.Sprintf("locals.%s", croppingVar)),
 								),
-							).Src(f.File.URL(media_library.QorPreviewSizeName)).Height(200).Contain(true),
+							).Src(f.File.URL(media_library.QorPreviewSizeName)).LazySrc(ThumbnailPlaceholder(f)).
+								Options(lazyThumbOptions).Height(200).Contain(true),
 						).Else(
 							fileThumb(f.File.FileName),
 						),
@@ -239,6 +433,12 @@ func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext,
 							Go(), field != mediaLibraryListField).
 						AttrIf("@click", imgClickVars, field == mediaLibraryListField),
 					VCardText(
+						h.If(field == mediaLibraryListField && updateDescIsAllowed(ctx.R, files[i]) == nil,
+							h.Input("").Type("checkbox").
+								Value(fmt.Sprint(f.ID)).
+								Attr("v-model", "locals.selectedMediaIDs").
+								Class("mr-1"),
+						),
 						h.A().Text(f.File.FileName).
 							Attr("@click", imgClickVars),
 						h.Input("").
@@ -249,6 +449,7 @@ func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext,
 								EventFunc(updateDescriptionEvent).
 								Query("field", field).
 								Query("id", fmt.Sprint(f.ID)).
+								Query(versionParam, fmt.Sprint(f.UpdatedAt.UnixNano())).
 								FieldValue("cfg", h.JSONString(cfg)).
 								FieldValue("CurrentDescription", web.Var("$event.target.value")).
 								Go(),
@@ -256,10 +457,17 @@ func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext,
 						h.If(media.IsImageFormat(f.File.FileName),
 							fileChips(f),
 						),
+						tagChips(tagsByID[f.ID]),
 					),
-					h.If(deleteIsAllowed(ctx.R, files[i]) == nil,
-						VCardActions(
-							VSpacer(),
+					VCardActions(
+						h.If(canView,
+							copyURLMenu(msgr, f.File.FileName, f.File.Description, mediaLibraryURLs(f)),
+						),
+						h.If(field == mediaLibraryListField && media.IsImageFormat(f.File.FileName) && convertIsAllowed(ctx.R, files[i]) == nil,
+							convertFormatMenu(msgr, f, cfg),
+						),
+						VSpacer(),
+						h.If(deleteIsAllowed(ctx.R, files[i]) == nil,
 							VBtn(msgr.Delete).
 								Text(true).
 								Attr("@click",
@@ -276,77 +484,122 @@ func fileChooserDialogContent(db *gorm.DB, field string, ctx *web.EventContext,
 			).Cols(6).Sm(4).Md(3),
 		)
 	}
+	return
+}
 
-	return h.Div(
-		VSnackbar(h.Text(msgr.DescriptionUpdated)).
-			Attr("v-model", "vars.snackbarShow").
-			Top(true).
-			Color("primary").
-			Timeout(5000),
-		web.Scope(
-			VContainer(
-				h.If(field == mediaLibraryListField,
-					VRow(
-						VCol(
-							VSelect().Items([]selectItem{
-								{Text: msgr.All, Value: typeAll},
-								{Text: msgr.Images, Value: typeImage},
-								{Text: msgr.Videos, Value: typeVideo},
-								{Text: msgr.Files, Value: typeFile},
-							}).ItemText("Text").ItemValue("Value").
-								FieldName(typeKey).Value(typeVal).
-								Attr("@change",
-									web.GET().PushState(true).
-										Query(typeKey, web.Var("$event")).
-										MergeQuery(true).Go(),
-								).
-								Dense(true).Solo(true).Class("mb-n8"),
-						).Cols(3),
-						VCol(
-							VSelect().Items([]selectItem{
-								{Text: msgr.UploadedAtDESC, Value: orderByCreatedAtDESC},
-								{Text: msgr.UploadedAt, Value: orderByCreatedAt},
-							}).ItemText("Text").ItemValue("Value").
-								FieldName(orderByKey).Value(orderByVal).
-								Attr("@change",
-									web.GET().PushState(true).
-										Query(orderByKey, web.Var("$event")).
-										MergeQuery(true).Go(),
-								).
-								Dense(true).Solo(true).Class("mb-n8"),
-						).Cols(3),
-					).Justify("end"),
-				),
-				row,
-				VRow(
-					VCol().Cols(1),
-					VCol(
-						VPagination().
-							Length(pagesCount).
-							Value(int(currentPageInt)).
-							Attr("@input", web.Plaid().
-								FieldValue(currentPageName(field), web.Var("$event")).
-								EventFunc(imageJumpPageEvent).
-								Query("field", field).
-								FieldValue("cfg", h.JSONString(cfg)).
-								Go()),
-					).Cols(10),
+// moreFiles is replaced by the page after cursor once it's scrolled into view, n numbers the pages appended
+func moreFiles(field string, cfg *media_library.MediaBoxConfig, perPage int, cursor string, n int) h.HTMLComponent {
+	return web.Portal(
+		h.Div(
+			VProgressCircular().Indeterminate(true).Color("primary"),
+		).Class("d-flex justify-center pa-4").
+			Attr("v-intersect", fmt.Sprintf(`function(entries, observer, isIntersecting) { if (!isIntersecting || vars.fileChooserLoadingMore) { return }; vars.fileChooserLoadingMore = true; %s }`,
+				web.Plaid().
+					FieldValue(perPageName(field), perPage).
+					EventFunc(loadMoreFilesEvent).
+					Query("field", field).
+					Query(cursorName(field), cursor).
+					Query(moreFilesParam, strconv.Itoa(n)).
+					FieldValue("cfg", h.JSONString(cfg)).
+					ThenScript("vars.fileChooserLoadingMore = false").
+					Go())),
+	).Name(moreFilesPortalName(field, n))
+}
+
+// moreFilesParam is the number of the page appended by loadMoreFiles
+const moreFilesParam = "more"
+
+// loadMoreFiles appends the page after the cursor to the infinite scroll of the file chooser, the pages before
+// aren't rendered again. The page has its own scope for the locals of its cards.
+func loadMoreFiles(b *Builder, db *gorm.DB) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		field := ctx.R.FormValue("field")
+		cfg := stringToCfg(ctx.R.FormValue("cfg"))
+		if err = listIsAllowed(ctx.R); err != nil {
+			return
+		}
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+		n, _ := strconv.Atoi(ctx.R.URL.Query().Get(moreFilesParam))
+
+		wh, orderByVal, _, _, _ := fileChooserQuery(db, ctx, field, cfg)
+		perPage := chooserPerPage(ctx, field, cfg)
+		var files []*media_library.MediaLibrary
+		cp, err := utils.CursorPaginate(wh, ctx.R.URL.Query().Get(cursorName(field)), "", perPage, orderByVal != orderByCreatedAt, &files)
+		if err != nil {
+			return
+		}
+		tagsByID, err := filesTags(db, files)
+		if err != nil {
+			return
+		}
+		cards, croppingVars := fileChooserCards(ctx, msgr, field, cfg, files, tagsByID)
+		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+			Name: moreFilesPortalName(field, n),
+			Body: web.Scope(
+				VRow(cards...),
+				h.If(cp.NextCursor != "",
+					moreFiles(field, cfg, perPage, cp.NextCursor, n+1),
 				),
-				VCol().Cols(1),
-			).Fluid(true),
-		).Init(fmt.Sprintf(`{fileChooserUploadingFiles: [], %s}`, strings.Join(initCroppingVars, ", "))).VSlot("{ locals }"),
-		VOverlay(
-			h.Img("").Attr(":src", "vars.isImage? vars.mediaShow: ''").
-				Style("max-height: 80vh; max-width: 80vw; background: rgba(0, 0, 0, 0.5)"),
-			h.Div(
-				h.A(
-					VIcon("info").Small(true).Class("mb-1"),
-					h.Text("{{vars.mediaName}}"),
-				).Attr(":href", "vars.mediaShow? vars.mediaShow: ''").Target("_blank").
-					Class("white--text").Style("text-decoration: none;"),
-			).Class("d-flex align-center justify-center pt-2"),
-		).Attr("v-if", "vars.mediaName").Attr("@click", "vars.mediaName = null").ZIndex(10),
-	).Attr(web.InitContextVars, `{snackbarShow: false, mediaShow: null, mediaName: null, isImage: false}`)
+			).Init(fmt.Sprintf(`{%s}`, strings.Join(croppingVars, ", "))).VSlot("{ locals }"),
+		})
+		return
+	}
+}
+
+// chooserPerPage resolves the page size from the user selection, then the config, then the global default
+func chooserPerPage(ctx *web.EventContext, field string, cfg *media_library.MediaBoxConfig) int {
+	perPage := MediaLibraryPerPage
+	if cfg.PerPage > 0 {
+		perPage = cfg.PerPage
+	}
+	if v, err := strconv.Atoi(ctx.R.FormValue(perPageName(field))); err == nil && v > 0 {
+		perPage = v
+	}
+	if perPage > MediaLibraryMaxPerPage {
+		perPage = MediaLibraryMaxPerPage
+	}
+	return perPage
+}
+
+func perPageOptions(current int) []int {
+	options := []int{current}
+	for _, v := range MediaLibraryPerPageOptions {
+		if v != current && v <= MediaLibraryMaxPerPage {
+			options = append(options, v)
+		}
+	}
+	sort.Ints(options)
+	return options
+}
+
+func convertFormatMenu(msgr *Messages, f *media_library.MediaLibrary, cfg *media_library.MediaBoxConfig) h.HTMLComponent {
+	current, _ := media.GetImageFormat(f.File.FileName)
+	var items []h.HTMLComponent
+	for _, format := range media.ConvertFormats {
+		if to, err := media.GetImageFormat("." + format); err != nil || (current != nil && *to == *current) {
+			continue
+		}
+		items = append(items,
+			VListItem(
+				VListItemTitle(h.Text(strings.ToUpper(format))),
+			).Attr("@click", web.Plaid().
+				EventFunc(convertFormatEvent).
+				Query("field", mediaLibraryListField).
+				Query("id", fmt.Sprint(f.ID)).
+				Query("format", format).
+				FieldValue("cfg", h.JSONString(cfg)).
+				Go()),
+		)
+	}
+
+	return VMenu(
+		web.Slot(
+			VBtn(msgr.ConvertTo).Text(true).Small(true).
+				Attr("v-bind", "attrs").
+				Attr("v-on", "on"),
+		).Name("activator").Scope("{ on, attrs }"),
+		VList(items...).Dense(true),
+	).OffsetY(true)
 }
 
 func fileChips(f *media_library.MediaLibrary) h.HTMLComponent {
@@ -378,7 +631,7 @@ type uploadFiles struct {
 	NewFiles []*multipart.FileHeader
 }
 
-func uploadFile(db *gorm.DB) web.EventFunc {
+func uploadFile(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		field := ctx.R.FormValue("field")
 		cfg := stringToCfg(ctx.R.FormValue("cfg"))
@@ -389,31 +642,165 @@ func uploadFile(db *gorm.DB) web.EventFunc {
 
 		var uf uploadFiles
 		ctx.MustUnmarshalForm(&uf)
+		// the rejected files don't stop the others from being uploaded, they are all reported at the end
+		var rejected []string
 		for _, fh := range uf.NewFiles {
-			m := media_library.MediaLibrary{}
-
-			if media.IsImageFormat(fh.Filename) {
-				m.SelectedType = media_library.ALLOW_TYPE_IMAGE
-			} else if media.IsVideoFormat(fh.Filename) {
-				m.SelectedType = media_library.ALLOW_TYPE_VIDEO
-			} else {
-				m.SelectedType = media_library.ALLOW_TYPE_FILE
+			var msg string
+			if _, msg, err = saveUpload(b, db, ctx, fh, cfg); err != nil {
+				return
 			}
-			err = m.File.Scan(fh)
-			if err != nil {
-				panic(err)
-			}
-
-			err = media.SaveUploadAndCropImage(db, &m)
-			if err != nil {
-				presets.ShowMessage(&r, err.Error(), "error")
-				return r, nil
+			if msg != "" {
+				rejected = append(rejected, msg)
 			}
 		}
 
 		renderFileChooserDialogContent(ctx, &r, field, db, cfg)
+		presets.ShowMessage(&r, strings.Join(rejected, "; "), "error")
+		return
+	}
+}
+
+// uploadAndChoose uploads the file dropped on a media box and chooses it, without opening the file chooser
+func uploadAndChoose(b *Builder, db *gorm.DB) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		field := ctx.R.FormValue("field")
+		cfg := stringToCfg(ctx.R.FormValue("cfg"))
+
+		if err = uploadIsAllowed(ctx.R); err != nil {
+			return
+		}
+
+		var uf uploadFiles
+		ctx.MustUnmarshalForm(&uf)
+		if len(uf.NewFiles) == 0 {
+			return
+		}
+		if len(uf.NewFiles) > 1 {
+			msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+			presets.ShowMessage(&r, msgr.UploadOneFile, "error")
+			return
+		}
+		m, msg, err := saveUpload(b, db, ctx, uf.NewFiles[0], cfg)
+		if err != nil {
+			return
+		}
+		if msg != "" {
+			presets.ShowMessage(&r, msg, "error")
+			return
+		}
+		err = chooseMedia(b, db, ctx, &r, field, cfg, m)
+		return
+	}
+}
+
+// saveUpload saves an uploaded file into the media library, msg tells the user why it's rejected.
+// The file is rejected before being saved when it's larger than the MaxUploadSize or the media box of cfg can't choose it.
+func saveUpload(b *Builder, db *gorm.DB, ctx *web.EventContext, fh *multipart.FileHeader, cfg *media_library.MediaBoxConfig) (m *media_library.MediaLibrary, msg string, err error) {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+	if b.maxUploadSize > 0 && fh.Size > b.maxUploadSize {
+		return nil, msgr.FileTooLarge(fh.Filename, media.ByteCountSI(int(b.maxUploadSize))), nil
+	}
+	m = &media_library.MediaLibrary{}
+
+	var mismatch bool
+	if m.SelectedType, mismatch, err = detectSelectedType(fh); err != nil {
+		return
+	}
+	if mismatch {
+		return nil, msgr.ContentMismatch(fh.Filename), nil
+	}
+	if !typeAllowed(cfg, m.SelectedType) {
+		return nil, msgr.TypeNotAllowed(fh.Filename), nil
+	}
+	err = m.File.Scan(fh)
+	if err != nil {
+		panic(err)
+	}
+	m.File.KeyScheme = b.keyScheme
+	if m.SelectedType == media_library.ALLOW_TYPE_IMAGE && cfg.ShouldStripMetadata() {
+		if err = stripUploadMetadata(m, fh, cfg); err != nil {
+			return nil, err.Error(), nil
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := media.SaveUploadAndCropImage(tx, m); err != nil {
+			return err
+		}
+		return runHooks(b.uploadHooks, m)
+	})
+	if err != nil {
+		// the files stored before the transaction failed, like when the request is cancelled, belong to no record
+		removeStoredFiles(m)
+		metrics.Inc("qor5_media_uploads_total", metrics.Labels{"result": "error"})
+		return nil, derivativeErrorMessage(ctx, err), nil
+	}
+	metrics.Inc("qor5_media_uploads_total", metrics.Labels{"result": "success"})
+	b.fireWebhooks(WebhookEventUploaded, m)
+	metrics.Add("qor5_media_upload_bytes_total", nil, float64(fh.Size))
+	return
+}
+
+// detectSelectedType gets the type of an uploaded file from its content, mismatch is true
+// when the extension claims an image or a video that the content isn't.
+// Videos of containers that can't be sniffed are trusted by their extension.
+func detectSelectedType(fh *multipart.FileHeader) (selectedType string, mismatch bool, err error) {
+	f, err := fh.Open()
+	if err != nil {
 		return
 	}
+	defer f.Close()
+	contentType, err := media.DetectContentType(f)
+	if err != nil {
+		return
+	}
+
+	selectedType = media_library.ALLOW_TYPE_FILE
+	switch {
+	case strings.HasPrefix(contentType, "image/") && contentType != "image/svg+xml":
+		selectedType = media_library.ALLOW_TYPE_IMAGE
+	case strings.HasPrefix(contentType, "video/") || contentType == "application/ogg":
+		selectedType = media_library.ALLOW_TYPE_VIDEO
+	case contentType == "application/octet-stream" && media.IsVideoFormat(fh.Filename):
+		selectedType = media_library.ALLOW_TYPE_VIDEO
+	}
+
+	switch {
+	case media.IsImageFormat(fh.Filename):
+		mismatch = selectedType != media_library.ALLOW_TYPE_IMAGE
+	case media.IsVideoFormat(fh.Filename):
+		mismatch = selectedType != media_library.ALLOW_TYPE_VIDEO
+	case selectedType == media_library.ALLOW_TYPE_IMAGE:
+		// the image is stored as a file, as it can't be cropped without a known extension
+		selectedType = media_library.ALLOW_TYPE_FILE
+	}
+	return
+}
+
+// stripUploadMetadata replaces the uploaded original with the one without metadata, the derivatives are made from it
+func stripUploadMetadata(m *media_library.MediaLibrary, fh *multipart.FileHeader, cfg *media_library.MediaBoxConfig) error {
+	format, err := media.GetImageFormat(fh.Filename)
+	if err != nil {
+		return nil
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	data, err = media.StripMetadata(data, *format, media.StripMetadataOptions{
+		KeepCopyright:  cfg.KeepCopyright,
+		KeepICCProfile: cfg.KeepICCProfile,
+	})
+	if err != nil {
+		return err
+	}
+	m.File.FileHeader = media.NewBytesFileHeader(data)
+	return nil
 }
 
 func mergeNewSizes(m *media_library.MediaLibrary, cfg *media_library.MediaBoxConfig) (sizes map[string]*media.Size, r bool) {
@@ -429,7 +816,17 @@ func mergeNewSizes(m *media_library.MediaLibrary, cfg *media_library.MediaBoxCon
 	return
 }
 
-func chooseFile(db *gorm.DB) web.EventFunc {
+// typeAllowed reports whether a file of selectedType can be chosen by the media box of cfg,
+// a media box with sizes only accepts images
+func typeAllowed(cfg *media_library.MediaBoxConfig, selectedType string) bool {
+	allowType := cfg.AllowType
+	if len(cfg.Sizes) > 0 {
+		allowType = media_library.ALLOW_TYPE_IMAGE
+	}
+	return allowType == "" || allowType == selectedType
+}
+
+func chooseFile(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		field := ctx.R.FormValue("field")
 		id := ctx.QueryAsInt("id")
@@ -440,64 +837,162 @@ func chooseFile(db *gorm.DB) web.EventFunc {
 		if err != nil {
 			return
 		}
-		sizes, needCrop := mergeNewSizes(&m, cfg)
-
-		if needCrop {
-			err = m.ScanMediaOptions(media_library.MediaOption{
-				Sizes: sizes,
-				Crop:  true,
-			})
-			if err != nil {
-				return
+		// cfg is posted by the client, the setter checks the type again with the config of the field
+		if !typeAllowed(cfg, m.SelectedType) {
+			msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+			presets.ShowMessage(&r, msgr.TypeNotAllowed(m.File.FileName), "error")
+			return r, nil
+		}
+		err = chooseMedia(b, db, ctx, &r, field, cfg, &m)
+		return
+	}
+}
+
+// chooseMedia generates the missing sizes of cfg and puts the file into the media box of field
+func chooseMedia(b *Builder, db *gorm.DB, ctx *web.EventContext, r *web.EventResponse, field string, cfg *media_library.MediaBoxConfig, m *media_library.MediaLibrary) (err error) {
+	if err = runHooks(b.chooseHooks, m); err != nil {
+		presets.ShowMessage(r, err.Error(), "error")
+		return nil
+	}
+	sizes, needCrop := mergeNewSizes(m, cfg)
+
+	var dropped []string
+	if replaceFrom := ctx.R.FormValue(replaceFromName(field)); replaceFrom != "" && replaceFrom != fmt.Sprint(m.ID) {
+		oldID, perr := strconv.ParseUint(replaceFrom, 10, 64)
+		if perr != nil {
+			return fmt.Errorf("invalid media id %q to replace", replaceFrom)
+		}
+		var old media_library.MediaLibrary
+		if err = db.Where("id = ?", oldID).Find(&old).Error; err != nil {
+			return
+		}
+		var crops map[string]*media.CropOption
+		crops, dropped = fitCropOptions(old.File.CropOptions, m.File.CropOptions, m.File.Width, m.File.Height)
+		if len(crops) > 0 {
+			if m.File.CropOptions == nil {
+				m.File.CropOptions = make(map[string]*media.CropOption)
 			}
-			err = db.Save(&m).Error
-			if err != nil {
-				return
+			for k, v := range crops {
+				m.File.CropOptions[k] = v
 			}
+			needCrop = true
+		}
+	}
 
-			err = media.SaveUploadAndCropImage(db, &m)
-			if err != nil {
-				presets.ShowMessage(&r, err.Error(), "error")
-				return r, nil
+	if needCrop {
+		err = m.ScanMediaOptions(media_library.MediaOption{
+			Sizes: sizes,
+			Crop:  true,
+		})
+		if err != nil {
+			return
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(m).Error; err != nil {
+				return err
 			}
+			return media.SaveUploadAndCropImage(tx, m)
+		})
+		if err != nil {
+			presets.ShowMessage(r, derivativeErrorMessage(ctx, err), "error")
+			return nil
 		}
+	}
+
+	if err = recordRecentUse(db, ctx.R, m.ID); err != nil {
+		return
+	}
 
-		mediaBox := media_library.MediaBox{
-			ID:          json.Number(fmt.Sprint(m.ID)),
-			Url:         m.File.Url,
-			VideoLink:   "",
-			FileName:    m.File.FileName,
-			Description: m.File.Description,
-			FileSizes:   m.File.FileSizes,
-			Width:       m.File.Width,
-			Height:      m.File.Height,
+	mediaBox := media_library.MediaBox{
+		ID:                  json.Number(fmt.Sprint(m.ID)),
+		Url:                 m.File.Url,
+		VideoLink:           "",
+		FileName:            m.File.FileName,
+		Description:         m.File.Description,
+		FileSizes:           m.File.FileSizes,
+		Width:               m.File.Width,
+		Height:              m.File.Height,
+		SeparateDerivatives: m.File.SeparateDerivatives,
+		SizeURL:             m.File.SizeURL,
+		Version:             m.UpdatedAt.UnixNano(),
+	}
+
+	r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+		Name: mediaBoxThumbnailsPortalName(field),
+		Body: b.mediaBoxThumbnails(ctx, &mediaBox, field, cfg, false),
+	})
+	r.VarsScript = `vars.showFileChooser = false`
+	if len(dropped) > 0 {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+		presets.ShowMessage(r, msgr.CropsDropped(strings.Join(dropped, ", ")), "warning")
+	}
+	return
+}
+
+// fitCropOptions return the crop options of the replaced file that fit into the new dimensions,
+// names already cropped on the new file are left alone, the ones out of bounds are dropped
+func fitCropOptions(crops map[string]*media.CropOption, existing map[string]*media.CropOption, width int, height int) (fit map[string]*media.CropOption, dropped []string) {
+	fit = make(map[string]*media.CropOption)
+	for name, crop := range crops {
+		if crop == nil || existing[name] != nil {
+			continue
+		}
+		if crop.X < 0 || crop.Y < 0 || crop.X+crop.Width > width || crop.Y+crop.Height > height {
+			dropped = append(dropped, name)
+			continue
+		}
+		c := *crop
+		fit[name] = &c
+	}
+	sort.Strings(dropped)
+	return
+}
+
+func chooseVideoLink(b *Builder) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+		field := ctx.R.FormValue("field")
+		cfg := stringToCfg(ctx.R.FormValue("cfg"))
+
+		link, err := media.NormalizeVideoLink(ctx.R.FormValue(videoLinkName(field)))
+		if err != nil {
+			presets.ShowMessage(&r, msgr.InvalidVideoLink, "warning")
+			return r, nil
 		}
 
+		mediaBox := media_library.MediaBox{VideoLink: link}
 		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
 			Name: mediaBoxThumbnailsPortalName(field),
-			Body: mediaBoxThumbnails(ctx, &mediaBox, field, cfg, false),
+			Body: b.mediaBoxThumbnails(ctx, &mediaBox, field, cfg, false),
 		})
 		r.VarsScript = `vars.showFileChooser = false`
 		return
 	}
 }
 
-func searchFile(db *gorm.DB) web.EventFunc {
+func searchFile(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		field := ctx.R.FormValue("field")
 		cfg := stringToCfg(ctx.R.FormValue("cfg"))
+		if err = listIsAllowed(ctx.R); err != nil {
+			return
+		}
 
-		ctx.R.Form[currentPageName(field)] = []string{"1"}
+		delete(ctx.R.Form, cursorName(field))
+		delete(ctx.R.Form, beforeName(field))
 
 		renderFileChooserDialogContent(ctx, &r, field, db, cfg)
 		return
 	}
 }
 
-func jumpPage(db *gorm.DB) web.EventFunc {
+func jumpPage(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		field := ctx.R.FormValue("field")
 		cfg := stringToCfg(ctx.R.FormValue("cfg"))
+		if err = listIsAllowed(ctx.R); err != nil {
+			return
+		}
 		renderFileChooserDialogContent(ctx, &r, field, db, cfg)
 		return
 	}
@@ -0,0 +1,128 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/utils"
+	"github.com/qor5/web"
+	"github.com/qor5/x/perm"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDetectSelectedType(t *testing.T) {
+	png := []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+	pdf := []byte("%PDF-1.4\n")
+	cases := []struct {
+		name         string
+		content      []byte
+		selectedType string
+		mismatch     bool
+	}{
+		{"a.png", png, media_library.ALLOW_TYPE_IMAGE, false},
+		{"a.jpg", pdf, media_library.ALLOW_TYPE_FILE, true},
+		{"a.pdf", pdf, media_library.ALLOW_TYPE_FILE, false},
+		{"a.bin", png, media_library.ALLOW_TYPE_FILE, false},
+		{"a.mov", []byte("\x00\x00\x00\x14ftypqt  "), media_library.ALLOW_TYPE_VIDEO, false},
+		{"a.mp4", pdf, media_library.ALLOW_TYPE_FILE, true},
+	}
+	for _, c := range cases {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		fw, _ := w.CreateFormFile("NewFiles", c.name)
+		fw.Write(c.content)
+		w.Close()
+		form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		selectedType, mismatch, err := detectSelectedType(form.File["NewFiles"][0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if selectedType != c.selectedType || mismatch != c.mismatch {
+			t.Errorf("%s: got %s, %v, want %s, %v", c.name, selectedType, mismatch, c.selectedType, c.mismatch)
+		}
+	}
+}
+
+func TestTypeAllowed(t *testing.T) {
+	image, video := media_library.ALLOW_TYPE_IMAGE, media_library.ALLOW_TYPE_VIDEO
+	if !typeAllowed(&media_library.MediaBoxConfig{}, video) {
+		t.Error("any type should be allowed without AllowType")
+	}
+	if typeAllowed(&media_library.MediaBoxConfig{AllowType: image}, video) {
+		t.Error("a video should not be allowed in an image field")
+	}
+	if typeAllowed(&media_library.MediaBoxConfig{Sizes: map[string]*media.Size{"thumb": {Width: 10}}}, video) {
+		t.Error("a video should not be allowed in a field with sizes")
+	}
+}
+
+func TestLoadMoreFilesAppendsTheNextPage(t *testing.T) {
+	defer func(v *perm.Verifier) { permVerifier = v }(permVerifier)
+	permVerifier = perm.NewVerifier("media_library", nil)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&media_library.MediaLibrary{}, &media_library.MediaLibraryTag{}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		m := &media_library.MediaLibrary{SelectedType: media_library.ALLOW_TYPE_FILE}
+		m.File.FileName = fmt.Sprintf("file%d.txt", i)
+		if err = db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &media_library.MediaBoxConfig{InfiniteScroll: true, PerPage: 2}
+	load := func(cursor string, n int) (*web.PortalUpdate, string) {
+		q := url.Values{"field": {"Image"}, cursorName("Image"): {cursor}, moreFilesParam: {fmt.Sprint(n)}}
+		form := url.Values{"cfg": {h.JSONString(cfg)}}
+		r := httptest.NewRequest("POST", "/?"+q.Encode(), strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := &web.EventContext{R: r}
+		res, err := loadMoreFiles(New(db), db)(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.UpdatePortals) != 1 {
+			t.Fatalf("got %d portal updates", len(res.UpdatePortals))
+		}
+		return res.UpdatePortals[0], h.MustString(res.UpdatePortals[0].Body, web.WrapEventContext(context.Background(), ctx))
+	}
+
+	var files []*media_library.MediaLibrary
+	first, err := utils.CursorPaginate(db.Model(&media_library.MediaLibrary{}).Order("created_at DESC"), "", "", 2, true, &files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update, html := load(first.NextCursor, 1)
+	if update.Name != moreFilesPortalName("Image", 1) {
+		t.Errorf("updated the portal %s", update.Name)
+	}
+	for _, name := range []string{"file4.txt", "file3.txt", "file0.txt"} {
+		if strings.Contains(html, name) {
+			t.Errorf("%s is rendered in the second page", name)
+		}
+	}
+	if !strings.Contains(html, "file2.txt") || !strings.Contains(html, "file1.txt") {
+		t.Errorf("the second page isn't rendered: %s", html)
+	}
+	if !strings.Contains(html, moreFilesPortalName("Image", 2)) {
+		t.Error("the third page can't be loaded")
+	}
+}
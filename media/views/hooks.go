@@ -0,0 +1,31 @@
+package views
+
+import (
+	"github.com/qor5/admin/media/media_library"
+)
+
+// MediaLibraryHook is called with the media library record being uploaded or chosen,
+// returning an error aborts the upload/choose and shows the error message
+type MediaLibraryHook func(m *media_library.MediaLibrary) error
+
+// OnUpload registers hooks called after an uploaded file is stored, e.g. virus scan, metadata extraction,
+// they run in the transaction saving the record, an aborted upload is rolled back and its stored files are removed
+func (b *Builder) OnUpload(hooks ...MediaLibraryHook) *Builder {
+	b.uploadHooks = append(b.uploadHooks, hooks...)
+	return b
+}
+
+// OnChoose registers hooks called before a file is put into a media box, an aborted choose leaves the file unchanged
+func (b *Builder) OnChoose(hooks ...MediaLibraryHook) *Builder {
+	b.chooseHooks = append(b.chooseHooks, hooks...)
+	return b
+}
+
+func runHooks(hooks []MediaLibraryHook, m *media_library.MediaLibrary) error {
+	for _, hook := range hooks {
+		if err := hook(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,7 +3,9 @@ package views
 import (
 	"github.com/qor5/admin/media/media_library"
 	"github.com/qor5/admin/presets"
+	. "github.com/qor5/ui/vuetify"
 	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
 	h "github.com/theplant/htmlgo"
 	"gorm.io/gorm"
 )
@@ -17,9 +19,25 @@ func configList(b *presets.Builder, db *gorm.DB) {
 
 	mm.Listing().PageFunc(func(ctx *web.EventContext) (r web.PageResponse, err error) {
 		r.PageTitle = "Media Library"
+		if err = listIsAllowed(ctx.R); err != nil {
+			return
+		}
+		if ctx.R.FormValue(trashParam) != "" && trashIsAllowed(ctx.R) == nil {
+			msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+			r.PageTitle = msgr.Trash
+			var body h.HTMLComponent
+			if body, err = trashContent(db, ctx); err != nil {
+				return
+			}
+			r.Body = web.Portal(body).Name(trashPortalName)
+			return
+		}
 		keyword := ctx.R.FormValue("keyword")
 		ctx.R.Form.Set(searchKeywordName(mediaLibraryListField), keyword)
 		r.Body = h.Components(
+			h.If(trashIsAllowed(ctx.R) == nil,
+				trashLink(ctx),
+			),
 			web.Portal().Name(deleteConfirmPortalName(mediaLibraryListField)),
 			web.Portal(
 				h.Input("").
@@ -32,3 +50,13 @@ func configList(b *presets.Builder, db *gorm.DB) {
 		return
 	})
 }
+
+func trashLink(ctx *web.EventContext) h.HTMLComponent {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+	return h.Div(
+		VBtn("").Text(true).Small(true).Children(
+			VIcon("delete_outline").Left(true),
+			h.Text(msgr.Trash),
+		).Attr("@click", web.Plaid().Query(trashParam, "1").PushState(true).Go()),
+	).Class("d-flex justify-end px-4 pt-2")
+}
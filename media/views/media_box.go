@@ -3,14 +3,18 @@ package views
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path"
 	"sort"
-	"time"
+	"strconv"
+	"strings"
 
+	"github.com/qor5/admin/l10n"
 	"github.com/qor5/admin/media"
 	"github.com/qor5/admin/media/media_library"
 	"github.com/qor5/admin/presets"
+	"github.com/qor5/admin/utils"
 	"github.com/qor5/ui/cropper"
 	"github.com/qor5/ui/fileicons"
 	. "github.com/qor5/ui/vuetify"
@@ -27,53 +31,129 @@ type MediaBoxConfigKey int
 
 var MediaLibraryPerPage int = 39
 
+// MediaLibraryMaxPerPage caps the page size of the file chooser, whatever is configured or selected
+var MediaLibraryMaxPerPage int = 120
+
+// MediaLibraryPerPageOptions are the page sizes users can pick in the file chooser
+var MediaLibraryPerPageOptions = []int{20, 40, 60, 100}
+
+// ThumbnailPlaceholder returns the image shown in place of a thumbnail of the file chooser until it scrolls into view
+// and is loaded, like a tiny blurred size of the file. It's a plain grey image by default.
+var ThumbnailPlaceholder = func(f *media_library.MediaLibrary) string {
+	return `data:image/svg+xml;utf8,<svg xmlns="http://www.w3.org/2000/svg" width="4" height="3"><rect width="4" height="3" fill="%23e0e0e0"/></svg>`
+}
+
+// lazyThumbOptions are the intersection observer options of the thumbnails, which are loaded once they are close to the view.
+// The overlays inside the thumbnails, like the cropping spinner, are shown on the placeholder too.
+var lazyThumbOptions = map[string]interface{}{"rootMargin": "200px", "threshold": 0}
+
 const MediaBoxConfig MediaBoxConfigKey = iota
+
+// WithMediaBoxConfig sets the config of a media box field, it panics with the invalid size of cfg
+// so a misconfigured field fails at startup instead of breaking the crops.
+func WithMediaBoxConfig(fb *presets.FieldBuilder, cfg *media_library.MediaBoxConfig) *presets.FieldBuilder {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	return fb.WithContextValue(MediaBoxConfig, cfg)
+}
+
+// FieldMediaBoxConfig returns the config of a media box field, like its sizes to warm with WarmThumbnails,
+// or nil if it has none
+func FieldMediaBoxConfig(fb *presets.FieldBuilder) *media_library.MediaBoxConfig {
+	cfg, _ := fb.ContextValue(MediaBoxConfig).(*media_library.MediaBoxConfig)
+	return cfg
+}
+
+// versionParam is the UnixNano of the UpdatedAt of the media being edited, to detect concurrent changes
+const versionParam = "version"
 const I18nMediaLibraryKey i18n.ModuleKey = "I18nMediaLibraryKey"
 
 var permVerifier *perm.Verifier
 
-func Configure(b *presets.Builder, db *gorm.DB) {
-	err := db.AutoMigrate(&media_library.MediaLibrary{})
+func (b *Builder) Configure(pb *presets.Builder) {
+	db := b.db
+	err := db.AutoMigrate(&media_library.MediaLibrary{}, &media_library.MediaLibraryRecentUse{}, &media_library.MediaLibraryTag{})
 	if err != nil {
 		panic(err)
 	}
+	if err = createSearchIndex(db); err != nil {
+		panic(err)
+	}
+	// for the cursor pagination of the API
+	if err = db.Exec("CREATE INDEX IF NOT EXISTS idx_media_libraries_created_at_id ON media_libraries (created_at, id)").Error; err != nil {
+		panic(err)
+	}
 
-	b.ExtraAsset("/cropper.js", "text/javascript", cropper.JSComponentsPack())
-	b.ExtraAsset("/cropper.css", "text/css", cropper.CSSComponentsPack())
+	pb.ExtraAsset("/cropper.js", "text/javascript", cropper.JSComponentsPack())
+	pb.ExtraAsset("/cropper.css", "text/css", cropper.CSSComponentsPack())
 
-	permVerifier = perm.NewVerifier("media_library", b.GetPermission())
+	permVerifier = perm.NewVerifier("media_library", pb.GetPermission())
 
-	b.FieldDefaults(presets.WRITE).
+	pb.FieldDefaults(presets.WRITE).
 		FieldType(media_library.MediaBox{}).
-		ComponentFunc(MediaBoxComponentFunc(db)).
+		ComponentFunc(mediaBoxComponentFunc(b)).
 		SetterFunc(MediaBoxSetterFunc(db))
 
-	b.FieldDefaults(presets.LIST).
+	pb.FieldDefaults(presets.LIST).
 		FieldType(media_library.MediaBox{}).
 		ComponentFunc(MediaBoxListFunc())
 
-	registerEventFuncs(b.GetWebBuilder(), db)
+	pb.FieldDefaults(presets.DETAIL).
+		FieldType(media_library.MediaBox{}).
+		ComponentFunc(mediaBoxDetailFunc(b))
 
-	b.I18n().
+	registerEventFuncs(pb.GetWebBuilder(), b)
+
+	pb.I18n().
 		RegisterForModule(language.English, I18nMediaLibraryKey, Messages_en_US).
 		RegisterForModule(language.SimplifiedChinese, I18nMediaLibraryKey, Messages_zh_CN).
 		RegisterForModule(language.Japanese, I18nMediaLibraryKey, Messages_ja_JP)
+	for _, m := range extraMessages {
+		pb.I18n().RegisterForModule(m.lang, I18nMediaLibraryKey, withEnglishFallback(m.msgs))
+	}
 
-	configList(b, db)
+	configList(pb, db)
 }
 
 func MediaBoxComponentFunc(db *gorm.DB) presets.FieldComponentFunc {
+	return mediaBoxComponentFunc(New(db))
+}
+
+func mediaBoxComponentFunc(b *Builder) presets.FieldComponentFunc {
 	return func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
 		cfg, ok := field.ContextValue(MediaBoxConfig).(*media_library.MediaBoxConfig)
 		if !ok {
 			cfg = &media_library.MediaBoxConfig{}
 		}
 		mediaBox := field.Value(obj).(media_library.MediaBox)
-		return QMediaBox(db).
+		return b.QMediaBox().
 			FieldName(field.FormKey).
 			Value(&mediaBox).
 			Label(field.Label).
-			Config(cfg).Disabled(field.Disabled)
+			Config(cfg).Disabled(field.Disabled).
+			ErrorMessages(field.Errors...)
+	}
+}
+
+// MediaBoxDetailFunc renders the media box read only, for detail pages
+func MediaBoxDetailFunc(db *gorm.DB) presets.FieldComponentFunc {
+	return mediaBoxDetailFunc(New(db))
+}
+
+func mediaBoxDetailFunc(b *Builder) presets.FieldComponentFunc {
+	return func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
+		cfg, ok := field.ContextValue(MediaBoxConfig).(*media_library.MediaBoxConfig)
+		if !ok {
+			cfg = &media_library.MediaBoxConfig{}
+		}
+		mediaBox := field.Value(obj).(media_library.MediaBox)
+		return b.QMediaBox().
+			FieldName(field.FormKey).
+			Value(&mediaBox).
+			Label(field.Label).
+			Config(cfg).
+			Readonly(true)
 	}
 }
 
@@ -87,6 +167,57 @@ func MediaBoxSetterFunc(db *gorm.DB) presets.FieldSetterFunc {
 		}
 		descriptionField := fmt.Sprintf("%s.Description", field.FormKey)
 		mediaBox.Description = ctx.R.FormValue(descriptionField)
+		if err = normalizeVideoLink(ctx, &mediaBox); err != nil {
+			return
+		}
+
+		cfg, _ := field.ContextValue(MediaBoxConfig).(*media_library.MediaBoxConfig)
+		if cfg != nil {
+			if err = checkMediaBoxType(db, ctx, cfg, &mediaBox); err != nil {
+				return
+			}
+		}
+		if cfg != nil && cfg.RichDescription {
+			mediaBox.Description = media_library.SanitizeDescription(mediaBox.Description)
+		}
+		if cfg != nil && cfg.RequireDescription && mediaBox.IsImage() && strings.TrimSpace(mediaBox.Description) == "" {
+			msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+			return errors.New(msgr.DescriptionRequired)
+		}
+		// the variants are only taken from the fields of the configured locales
+		for locale := range mediaBox.Locales {
+			if cfg == nil || !utils.Contains(cfg.Locales, locale) {
+				return fmt.Errorf("unknown locale %q of the media box", locale)
+			}
+		}
+		mediaBox.Locales = nil
+		if cfg != nil && len(cfg.Locales) > 0 {
+			for _, locale := range cfg.Locales {
+				localeField := localeVariantFieldName(field.FormKey, locale)
+				v := &media_library.MediaBox{}
+				if err = v.Scan(ctx.R.FormValue(fmt.Sprintf("%s.Values", localeField))); err != nil {
+					return
+				}
+				if v.IsEmpty() {
+					continue
+				}
+				v.Locales = nil
+				v.Description = ctx.R.FormValue(fmt.Sprintf("%s.Description", localeField))
+				if err = normalizeVideoLink(ctx, v); err != nil {
+					return
+				}
+				if err = checkMediaBoxType(db, ctx, cfg, v); err != nil {
+					return
+				}
+				if cfg.RichDescription {
+					v.Description = media_library.SanitizeDescription(v.Description)
+				}
+				if mediaBox.Locales == nil {
+					mediaBox.Locales = make(map[string]*media_library.MediaBox)
+				}
+				mediaBox.Locales[locale] = v
+			}
+		}
 		err = reflectutils.Set(obj, field.Name, mediaBox)
 		if err != nil {
 			return
@@ -96,18 +227,69 @@ func MediaBoxSetterFunc(db *gorm.DB) presets.FieldSetterFunc {
 	}
 }
 
+// normalizeVideoLink turns the posted video link into the embed URL, links of other hosts than the supported ones are refused
+func normalizeVideoLink(ctx *web.EventContext, mediaBox *media_library.MediaBox) (err error) {
+	if mediaBox.VideoLink == "" {
+		return nil
+	}
+	if mediaBox.VideoLink, err = media.NormalizeVideoLink(mediaBox.VideoLink); err != nil {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+		return errors.New(msgr.InvalidVideoLink)
+	}
+	return nil
+}
+
+// checkMediaBoxType checks the type of the file of the media box against the config of the field,
+// with the type stored in the media library rather than the posted config the file chooser checked it with
+func checkMediaBoxType(db *gorm.DB, ctx *web.EventContext, cfg *media_library.MediaBoxConfig, mediaBox *media_library.MediaBox) error {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+	if mediaBox.VideoLink != "" {
+		if !typeAllowed(cfg, media_library.ALLOW_TYPE_VIDEO) {
+			return errors.New(msgr.TypeNotAllowed(mediaBox.VideoLink))
+		}
+		return nil
+	}
+	if mediaBox.ID.String() == "" {
+		return nil
+	}
+	id, err := strconv.ParseUint(mediaBox.ID.String(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid media id %q", mediaBox.ID)
+	}
+	var m media_library.MediaLibrary
+	if err = db.Where("id = ?", id).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(msgr.FileNotFound)
+		}
+		return err
+	}
+	if !typeAllowed(cfg, m.SelectedType) {
+		return errors.New(msgr.TypeNotAllowed(m.File.FileName))
+	}
+	return nil
+}
+
 type QMediaBoxBuilder struct {
 	fieldName string
 	label     string
 	value     *media_library.MediaBox
 	config    *media_library.MediaBoxConfig
 	db        *gorm.DB
+	builder   *Builder
 	disabled  bool
+	readonly  bool
+	errors    []string
 }
 
 func QMediaBox(db *gorm.DB) (r *QMediaBoxBuilder) {
+	return New(db).QMediaBox()
+}
+
+// QMediaBox renders a media box with the settings of the builder, like its download links
+func (b *Builder) QMediaBox() (r *QMediaBoxBuilder) {
 	r = &QMediaBoxBuilder{
-		db: db,
+		db:      b.db,
+		builder: b,
 	}
 	return
 }
@@ -127,6 +309,13 @@ func (b *QMediaBoxBuilder) Disabled(v bool) (r *QMediaBoxBuilder) {
 	return b
 }
 
+// Readonly shows the thumbnails and the description without the buttons changing them,
+// clicking a thumbnail opens the file in full size.
+func (b *QMediaBoxBuilder) Readonly(v bool) (r *QMediaBoxBuilder) {
+	b.readonly = v
+	return b
+}
+
 func (b *QMediaBoxBuilder) Label(v string) (r *QMediaBoxBuilder) {
 	b.label = v
 	return b
@@ -137,6 +326,11 @@ func (b *QMediaBoxBuilder) Config(v *media_library.MediaBoxConfig) (r *QMediaBox
 	return b
 }
 
+func (b *QMediaBoxBuilder) ErrorMessages(v ...string) (r *QMediaBoxBuilder) {
+	b.errors = v
+	return b
+}
+
 func (b *QMediaBoxBuilder) MarshalHTML(c context.Context) (r []byte, err error) {
 	if len(b.fieldName) == 0 {
 		panic("FieldName required")
@@ -149,40 +343,112 @@ func (b *QMediaBoxBuilder) MarshalHTML(c context.Context) (r []byte, err error)
 
 	portalName := mainPortalName(b.fieldName)
 
+	// a config set without WithMediaBoxConfig isn't validated at startup, the field shows the error instead of broken crops
+	if err := b.config.Validate(); err != nil {
+		return VAlert(h.Text(fmt.Sprintf("%s: %v", b.label, err))).
+			Border("left").
+			Type("error").
+			Elevation(2).
+			ColoredBorder(true).
+			MarshalHTML(c)
+	}
+
+	if b.readonly {
+		value := b.value
+		if locale, ok := l10n.IsLocalizableFromCtx(ctx.R.Context()); ok {
+			value = value.ForLocale(locale)
+		}
+		return h.Components(
+			VSheet(
+				h.If(len(b.label) > 0,
+					h.Label(b.label).Class("v-label theme--light"),
+				),
+				b.builder.mediaBoxReadonlyThumbnails(ctx, value, b.config),
+				b.localeVariants(ctx),
+			).Class("pb-4").Rounded(true),
+		).MarshalHTML(c)
+	}
+
 	return h.Components(
 		VSheet(
 			h.If(len(b.label) > 0,
 				h.Label(b.label).Class("v-label theme--light"),
 			),
 			web.Portal(
-				mediaBoxThumbnails(ctx, b.value, b.fieldName, b.config, b.disabled),
+				b.builder.mediaBoxThumbnails(ctx, b.value, b.fieldName, b.config, b.disabled),
 			).Name(mediaBoxThumbnailsPortalName(b.fieldName)),
 			web.Portal().Name(portalName),
+			b.localeVariants(ctx),
+			h.Iff(len(b.errors) > 0, func() h.HTMLComponent {
+				var msgs []h.HTMLComponent
+				for _, e := range b.errors {
+					msgs = append(msgs, h.Div().Text(e))
+				}
+				return h.Div(msgs...).Class("error--text text-caption")
+			}),
 		).Class("pb-4").
 			Rounded(true).
 			Attr(web.InitContextVars, `{showFileChooser: false}`),
 	).MarshalHTML(c)
 }
 
-func mediaBoxThumb(msgr *Messages, cfg *media_library.MediaBoxConfig,
-	f *media_library.MediaBox, field string, thumb string, disabled bool) h.HTMLComponent {
+// localeVariants renders a media box for every locale of the config,
+// they are submitted as <field>.Locales.<locale> and collected by MediaBoxSetterFunc.
+func (b *QMediaBoxBuilder) localeVariants(ctx *web.EventContext) h.HTMLComponent {
+	if b.config == nil || len(b.config.Locales) == 0 {
+		return nil
+	}
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+	cfg := *b.config
+	cfg.Locales = nil
+
+	var comps h.HTMLComponents
+	for _, locale := range b.config.Locales {
+		v := b.value.Locales[locale]
+		if v == nil {
+			v = &media_library.MediaBox{}
+		}
+		comps = append(comps, b.builder.QMediaBox().
+			FieldName(localeVariantFieldName(b.fieldName, locale)).
+			Value(v).
+			Label(msgr.LocaleVariant(locale)).
+			Config(&cfg).
+			Disabled(b.disabled).
+			Readonly(b.readonly))
+	}
+	return h.Div(comps...).Class("pl-4")
+}
+
+func (b *Builder) mediaBoxThumb(msgr *Messages, cfg *media_library.MediaBoxConfig,
+	f *media_library.MediaBox, field string, thumb string, disabled bool, readonly bool) h.HTMLComponent {
 	size := cfg.Sizes[thumb]
 	fileSize := f.FileSizes[thumb]
 	url := f.URL(thumb)
+	downloadSize := thumb
 	if thumb == media.DefaultSizeKey {
 		url = f.URL()
+		downloadSize = ""
+	}
+	var img h.HTMLComponent = VImg().Src(fmt.Sprintf("%s?v=%s", url, f.CacheToken())).Height(150)
+	if readonly {
+		img = h.A(img).Href(f.URL()).Target("_blank")
 	}
 	return VCard(
 		h.If(media.IsImageFormat(f.FileName),
-			VImg().Src(fmt.Sprintf("%s?%d", url, time.Now().UnixNano())).Height(150),
+			img,
 		).Else(
 			h.Div(
 				fileThumb(f.FileName),
 				h.A().Text(f.FileName).Href(f.Url).Target("_blank"),
 			).Style("text-align:center"),
 		),
-		h.If(media.IsImageFormat(f.FileName) && (size != nil || thumb == media.DefaultSizeKey),
-			VCardActions(
+		VCardActions(
+			h.If(readonly && media.IsImageFormat(f.FileName) && (size != nil || thumb == media.DefaultSizeKey),
+				VChip(
+					thumbName(thumb, size, fileSize, f),
+				).Small(true),
+			),
+			h.If(!readonly && media.IsImageFormat(f.FileName) && (size != nil || thumb == media.DefaultSizeKey),
 				VChip(
 					thumbName(thumb, size, fileSize, f),
 				).Small(true).Disabled(disabled).Attr("@click", web.Plaid().
@@ -193,10 +459,29 @@ func mediaBoxThumb(msgr *Messages, cfg *media_library.MediaBoxConfig,
 					FieldValue("cfg", h.JSONString(cfg)).
 					Go()),
 			),
+			VSpacer(),
+			copyURLMenu(msgr, f.FileName, f.Description, []copyableURL{{Label: thumb, URL: url}}),
+			b.downloadBtn(msgr, f, downloadSize),
 		),
 	)
 }
 
+func (b *Builder) downloadBtn(msgr *Messages, f *media_library.MediaBox, size string) h.HTMLComponent {
+	u := b.downloadURL(f.ID.String(), size)
+	if u == "" {
+		return nil
+	}
+	return VBtn("").Icon(true).Small(true).Href(u).Attr("title", msgr.Download).Children(
+		VIcon("file_download").Small(true),
+	)
+}
+
+func lockedThumb() h.HTMLComponent {
+	return h.Div(
+		VIcon("lock").XLarge(true),
+	).Class("d-flex align-center justify-center").Style("height: 150px")
+}
+
 func fileThumb(filename string) h.HTMLComponent {
 
 	return h.Div(
@@ -204,7 +489,7 @@ func fileThumb(filename string) h.HTMLComponent {
 	).Class("d-flex align-center justify-center")
 }
 
-func deleteConfirmation(db *gorm.DB) web.EventFunc {
+func deleteConfirmation(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		msgr := i18n.MustGetModuleMessages(ctx.R, presets.CoreI18nModuleKey, Messages_en_US).(*presets.Messages)
 		field := ctx.R.FormValue("field")
@@ -244,7 +529,7 @@ func deleteConfirmation(db *gorm.DB) web.EventFunc {
 		return
 	}
 }
-func doDelete(db *gorm.DB) web.EventFunc {
+func doDelete(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		field := ctx.R.FormValue("field")
 		id := ctx.R.FormValue("id")
@@ -274,6 +559,7 @@ func doDelete(db *gorm.DB) web.EventFunc {
 		if err != nil {
 			panic(err)
 		}
+		b.fireWebhooks(WebhookEventDeleted, &obj)
 
 		renderFileChooserDialogContent(
 			ctx,
@@ -287,66 +573,86 @@ func doDelete(db *gorm.DB) web.EventFunc {
 	}
 }
 
-func mediaBoxThumbnails(ctx *web.EventContext, mediaBox *media_library.MediaBox, field string, cfg *media_library.MediaBoxConfig, disabled bool) h.HTMLComponent {
+func (b *Builder) mediaBoxThumbnails(ctx *web.EventContext, mediaBox *media_library.MediaBox, field string, cfg *media_library.MediaBoxConfig, disabled bool) h.HTMLComponent {
 	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
 	c := VContainer().Fluid(true)
 
-	if mediaBox.ID.String() != "" && mediaBox.ID.String() != "0" {
-		row := VRow()
-		if len(cfg.Sizes) == 0 {
-			row.AppendChildren(
-				VCol(
-					mediaBoxThumb(msgr, cfg, mediaBox, field, media.DefaultSizeKey, disabled),
-				).Cols(6).Sm(4).Class("pl-0"),
+	if mediaBox.IsVideoLink() {
+		c.AppendChildren(videoLinkRow(mediaBox))
+	} else if mediaBox.ID.String() != "" && mediaBox.ID.String() != "0" {
+		c.AppendChildren(b.mediaBoxThumbnailsRow(ctx, msgr, cfg, mediaBox, field, disabled, false))
+		if mediaBox.IsImage() && mediaBoxViewIsAllowed(ctx.R, mediaBox) && b.downloadPath != "" {
+			c.AppendChildren(
+				VRow(
+					VBtn(msgr.DownloadOriginal).Text(true).Small(true).
+						Href(b.downloadURL(mediaBox.ID.String(), downloadOriginal)),
+				).Class("pb-2"),
 			)
-		} else {
-			var keys []string
-			for k, _ := range cfg.Sizes {
-				keys = append(keys, k)
-			}
-
-			sort.Strings(keys)
-
-			for _, k := range keys {
-				row.AppendChildren(
-					VCol(
-						mediaBoxThumb(msgr, cfg, mediaBox, field, k, disabled),
-					).Cols(6).Sm(4).Class("pl-0"),
-				)
-			}
 		}
 
-		c.AppendChildren(row)
-
 		fieldName := fmt.Sprintf("%s.Description", field)
 		value := ctx.R.FormValue(fieldName)
 		if len(value) == 0 {
 			value = mediaBox.Description
 		}
+		var descInput h.HTMLComponent = VTextField().
+			Value(value).
+			Attr(web.VFieldName(fieldName)...).
+			Label(msgr.DescriptionForAccessibility).
+			Clearable(true).
+			ClearIcon("backspace").
+			Dense(true).
+			HideDetails(true).
+			Outlined(true).
+			Disabled(disabled)
+		if cfg.RichDescription {
+			descInput = VTextarea().
+				Value(value).
+				Attr(web.VFieldName(fieldName)...).
+				Label(msgr.DescriptionForAccessibility).
+				Hint(msgr.DescriptionFormattingHint).
+				PersistentHint(true).
+				Rows(2).
+				AutoGrow(true).
+				Dense(true).
+				Outlined(true).
+				Disabled(disabled)
+		}
 		c.AppendChildren(
 			VRow(
-				VCol(
-					VTextField().
-						Value(value).
-						Attr(web.VFieldName(fieldName)...).
-						Label(msgr.DescriptionForAccessibility).
-						Dense(true).
-						HideDetails(true).
-						Outlined(true).
-						Disabled(disabled),
-				).Cols(12).Class("pl-0 pt-0"),
+				VCol(descInput).Cols(12).Class("pl-0 pt-0"),
 			),
 		)
 	}
 
+	hasValue := mediaBox.IsVideoLink() || (mediaBox.ID.String() != "" && mediaBox.ID.String() != "0")
 	mediaBoxValue := ""
-	if mediaBox.ID.String() != "" && mediaBox.ID.String() != "0" {
+	if hasValue {
 		mediaBoxValue = h.JSONString(mediaBox)
 	}
 
-	return h.Components(
+	uploadAndChoose := func(files string) string {
+		return web.Plaid().
+			FieldValue("NewFiles", web.Var(files)).
+			EventFunc(uploadAndChooseEvent).
+			Query("field", field).
+			FieldValue("cfg", h.JSONString(cfg)).
+			Go()
+	}
+	canUpload := !disabled && uploadIsAllowed(ctx.R) == nil
+	fileAccept := "*/*"
+	if cfg.AllowType == media_library.ALLOW_TYPE_IMAGE || len(cfg.Sizes) > 0 {
+		fileAccept = "image/*"
+	}
+	var dropScript string
+	if canUpload {
+		dropScript = uploadAndChoose("$event.dataTransfer.files")
+	}
+
+	return h.Div(
 		c,
 		web.Portal().Name(cropperPortalName(field)),
+		copiedSnackbar(msgr),
 		h.Input("").Type("hidden").
 			Value(mediaBoxValue).
 			Attr(web.VFieldName(fmt.Sprintf("%s.Values", field))...),
@@ -359,7 +665,17 @@ func mediaBoxThumbnails(ctx *web.EventContext, mediaBox *media_library.MediaBox,
 				Go(),
 			).Disabled(disabled),
 
-		h.If(mediaBox != nil && mediaBox.ID.String() != "" && mediaBox.ID.String() != "0",
+		h.If(hasValue && !mediaBox.IsVideoLink(),
+			VBtn(msgr.Replace).
+				Depressed(true).
+				Attr("@click", web.Plaid().EventFunc(openFileChooserEvent).
+					Query("field", field).
+					Query("replace", mediaBox.ID.String()).
+					FieldValue("cfg", h.JSONString(cfg)).
+					Go(),
+				).Disabled(disabled),
+		),
+		h.If(hasValue,
 			VBtn(msgr.Delete).
 				Depressed(true).
 				Attr("@click", web.Plaid().EventFunc(deleteFileEvent).
@@ -368,23 +684,130 @@ func mediaBoxThumbnails(ctx *web.EventContext, mediaBox *media_library.MediaBox,
 					Go(),
 				).Disabled(disabled),
 		),
+		h.If(canUpload,
+			h.Label("").Children(
+				h.Text(msgr.Upload),
+				h.Input("").
+					Attr("accept", fileAccept).
+					Type("file").
+					Style("display:none").
+					Attr("@change", uploadAndChoose("$event")),
+			).Class("v-btn v-btn--depressed theme--light v-size--default").Attr("role", "button"),
+			h.Span(msgr.DropToUpload).Class("text-caption grey--text ml-2"),
+		),
+	).Attr("@dragover.prevent", "").
+		Attr("@drop.prevent", dropScript)
+}
+
+// mediaBoxReadonlyThumbnails renders the thumbnails and the description of mediaBoxThumbnails without any input
+func (b *Builder) mediaBoxReadonlyThumbnails(ctx *web.EventContext, mediaBox *media_library.MediaBox, cfg *media_library.MediaBoxConfig) h.HTMLComponent {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+	if cfg == nil {
+		cfg = &media_library.MediaBoxConfig{}
+	}
+	c := VContainer().Fluid(true)
+
+	if mediaBox.IsVideoLink() {
+		return c.AppendChildren(videoLinkRow(mediaBox))
+	}
+	if mediaBox.ID.String() == "" || mediaBox.ID.String() == "0" {
+		return c.AppendChildren(h.Div(h.Text("-")).Class("grey--text"))
+	}
+
+	c.AppendChildren(b.mediaBoxThumbnailsRow(ctx, msgr, cfg, mediaBox, "", false, true))
+	if !mediaBoxViewIsAllowed(ctx.R, mediaBox) {
+		return c
+	}
+	if mediaBox.Description != "" {
+		c.AppendChildren(
+			VRow(
+				h.Div(descriptionComp(mediaBox, cfg)).Class("text-body-2"),
+			),
+		)
+	}
+
+	return h.Components(c, copiedSnackbar(msgr))
+}
+
+func videoLinkRow(mediaBox *media_library.MediaBox) h.HTMLComponent {
+	return VRow(
+		VCol(
+			VCard(
+				h.Iframe().Src(mediaBox.VideoLink).
+					Attr("frameborder", "0").
+					Attr("allowfullscreen", true).
+					Attr("allow", "encrypted-media; picture-in-picture").
+					Style("width: 100%; aspect-ratio: 16 / 9; display: block;"),
+			),
+		).Cols(12).Sm(8).Class("pl-0"),
 	)
 }
 
+// mediaBoxThumbnailsRow renders a thumbnail for each of the ThumbnailKeys, or a lock if the file can't be viewed
+func (b *Builder) mediaBoxThumbnailsRow(ctx *web.EventContext, msgr *Messages, cfg *media_library.MediaBoxConfig, mediaBox *media_library.MediaBox, field string, disabled bool, readonly bool) h.HTMLComponent {
+	row := VRow()
+	if !mediaBoxViewIsAllowed(ctx.R, mediaBox) {
+		return row.AppendChildren(
+			VCol(
+				VCard(lockedThumb()),
+			).Cols(6).Sm(4).Class("pl-0"),
+		)
+	}
+	for _, k := range cfg.ThumbnailKeys() {
+		row.AppendChildren(
+			VCol(
+				b.mediaBoxThumb(msgr, cfg, mediaBox, field, k, disabled, readonly),
+			).Cols(6).Sm(4).Class("pl-0"),
+		)
+	}
+	return row
+}
+
+func descriptionComp(mediaBox *media_library.MediaBox, cfg *media_library.MediaBoxConfig) h.HTMLComponent {
+	if cfg.RichDescription {
+		return h.RawHTML(string(mediaBox.DescriptionHTML()))
+	}
+	return h.Text(mediaBox.Description)
+}
+
 func MediaBoxListFunc() presets.FieldComponentFunc {
 	return func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
 		mediaBox := field.Value(obj).(media_library.MediaBox)
+		if locale, ok := l10n.IsLocalizableFromCtx(ctx.R.Context()); ok {
+			mediaBox = *mediaBox.ForLocale(locale)
+		}
+		if !mediaBoxViewIsAllowed(ctx.R, &mediaBox) {
+			return h.Td(VIcon("lock"))
+		}
+		if mediaBox.IsVideoLink() {
+			return h.Td(h.A(VIcon("smart_display")).Href(mediaBox.VideoLink).Target("_blank"))
+		}
 		return h.Td(h.Img("").Src(mediaBox.URL(media_library.QorPreviewSizeName)).Style("height: 48px;"))
 	}
 }
 
-func deleteFileField() web.EventFunc {
+// derivativeErrorMessage tells which sizes failed, the record itself has been rolled back
+func derivativeErrorMessage(ctx *web.EventContext, err error) string {
+	var derr *media.DerivativeError
+	if !errors.As(err, &derr) {
+		return err.Error()
+	}
+	var failed []string
+	for k := range derr.Failed {
+		failed = append(failed, k)
+	}
+	sort.Strings(failed)
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+	return msgr.DerivativesFailed(strings.Join(failed, ", "))
+}
+
+func deleteFileField(b *Builder) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		field := ctx.R.FormValue("field")
 		cfg := stringToCfg(ctx.R.FormValue("cfg"))
 		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
 			Name: mediaBoxThumbnailsPortalName(field),
-			Body: mediaBoxThumbnails(ctx, &media_library.MediaBox{}, field, cfg, false),
+			Body: b.mediaBoxThumbnails(ctx, &media_library.MediaBox{}, field, cfg, false),
 		})
 
 		return
@@ -418,7 +841,7 @@ func thumbName(name string, size *media.Size, fileSize int, f *media_library.Med
 	return h.Text(text)
 }
 
-func updateDescription(db *gorm.DB) web.EventFunc {
+func updateDescription(b *Builder, db *gorm.DB) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		field := ctx.R.FormValue("field")
 		id := ctx.R.FormValue("id")
@@ -444,17 +867,74 @@ func updateDescription(db *gorm.DB) web.EventFunc {
 			return
 		}
 
-		var media media_library.MediaLibrary
-		if err = db.Find(&media, id).Error; err != nil {
+		version, _ := strconv.ParseInt(ctx.R.FormValue(versionParam), 10, 64)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := media_library.ClaimVersion(tx, obj.ID, version); err != nil {
+				return err
+			}
+			if err := tx.Find(&obj, id).Error; err != nil {
+				return err
+			}
+			obj.File.Description = ctx.R.FormValue("CurrentDescription")
+			return tx.Save(&obj).Error
+		})
+		if errors.Is(err, media_library.ErrConflict) {
+			msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+			presets.ShowMessage(&r, msgr.MediaConflict, "warning")
+			err = nil
+		} else if err != nil {
 			return
+		} else {
+			r.VarsScript = `vars.snackbarShow = true;`
+			b.fireWebhooks(WebhookEventDescriptionUpdated, &obj)
 		}
+		// reload the files for the latest versions
+		renderFileChooserDialogContent(ctx, &r, field, db, stringToCfg(cfg))
+		return
+	}
+}
+
+func convertFormat(b *Builder, db *gorm.DB) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+		field := ctx.R.FormValue("field")
+		id := ctx.QueryAsInt("id")
+		format := ctx.R.FormValue("format")
+		cfg := stringToCfg(ctx.R.FormValue("cfg"))
 
-		media.File.Description = ctx.R.FormValue("CurrentDescription")
-		if err = db.Save(&media).Error; err != nil {
+		var m media_library.MediaLibrary
+		if err = db.First(&m, id).Error; err != nil {
 			return
 		}
+		if err = convertIsAllowed(ctx.R, &m); err != nil {
+			return
+		}
+
+		original, err := m.File.Retrieve(m.File.URL("original"))
+		if err != nil {
+			presets.ShowMessage(&r, err.Error(), "error")
+			return r, nil
+		}
+		defer original.Close()
+
+		fileName, data, err := media.ConvertImage(original, m.File.FileName, format)
+		if err != nil {
+			presets.ShowMessage(&r, err.Error(), "error")
+			return r, nil
+		}
+
+		// the dimensions don't change, so the crop options are kept and all sizes are regenerated with them,
+		// the old files stay in the storage for the media boxes still referring to them
+		m.File.FileName = fileName
+		m.File.FileHeader = media.NewBytesFileHeader(data)
+		m.File.FileSizes = nil
+		if err = media.SaveUploadAndCropImage(db, &m); err != nil {
+			presets.ShowMessage(&r, derivativeErrorMessage(ctx, err), "error")
+			return r, nil
+		}
 
-		r.VarsScript = `vars.snackbarShow = true;`
+		renderFileChooserDialogContent(ctx, &r, field, db, cfg)
+		presets.ShowMessage(&r, msgr.ConvertedTo(strings.ToUpper(format)), "")
 		return
 	}
 }
@@ -0,0 +1,121 @@
+package views
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/web"
+	"github.com/qor5/x/perm"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMediaBoxSetterRefusesUnknownLocales(t *testing.T) {
+	type post struct {
+		Image media_library.MediaBox
+	}
+	form := url.Values{"Image.Values": {`{"Locales":{"fr":{"VideoLink":"https://www.youtube.com/embed/dQw4w9WgXcQ"}}}`}}
+	r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ctx := &web.EventContext{R: r}
+	field := &presets.FieldContext{Name: "Image", FormKey: "Image"}
+
+	var obj post
+	if err := MediaBoxSetterFunc(nil)(&obj, field, ctx); err == nil {
+		t.Fatal("the variant of a locale without a config is accepted")
+	}
+}
+
+func TestMediaBoxShowsInvalidConfig(t *testing.T) {
+	cfg := &media_library.MediaBoxConfig{Sizes: map[string]*media.Size{"broken": {}}}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WithMediaBoxConfig accepts a 0x0 size")
+			}
+		}()
+		WithMediaBoxConfig(presets.NewFieldsBuilder().Field("Image"), cfg)
+	}()
+
+	ctx := &web.EventContext{R: httptest.NewRequest("GET", "/", nil)}
+	html, err := New(nil).QMediaBox().FieldName("Image").Label("Image").Value(&media_library.MediaBox{}).Config(cfg).
+		MarshalHTML(web.WrapEventContext(context.Background(), ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "v-alert") || !strings.Contains(string(html), "broken") {
+		t.Errorf("the media box doesn't show the invalid size: %s", html)
+	}
+}
+
+func TestReadonlyMediaBoxSharesTheThumbnails(t *testing.T) {
+	defer func(v *perm.Verifier) { permVerifier = v }(permVerifier)
+	permVerifier = perm.NewVerifier("media_library", nil)
+
+	cfg := &media_library.MediaBoxConfig{Sizes: map[string]*media.Size{"thumb": {Width: 100, Height: 100}}}
+	value := &media_library.MediaBox{ID: "1", Url: "/system/media_libraries/1/file.jpg", FileName: "file.jpg", Description: "A cat"}
+	render := func(readonly bool) string {
+		ctx := &web.EventContext{R: httptest.NewRequest("GET", "/", nil)}
+		html, err := New(nil).QMediaBox().FieldName("Image").Value(value).Config(cfg).Readonly(readonly).
+			MarshalHTML(web.WrapEventContext(context.Background(), ctx))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(html)
+	}
+
+	editable, readonly := render(false), render(true)
+	for _, u := range []string{value.URL("thumb"), value.URL()} {
+		if !strings.Contains(editable, u) || !strings.Contains(readonly, u) {
+			t.Errorf("both media boxes should show %s", u)
+		}
+	}
+	if !strings.Contains(editable, loadImageCropperEvent) || !strings.Contains(editable, "Image.Values") {
+		t.Error("the editable media box has no cropper or value")
+	}
+	if strings.Contains(readonly, loadImageCropperEvent) || strings.Contains(readonly, "Image.Values") {
+		t.Error("the readonly media box can be changed")
+	}
+	if !strings.Contains(readonly, "A cat") || !strings.Contains(readonly, "target='_blank'") {
+		t.Errorf("the readonly media box doesn't show the description or the full size link: %s", readonly)
+	}
+}
+
+func TestFieldMediaBoxConfig(t *testing.T) {
+	type post struct {
+		Image media_library.MediaBox
+	}
+	fields := presets.NewFieldsBuilder().Model(&post{})
+	if cfg := FieldMediaBoxConfig(fields.Field("Image")); cfg != nil {
+		t.Errorf("got %v without a config", cfg)
+	}
+	cfg := &media_library.MediaBoxConfig{Sizes: map[string]*media.Size{"thumb": {Width: 100, Height: 100}}}
+	WithMediaBoxConfig(fields.Field("Image"), cfg)
+	if got := FieldMediaBoxConfig(fields.Field("Image")); got != cfg {
+		t.Errorf("got %v, want %v", got, cfg)
+	}
+}
+
+func TestCheckMediaBoxTypeOfDeletedFile(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&media_library.MediaLibrary{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := &web.EventContext{R: httptest.NewRequest("POST", "/", nil)}
+	mb := &media_library.MediaBox{ID: json.Number("1")}
+	err = checkMediaBoxType(db, ctx, &media_library.MediaBoxConfig{}, mb)
+	if err == nil || err.Error() != Messages_en_US.FileNotFound {
+		t.Errorf("the deleted file is reported as %v", err)
+	}
+}
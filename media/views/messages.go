@@ -1,5 +1,37 @@
 package views
 
+import (
+	"fmt"
+	"reflect"
+
+	"golang.org/x/text/language"
+)
+
+type languageMessages struct {
+	lang language.Tag
+	msgs *Messages
+}
+
+var extraMessages []languageMessages
+
+// RegisterMessages adds or replaces the messages of a language, call it before Configure.
+// The messages that are left empty use the English ones, so a partial translation works.
+func RegisterMessages(lang language.Tag, msgs *Messages) {
+	extraMessages = append(extraMessages, languageMessages{lang: lang, msgs: msgs})
+}
+
+// withEnglishFallback returns a copy of msgs with the zero fields set to the English messages
+func withEnglishFallback(msgs *Messages) *Messages {
+	r := *msgs
+	v, en := reflect.ValueOf(&r).Elem(), reflect.ValueOf(Messages_en_US).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			v.Field(i).Set(en.Field(i))
+		}
+	}
+	return &r
+}
+
 type Messages struct {
 	Crop                        string
 	CropImage                   string
@@ -18,7 +50,54 @@ type Messages struct {
 	Images                      string
 	Videos                      string
 	Files                       string
+	PerPage                     string
+	MediaLibrary                string
+	ExternalVideo               string
+	VideoLink                   string
+	UseVideoLink                string
+	InvalidVideoLink            string
+	DescriptionRequired         string
+	DescriptionFormattingHint   string
+	CopyURL                     string
+	CopyEmbedHTML               string
+	URLCopied                   string
+	URLExpiresAt                func(t string) string
+	ConvertTo                   string
+	RecentlyUsed                string
+	DerivativesFailed           func(sizes string) string
+	Replace                     string
+	CropsDropped                func(sizes string) string
+	ConvertedTo                 func(format string) string
 	SampleArgsText              func(id string) string
+	LocaleVariant               func(locale string) string
+	ContentMismatch             func(name string) string
+	Trash                       string
+	BackToMediaLibrary          string
+	TrashEmpty                  string
+	Restore                     string
+	Restored                    func(name string) string
+	DeletePermanently           string
+	DeletePermanentlyConfirm    func(name string) string
+	PurgedAt                    func(t string) string
+	MediaConflict               string
+	CroppingInBackground        string
+	ViewCropProgress            string
+	Close                       string
+	FilterByTag                 string
+	Selected                    string
+	Tag                         string
+	AddTag                      string
+	RemoveTag                   string
+	Tagged                      func(tag string, count int) string
+	Untagged                    func(tag string, count int) string
+	TypeNotAllowed              func(name string) string
+	Download                    string
+	DownloadOriginal            string
+	Upload                      string
+	DropToUpload                string
+	UploadOneFile               string
+	FileTooLarge                func(name string, max string) string
+	FileNotFound                string
 }
 
 var Messages_en_US = &Messages{
@@ -39,6 +118,79 @@ var Messages_en_US = &Messages{
 	Images:                      "Images",
 	Videos:                      "Videos",
 	Files:                       "Files",
+	PerPage:                     "Per Page",
+	MediaLibrary:                "Media Library",
+	ExternalVideo:               "External Video",
+	VideoLink:                   "YouTube or Vimeo link",
+	UseVideoLink:                "Use Video Link",
+	InvalidVideoLink:            "Only YouTube and Vimeo video links are supported",
+	DescriptionRequired:         "Please add a description for accessibility to the image",
+	DescriptionFormattingHint:   "**bold**, *italic* and [link](https://...) are supported",
+	CopyURL:                     "Copy URL",
+	CopyEmbedHTML:               "Copy Embed HTML",
+	URLCopied:                   "Copied to clipboard",
+	URLExpiresAt: func(t string) string {
+		return fmt.Sprintf("Signed URL, expires at %s", t)
+	},
+	ConvertTo:    "Convert to…",
+	RecentlyUsed: "Recently used",
+	DerivativesFailed: func(sizes string) string {
+		return fmt.Sprintf("Failed to store %s, the changes were not saved", sizes)
+	},
+	Replace: "Replace",
+	CropsDropped: func(sizes string) string {
+		return fmt.Sprintf("The crops of %s don't fit the new file and were dropped", sizes)
+	},
+	ConvertedTo: func(format string) string {
+		return fmt.Sprintf("Converted to %s", format)
+	},
+	LocaleVariant: func(locale string) string {
+		return fmt.Sprintf("%s (uses the default when empty)", locale)
+	},
+	ContentMismatch: func(name string) string {
+		return fmt.Sprintf("The content of %s doesn't match its extension", name)
+	},
+	Trash:              "Trash",
+	BackToMediaLibrary: "Back to Media Library",
+	TrashEmpty:         "Trash is empty",
+	Restore:            "Restore",
+	Restored: func(name string) string {
+		return fmt.Sprintf("%s is restored", name)
+	},
+	DeletePermanently: "Delete Permanently",
+	DeletePermanentlyConfirm: func(name string) string {
+		return fmt.Sprintf("%s will be deleted permanently and can't be restored, continue?", name)
+	},
+	PurgedAt: func(t string) string {
+		return fmt.Sprintf("Will be deleted permanently at %s", t)
+	},
+	MediaConflict:        "The file has been changed by someone else, the latest version is reloaded, please make your change again",
+	CroppingInBackground: "The image is big, it's being cropped in the background and the new crop shows when it's done",
+	ViewCropProgress:     "View progress",
+	Close:                "Close",
+	FilterByTag:          "Filter by tag",
+	Selected:             "Selected:",
+	Tag:                  "Tag",
+	AddTag:               "Add Tag",
+	RemoveTag:            "Remove Tag",
+	Tagged: func(tag string, count int) string {
+		return fmt.Sprintf("%d files are tagged %s", count, tag)
+	},
+	Untagged: func(tag string, count int) string {
+		return fmt.Sprintf("Tag %s is removed from %d files", tag, count)
+	},
+	TypeNotAllowed: func(name string) string {
+		return fmt.Sprintf("%s can't be chosen, the type of the file is not allowed here", name)
+	},
+	Download:         "Download",
+	DownloadOriginal: "Download original",
+	Upload:           "Upload",
+	DropToUpload:     "or drop a file here to upload and choose it",
+	UploadOneFile:    "Only one file can be dropped here",
+	FileTooLarge: func(name string, max string) string {
+		return fmt.Sprintf("%s can't be uploaded, it's larger than %s", name, max)
+	},
+	FileNotFound: "The file is not found, it may have been deleted",
 }
 
 var Messages_zh_CN = &Messages{
@@ -59,6 +211,79 @@ var Messages_zh_CN = &Messages{
 	Images:                      "图片",
 	Videos:                      "视频",
 	Files:                       "文件",
+	PerPage:                     "每页数量",
+	MediaLibrary:                "媒体库",
+	ExternalVideo:               "外部视频",
+	VideoLink:                   "YouTube 或 Vimeo 链接",
+	UseVideoLink:                "使用视频链接",
+	InvalidVideoLink:            "仅支持 YouTube 和 Vimeo 视频链接",
+	DescriptionRequired:         "请填写图片描述",
+	DescriptionFormattingHint:   "支持 **粗体**、*斜体* 和 [链接](https://...)",
+	CopyURL:                     "复制链接",
+	CopyEmbedHTML:               "复制嵌入代码",
+	URLCopied:                   "已复制到剪贴板",
+	URLExpiresAt: func(t string) string {
+		return fmt.Sprintf("签名链接，%s 过期", t)
+	},
+	ConvertTo:    "转换为…",
+	RecentlyUsed: "最近使用",
+	DerivativesFailed: func(sizes string) string {
+		return fmt.Sprintf("%s 保存失败，修改未生效", sizes)
+	},
+	Replace: "替换",
+	CropsDropped: func(sizes string) string {
+		return fmt.Sprintf("%s 的剪裁超出新文件范围，已被移除", sizes)
+	},
+	ConvertedTo: func(format string) string {
+		return fmt.Sprintf("已转换为 %s", format)
+	},
+	LocaleVariant: func(locale string) string {
+		return fmt.Sprintf("%s（为空时使用默认文件）", locale)
+	},
+	ContentMismatch: func(name string) string {
+		return fmt.Sprintf("%s 的内容与扩展名不符", name)
+	},
+	Trash:              "回收站",
+	BackToMediaLibrary: "返回媒体库",
+	TrashEmpty:         "回收站是空的",
+	Restore:            "恢复",
+	Restored: func(name string) string {
+		return fmt.Sprintf("%s 已恢复", name)
+	},
+	DeletePermanently: "永久删除",
+	DeletePermanentlyConfirm: func(name string) string {
+		return fmt.Sprintf("%s 将被永久删除且无法恢复，是否继续？", name)
+	},
+	PurgedAt: func(t string) string {
+		return fmt.Sprintf("将于 %s 永久删除", t)
+	},
+	MediaConflict:        "文件已被其他人修改，已重新加载最新版本，请重新修改",
+	CroppingInBackground: "图片较大，正在后台裁剪，完成后将显示新的裁剪结果",
+	ViewCropProgress:     "查看进度",
+	Close:                "关闭",
+	FilterByTag:          "按标签筛选",
+	Selected:             "已选择：",
+	Tag:                  "标签",
+	AddTag:               "添加标签",
+	RemoveTag:            "移除标签",
+	Tagged: func(tag string, count int) string {
+		return fmt.Sprintf("已为 %d 个文件添加标签 %s", count, tag)
+	},
+	Untagged: func(tag string, count int) string {
+		return fmt.Sprintf("已从 %d 个文件移除标签 %s", count, tag)
+	},
+	TypeNotAllowed: func(name string) string {
+		return fmt.Sprintf("无法选择 %s，此处不允许该类型的文件", name)
+	},
+	Download:         "下载",
+	DownloadOriginal: "下载原图",
+	Upload:           "上传",
+	DropToUpload:     "或将文件拖到此处上传并选择",
+	UploadOneFile:    "此处只能拖入一个文件",
+	FileTooLarge: func(name string, max string) string {
+		return fmt.Sprintf("无法上传 %s，文件大于 %s", name, max)
+	},
+	FileNotFound: "文件不存在，可能已被删除",
 }
 
 var Messages_ja_JP = &Messages{
@@ -79,4 +304,77 @@ var Messages_ja_JP = &Messages{
 	Images:                      "画像",
 	Videos:                      "動画",
 	Files:                       "ファイル",
+	PerPage:                     "表示件数",
+	MediaLibrary:                "メディアライブラリ",
+	ExternalVideo:               "外部動画",
+	VideoLink:                   "YouTube または Vimeo のリンク",
+	UseVideoLink:                "動画リンクを使用",
+	InvalidVideoLink:            "YouTube と Vimeo の動画リンクのみ対応しています",
+	DescriptionRequired:         "画像の説明を入力してください",
+	DescriptionFormattingHint:   "**太字**、*斜体*、[リンク](https://...) が使えます",
+	CopyURL:                     "URLをコピー",
+	CopyEmbedHTML:               "埋め込みHTMLをコピー",
+	URLCopied:                   "クリップボードにコピーしました",
+	URLExpiresAt: func(t string) string {
+		return fmt.Sprintf("署名付きURL、%s に期限切れ", t)
+	},
+	ConvertTo:    "形式を変換…",
+	RecentlyUsed: "最近使用したファイル",
+	DerivativesFailed: func(sizes string) string {
+		return fmt.Sprintf("%s の保存に失敗したため、変更は保存されませんでした", sizes)
+	},
+	Replace: "置き換え",
+	CropsDropped: func(sizes string) string {
+		return fmt.Sprintf("%s のトリミングが新しいファイルに収まらないため削除しました", sizes)
+	},
+	ConvertedTo: func(format string) string {
+		return fmt.Sprintf("%s に変換しました", format)
+	},
+	LocaleVariant: func(locale string) string {
+		return fmt.Sprintf("%s（空の場合はデフォルトを使用）", locale)
+	},
+	ContentMismatch: func(name string) string {
+		return fmt.Sprintf("%s の内容が拡張子と一致しません", name)
+	},
+	Trash:              "ゴミ箱",
+	BackToMediaLibrary: "メディアライブラリに戻る",
+	TrashEmpty:         "ゴミ箱は空です",
+	Restore:            "復元",
+	Restored: func(name string) string {
+		return fmt.Sprintf("%s を復元しました", name)
+	},
+	DeletePermanently: "完全に削除",
+	DeletePermanentlyConfirm: func(name string) string {
+		return fmt.Sprintf("%s は完全に削除され、復元できません。続行しますか？", name)
+	},
+	PurgedAt: func(t string) string {
+		return fmt.Sprintf("%s に完全に削除されます", t)
+	},
+	MediaConflict:        "ファイルが他のユーザーによって変更されました。最新の状態を再読み込みしましたので、もう一度変更してください",
+	CroppingInBackground: "画像が大きいため、バックグラウンドでトリミングしています。完了すると新しいトリミングが表示されます",
+	ViewCropProgress:     "進捗を表示",
+	Close:                "閉じる",
+	FilterByTag:          "タグで絞り込む",
+	Selected:             "選択中：",
+	Tag:                  "タグ",
+	AddTag:               "タグを追加",
+	RemoveTag:            "タグを削除",
+	Tagged: func(tag string, count int) string {
+		return fmt.Sprintf("%d 件のファイルにタグ %s を追加しました", count, tag)
+	},
+	Untagged: func(tag string, count int) string {
+		return fmt.Sprintf("%d 件のファイルからタグ %s を削除しました", count, tag)
+	},
+	TypeNotAllowed: func(name string) string {
+		return fmt.Sprintf("%s は選択できません。このファイルの種類はここでは許可されていません", name)
+	},
+	Download:         "ダウンロード",
+	DownloadOriginal: "元の画像をダウンロード",
+	Upload:           "アップロード",
+	DropToUpload:     "またはここにファイルをドロップしてアップロードして選択",
+	UploadOneFile:    "ここにドロップできるファイルは 1 つだけです",
+	FileTooLarge: func(name string, max string) string {
+		return fmt.Sprintf("%s はアップロードできません。%s を超えています", name, max)
+	},
+	FileNotFound: "ファイルが見つかりません。削除された可能性があります",
 }
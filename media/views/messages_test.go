@@ -0,0 +1,13 @@
+package views
+
+import "testing"
+
+func TestWithEnglishFallback(t *testing.T) {
+	m := withEnglishFallback(&Messages{Crop: "Recadrer"})
+	if m.Crop != "Recadrer" {
+		t.Errorf("translated message is replaced: %q", m.Crop)
+	}
+	if m.Delete != Messages_en_US.Delete || m.LocaleVariant == nil {
+		t.Errorf("missing messages don't fall back to English: %q", m.Delete)
+	}
+}
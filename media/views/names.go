@@ -22,14 +22,42 @@ func dialogContentPortalName(field string) string {
 	return fmt.Sprintf("%s_dialog_content", field)
 }
 
+func moreFilesPortalName(field string, n int) string {
+	return fmt.Sprintf("%s_more_files_%d", field, n)
+}
+
 func searchKeywordName(field string) string {
 	return fmt.Sprintf("%s_file_chooser_search_keyword", field)
 }
 
-func currentPageName(field string) string {
-	return fmt.Sprintf("%s_file_chooser_current_page", field)
+func tagFilterName(field string) string {
+	return fmt.Sprintf("%s_file_chooser_tag", field)
+}
+
+func cursorName(field string) string {
+	return fmt.Sprintf("%s_file_chooser_cursor", field)
+}
+
+func beforeName(field string) string {
+	return fmt.Sprintf("%s_file_chooser_before", field)
+}
+
+func perPageName(field string) string {
+	return fmt.Sprintf("%s_file_chooser_per_page", field)
+}
+
+func replaceFromName(field string) string {
+	return fmt.Sprintf("%s_file_chooser_replace_from", field)
+}
+
+func videoLinkName(field string) string {
+	return fmt.Sprintf("%s_file_chooser_video_link", field)
 }
 
 func fileCroppingVarName(id uint) string {
 	return fmt.Sprintf("fileChooser%d_cropping", id)
 }
+
+func localeVariantFieldName(field string, locale string) string {
+	return fmt.Sprintf("%s.Locales.%s", field, locale)
+}
@@ -0,0 +1,51 @@
+package views
+
+import (
+	"gorm.io/gorm"
+)
+
+type pagination struct {
+	Page       int
+	PerPage    int
+	Total      int64
+	PagesCount int
+}
+
+func newPagination(total int64, page int, perPage int) (p pagination) {
+	if perPage <= 0 {
+		perPage = MediaLibraryPerPage
+	}
+	p = pagination{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		PagesCount: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+	// zero results still show page 1 of 1
+	if p.PagesCount < 1 {
+		p.PagesCount = 1
+	}
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	// e.g. the last item of the last page was deleted
+	if p.Page > p.PagesCount {
+		p.Page = p.PagesCount
+	}
+	return
+}
+
+func (p pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// paginate counts the filtered query and loads the requested page into dest
+func paginate(wh *gorm.DB, page int, perPage int, dest interface{}) (p pagination, err error) {
+	var count int64
+	if err = wh.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return
+	}
+	p = newPagination(count, page, perPage)
+	err = wh.Limit(p.PerPage).Offset(p.Offset()).Find(dest).Error
+	return
+}
@@ -0,0 +1,38 @@
+package views
+
+import (
+	"testing"
+)
+
+func TestNewPagination(t *testing.T) {
+	cases := []struct {
+		name           string
+		total          int64
+		page           int
+		perPage        int
+		wantPage       int
+		wantPagesCount int
+		wantOffset     int
+	}{
+		{name: "zero results", total: 0, page: 1, perPage: 10, wantPage: 1, wantPagesCount: 1, wantOffset: 0},
+		{name: "zero page", total: 5, page: 0, perPage: 10, wantPage: 1, wantPagesCount: 1, wantOffset: 0},
+		{name: "exact multiple", total: 20, page: 2, perPage: 10, wantPage: 2, wantPagesCount: 2, wantOffset: 10},
+		{name: "one more than multiple", total: 21, page: 3, perPage: 10, wantPage: 3, wantPagesCount: 3, wantOffset: 20},
+		{name: "page beyond last", total: 20, page: 3, perPage: 10, wantPage: 2, wantPagesCount: 2, wantOffset: 10},
+		{name: "default per page", total: 40, page: 2, perPage: 0, wantPage: 2, wantPagesCount: 2, wantOffset: MediaLibraryPerPage},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := newPagination(c.total, c.page, c.perPage)
+			if p.Page != c.wantPage {
+				t.Errorf("page = %d, want %d", p.Page, c.wantPage)
+			}
+			if p.PagesCount != c.wantPagesCount {
+				t.Errorf("pages count = %d, want %d", p.PagesCount, c.wantPagesCount)
+			}
+			if p.Offset() != c.wantOffset {
+				t.Errorf("offset = %d, want %d", p.Offset(), c.wantOffset)
+			}
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package views
 
-import "net/http"
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/qor5/admin/media/media_library"
+	"gorm.io/gorm"
+)
 
 // DO NOT associate media_library permissions with parent resources
 // WRONG: permPolicy.On("*:post:*")
@@ -11,8 +17,37 @@ const (
 	PermUpload     = "perm_media_library_upload"
 	PermDelete     = "perm_media_library_delete"
 	PermUpdateDesc = "perm_media_library_update_desc"
+	PermView       = "perm_media_library_view"
+	PermConvert    = "perm_media_library_convert"
+	PermList       = "perm_media_library_list"
 )
 
+// MediaAuthorizer decides whether the current user can see the media,
+// unauthorized users get a locked placeholder instead of the media URL
+type MediaAuthorizer func(r *http.Request, obj *media_library.MediaLibrary) error
+
+var mediaAuthorizer MediaAuthorizer
+
+// Authorize sets the authorizer consulted before rendering media URLs, all media are visible if not set.
+// Use PermViewAuthorizer to check the PermView permission of the role/perm policies.
+func Authorize(v MediaAuthorizer) {
+	mediaAuthorizer = v
+}
+
+func PermViewAuthorizer(r *http.Request, obj *media_library.MediaLibrary) error {
+	return permVerifier.Do(PermView).ObjectOn(obj).WithReq(r).IsAllowed()
+}
+
+// listIsAllowed gates browsing the files, in the media library page and the file chooser
+func listIsAllowed(r *http.Request) error {
+	return permVerifier.Do(PermList).On("media_libraries").WithReq(r).IsAllowed()
+}
+
+// trashIsAllowed gates the trash, files in it can be restored or purged by whom can delete them
+func trashIsAllowed(r *http.Request) error {
+	return permVerifier.Do(PermDelete).On("media_libraries").WithReq(r).IsAllowed()
+}
+
 func uploadIsAllowed(r *http.Request) error {
 	return permVerifier.Do(PermUpload).On("media_libraries").WithReq(r).IsAllowed()
 }
@@ -24,3 +59,25 @@ func deleteIsAllowed(r *http.Request, obj interface{}) error {
 func updateDescIsAllowed(r *http.Request, obj interface{}) error {
 	return permVerifier.Do(PermUpdateDesc).ObjectOn(obj).WithReq(r).IsAllowed()
 }
+
+func convertIsAllowed(r *http.Request, obj interface{}) error {
+	return permVerifier.Do(PermConvert).ObjectOn(obj).WithReq(r).IsAllowed()
+}
+
+func viewIsAllowed(r *http.Request, obj *media_library.MediaLibrary) bool {
+	if mediaAuthorizer == nil {
+		return true
+	}
+	return mediaAuthorizer(r, obj) == nil
+}
+
+func mediaBoxViewIsAllowed(r *http.Request, mediaBox *media_library.MediaBox) bool {
+	if mediaAuthorizer == nil {
+		return true
+	}
+	id, err := strconv.ParseUint(mediaBox.ID.String(), 10, 64)
+	if err != nil {
+		return true
+	}
+	return viewIsAllowed(r, &media_library.MediaLibrary{Model: gorm.Model{ID: uint(id)}})
+}
@@ -0,0 +1,93 @@
+package views
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/media/media_library"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+)
+
+// MediaLibraryRecentlyUsedCount is the number of recently used files shown on top of the file chooser
+var MediaLibraryRecentlyUsedCount = 6
+
+var recentUserIDFunc func(r *http.Request) string
+
+// TrackRecentlyUsed records the files chosen by the user returned by f,
+// and shows them in a "Recently used" band of the file chooser
+func TrackRecentlyUsed(f func(r *http.Request) string) {
+	recentUserIDFunc = f
+}
+
+func recentUserID(r *http.Request) string {
+	if recentUserIDFunc == nil {
+		return ""
+	}
+	return recentUserIDFunc(r)
+}
+
+func recordRecentUse(db *gorm.DB, r *http.Request, id uint) error {
+	userID := recentUserID(r)
+	if userID == "" {
+		return nil
+	}
+	var use media_library.MediaLibraryRecentUse
+	return db.Where(media_library.MediaLibraryRecentUse{UserID: userID, MediaLibraryID: id}).
+		Assign(media_library.MediaLibraryRecentUse{UsedAt: time.Now()}).
+		FirstOrCreate(&use).Error
+}
+
+func recentlyUsedFiles(db *gorm.DB, r *http.Request, cfg *media_library.MediaBoxConfig) (files []*media_library.MediaLibrary, err error) {
+	userID := recentUserID(r)
+	if userID == "" || MediaLibraryRecentlyUsedCount <= 0 {
+		return
+	}
+	wh := db.Model(&media_library.MediaLibrary{}).
+		Joins("JOIN media_library_recent_uses ON media_library_recent_uses.media_library_id = media_libraries.id").
+		Where("media_library_recent_uses.user_id = ?", userID)
+	if len(cfg.AllowType) > 0 {
+		wh = wh.Where("media_libraries.selected_type = ?", cfg.AllowType)
+	}
+	err = wh.Order("media_library_recent_uses.used_at DESC").
+		Limit(MediaLibraryRecentlyUsedCount).
+		Find(&files).Error
+	return
+}
+
+func recentlyUsedRow(msgr *Messages, r *http.Request, files []*media_library.MediaLibrary, field string, cfg *media_library.MediaBoxConfig) h.HTMLComponent {
+	row := VRow()
+	for _, f := range files {
+		if !viewIsAllowed(r, f) {
+			continue
+		}
+		row.AppendChildren(
+			VCol(
+				VCard(
+					h.If(media.IsImageFormat(f.File.FileName),
+						VImg().Src(f.File.URL(media_library.QorPreviewSizeName)).LazySrc(ThumbnailPlaceholder(f)).
+							Options(lazyThumbOptions).Height(100).Contain(true),
+					).Else(
+						h.Div(fileThumb(f.File.FileName)).Style("height: 100px; overflow: hidden"),
+					),
+					VCardText(h.Text(f.File.FileName)).Class("text-truncate pa-2"),
+				).Attr("role", "button").
+					Attr("@click", web.Plaid().
+						EventFunc(chooseFileEvent).
+						Query("field", field).
+						Query("id", fmt.Sprint(f.ID)).
+						FieldValue("cfg", h.JSONString(cfg)).
+						Go()),
+			).Cols(4).Sm(3).Md(2),
+		)
+	}
+	return h.Div(
+		h.Div(h.Text(msgr.RecentlyUsed)).Class("text-subtitle-1 mb-2"),
+		row,
+		VDivider().Class("my-4"),
+	)
+}
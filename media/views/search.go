@@ -0,0 +1,58 @@
+package views
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SearchFunc filters the files of the media library by the keyword of the file chooser and orders them by relevance,
+// replace it to search with another index.
+var SearchFunc = defaultSearch
+
+// searchVector is the words of the file name and description of a file, the search index is created on it
+const searchVector = `to_tsvector('simple', regexp_replace(coalesce(NULLIF(file, '')::jsonb->>'FileName', ''), '[^[:alnum:]]+', ' ', 'g') || ' ' || coalesce(NULLIF(file, '')::jsonb->>'Description', ''))`
+
+var nonWordChars = regexp.MustCompile(`[^\pL\pN]+`)
+
+// prefixTSQuery makes a tsquery matching the words starting with each word of the keyword,
+// so that "IMG_12" finds the file "IMG_1234.jpg"
+func prefixTSQuery(keyword string) string {
+	var terms []string
+	for _, w := range strings.Fields(nonWordChars.ReplaceAllString(keyword, " ")) {
+		terms = append(terms, strings.ToLower(w)+":*")
+	}
+	return strings.Join(terms, " & ")
+}
+
+// defaultSearch uses the full text search of Postgres with prefix matches, or'ed with ILIKE for the matches in the
+// middle of a word, the other databases match each word of the keyword with ILIKE
+func defaultSearch(db *gorm.DB, keyword string) *gorm.DB {
+	if db.Dialector.Name() != "postgres" {
+		for _, w := range strings.Fields(keyword) {
+			db = db.Where("file ILIKE ?", fmt.Sprintf("%%%s%%", w))
+		}
+		return db
+	}
+	like := fmt.Sprintf("%%%s%%", strings.TrimSpace(keyword))
+	query := prefixTSQuery(keyword)
+	if query == "" {
+		return db.Where("file ILIKE ?", like)
+	}
+	return db.Where(searchVector+" @@ to_tsquery('simple', ?) OR file ILIKE ?", query, like).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(" + searchVector + ", to_tsquery('simple', ?)) DESC",
+			Vars:               []interface{}{query},
+			WithoutParentheses: true,
+		}})
+}
+
+func createSearchIndex(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_media_libraries_search ON media_libraries USING GIN (" + searchVector + ")").Error
+}
@@ -0,0 +1,17 @@
+package views
+
+import "testing"
+
+func TestPrefixTSQuery(t *testing.T) {
+	for keyword, want := range map[string]string{
+		"IMG_12":              "img:* & 12:*",
+		"  sunset  beach":     "sunset:* & beach:*",
+		"it's a 'test' & | !": "it:* & s:* & a:* & test:*",
+		"équipe":              "équipe:*",
+		"_-.":                 "",
+	} {
+		if got := prefixTSQuery(keyword); got != want {
+			t.Errorf("prefixTSQuery(%q) = %q, want %q", keyword, got, want)
+		}
+	}
+}
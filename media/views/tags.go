@@ -0,0 +1,178 @@
+package views
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/presets"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// allTagNames returns the names of the tags in use, for the autocompletion of the tag filter and the tag input
+func allTagNames(db *gorm.DB) (names []string, err error) {
+	err = db.Model(&media_library.MediaLibraryTag{}).Distinct("name").Order("name").Pluck("name", &names).Error
+	return
+}
+
+// filesTags returns the tag names of the files by their ids
+func filesTags(db *gorm.DB, files []*media_library.MediaLibrary) (map[uint][]string, error) {
+	var ids []uint
+	for _, f := range files {
+		ids = append(ids, f.ID)
+	}
+	var tags []media_library.MediaLibraryTag
+	if len(ids) > 0 {
+		if err := db.Where("media_library_id IN ?", ids).Order("name").Find(&tags).Error; err != nil {
+			return nil, err
+		}
+	}
+	r := map[uint][]string{}
+	for _, t := range tags {
+		r[t.MediaLibraryID] = append(r[t.MediaLibraryID], t.Name)
+	}
+	return r, nil
+}
+
+// parseIDs parses the comma separated ids of the selected files
+func parseIDs(s string) (ids []uint) {
+	for _, v := range strings.Split(s, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+		if err != nil || id == 0 {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return
+}
+
+func tagFilter(ctx *web.EventContext, field string, cfg *media_library.MediaBoxConfig, tags []string, current string) h.HTMLComponent {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+
+	onChange := web.Plaid().
+		EventFunc(imageSearchEvent).
+		Query("field", field).
+		FieldValue(tagFilterName(field), web.Var("$event || ''")).
+		FieldValue("cfg", h.JSONString(cfg)).
+		Go()
+	if field == mediaLibraryListField {
+		onChange = web.GET().PushState(true).
+			Query(tagFilterName(field), web.Var("$event || ''")).
+			MergeQuery(true).Go()
+	}
+	return VAutocomplete().
+		Items(tags).
+		Label(msgr.FilterByTag).
+		Value(current).
+		FieldName(tagFilterName(field)).
+		Clearable(true).
+		Attr("@change", onChange).
+		Dense(true).Solo(true).Class("mb-n8")
+}
+
+// bulkTagBar tags or untags the files checked in the media library page
+func bulkTagBar(ctx *web.EventContext, cfg *media_library.MediaBoxConfig, tags []string) h.HTMLComponent {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+
+	event := func(untag bool) string {
+		e := web.Plaid().
+			EventFunc(bulkTagEvent).
+			Query("field", mediaLibraryListField).
+			FieldValue("ids", web.Var("locals.selectedMediaIDs.join(',')")).
+			FieldValue("tag", web.Var("locals.bulkTag || ''")).
+			FieldValue("cfg", h.JSONString(cfg))
+		if untag {
+			e = e.Query("untag", "1")
+		}
+		return e.Go()
+	}
+	return VRow(
+		VCol(
+			h.Div(h.Text(msgr.Selected+" "), h.Span("").Attr("v-text", "locals.selectedMediaIDs.length")).
+				Class("text-body-2"),
+		).Cols(3).Class("d-flex align-center"),
+		VCol(
+			VCombobox().
+				Items(tags).
+				Label(msgr.Tag).
+				Attr("v-model", "locals.bulkTag").
+				Dense(true).Solo(true).HideDetails(true),
+		).Cols(5),
+		VCol(
+			VBtn(msgr.AddTag).Color("primary").Depressed(true).
+				Attr(":disabled", "locals.selectedMediaIDs.length == 0 || !locals.bulkTag").
+				Attr("@click", event(false)),
+			VBtn(msgr.RemoveTag).Text(true).Class("ml-2").
+				Attr(":disabled", "locals.selectedMediaIDs.length == 0 || !locals.bulkTag").
+				Attr("@click", event(true)),
+		).Cols(4).Class("d-flex align-center"),
+	).Attr("v-if", "locals.selectedMediaIDs.length > 0")
+}
+
+func tagChips(tags []string) h.HTMLComponent {
+	if len(tags) == 0 {
+		return nil
+	}
+	var chips []h.HTMLComponent
+	for _, t := range tags {
+		chips = append(chips, VChip(h.Text(t)).XSmall(true).Outlined(true).Class("mr-1 mt-1"))
+	}
+	return h.Div(chips...)
+}
+
+func bulkTag(b *Builder, db *gorm.DB) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+		field := ctx.R.FormValue("field")
+		cfg := stringToCfg(ctx.R.FormValue("cfg"))
+		untag := ctx.R.FormValue("untag") != ""
+
+		name := media_library.NormalizeTag(ctx.R.FormValue("tag"))
+		ids := parseIDs(ctx.R.FormValue("ids"))
+		if name == "" || len(ids) == 0 {
+			return
+		}
+		var files []*media_library.MediaLibrary
+		if err = db.Where("id IN ?", ids).Find(&files).Error; err != nil {
+			return
+		}
+		for _, f := range files {
+			if err = updateDescIsAllowed(ctx.R, f); err != nil {
+				return
+			}
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, f := range files {
+				if untag {
+					if err := tx.Where("media_library_id = ? AND name = ?", f.ID, name).
+						Delete(&media_library.MediaLibraryTag{}).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+					Create(&media_library.MediaLibraryTag{MediaLibraryID: f.ID, Name: name}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+
+		if untag {
+			presets.ShowMessage(&r, msgr.Untagged(name, len(files)), "")
+		} else {
+			presets.ShowMessage(&r, msgr.Tagged(name, len(files)), "")
+		}
+		renderFileChooserDialogContent(ctx, &r, field, db, cfg)
+		return
+	}
+}
@@ -0,0 +1,240 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/presets"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+)
+
+// TrashRetention is how long deleted files stay in the trash before PurgeTrash deletes them permanently
+var TrashRetention = 30 * 24 * time.Hour
+
+// TrashPerPage is how many files a page of the trash shows
+var TrashPerPage = 48
+
+const (
+	trashParam      = "trash"
+	trashPageParam  = "trash_page"
+	trashPortalName = "mediaLibrary_TrashPortal"
+
+	purgeTrashBatchSize = 100
+)
+
+// PurgeTrash permanently deletes the files that have been in the trash longer than TrashRetention,
+// run it from a scheduled job, e.g. a worker cron job. It loads the files in batches and stops once ctx is done.
+func (b *Builder) PurgeTrash(ctx context.Context) (count int, err error) {
+	db := b.db
+	before := time.Now().Add(-TrashRetention)
+	for {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		// purged files are gone from the query, so every batch is the first one
+		var files []*media_library.MediaLibrary
+		if err = db.WithContext(ctx).Unscoped().Where("deleted_at < ?", before).
+			Order("id").Limit(purgeTrashBatchSize).Find(&files).Error; err != nil {
+			return
+		}
+		if len(files) == 0 {
+			return
+		}
+		for _, f := range files {
+			if err = b.purge(db, f); err != nil {
+				return
+			}
+			count++
+		}
+	}
+}
+
+// purge deletes the record and then its stored files, a file that fails to be removed is left in the storage
+func (b *Builder) purge(db *gorm.DB, f *media_library.MediaLibrary) error {
+	if err := db.Unscoped().Delete(&media_library.MediaLibrary{}, f.ID).Error; err != nil {
+		return err
+	}
+	if err := db.Where("media_library_id = ?", f.ID).Delete(&media_library.MediaLibraryTag{}).Error; err != nil {
+		return err
+	}
+	removeStoredFiles(f)
+	b.fireWebhooks(WebhookEventPurged, f)
+	return nil
+}
+
+// removeStoredFiles removes the file and the sizes of f from the storage, ignoring the ones failing to be removed
+func removeStoredFiles(f *media_library.MediaLibrary) {
+	if f.File.Url == "" {
+		return
+	}
+	urls := []string{f.File.URL(), f.File.URL("original")}
+	for k := range f.File.GetSizes() {
+		urls = append(urls, f.File.URL(k))
+	}
+	for _, url := range urls {
+		f.File.Remove(url)
+	}
+}
+
+// restoreDerivatives regenerates the sizes of a restored image if any of them is missing in the storage
+func restoreDerivatives(db *gorm.DB, m *media_library.MediaLibrary) error {
+	if !media.IsImageFormat(m.File.FileName) {
+		return nil
+	}
+	var missing bool
+	for k := range m.File.GetSizes() {
+		f, err := m.File.Retrieve(m.File.URL(k))
+		if err != nil {
+			missing = true
+			break
+		}
+		f.Close()
+	}
+	if !missing {
+		return nil
+	}
+	if err := m.ScanMediaOptions(media_library.MediaOption{
+		Sizes: m.File.Sizes,
+		Crop:  true,
+	}); err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(m).Error; err != nil {
+			return err
+		}
+		return media.SaveUploadAndCropImage(tx, m)
+	})
+}
+
+func trashContent(db *gorm.DB, ctx *web.EventContext) (h.HTMLComponent, error) {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+
+	page, _ := strconv.Atoi(ctx.R.FormValue(trashPageParam))
+	var files []*media_library.MediaLibrary
+	pg, err := paginate(db.Model(&media_library.MediaLibrary{}).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC"),
+		page, TrashPerPage, &files)
+	if err != nil {
+		return nil, err
+	}
+
+	row := VRow()
+	for _, f := range files {
+		if !viewIsAllowed(ctx.R, f) {
+			continue
+		}
+		var actions []h.HTMLComponent
+		if deleteIsAllowed(ctx.R, f) == nil {
+			actions = append(actions,
+				VBtn(msgr.Restore).Text(true).Color("primary").
+					Attr("@click", web.Plaid().EventFunc(restoreFileEvent).Query("id", fmt.Sprint(f.ID)).Go()),
+				VSpacer(),
+				VBtn(msgr.DeletePermanently).Text(true).Color("error").
+					Attr("@click", fmt.Sprintf("confirm(%q) && %s", msgr.DeletePermanentlyConfirm(f.File.FileName),
+						web.Plaid().EventFunc(purgeFileEvent).Query("id", fmt.Sprint(f.ID)).Go())),
+			)
+		}
+		row.AppendChildren(
+			VCol(
+				VCard(
+					h.If(media.IsImageFormat(f.File.FileName),
+						VImg().Src(f.File.URL(media_library.QorPreviewSizeName)).LazySrc(ThumbnailPlaceholder(f)).
+							Options(lazyThumbOptions).Height(200).Contain(true),
+					).Else(
+						fileThumb(f.File.FileName),
+					),
+					VCardText(
+						h.Div(h.Text(f.File.FileName)).Class("text-truncate"),
+						h.Div(h.Text(msgr.PurgedAt(presets.LocalTime(ctx.R, f.DeletedAt.Time.Add(TrashRetention)).Format("2006-01-02 15:04")))).
+							Class("text-caption grey--text"),
+					),
+					VCardActions(actions...),
+				),
+			).Cols(6).Sm(4).Md(3),
+		)
+	}
+
+	return VContainer(
+		VRow(
+			VCol(
+				VBtn("").Text(true).Children(
+					VIcon("arrow_back").Left(true),
+					h.Text(msgr.BackToMediaLibrary),
+				).Attr("@click", web.Plaid().Query(trashParam, "").PushState(true).Go()),
+			),
+		),
+		h.If(len(files) == 0,
+			h.Div(h.Text(msgr.TrashEmpty)).Class("grey--text pa-4"),
+		),
+		row,
+		h.If(pg.PagesCount > 1,
+			VPagination().
+				Length(pg.PagesCount).
+				Value(pg.Page).
+				Attr("@input", web.Plaid().Query(trashPageParam, web.Var("$event")).MergeQuery(true).PushState(true).Go()),
+		),
+	).Fluid(true), nil
+}
+
+func renderTrash(db *gorm.DB, ctx *web.EventContext, r *web.EventResponse) error {
+	body, err := trashContent(db, ctx)
+	if err != nil {
+		return err
+	}
+	r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+		Name: trashPortalName,
+		Body: body,
+	})
+	return nil
+}
+
+func restoreFile(b *Builder, db *gorm.DB) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMediaLibraryKey, Messages_en_US).(*Messages)
+
+		var m media_library.MediaLibrary
+		if err = db.Unscoped().Where("deleted_at IS NOT NULL").First(&m, ctx.QueryAsInt("id")).Error; err != nil {
+			return
+		}
+		if err = deleteIsAllowed(ctx.R, &m); err != nil {
+			return
+		}
+		if err = db.Unscoped().Model(&m).Update("deleted_at", nil).Error; err != nil {
+			return
+		}
+		m.DeletedAt = gorm.DeletedAt{}
+		b.fireWebhooks(WebhookEventRestored, &m)
+		if rerr := restoreDerivatives(db, &m); rerr != nil {
+			presets.ShowMessage(&r, derivativeErrorMessage(ctx, rerr), "warning")
+		} else {
+			presets.ShowMessage(&r, msgr.Restored(m.File.FileName), "")
+		}
+		err = renderTrash(db, ctx, &r)
+		return
+	}
+}
+
+func purgeFile(b *Builder, db *gorm.DB) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		var m media_library.MediaLibrary
+		if err = db.Unscoped().Where("deleted_at IS NOT NULL").First(&m, ctx.QueryAsInt("id")).Error; err != nil {
+			return
+		}
+		if err = deleteIsAllowed(ctx.R, &m); err != nil {
+			return
+		}
+		if err = b.purge(db, &m); err != nil {
+			return
+		}
+		err = renderTrash(db, ctx, &r)
+		return
+	}
+}
@@ -0,0 +1,97 @@
+package views
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qor5/admin/media/media_library"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPurgeTrash(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&media_library.MediaLibrary{}, &media_library.MediaLibraryTag{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := time.Now().Add(-TrashRetention - time.Hour)
+	for i := 0; i < purgeTrashBatchSize+5; i++ {
+		m := &media_library.MediaLibrary{Model: gorm.Model{DeletedAt: gorm.DeletedAt{Time: expired, Valid: true}}}
+		if err = db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	recent := &media_library.MediaLibrary{Model: gorm.Model{DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}}
+	if err = db.Create(recent).Error; err != nil {
+		t.Fatal(err)
+	}
+	kept := &media_library.MediaLibrary{}
+	if err = db.Create(kept).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := New(db).PurgeTrash(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != purgeTrashBatchSize+5 {
+		t.Fatalf("expected %d files purged, got %d", purgeTrashBatchSize+5, count)
+	}
+	var left int64
+	if err = db.Unscoped().Model(&media_library.MediaLibrary{}).Count(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if left != 2 {
+		t.Fatalf("expected the recently deleted and the live file to be left, got %d files", left)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = New(db).PurgeTrash(ctx); err != context.Canceled {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+}
+
+func TestPurgeTrashFiresWebhooks(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&media_library.MediaLibrary{}, &media_library.MediaLibraryTag{}); err != nil {
+		t.Fatal(err)
+	}
+	m := &media_library.MediaLibrary{Model: gorm.Model{DeletedAt: gorm.DeletedAt{Time: time.Now().Add(-TrashRetention - time.Hour), Valid: true}}}
+	if err = db.Create(m).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(WebhookSignatureHeader) != WebhookSignature("secret", r.Header.Get(WebhookTimestampHeader), body) {
+			t.Error("wrong signature")
+		}
+		got <- r
+	}))
+	defer srv.Close()
+
+	if _, err = New(db).Webhooks(Webhook{URL: srv.URL, Secret: "secret"}).PurgeTrash(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-got:
+		if v := r.Header.Get(WebhookEventHeader); v != WebhookEventPurged {
+			t.Errorf("got event %q", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook is not sent")
+	}
+}
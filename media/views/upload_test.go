@@ -0,0 +1,171 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qor/oss/filesystem"
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/media/oss"
+	"github.com/qor5/web"
+	"github.com/qor5/x/perm"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newUploadContext makes the event context of uploading a file named name in the context rctx
+func newUploadContext(t *testing.T, rctx context.Context, name string) (*web.EventContext, *multipart.FileHeader) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile("NewFiles", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte("hello"))
+	w.Close()
+	r := httptest.NewRequest("POST", "/", &body).WithContext(rctx)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	if err = r.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	return &web.EventContext{R: r}, r.MultipartForm.File["NewFiles"][0]
+}
+
+// useTestStorage stores the files in a temp dir during the test, and returns a func listing them
+func useTestStorage(t *testing.T) func() []string {
+	dir := t.TempDir()
+	old := oss.Storage
+	t.Cleanup(func() { oss.Storage = old })
+	oss.Storage = filesystem.New(dir)
+	return func() (files []string) {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		return
+	}
+}
+
+func TestSaveUploadRemovesFilesOfCancelledUpload(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&media_library.MediaLibrary{}); err != nil {
+		t.Fatal(err)
+	}
+	storedFiles := useTestStorage(t)
+
+	rctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, fh := newUploadContext(t, rctx, "a.txt")
+	b := New(db).OnUpload(func(m *media_library.MediaLibrary) error {
+		if len(storedFiles()) == 0 {
+			t.Error("the file isn't stored before the upload hooks")
+		}
+		// the request is cancelled after the file is stored
+		cancel()
+		return nil
+	})
+
+	_, msg, err := saveUpload(b, db.WithContext(rctx), ctx, fh, &media_library.MediaBoxConfig{})
+	if err != nil || msg == "" {
+		t.Fatalf("the cancelled upload is not refused: %q, %v", msg, err)
+	}
+	if files := storedFiles(); len(files) != 0 {
+		t.Errorf("the files of the cancelled upload are left: %v", files)
+	}
+	var count int64
+	db.Model(&media_library.MediaLibrary{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d records are saved", count)
+	}
+}
+
+func TestSaveUploadRemovesFilesOfRejectedUpload(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&media_library.MediaLibrary{}); err != nil {
+		t.Fatal(err)
+	}
+	storedFiles := useTestStorage(t)
+
+	ctx, fh := newUploadContext(t, context.Background(), "a.txt")
+	b := New(db).OnUpload(func(m *media_library.MediaLibrary) error {
+		return errors.New("file is infected")
+	})
+	_, msg, err := saveUpload(b, db, ctx, fh, &media_library.MediaBoxConfig{})
+	if err != nil || msg != "file is infected" {
+		t.Fatalf("the upload is not rejected by the hook: %q, %v", msg, err)
+	}
+	if files := storedFiles(); len(files) != 0 {
+		t.Errorf("the files of the rejected upload are left: %v", files)
+	}
+	var count int64
+	db.Model(&media_library.MediaLibrary{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d records are saved", count)
+	}
+}
+
+func TestUploadFileUploadsTheFilesAfterARejectedOne(t *testing.T) {
+	defer func(v *perm.Verifier) { permVerifier = v }(permVerifier)
+	permVerifier = perm.NewVerifier("media_library", nil)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&media_library.MediaLibrary{}, &media_library.MediaLibraryTag{}); err != nil {
+		t.Fatal(err)
+	}
+	useTestStorage(t)
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		fw, err := w.CreateFormFile("NewFiles", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte("hello"))
+	}
+	w.WriteField("field", "Image")
+	w.WriteField("cfg", "{}")
+	w.Close()
+	r := httptest.NewRequest("POST", "/", &body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	if err = r.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	b := New(db).OnUpload(func(m *media_library.MediaLibrary) error {
+		if m.File.FileName == "b.txt" || m.File.FileName == "d.txt" {
+			return fmt.Errorf("%s is infected", m.File.FileName)
+		}
+		return nil
+	})
+	res, err := uploadFile(b, db)(&web.EventContext{R: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	db.Model(&media_library.MediaLibrary{}).Order("id").Pluck("file", &names)
+	if len(names) != 2 || !strings.Contains(names[0], "a.txt") || !strings.Contains(names[1], "c.txt") {
+		t.Errorf("uploaded %v, want a.txt and c.txt", names)
+	}
+	if !strings.Contains(res.VarsScript, "b.txt is infected; d.txt is infected") {
+		t.Errorf("the rejected files are not reported: %s", res.VarsScript)
+	}
+}
@@ -0,0 +1,79 @@
+package views
+
+import (
+	"context"
+	"errors"
+
+	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/media/media_library"
+	"gorm.io/gorm"
+)
+
+// ErrUnsupportedFormat is reported by WarmThumbnails for files that have no sizes, like videos and SVGs
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// WarmThumbnailResult is reported by WarmThumbnails after each file,
+// Skipped is true when all the sizes were already generated.
+type WarmThumbnailResult struct {
+	ID      uint
+	Done    int
+	Total   int
+	Skipped bool
+	Err     error
+}
+
+// WarmThumbnails generates the missing sizes of the files, so choosing them in a media box with these sizes
+// doesn't wait for the crop. A failed file is reported and the others go on, it stops when ctx is done.
+func WarmThumbnails(ctx context.Context, db *gorm.DB, ids []uint, sizes map[string]*media.Size, progress func(r WarmThumbnailResult)) error {
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r := WarmThumbnailResult{ID: id, Done: i + 1, Total: len(ids)}
+		r.Skipped, r.Err = warmThumbnails(db, id, sizes)
+		if progress != nil {
+			progress(r)
+		}
+	}
+	return nil
+}
+
+func warmThumbnails(db *gorm.DB, id uint, sizes map[string]*media.Size) (skipped bool, err error) {
+	var m media_library.MediaLibrary
+	if err = db.First(&m, id).Error; err != nil {
+		return
+	}
+	if !m.File.IsImage() {
+		return false, ErrUnsupportedFormat
+	}
+
+	merged, _ := mergeNewSizes(&m, &media_library.MediaBoxConfig{Sizes: sizes})
+	var missing bool
+	for k := range m.File.GetSizes() {
+		if m.File.FileSizes[k] == 0 {
+			missing = true
+		}
+	}
+	for k := range merged {
+		if m.File.FileSizes[k] == 0 {
+			missing = true
+		}
+	}
+	if !missing {
+		return true, nil
+	}
+
+	if err = m.ScanMediaOptions(media_library.MediaOption{
+		Sizes: merged,
+		Crop:  true,
+	}); err != nil {
+		return
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&m).Error; err != nil {
+			return err
+		}
+		return media.SaveUploadAndCropImage(tx, &m)
+	})
+	return
+}
@@ -0,0 +1,173 @@
+package views
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/qor5/admin/media/media_library"
+)
+
+const (
+	WebhookEventUploaded           = "media.uploaded"
+	WebhookEventDescriptionUpdated = "media.description_updated"
+	WebhookEventDeleted            = "media.deleted"
+	WebhookEventRestored           = "media.restored"
+	WebhookEventPurged             = "media.purged"
+
+	// WebhookSignatureHeader is the hex HMAC-SHA256 of the timestamp header, a dot and the body, keyed by the secret
+	WebhookSignatureHeader = "X-Media-Signature"
+	WebhookTimestampHeader = "X-Media-Timestamp"
+	WebhookEventHeader     = "X-Media-Event"
+)
+
+// Webhook is an endpoint notified with a POST of the media metadata after a file is uploaded, described, deleted,
+// restored from the trash or purged
+type Webhook struct {
+	URL    string
+	Secret string
+}
+
+// Webhooks adds webhooks notified of the changes of the media library, they are sent in the background
+func (b *Builder) Webhooks(hooks ...Webhook) *Builder {
+	b.webhooks = append(b.webhooks, hooks...)
+	return b
+}
+
+// WebhookClient sends the webhooks, the default one times out after 10 seconds
+func (b *Builder) WebhookClient(v *http.Client) *Builder {
+	b.webhookClient = v
+	return b
+}
+
+// WebhookRetries is how many times a failed webhook is retried, waiting backoff doubled after each attempt,
+// 3 times from a second by default
+func (b *Builder) WebhookRetries(v int, backoff time.Duration) *Builder {
+	b.webhookRetries = v
+	b.webhookBackoff = backoff
+	return b
+}
+
+type WebhookPayload struct {
+	Event string        `json:"event"`
+	Time  time.Time     `json:"time"`
+	Media MediaMetadata `json:"media"`
+}
+
+// MediaMetadata is the JSON of a file of the media library in the API and the webhooks
+type MediaMetadata struct {
+	ID           uint              `json:"id"`
+	FileName     string            `json:"file_name"`
+	URL          string            `json:"url,omitempty"`
+	SizeURLs     map[string]string `json:"size_urls,omitempty"`
+	URLExpiresAt *time.Time        `json:"url_expires_at,omitempty"`
+	// Locked files are not visible to the requester, their URLs are left out
+	Locked       bool           `json:"locked,omitempty"`
+	SelectedType string         `json:"selected_type"`
+	Description  string         `json:"description"`
+	Width        int            `json:"width,omitempty"`
+	Height       int            `json:"height,omitempty"`
+	FileSizes    map[string]int `json:"file_sizes,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+func newMediaMetadata(m *media_library.MediaLibrary) MediaMetadata {
+	md := MediaMetadata{
+		ID:           m.ID,
+		FileName:     m.File.FileName,
+		URL:          m.File.URL(),
+		SelectedType: m.SelectedType,
+		Description:  m.File.Description,
+		Width:        m.File.Width,
+		Height:       m.File.Height,
+		FileSizes:    m.File.FileSizes,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+	for k := range m.File.GetSizes() {
+		if md.SizeURLs == nil {
+			md.SizeURLs = make(map[string]string)
+		}
+		md.SizeURLs[k] = m.File.URL(k)
+	}
+	if t, ok := signedURLExpiresAt(md.URL); ok {
+		md.URLExpiresAt = &t
+	}
+	return md
+}
+
+func (b *Builder) fireWebhooks(event string, m *media_library.MediaLibrary) {
+	if len(b.webhooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(WebhookPayload{
+		Event: event,
+		Time:  time.Now(),
+		Media: newMediaMetadata(m),
+	})
+	if err != nil {
+		log.Printf("media webhook %s of %d: %v", event, m.ID, err)
+		return
+	}
+	for _, hook := range b.webhooks {
+		go func(hook Webhook) {
+			if err := b.deliverWebhook(hook, event, body); err != nil {
+				log.Printf("media webhook %s of %d to %s: %v", event, m.ID, hook.URL, err)
+			}
+		}(hook)
+	}
+}
+
+// deliverWebhook retries the network errors, the 429 and the 5xx responses
+func (b *Builder) deliverWebhook(hook Webhook, event string, body []byte) (err error) {
+	backoff := b.webhookBackoff
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		if retry, err = b.postWebhook(hook, event, body); err == nil || !retry || attempt >= b.webhookRetries {
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (b *Builder) postWebhook(hook Webhook, event string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event)
+	req.Header.Set(WebhookTimestampHeader, ts)
+	if hook.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, WebhookSignature(hook.Secret, ts, body))
+	}
+	res, err := b.webhookClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500, fmt.Errorf("status %s", res.Status)
+	}
+	return false, nil
+}
+
+// WebhookSignature returns the signature of a webhook, receivers compare it with the signature header
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,12 +2,16 @@ package vips
 
 import (
 	"bytes"
+	"image"
 	"io"
+	"math"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/qor5/admin/media"
+	"github.com/qor5/admin/metrics"
 	"github.com/theplant/bimg"
 )
 
@@ -107,17 +111,7 @@ func (bimgImageHandler) Handle(m media.Media, file media.FileInterface, option *
 		bimgOption := bimg.Options{Quality: quality, Palette: true, Compression: PNGCompression}
 		// Crop original image if specified
 		if cropOption := m.GetCropOption(media.DefaultSizeKey); cropOption != nil {
-			options := bimg.Options{
-				Quality:    100, // Don't compress twice
-				Top:        cropOption.Min.Y,
-				Left:       cropOption.Min.X,
-				AreaWidth:  cropOption.Max.X - cropOption.Min.X,
-				AreaHeight: cropOption.Max.Y - cropOption.Min.Y,
-			}
-			if options.Top == 0 && options.Left == 0 {
-				options.Top = -1
-			}
-			if _, err := img.Process(options); err != nil {
+			if err = cropArea(img, *cropOption); err != nil {
 				return err
 			}
 		}
@@ -136,41 +130,52 @@ func (bimgImageHandler) Handle(m media.Media, file media.FileInterface, option *
 	}
 
 	// Handle size images
+	// decoded for the smart crops only, they are chosen like in the imaging handler
+	var decoded image.Image
 	for key, size := range m.GetSizes() {
 		if key == media.DefaultSizeKey {
 			continue
 		}
+		start := time.Now()
 		img := copyImage(buffer.Bytes())
 		if cropOption := m.GetCropOption(key); cropOption != nil {
-			options := bimg.Options{
-				Quality:    100, // Don't compress twice
-				Top:        cropOption.Min.Y,
-				Left:       cropOption.Min.X,
-				AreaWidth:  cropOption.Max.X - cropOption.Min.X,
-				AreaHeight: cropOption.Max.Y - cropOption.Min.Y,
+			if err = cropArea(img, *cropOption); err != nil {
+				return err
 			}
-			if options.Top == 0 && options.Left == 0 {
-				options.Top = -1
+		} else if size.SmartCrop && !size.Padding && size.Width > 0 && size.Height > 0 {
+			if decoded == nil {
+				if decoded, _, err = image.Decode(bytes.NewReader(buffer.Bytes())); err != nil {
+					return err
+				}
 			}
-			if _, err := img.Process(options); err != nil {
+			if err = cropArea(img, media.SmartCropRect(decoded, size.Width, size.Height)); err != nil {
 				return err
 			}
 		}
 		copy := copyImage(img.Image())
+		sizeQuality := quality
+		if q := size.GetQuality(); q > 0 {
+			sizeQuality = q
+		}
 		bimgOption := bimg.Options{
 			Width:       size.Width,
 			Height:      size.Height,
-			Quality:     quality,
+			Quality:     sizeQuality,
 			Compression: PNGCompression,
 			Palette:     true,
 			Enlarge:     true,
 		}
+		if size.Sharpen > 0 {
+			// libvips defaults except the radius, which follows the configured sigma
+			bimgOption.Sharpen = bimg.Sharpen{Radius: int(math.Ceil(size.Sharpen)), X1: 2, Y2: 10, Y3: 20, M2: 3}
+		}
 		// Process & Save size image
 		if buf, err := img.Process(bimgOption); err == nil {
 			if err = m.Store(m.URL(key), option, bytes.NewReader(buf)); err != nil {
 				return err
 			}
 			fileSizes[key] = len(buf)
+			metrics.ObserveSince("qor5_media_thumbnail_duration_seconds", metrics.Labels{"size": key}, start)
 		} else {
 			return err
 		}
@@ -201,6 +206,22 @@ func generateWebp(m media.Media, option *media.Option, bimgOption bimg.Options,
 	return
 }
 
+// cropArea extracts the area of rect from img
+func cropArea(img *bimg.Image, rect image.Rectangle) error {
+	options := bimg.Options{
+		Quality:    100, // Don't compress twice
+		Top:        rect.Min.Y,
+		Left:       rect.Min.X,
+		AreaWidth:  rect.Dx(),
+		AreaHeight: rect.Dy(),
+	}
+	if options.Top == 0 && options.Left == 0 {
+		options.Top = -1
+	}
+	_, err := img.Process(options)
+	return err
+}
+
 func copyImage(buffer []byte) (img *bimg.Image) {
 	bs := make([]byte, len(buffer))
 	copy(bs, buffer)
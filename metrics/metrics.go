@@ -0,0 +1,179 @@
+// Package metrics records counters and histograms of the admin subsystems,
+// it is a no-op until a Recorder is set.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Labels map[string]string
+
+type Recorder interface {
+	// Add adds v to the counter
+	Add(name string, labels Labels, v float64)
+	// Observe records v in the histogram
+	Observe(name string, labels Labels, v float64)
+}
+
+var recorder Recorder
+
+// SetRecorder sets the recorder of all subsystems, use NewRegistry for a built-in one
+// or implement Recorder to forward to an existing prometheus registry
+func SetRecorder(r Recorder) {
+	recorder = r
+}
+
+func Add(name string, labels Labels, v float64) {
+	if recorder == nil {
+		return
+	}
+	recorder.Add(name, labels, v)
+}
+
+func Inc(name string, labels Labels) {
+	Add(name, labels, 1)
+}
+
+func Observe(name string, labels Labels, v float64) {
+	if recorder == nil {
+		return
+	}
+	recorder.Observe(name, labels, v)
+}
+
+// ObserveSince records the seconds since start in the histogram
+func ObserveSince(name string, labels Labels, start time.Time) {
+	Observe(name, labels, time.Since(start).Seconds())
+}
+
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Registry is an in-memory Recorder serving the Prometheus text format
+type Registry struct {
+	mutex      sync.Mutex
+	buckets    []float64
+	counters   map[string]map[string]float64
+	histograms map[string]map[string]*histogram
+}
+
+var _ Recorder = (*Registry)(nil)
+var _ http.Handler = (*Registry)(nil)
+
+func NewRegistry() *Registry {
+	return &Registry{
+		buckets:    DefaultBuckets,
+		counters:   make(map[string]map[string]float64),
+		histograms: make(map[string]map[string]*histogram),
+	}
+}
+
+// Buckets sets the upper bounds of histogram buckets, must be called before any observation
+func (r *Registry) Buckets(v []float64) *Registry {
+	r.buckets = append([]float64{}, v...)
+	sort.Float64s(r.buckets)
+	return r
+}
+
+func (r *Registry) Add(name string, labels Labels, v float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	series, ok := r.counters[name]
+	if !ok {
+		series = make(map[string]float64)
+		r.counters[name] = series
+	}
+	series[formatLabels(labels)] += v
+}
+
+func (r *Registry) Observe(name string, labels Labels, v float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	series, ok := r.histograms[name]
+	if !ok {
+		series = make(map[string]*histogram)
+		r.histograms[name] = series
+	}
+	key := formatLabels(labels)
+	h, ok := series[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(r.buckets))}
+		series[key] = h
+	}
+	for i, b := range r.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, name := range sortedKeys(r.counters) {
+		fmt.Fprintf(w, "# TYPE %s counter\n", name)
+		series := r.counters[name]
+		for _, key := range sortedKeys(series) {
+			fmt.Fprintf(w, "%s%s %v\n", name, key, series[key])
+		}
+	}
+	for _, name := range sortedKeys(r.histograms) {
+		fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+		series := r.histograms[name]
+		for _, key := range sortedKeys(series) {
+			h := series[key]
+			for i, b := range r.buckets {
+				fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(key, "le", fmt.Sprint(b)), h.counts[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(key, "le", "+Inf"), h.count)
+			fmt.Fprintf(w, "%s_sum%s %v\n", name, key, h.sum)
+			fmt.Fprintf(w, "%s_count%s %d\n", name, key, h.count)
+		}
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels))
+	for _, k := range sortedKeys(labels) {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, labelValueReplacer.Replace(labels[k])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func withLabel(key string, name string, value string) string {
+	pair := fmt.Sprintf(`%s="%s"`, name, value)
+	if key == "" {
+		return "{" + pair + "}"
+	}
+	return key[:len(key)-1] + "," + pair + "}"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNoopWithoutRecorder(t *testing.T) {
+	SetRecorder(nil)
+	Inc("qor5_test_total", nil)
+	Observe("qor5_test_seconds", nil, 1)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry().Buckets([]float64{1, 0.1})
+	SetRecorder(r)
+	defer SetRecorder(nil)
+
+	Inc("qor5_login_total", Labels{"result": "success"})
+	Inc("qor5_login_total", Labels{"result": "success"})
+	Observe("qor5_worker_job_duration_seconds", Labels{"job": "export", "status": "done"}, 0.5)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE qor5_login_total counter\n",
+		`qor5_login_total{result="success"} 2`,
+		`qor5_worker_job_duration_seconds_bucket{job="export",status="done",le="0.1"} 0`,
+		`qor5_worker_job_duration_seconds_bucket{job="export",status="done",le="1"} 1`,
+		`qor5_worker_job_duration_seconds_bucket{job="export",status="done",le="+Inf"} 1`,
+		`qor5_worker_job_duration_seconds_count{job="export",status="done"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
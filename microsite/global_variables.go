@@ -6,3 +6,13 @@ var putSemaphore = make(chan struct{}, MaximumNumberOfFilesUploadedAtTheSameTime
 
 var MaximumNumberOfFilesCopiedAtTheSameTime = 10
 var copySemaphore = make(chan struct{}, MaximumNumberOfFilesCopiedAtTheSameTime)
+
+// MaximumNumberOfFilesInArchive is the most files a package archive can have, 0 is no limit
+var MaximumNumberOfFilesInArchive = 0
+
+// MaximumTotalSizeOfArchive is the most bytes the files of a package archive can have uncompressed, 0 is no limit
+var MaximumTotalSizeOfArchive int64 = 0
+
+// MaximumSizeOfFileUploadedConcurrently is the biggest file of a package archive that is buffered to be uploaded concurrently,
+// bigger files are streamed to the storage one by one
+var MaximumSizeOfFileUploadedConcurrently int64 = 8 << 20
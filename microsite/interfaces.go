@@ -25,3 +25,14 @@ type MicroSiteInterface interface {
 	SetPackage(fileName, url string)
 	UnArchiveAndPublish(getPath func(string) string, fileName string, f io.Reader, storage oss.StorageInterface) (filesList []string, err error)
 }
+
+// ProgressPublisher is implemented by the microsites that report the progress of extracting their package, like MicroSite
+type ProgressPublisher interface {
+	UnArchiveAndPublishWithProgress(getPath func(string) string, fileName string, f io.Reader, storage oss.StorageInterface, progress func(files int, bytes int64)) (filesList []string, err error)
+}
+
+// Promoter is implemented by the microsites that can promote their preview to the published paths
+// without extracting the archive again, like MicroSite
+type Promoter interface {
+	Promote(storage oss.StorageInterface) error
+}
@@ -1,10 +1,14 @@
 package microsite
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log"
 	"path"
 	"strconv"
 	"strings"
@@ -31,6 +35,8 @@ type MicroSite struct {
 	FilesList string     `gorm:"type:text"`
 
 	UnixKey string
+	// PreviewKey is rotated to revoke the preview links, see RevokePreviewLinks
+	PreviewKey string
 }
 
 func (this *MicroSite) PermissionRN() []string {
@@ -115,40 +121,128 @@ func (this *MicroSite) SetPackage(fileName, url string) {
 }
 
 func (this *MicroSite) GetPublishActions(db *gorm.DB, ctx context.Context, storage oss.StorageInterface) (objs []*publish.PublishAction, err error) {
-	if len(this.GetFileList()) > 0 {
-		var previewPaths []string
+	err = this.Promote(storage)
+	return
+}
 
-		var wg = sync.WaitGroup{}
-		var copyError error
-		var mutex sync.Mutex
-		for _, v := range this.GetFileList() {
-			wg.Add(1)
-			copySemaphore <- struct{}{}
-			go func(v string) {
-				defer func() {
-					wg.Done()
-					<-copySemaphore
-				}()
-				err = utils.Copy(storage, this.GetPreviewPath(v), this.GetPublishedPath(v))
-				if err != nil {
-					mutex.Lock()
-					copyError = multierror.Append(copyError, err).ErrorOrNil()
-					mutex.Unlock()
-					return
-				}
-				mutex.Lock()
-				previewPaths = append(previewPaths, this.GetPreviewPath(v))
-				mutex.Unlock()
-			}(v)
+var _ Promoter = (*MicroSite)(nil)
+
+// Promote copies the verified preview files to the published paths within the storage, the archive is not extracted again.
+// Storages can't swap many files at once, so the live files are backed up first and restored if any copy fails,
+// the live site ends up with either the old or the new version and never a mix of them.
+// While the files are copied the pages are copied after all the assets they reference.
+// The preview is removed only when every file is copied so a failed promotion can be retried.
+func (this *MicroSite) Promote(storage oss.StorageInterface) (err error) {
+	var assets, pages []string
+	for _, v := range this.GetFileList() {
+		if ext := strings.ToLower(path.Ext(v)); ext == ".html" || ext == ".htm" {
+			pages = append(pages, v)
+		} else {
+			assets = append(assets, v)
+		}
+	}
+	if len(assets)+len(pages) == 0 {
+		return
+	}
+
+	var mutex sync.Mutex
+	var backedUp, created []string
+	if err = eachFile(append(assets, pages...), func(v string) error {
+		err := utils.Copy(storage, this.GetPublishedPath(v), this.backupPath(v))
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case err == nil:
+			backedUp = append(backedUp, v)
+		case utils.IsNotFound(err):
+			created = append(created, v)
+		default:
+			return err
 		}
+		return nil
+	}); err != nil {
+		this.removeBackups(storage, backedUp)
+		return
+	}
 
-		wg.Wait()
+	copyToPublished := func(v string) error {
+		return utils.Copy(storage, this.GetPreviewPath(v), this.GetPublishedPath(v))
+	}
+	if err = eachFile(assets, copyToPublished); err == nil {
+		err = eachFile(pages, copyToPublished)
+	}
+	if err != nil {
+		this.restoreBackups(storage, backedUp, created)
+		return
+	}
+	this.removeBackups(storage, backedUp)
 
-		if len(previewPaths) > 0 {
-			err = utils.DeleteObjects(storage, previewPaths)
+	var previewPaths []string
+	for _, v := range this.GetFileList() {
+		previewPaths = append(previewPaths, this.GetPreviewPath(v))
+	}
+	return utils.DeleteObjects(storage, previewPaths)
+}
+
+func (this MicroSite) backupPath(fileName string) string {
+	return strings.TrimPrefix(path.Join(PackageAndPreviewPrepath, "__backup__", this.GetUnixKey(), fileName), "/")
+}
+
+// restoreBackups puts back the live files of a failed promotion and removes the ones it created,
+// errors are logged since the error of the promotion is returned. The backups are kept when they fail to be restored.
+func (this *MicroSite) restoreBackups(storage oss.StorageInterface, backedUp []string, created []string) {
+	restoreErr := eachFile(backedUp, func(v string) error {
+		return utils.Copy(storage, this.backupPath(v), this.GetPublishedPath(v))
+	})
+	if restoreErr != nil {
+		log.Printf("microsite: failed to restore the live files of %d from %s: %v\n", this.ID, this.backupPath(""), restoreErr)
+	}
+	var paths []string
+	for _, v := range created {
+		paths = append(paths, this.GetPublishedPath(v))
+	}
+	if len(paths) > 0 {
+		if err := utils.DeleteObjects(storage, paths); err != nil {
+			log.Printf("microsite: failed to remove the promoted files of %d: %v\n", this.ID, err)
 		}
-		err = multierror.Append(err, copyError).ErrorOrNil()
 	}
+	if restoreErr == nil {
+		this.removeBackups(storage, backedUp)
+	}
+}
+
+func (this *MicroSite) removeBackups(storage oss.StorageInterface, backedUp []string) {
+	var paths []string
+	for _, v := range backedUp {
+		paths = append(paths, this.backupPath(v))
+	}
+	if len(paths) > 0 {
+		if err := utils.DeleteObjects(storage, paths); err != nil {
+			log.Printf("microsite: failed to remove the backups of %d: %v\n", this.ID, err)
+		}
+	}
+}
+
+// eachFile calls fn for the files concurrently, limited by copySemaphore
+func eachFile(files []string, fn func(v string) error) (err error) {
+	var wg = sync.WaitGroup{}
+	var mutex sync.Mutex
+	for _, v := range files {
+		wg.Add(1)
+		copySemaphore <- struct{}{}
+		go func(v string) {
+			defer func() {
+				wg.Done()
+				<-copySemaphore
+			}()
+			if ferr := fn(v); ferr != nil {
+				mutex.Lock()
+				err = multierror.Append(err, ferr).ErrorOrNil()
+				mutex.Unlock()
+			}
+		}(v)
+	}
+	wg.Wait()
 	return
 }
 
@@ -164,11 +258,56 @@ func (this *MicroSite) GetUnPublishActions(db *gorm.DB, ctx context.Context, sto
 	return
 }
 
+// ErrArchiveLimitExceeded is returned when a package archive has more files or bytes than
+// MaximumNumberOfFilesInArchive or MaximumTotalSizeOfArchive
+var ErrArchiveLimitExceeded = errors.New("archive exceeds the limits")
+
+// checkArchiveLimits walks the archive without extracting it, symlinks count as files, directories don't
+func checkArchiveLimits(ex archiver.Extractor, r io.Reader) error {
+	var count int
+	var size int64
+	return ex.Extract(context.Background(), r, nil, func(ctx context.Context, f archiver.File) error {
+		if f.IsDir() {
+			return nil
+		}
+		count++
+		if f.Mode()&fs.ModeSymlink == 0 {
+			size += f.Size()
+		}
+		if MaximumNumberOfFilesInArchive > 0 && count > MaximumNumberOfFilesInArchive {
+			return fmt.Errorf("%w: more than %d files", ErrArchiveLimitExceeded, MaximumNumberOfFilesInArchive)
+		}
+		if MaximumTotalSizeOfArchive > 0 && size > MaximumTotalSizeOfArchive {
+			return fmt.Errorf("%w: more than %d bytes", ErrArchiveLimitExceeded, MaximumTotalSizeOfArchive)
+		}
+		return nil
+	})
+}
+
 func (this *MicroSite) UnArchiveAndPublish(getPath func(string) string, fileName string, f io.Reader, storage oss.StorageInterface) (filesList []string, err error) {
+	return this.UnArchiveAndPublishWithProgress(getPath, fileName, f, storage, nil)
+}
+
+// UnArchiveAndPublishWithProgress streams the files of the archive to the storage, progress is called after each file.
+// A seekable archive is checked against the limits before any upload, others are checked while they're streamed
+// and the files uploaded are removed when a limit is exceeded.
+// Files up to MaximumSizeOfFileUploadedConcurrently are uploaded concurrently, bigger ones one by one without buffering them.
+func (this *MicroSite) UnArchiveAndPublishWithProgress(getPath func(string) string, fileName string, f io.Reader, storage oss.StorageInterface, progress func(files int, bytes int64)) (filesList []string, err error) {
+	if seeker, ok := f.(io.ReadSeeker); ok && (MaximumNumberOfFilesInArchive > 0 || MaximumTotalSizeOfArchive > 0) {
+		if format, reader, ierr := archiver.Identify(fileName, seeker); ierr == nil {
+			if err = checkArchiveLimits(format.(archiver.Extractor), reader); err != nil {
+				return
+			}
+		}
+		if _, err = seeker.Seek(0, io.SeekStart); err != nil {
+			return
+		}
+	}
+
 	format, reader, err := archiver.Identify(fileName, f)
 	if err != nil {
 		if err == archiver.ErrNoMatch {
-			err = utils.Upload(storage, getPath(fileName), f)
+			err = utils.Upload(storage, getPath(fileName), reader)
 			return
 		}
 		return
@@ -177,11 +316,21 @@ func (this *MicroSite) UnArchiveAndPublish(getPath func(string) string, fileName
 	var wg = sync.WaitGroup{}
 	var putError error
 	var mutex sync.Mutex
+	var count int
+	var total int64
 
 	err = format.(archiver.Extractor).Extract(context.Background(), reader, nil, func(ctx context.Context, f archiver.File) (err error) {
 		if f.IsDir() {
 			return
 		}
+		count++
+		if MaximumNumberOfFilesInArchive > 0 && count > MaximumNumberOfFilesInArchive {
+			return fmt.Errorf("%w: more than %d files", ErrArchiveLimitExceeded, MaximumNumberOfFilesInArchive)
+		}
+		// checked before the file is uploaded, the size read is checked again below as the header can lie
+		if MaximumTotalSizeOfArchive > 0 && f.Mode()&fs.ModeSymlink == 0 && total+f.Size() > MaximumTotalSizeOfArchive {
+			return fmt.Errorf("%w: more than %d bytes", ErrArchiveLimitExceeded, MaximumTotalSizeOfArchive)
+		}
 
 		rc, err := f.Open()
 		if err != nil {
@@ -189,27 +338,82 @@ func (this *MicroSite) UnArchiveAndPublish(getPath func(string) string, fileName
 		}
 		defer rc.Close()
 
-		filesList = append(filesList, f.NameInArchive)
+		// the size in the header can't be trusted, the cap is enforced on the bytes read too
+		var body io.Reader = rc
+		if MaximumTotalSizeOfArchive > 0 {
+			body = io.LimitReader(rc, MaximumTotalSizeOfArchive-total+1)
+		}
+		body = &countingReader{Reader: body, n: &total}
+		exceeded := func() error {
+			if MaximumTotalSizeOfArchive > 0 && total > MaximumTotalSizeOfArchive {
+				return fmt.Errorf("%w: more than %d bytes", ErrArchiveLimitExceeded, MaximumTotalSizeOfArchive)
+			}
+			return nil
+		}
 
+		filesList = append(filesList, f.NameInArchive)
 		publishedPath := getPath(f.NameInArchive)
-		wg.Add(1)
-		putSemaphore <- struct{}{}
-		go func() {
-			defer func() {
-				<-putSemaphore
-				wg.Done()
-			}()
-			err2 := utils.Upload(storage, publishedPath, rc)
-			if err2 != nil {
-				mutex.Lock()
-				putError = multierror.Append(putError, err2).ErrorOrNil()
-				mutex.Unlock()
+
+		var data []byte
+		if f.Size() <= MaximumSizeOfFileUploadedConcurrently {
+			// the header can lie here too, at most MaximumSizeOfFileUploadedConcurrently bytes are buffered
+			if data, err = io.ReadAll(io.LimitReader(body, MaximumSizeOfFileUploadedConcurrently+1)); err != nil {
+				return
+			}
+		}
+		if f.Size() > MaximumSizeOfFileUploadedConcurrently || int64(len(data)) > MaximumSizeOfFileUploadedConcurrently {
+			if err = utils.Upload(storage, publishedPath, io.MultiReader(bytes.NewReader(data), body)); err != nil {
+				return
+			}
+			if err = exceeded(); err != nil {
+				return
 			}
-		}()
+		} else {
+			if err = exceeded(); err != nil {
+				return
+			}
+			wg.Add(1)
+			putSemaphore <- struct{}{}
+			go func() {
+				defer func() {
+					<-putSemaphore
+					wg.Done()
+				}()
+				err2 := utils.Upload(storage, publishedPath, bytes.NewReader(data))
+				if err2 != nil {
+					mutex.Lock()
+					putError = multierror.Append(putError, err2).ErrorOrNil()
+					mutex.Unlock()
+				}
+			}()
+		}
 
+		if progress != nil {
+			progress(count, total)
+		}
 		return
 	})
 	wg.Wait()
 	err = multierror.Append(err, putError).ErrorOrNil()
+	if err != nil && len(filesList) > 0 {
+		// don't leave a partial publish, the error of the removal is logged by DeleteObjects
+		var paths []string
+		for _, v := range filesList {
+			paths = append(paths, getPath(v))
+		}
+		utils.DeleteObjects(storage, paths)
+		filesList = nil
+	}
+	return
+}
+
+type countingReader struct {
+	io.Reader
+	n *int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	*r.n += int64(n)
 	return
 }
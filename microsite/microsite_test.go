@@ -0,0 +1,72 @@
+package microsite
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestUnArchiveAndPublishRemovesThePartialPublish(t *testing.T) {
+	defer func(v int64) { MaximumTotalSizeOfArchive = v }(MaximumTotalSizeOfArchive)
+	MaximumTotalSizeOfArchive = 250
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"a.html", "b.html", "c.html"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: 100}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(strings.Repeat("x", 100)))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	site := &MicroSite{PrePath: "/site", UnixKey: "1"}
+	storage := &memStorage{files: map[string]string{}}
+	var progressed int
+	// not seekable, the limits are checked while the files are streamed
+	filesList, err := site.UnArchiveAndPublishWithProgress(site.GetPreviewPath, "site.tar", struct{ io.Reader }{&buf}, storage,
+		func(files int, bytes int64) { progressed = files })
+	if !errors.Is(err, ErrArchiveLimitExceeded) {
+		t.Fatalf("expected ErrArchiveLimitExceeded, got %v", err)
+	}
+	if len(filesList) != 0 || len(storage.files) != 0 {
+		t.Fatalf("the partial publish is left: %v, %v", filesList, storage.files)
+	}
+	if progressed != 2 {
+		t.Fatalf("expected the progress of 2 files, got %d", progressed)
+	}
+}
+
+func TestUnArchiveAndPublishStreamsTheBigFiles(t *testing.T) {
+	defer func(v int64) { MaximumSizeOfFileUploadedConcurrently = v }(MaximumSizeOfFileUploadedConcurrently)
+	MaximumSizeOfFileUploadedConcurrently = 10
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	files := map[string]string{"small.html": "small", "big.html": strings.Repeat("x", 100)}
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	site := &MicroSite{PrePath: "/site", UnixKey: "1"}
+	storage := &memStorage{files: map[string]string{}}
+	if _, err := site.UnArchiveAndPublish(site.GetPreviewPath, "site.tar", bytes.NewReader(buf.Bytes()), storage); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		if got := storage.files[site.GetPreviewPath(name)]; got != body {
+			t.Errorf("%s = %q, want %q", name, got, body)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package microsite
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qor/oss"
+	"github.com/qor5/admin/microsite/utils"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPreviewToken = errors.New("invalid or expired preview token")
+
+// PreviewLinker is implemented by the microsites whose preview links can be signed and revoked, like MicroSite
+type PreviewLinker interface {
+	PrimarySlug() string
+	PreviewToken(secret []byte, expiresAt time.Time) string
+	VerifyPreviewToken(secret []byte, token string) error
+	RevokePreviewLinks(db *gorm.DB) error
+}
+
+// PreviewToken returns a token signed with secret valid until expiresAt, scoped to the version of the site
+func (this MicroSite) PreviewToken(secret []byte, expiresAt time.Time) string {
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expiry + "." + this.previewSignature(secret, expiry)
+}
+
+func (this MicroSite) previewSignature(secret []byte, expiry string) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d|%s|%s|%s", this.ID, this.Version.Version, this.PreviewKey, expiry)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyPreviewToken checks the token is signed with secret for the version of the site, not expired nor revoked
+func (this MicroSite) VerifyPreviewToken(secret []byte, token string) error {
+	expiry, sig, ok := strings.Cut(token, ".")
+	if !ok || len(secret) == 0 {
+		return ErrInvalidPreviewToken
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return ErrInvalidPreviewToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(this.previewSignature(secret, expiry))) {
+		return ErrInvalidPreviewToken
+	}
+	return nil
+}
+
+// RevokePreviewLinks makes all the preview links of the version stop working
+func (this *MicroSite) RevokePreviewLinks(db *gorm.DB) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	this.PreviewKey = hex.EncodeToString(b)
+	return db.Model(this).Where("version = ?", this.Version.Version).Update("preview_key", this.PreviewKey).Error
+}
+
+// PreviewBuilder serves the preview files of the sites of a model to the holders of a preview link, mount it at its prefix.
+// Keep the preview paths of the storage private, the files are read with the storage credentials.
+type PreviewBuilder struct {
+	prefix    string
+	db        *gorm.DB
+	storage   oss.StorageInterface
+	modelType reflect.Type
+	secret    []byte
+	ttl       time.Duration
+}
+
+func NewPreviewBuilder(prefix string, db *gorm.DB, storage oss.StorageInterface, model MicroSiteInterface) *PreviewBuilder {
+	return &PreviewBuilder{
+		prefix:    strings.TrimSuffix(prefix, "/"),
+		db:        db,
+		storage:   storage,
+		modelType: reflect.TypeOf(model).Elem(),
+		ttl:       24 * time.Hour,
+	}
+}
+
+// Secret signs the preview links, use a key of its own, no link is signed nor served without it
+func (b *PreviewBuilder) Secret(v []byte) (r *PreviewBuilder) {
+	b.secret = v
+	return b
+}
+
+// TTL is how long a preview link works after it's shown, 24 hours by default
+func (b *PreviewBuilder) TTL(v time.Duration) (r *PreviewBuilder) {
+	b.ttl = v
+	return b
+}
+
+func (b *PreviewBuilder) Prefix() string {
+	return b.prefix
+}
+
+// SignedURL returns the link of the preview file of the site with a token expiring in TTL,
+// the token is a segment of the path so the relative links of the pages keep it.
+// It's empty when the site isn't a PreviewLinker or the secret isn't set.
+func (b *PreviewBuilder) SignedURL(site MicroSiteInterface, fileName string) string {
+	pl, ok := site.(PreviewLinker)
+	if !ok || len(b.secret) == 0 {
+		return ""
+	}
+	return b.prefix + "/" + path.Join(pl.PreviewToken(b.secret, time.Now().Add(b.ttl)), pl.PrimarySlug(), fileName)
+}
+
+func (b *PreviewBuilder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segs := strings.SplitN(strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, b.prefix), "/"), "/", 3)
+	if len(segs) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+	token, slug, fileName := segs[0], segs[1], segs[2]
+	// the routers don't all clean the path, a file name must not reach out of the preview folder of the site
+	if fileName == "" || path.Clean(fileName) != fileName || strings.HasPrefix(fileName, "/") || strings.Contains(fileName, "..") {
+		http.NotFound(w, r)
+		return
+	}
+	id, version, ok := strings.Cut(slug, "_")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	site := reflect.New(b.modelType).Interface().(MicroSiteInterface)
+	pl, ok := site.(PreviewLinker)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := b.db.Where("id = ? AND version = ?", id, version).First(site).Error; err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := pl.VerifyPreviewToken(b.secret, token); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	key := site.GetPreviewPath(fileName)
+	if !strings.HasPrefix(key, site.GetPreviewPath("")+"/") {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := b.storage.GetStream(key)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	br := bufio.NewReaderSize(f, 512)
+	head, _ := br.Peek(512)
+	w.Header().Set("Content-Type", utils.ContentType(fileName, head))
+	w.Header().Set("Cache-Control", "private, no-store")
+	io.Copy(w, br)
+}
@@ -0,0 +1,90 @@
+package microsite
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qor5/admin/publish"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPreviewToken(t *testing.T) {
+	secret := []byte("secret")
+
+	site := MicroSite{Model: gorm.Model{ID: 1}, Version: publish.Version{Version: "2023-01-05-v01"}}
+	token := site.PreviewToken(secret, time.Now().Add(time.Hour))
+	if err := site.VerifyPreviewToken(secret, token); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if err := site.VerifyPreviewToken(secret, site.PreviewToken(secret, time.Now().Add(-time.Second))); err == nil {
+		t.Error("expired token accepted")
+	}
+	if err := site.VerifyPreviewToken([]byte("other"), token); err == nil {
+		t.Error("token of another secret accepted")
+	}
+
+	other := site
+	other.Version.Version = "2023-01-05-v02"
+	if err := other.VerifyPreviewToken(secret, token); err == nil {
+		t.Error("token of another version accepted")
+	}
+	site.PreviewKey = "rotated"
+	if err := site.VerifyPreviewToken(secret, token); err == nil {
+		t.Error("revoked token accepted")
+	}
+}
+
+func TestPreviewBuilder(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&MicroSite{}); err != nil {
+		t.Fatal(err)
+	}
+	site := &MicroSite{Model: gorm.Model{ID: 1}, Version: publish.Version{Version: "2023-01-05-v01"}, UnixKey: "1"}
+	if err = db.Create(site).Error; err != nil {
+		t.Fatal(err)
+	}
+	storage := &memStorage{files: map[string]string{
+		site.GetPreviewPath("index.html"):                "<html>preview</html>",
+		site.GetPreviewPath("../../__package__/1/a.zip"): "archive",
+	}}
+	pb := NewPreviewBuilder("/preview/", db, storage, &MicroSite{})
+
+	if u := pb.SignedURL(site, "index.html"); u != "" {
+		t.Fatalf("signed a link without a secret: %s", u)
+	}
+	pb.Secret([]byte("secret"))
+	u := pb.SignedURL(site, "index.html")
+	if !strings.HasPrefix(u, "/preview/") {
+		t.Fatalf("unexpected link %s", u)
+	}
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		pb.ServeHTTP(w, httptest.NewRequest("GET", u, nil))
+		return w
+	}
+	if w := serve(); w.Code != 200 || w.Body.String() != "<html>preview</html>" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("unexpected response %d %q %s", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+
+	for _, name := range []string{"../../__package__/1/a.zip", "a/../../index.html", "/index.html", "a//index.html", ""} {
+		w := httptest.NewRecorder()
+		pb.ServeHTTP(w, httptest.NewRequest("GET", strings.TrimSuffix(u, "index.html")+name, nil))
+		if w.Code != 404 {
+			t.Errorf("%q is served with %d", name, w.Code)
+		}
+	}
+
+	if err = site.RevokePreviewLinks(db); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(); w.Code != 403 {
+		t.Fatalf("a revoked link is served: %d", w.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package microsite
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qor/oss"
+)
+
+type memStorage struct {
+	mu       sync.Mutex
+	files    map[string]string
+	failCopy string
+}
+
+func (s *memStorage) Get(path string) (*os.File, error) { return nil, errors.New("not supported") }
+
+func (s *memStorage) GetStream(path string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(v)), nil
+}
+
+func (s *memStorage) Put(path string, reader io.Reader) (*oss.Object, error) {
+	var b bytes.Buffer
+	if _, err := b.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = b.String()
+	return &oss.Object{Path: path}, nil
+}
+
+func (s *memStorage) Delete(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, path)
+	return nil
+}
+
+func (s *memStorage) List(path string) ([]*oss.Object, error) { return nil, nil }
+func (s *memStorage) GetURL(path string) (string, error)      { return path, nil }
+func (s *memStorage) GetEndpoint() string                     { return "" }
+
+func (s *memStorage) Copy(from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if from == s.failCopy {
+		return errors.New("copy failed")
+	}
+	v, ok := s.files[from]
+	if !ok {
+		return os.ErrNotExist
+	}
+	s.files[to] = v
+	return nil
+}
+
+func TestPromote(t *testing.T) {
+	site := &MicroSite{PrePath: "/site", UnixKey: "1"}
+	site.SetFilesList([]string{"index.html", "app.js", "new.css"})
+	newStorage := func() *memStorage {
+		s := &memStorage{files: map[string]string{
+			"site/index.html": "old",
+			"site/app.js":     "old",
+		}}
+		for _, v := range site.GetFileList() {
+			s.files[site.GetPreviewPath(v)] = "new"
+		}
+		return s
+	}
+
+	// the page is copied last, its failure rolls back the assets copied before
+	storage := newStorage()
+	storage.failCopy = site.GetPreviewPath("index.html")
+	if err := site.Promote(storage); err == nil {
+		t.Fatal("expected the copy error")
+	}
+	want := map[string]string{"site/index.html": "old", "site/app.js": "old"}
+	for _, v := range site.GetFileList() {
+		want[site.GetPreviewPath(v)] = "new"
+	}
+	if !equalFiles(storage.files, want) {
+		t.Fatalf("after the failed promotion got %v, want %v", storage.files, want)
+	}
+
+	storage = newStorage()
+	if err := site.Promote(storage); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{"site/index.html": "new", "site/app.js": "new", "site/new.css": "new"}
+	if !equalFiles(storage.files, want) {
+		t.Fatalf("after the promotion got %v, want %v", storage.files, want)
+	}
+}
+
+func equalFiles(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
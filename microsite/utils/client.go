@@ -1,6 +1,12 @@
 package utils
 
-import "github.com/qor/oss/s3"
+import (
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/qor/oss/s3"
+)
 
 func NewClient(client *s3.Client) S3Client {
 	return S3Client{client}
@@ -13,3 +19,20 @@ type S3Client struct {
 func (this S3Client) GetBucket() string {
 	return this.Config.Bucket
 }
+
+// PutWithContentType is Put with the given content type instead of the guess of the s3 client,
+// the reader is streamed in parts instead of being read into memory
+func (this S3Client) PutWithContentType(path string, reader io.Reader, contentType string) (err error) {
+	params := &s3manager.UploadInput{
+		Bucket:      aws.String(this.Config.Bucket),
+		Key:         aws.String(this.ToRelativePath(path)),
+		ACL:         aws.String(this.Config.ACL),
+		Body:        reader,
+		ContentType: aws.String(contentType),
+	}
+	if this.Config.CacheControl != "" {
+		params.CacheControl = aws.String(this.Config.CacheControl)
+	}
+	_, err = s3manager.NewUploaderWithClient(this.S3).Upload(params)
+	return
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// webContentTypes are the types browsers need to render the assets of a site, the system MIME tables often lack or differ on them
+var webContentTypes = map[string]string{
+	".html":  "text/html; charset=utf-8",
+	".htm":   "text/html; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".mjs":   "text/javascript; charset=utf-8",
+	".css":   "text/css; charset=utf-8",
+	".json":  "application/json",
+	".map":   "application/json",
+	".svg":   "image/svg+xml",
+	".wasm":  "application/wasm",
+	".webp":  "image/webp",
+	".avif":  "image/avif",
+	".ico":   "image/x-icon",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".txt":   "text/plain; charset=utf-8",
+	".xml":   "application/xml",
+}
+
+// ContentType returns the content type of the file at path by its extension, or by sniffing head when the extension is unknown
+func ContentType(p string, head []byte) string {
+	ext := strings.ToLower(path.Ext(p))
+	if t, ok := webContentTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return http.DetectContentType(head)
+}
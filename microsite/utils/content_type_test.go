@@ -0,0 +1,19 @@
+package utils
+
+import "testing"
+
+func TestContentType(t *testing.T) {
+	cases := map[string]string{
+		"index.html":        "text/html; charset=utf-8",
+		"assets/app.JS":     "text/javascript; charset=utf-8",
+		"assets/app.css":    "text/css; charset=utf-8",
+		"assets/app.wasm":   "application/wasm",
+		"images/logo.svg":   "image/svg+xml",
+		"images/no-ext-png": "image/png",
+	}
+	for p, want := range cases {
+		if got := ContentType(p, []byte("\x89PNG\x0D\x0A\x1A\x0A")); got != want {
+			t.Errorf("ContentType(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
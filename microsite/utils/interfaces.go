@@ -1,5 +1,7 @@
 package utils
 
+import "io"
+
 type DeleteObjectsInterface interface {
 	DeleteObjects(paths []string) (err error)
 }
@@ -11,3 +13,7 @@ type CopyInterface interface {
 type GetBucketInterface interface {
 	GetBucket() string
 }
+
+type PutWithContentTypeInterface interface {
+	PutWithContentType(path string, reader io.Reader, contentType string) (err error)
+}
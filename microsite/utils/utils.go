@@ -1,13 +1,18 @@
 package utils
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"os"
 	"path"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/qor/oss"
 )
 
@@ -22,7 +27,13 @@ func Upload(storage oss.StorageInterface, path string, reader io.Reader) (err er
 			log.Printf("upload: %s, time_spent_ms: %s \n", path, fmt.Sprintf("%f", float64(timeFinish.Sub(timeBegin))/float64(time.Millisecond)))
 		}
 	}()
-	_, err = storage.Put(path, reader)
+	if s, ok := storage.(PutWithContentTypeInterface); ok {
+		br := bufio.NewReaderSize(reader, 512)
+		head, _ := br.Peek(512)
+		err = s.PutWithContentType(path, br, ContentType(path, head))
+	} else {
+		_, err = storage.Put(path, reader)
+	}
 	if err != nil {
 		err = errors.New(fmt.Sprintf("upload error: %v, path: %v", err, path))
 		return
@@ -92,7 +103,21 @@ func Copy(storage oss.StorageInterface, from, to string) (err error) {
 
 	err = storage.(CopyInterface).Copy(from, to)
 	if err != nil {
-		err = errors.New(fmt.Sprintf("copy error: %v, from: %v, to: %v", err, from, to))
+		err = fmt.Errorf("copy error: %w, from: %v, to: %v", err, from, to)
 	}
 	return
 }
+
+// IsNotFound reports whether the error of Copy or GetStream means nothing is stored at the path,
+// for the file system and the S3 storages
+func IsNotFound(err error) bool {
+	if errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	var aerr awserr.RequestFailure
+	if errors.As(err, &aerr) && aerr.StatusCode() == http.StatusNotFound {
+		return true
+	}
+	var cerr awserr.Error
+	return errors.As(err, &cerr) && cerr.Code() == s3.ErrCodeNoSuchKey
+}
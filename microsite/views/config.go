@@ -1,10 +1,10 @@
 package views
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 
+	"github.com/google/uuid"
 	"github.com/qor/oss"
 	"github.com/qor5/admin/activity"
 	"github.com/qor5/admin/microsite"
@@ -27,15 +27,31 @@ func Configure(b *presets.Builder, db *gorm.DB, ab *activity.ActivityBuilder, st
 		RegisterForModule(language.English, I18nMicrositeKey, Messages_en_US).
 		RegisterForModule(language.SimplifiedChinese, I18nMicrositeKey, Messages_zh_CN)
 
+	if err := db.AutoMigrate(&publishProgress{}); err != nil {
+		panic(err)
+	}
+	b.GetWebBuilder().RegisterEventFunc(packageProgressEvent, packageProgress(db))
+
 	publish_view.Configure(b, db, ab, publisher, models...)
 	for _, model := range models {
 		model.Editing().Field("Package").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
 			this := obj.(microsite.MicroSiteInterface)
 
+			// the progress of extracting the package is polled while it's saved
+			uploadID := uuid.New().String()
+			onChange := "locals.progressInterval = 1000;" + web.Plaid().
+				FieldValue("PackageChanged", "true").
+				FieldValue("PackageUploadID", uploadID).String()
+			progress := web.Portal().
+				Loader(web.Plaid().URL(model.Info().ListingHref()).EventFunc(packageProgressEvent).Query("id", uploadID)).
+				AutoReloadInterval("locals.progressInterval")
+
 			if this.GetPackage().FileName == "" {
-				return vuetify.VFileInput().Chips(true).ErrorMessages(field.Errors...).Label(field.Label).FieldName(field.Name).Attr("accept", ".rar,.zip,.7z,.tar").Clearable(false).
-					On("change", web.Plaid().
-						FieldValue("PackageChanged", "true").String())
+				return web.Scope(
+					vuetify.VFileInput().Chips(true).ErrorMessages(field.Errors...).Label(field.Label).FieldName(field.Name).Attr("accept", ".rar,.zip,.7z,.tar").Clearable(false).
+						On("change", onChange),
+					progress,
+				).Init(`{ progressInterval: 0 }`).VSlot("{ locals }")
 			}
 			return web.Scope(
 				h.Div(
@@ -48,11 +64,11 @@ func Configure(b *presets.Builder, db *gorm.DB, ab *activity.ActivityBuilder, st
 				).Class("v-input v-input--is-label-active v-input--is-dirty theme--light v-text-field v-text-field--is-booted"),
 
 				vuetify.VFileInput().Chips(true).ErrorMessages(field.Errors...).Label(field.Label).FieldName(field.Name).Attr("accept", ".rar,.zip,.7z,.tar").Clearable(false).
-					Attr("v-model", "locals.file").On("change", web.Plaid().
-					FieldValue("PackageChanged", "true").String()),
+					Attr("v-model", "locals.file").On("change", onChange),
+				progress,
 			).Init(fmt.Sprintf(`{ file: new File([""], "%v", {
                   lastModified: 0,
-                }) , change: false}`, this.GetPackage().FileName)).
+                }) , change: false, progressInterval: 0}`, this.GetPackage().FileName)).
 				VSlot("{ locals }")
 		}).
 			SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
@@ -80,18 +96,27 @@ func Configure(b *presets.Builder, db *gorm.DB, ab *activity.ActivityBuilder, st
 				if err != nil {
 					return
 				}
-
-				fileBytes, err := io.ReadAll(f)
+				defer f.Close()
+
+				// the archive is streamed from the uploaded file, it's not read into memory
+				var filesList []string
+				if p, ok := this.(microsite.ProgressPublisher); ok {
+					uploadID := packageUploadID(ctx)
+					if uploadID != "" {
+						defer db.Delete(&publishProgress{}, "id = ?", uploadID)
+					}
+					filesList, err = p.UnArchiveAndPublishWithProgress(this.GetPreviewPath, fileName, f, storage, saveProgress(db, uploadID))
+				} else {
+					filesList, err = this.UnArchiveAndPublish(this.GetPreviewPath, fileName, f, storage)
+				}
 				if err != nil {
 					return
 				}
 
-				filesList, err := this.UnArchiveAndPublish(this.GetPreviewPath, fileName, bytes.NewReader(fileBytes), storage)
-				if err != nil {
+				if _, err = f.Seek(0, io.SeekStart); err != nil {
 					return
 				}
-
-				err = utils.Upload(storage, packagePath, bytes.NewReader(fileBytes))
+				err = utils.Upload(storage, packagePath, f)
 				if err != nil {
 					return
 				}
@@ -102,47 +127,80 @@ func Configure(b *presets.Builder, db *gorm.DB, ab *activity.ActivityBuilder, st
 				return
 			})
 
-		model.Editing().Field("FilesList").ComponentFunc(
-			func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (r h.HTMLComponent) {
-				this := obj.(microsite.MicroSiteInterface)
-				if this.GetStatus() == publish.StatusOffline || len(this.GetFileList()) == 0 {
-					return nil
-				}
+		model.Editing().Field("FilesList").ComponentFunc(filesListComponent(model, storage, nil)).
+			SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
+				return nil
+			})
+	}
 
-				var content []h.HTMLComponent
+	return
+}
 
-				content = append(content,
-					h.Label(i18n.PT(ctx.R, presets.ModelsI18nModuleKey, model.Info().Label(), field.Label)).Class("v-label v-label--active theme--light").Style("left: 0px; right: auto; position: absolute;"),
-				)
+// ConfigurePreview links the preview files of the models through pb instead of the storage and adds the button to
+// revoke the preview links, call it after Configure and mount pb at its prefix.
+func ConfigurePreview(b *presets.Builder, db *gorm.DB, storage oss.StorageInterface, pb *microsite.PreviewBuilder, models ...*presets.ModelBuilder) {
+	for _, model := range models {
+		model.Editing().Field("FilesList").ComponentFunc(filesListComponent(model, storage, pb))
+		model.RegisterEventFunc(revokePreviewLinksEvent, revokePreviewLinks(db, model))
+	}
+}
 
-				if this.GetStatus() == publish.StatusOnline {
-					for k, v := range this.GetFileList() {
-						if k != 0 {
-							content = append(content, h.Br())
-						}
-						content = append(content, h.A(h.Text(v)).Href(this.GetPublishedUrl(storage.GetEndpoint(), v)))
-					}
+func filesListComponent(model *presets.ModelBuilder, storage oss.StorageInterface, pb *microsite.PreviewBuilder) presets.FieldComponentFunc {
+	return func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (r h.HTMLComponent) {
+		this := obj.(microsite.MicroSiteInterface)
+		if this.GetStatus() == publish.StatusOffline || len(this.GetFileList()) == 0 {
+			return nil
+		}
+
+		var content []h.HTMLComponent
+
+		content = append(content,
+			h.Label(i18n.PT(ctx.R, presets.ModelsI18nModuleKey, model.Info().Label(), field.Label)).Class("v-label v-label--active theme--light").Style("left: 0px; right: auto; position: absolute;"),
+		)
+
+		var revokeBtn h.HTMLComponent
+		if this.GetStatus() == publish.StatusOnline {
+			for k, v := range this.GetFileList() {
+				if k != 0 {
+					content = append(content, h.Br())
+				}
+				content = append(content, h.A(h.Text(v)).Href(this.GetPublishedUrl(storage.GetEndpoint(), v)))
+			}
+		} else {
+			var signed bool
+			for k, v := range this.GetFileList() {
+				if k != 0 {
+					content = append(content, h.Br())
+				}
+				var u string
+				if pb != nil {
+					u = pb.SignedURL(this, v)
+				}
+				if u == "" {
+					u = this.GetPreviewUrl(storage.GetEndpoint(), v)
 				} else {
-					for k, v := range this.GetFileList() {
-						if k != 0 {
-							content = append(content, h.Br())
-						}
-						content = append(content, h.A(h.Text(v)).Href(this.GetPreviewUrl(storage.GetEndpoint(), v)))
-					}
+					signed = true
 				}
+				content = append(content, h.A(h.Text(v)).Href(u))
+			}
+			if signed && model.Info().Verifier().Do(presets.PermUpdate).ObjectOn(obj).WithReq(ctx.R).IsAllowed() == nil {
+				msgr := i18n.MustGetModuleMessages(ctx.R, I18nMicrositeKey, Messages_en_US).(*Messages)
+				revokeBtn = vuetify.VBtn(msgr.RevokePreviewLinks).Small(true).Text(true).Color("error").Class("px-0").
+					Attr("@click", web.Plaid().
+						URL(model.Info().ListingHref()).
+						EventFunc(revokePreviewLinksEvent).
+						Query(presets.ParamID, this.(microsite.PreviewLinker).PrimarySlug()).
+						Go())
+			}
+		}
 
-				return h.Div(
-					h.Div(
-						h.Div(
-							content...,
-						).Class("v-text-field__slot").Style("padding: 8px 0;"),
-					).Class("v-input__slot"),
-				).Class("v-input v-input--is-label-active v-input--is-dirty theme--light v-text-field v-text-field--is-booted")
-			},
-		).SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
-			return nil
-		})
+		return h.Div(
+			h.Div(
+				h.Div(
+					content...,
+				).Class("v-text-field__slot").Style("padding: 8px 0;"),
+			).Class("v-input__slot"),
+			revokeBtn,
+		).Class("v-input v-input--is-label-active v-input--is-dirty theme--light v-text-field v-text-field--is-booted")
 	}
-
-	return
 }
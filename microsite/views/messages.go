@@ -1,13 +1,22 @@
 package views
 
 type Messages struct {
-	CurrentPackage string
+	CurrentPackage      string
+	PackageProgress     string
+	RevokePreviewLinks  string
+	PreviewLinksRevoked string
 }
 
 var Messages_en_US = &Messages{
-	CurrentPackage: "Current Package",
+	CurrentPackage:      "Current Package",
+	PackageProgress:     "Extracting the package: {Files} files, {Size}",
+	RevokePreviewLinks:  "Revoke the preview links",
+	PreviewLinksRevoked: "The preview links shared before don't work any more",
 }
 
 var Messages_zh_CN = &Messages{
-	CurrentPackage: "当前压缩包",
+	CurrentPackage:      "当前压缩包",
+	PackageProgress:     "正在解压：{Files} 个文件，{Size}",
+	RevokePreviewLinks:  "撤销预览链接",
+	PreviewLinksRevoked: "之前分享的预览链接已失效",
 }
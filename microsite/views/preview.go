@@ -0,0 +1,44 @@
+package views
+
+import (
+	"errors"
+
+	"github.com/qor5/admin/microsite"
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/admin/presets/actions"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	"gorm.io/gorm"
+)
+
+const revokePreviewLinksEvent = "microsite_RevokePreviewLinksEvent"
+
+func revokePreviewLinks(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		paramID := ctx.R.FormValue(presets.ParamID)
+
+		obj := mb.NewModel()
+		obj, err = mb.Editing().Fetcher(obj, paramID, ctx)
+		if err != nil {
+			return
+		}
+		if err = mb.Info().Verifier().Do(presets.PermUpdate).ObjectOn(obj).WithReq(ctx.R).IsAllowed(); err != nil {
+			return
+		}
+		pl, ok := obj.(microsite.PreviewLinker)
+		if !ok {
+			err = errors.New("the preview links of the model can't be revoked")
+			return
+		}
+		if err = pl.RevokePreviewLinks(db); err != nil {
+			return
+		}
+
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMicrositeKey, Messages_en_US).(*Messages)
+		presets.ShowMessage(&r, msgr.PreviewLinksRevoked, "")
+		// the drawer is opened again to show the new links
+		web.AppendVarsScripts(&r, web.Plaid().URL(mb.Info().ListingHref()).EventFunc(actions.Edit).
+			Query(presets.ParamOverlay, actions.Drawer).Query(presets.ParamID, paramID).Go())
+		return
+	}
+}
@@ -0,0 +1,66 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+)
+
+const packageProgressEvent = "microsite_PackageProgressEvent"
+
+// publishProgress is the progress of extracting the package of a microsite being saved,
+// it's kept in the db so the polls of the editing drawer see it whichever process serves them
+type publishProgress struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	Files     int
+	Bytes     int64
+	UpdatedAt time.Time
+}
+
+func (publishProgress) TableName() string {
+	return "microsite_publish_progresses"
+}
+
+// packageUploadID is the id the progress of the upload posted by the request is saved with
+func packageUploadID(ctx *web.EventContext) string {
+	id := ctx.R.FormValue("PackageUploadID")
+	if len(id) > 64 {
+		return ""
+	}
+	return id
+}
+
+func saveProgress(db *gorm.DB, id string) func(files int, bytes int64) {
+	var last time.Time
+	return func(files int, bytes int64) {
+		if id == "" || time.Since(last) < time.Second {
+			return
+		}
+		last = time.Now()
+		db.Save(&publishProgress{ID: id, Files: files, Bytes: bytes})
+	}
+}
+
+func packageProgress(db *gorm.DB) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		var p publishProgress
+		if err = db.Where("id = ?", ctx.R.FormValue("id")).Limit(1).Find(&p).Error; err != nil {
+			return
+		}
+		if p.ID == "" {
+			r.Body = h.Text("")
+			return
+		}
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nMicrositeKey, Messages_en_US).(*Messages)
+		r.Body = h.Div(h.Text(strings.NewReplacer(
+			"{Files}", fmt.Sprint(p.Files),
+			"{Size}", fmt.Sprintf("%.1f MB", float64(p.Bytes)/(1<<20)),
+		).Replace(msgr.PackageProgress))).Class("text-caption grey--text")
+		return
+	}
+}
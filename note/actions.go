@@ -1,6 +1,12 @@
 package note
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/qor5/admin/media/media_library"
 	"github.com/qor5/admin/presets"
 	"github.com/qor5/web"
 	"github.com/qor5/x/i18n"
@@ -14,13 +20,29 @@ func createNoteAction(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc {
 
 		content := ctx.R.FormValue("Content")
 
+		attachment, err := attachmentFromForm(db, ctx.R)
+		if err != nil {
+			return
+		}
+
+		var parentID uint
+		if pid := ctx.QueryAsInt("parent_id"); pid > 0 {
+			var parent QorNote
+			if err = db.Unscoped().Where("resource_type = ? AND resource_id = ?", rt, ri).First(&parent, pid).Error; err != nil {
+				return
+			}
+			parentID = parent.ID
+		}
+
 		userID, creator := GetUserData(ctx)
 		note := QorNote{
 			UserID:       userID,
 			Creator:      creator,
 			ResourceID:   ri,
 			ResourceType: rt,
+			ParentID:     parentID,
 			Content:      content,
+			Attachment:   attachment,
 		}
 
 		if err = db.Save(&note).Error; err != nil {
@@ -53,6 +75,76 @@ func createNoteAction(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc {
 	}
 }
 
+// deleteNoteAction soft deletes a note of the current user, its replies are kept under a placeholder
+func deleteNoteAction(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		ri := ctx.R.FormValue("resource_id")
+		rt := ctx.R.FormValue("resource_type")
+
+		userID, _ := GetUserData(ctx)
+		if err = db.Where("resource_type = ? AND resource_id = ? AND user_id = ?", rt, ri, userID).
+			Delete(&QorNote{}, ctx.QueryAsInt("note_id")).Error; err != nil {
+			return
+		}
+
+		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+			Name: "notes-section",
+			Body: getNotesTab(ctx, db, rt, ri),
+		})
+		return
+	}
+}
+
+// resolveNoteAction resolves or reopens the thread started by a note and logs the change
+func resolveNoteAction(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		ri := ctx.R.FormValue("resource_id")
+		rt := ctx.R.FormValue("resource_type")
+		status := ctx.R.FormValue("status")
+
+		var note QorNote
+		if err = db.Where("resource_type = ? AND resource_id = ? AND parent_id = 0", rt, ri).
+			First(&note, ctx.QueryAsInt("note_id")).Error; err != nil {
+			return
+		}
+		if note.Status() == status {
+			return reloadNotesAction(db, mb)(ctx)
+		}
+
+		userID, creator := GetUserData(ctx)
+		updates := map[string]interface{}{"resolved_at": nil, "resolved_by_id": 0, "resolved_by": ""}
+		switch status {
+		case NoteStatusResolved:
+			updates = map[string]interface{}{"resolved_at": db.NowFunc(), "resolved_by_id": userID, "resolved_by": creator}
+		case NoteStatusOpen:
+		default:
+			err = fmt.Errorf("unknown note status %q", status)
+			return
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&note).UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+			return tx.Create(&QorNoteStatusLog{NoteID: note.ID, Status: status, UserID: userID, Creator: creator}).Error
+		})
+		if err != nil {
+			return
+		}
+
+		return reloadNotesAction(db, mb)(ctx)
+	}
+}
+
+func reloadNotesAction(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+			Name: "notes-section",
+			Body: getNotesTab(ctx, db, ctx.R.FormValue("resource_type"), ctx.R.FormValue("resource_id")),
+		})
+		return
+	}
+}
+
 func updateUserNoteAction(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		ri := ctx.R.FormValue("resource_id")
@@ -86,3 +178,35 @@ func updateUserNoteAction(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc {
 		return
 	}
 }
+
+// attachmentFromForm only takes the ID of the chosen file from the form, the rest of the media box comes from the media library
+func attachmentFromForm(db *gorm.DB, r *http.Request) (mb media_library.MediaBox, err error) {
+	var posted media_library.MediaBox
+	if err = posted.Scan(r.FormValue(attachmentField + ".Values")); err != nil {
+		return
+	}
+	if posted.ID.String() == "" {
+		return
+	}
+	id, err := strconv.ParseUint(posted.ID.String(), 10, 64)
+	if err != nil {
+		return mb, fmt.Errorf("invalid attachment %q", posted.ID)
+	}
+	var m media_library.MediaLibrary
+	if err = db.Where("id = ?", id).First(&m).Error; err != nil {
+		return
+	}
+	mb = media_library.MediaBox{
+		ID:                  json.Number(fmt.Sprint(m.ID)),
+		Url:                 m.File.Url,
+		FileName:            m.File.FileName,
+		Description:         r.FormValue(attachmentField + ".Description"),
+		FileSizes:           m.File.FileSizes,
+		Width:               m.File.Width,
+		Height:              m.File.Height,
+		SeparateDerivatives: m.File.SeparateDerivatives,
+		SizeURL:             m.File.SizeURL,
+		Version:             m.UpdatedAt.UnixNano(),
+	}
+	return
+}
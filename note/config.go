@@ -19,10 +19,13 @@ const (
 
 	createNoteEvent     = "note_CreateNoteEvent"
 	updateUserNoteEvent = "note_UpdateUserNoteEvent"
+	deleteNoteEvent     = "note_DeleteNoteEvent"
+	resolveNoteEvent    = "note_ResolveNoteEvent"
+	reloadNotesEvent    = "note_ReloadNotesEvent"
 )
 
 func Configure(db *gorm.DB, pb *presets.Builder, models ...*presets.ModelBuilder) {
-	if err := db.AutoMigrate(QorNote{}, UserNote{}); err != nil {
+	if err := db.AutoMigrate(QorNote{}, UserNote{}, QorNoteStatusLog{}); err != nil {
 		panic(err)
 	}
 
@@ -33,6 +36,9 @@ func Configure(db *gorm.DB, pb *presets.Builder, models ...*presets.ModelBuilder
 		m.Editing().AppendTabsPanelFunc(tabsPanel(db, m))
 		m.RegisterEventFunc(createNoteEvent, createNoteAction(db, m))
 		m.RegisterEventFunc(updateUserNoteEvent, updateUserNoteAction(db, m))
+		m.RegisterEventFunc(deleteNoteEvent, deleteNoteAction(db, m))
+		m.RegisterEventFunc(resolveNoteEvent, resolveNoteAction(db, m))
+		m.RegisterEventFunc(reloadNotesEvent, reloadNotesAction(db, m))
 		m.Listing().Field("Notes").ComponentFunc(noteFunc(db, m))
 	}
 
@@ -2,7 +2,10 @@ package note
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/media/views"
 	"github.com/qor5/admin/presets"
 	"github.com/qor5/admin/presets/actions"
 	"github.com/qor5/ui/vuetify"
@@ -33,12 +36,15 @@ func GetUserData(ctx *web.EventContext) (userID uint, creator string) {
 
 func getNotesTab(ctx *web.EventContext, db *gorm.DB, resourceType string, resourceId string) h.HTMLComponent {
 	msgr := i18n.MustGetModuleMessages(ctx.R, I18nNoteKey, Messages_en_US).(*Messages)
+	hideResolved := ctx.R.FormValue("hide_resolved") == "true"
 
 	c := h.Div(
 		web.Scope(
 			VCardText(
 				h.Text(msgr.NewNote),
 				VRow(VCol(VTextField().Attr(web.VFieldName("Content")...).Clearable(true))),
+				VRow(VCol(views.QMediaBox(db).FieldName(attachmentField).Label(msgr.Attachment).
+					Value(&media_library.MediaBox{}).Config(&media_library.MediaBoxConfig{}))),
 			),
 			VCardActions(h.Components(
 				VSpacer(),
@@ -48,29 +54,179 @@ func getNotesTab(ctx *web.EventContext, db *gorm.DB, resourceType string, resour
 						EventFunc(createNoteEvent).
 						Query("resource_id", resourceId).
 						Query("resource_type", resourceType).
+						Query("hide_resolved", fmt.Sprint(hideResolved)).
 						Go()+";"+web.Plaid().
 						EventFunc(actions.ReloadList).
 						Go(),
 					),
 			)),
 		).VSlot("{plaidForm}"),
+		VCheckbox().Label(msgr.HideResolved).InputValue(hideResolved).Dense(true).Class("px-4").
+			Attr("@change", web.Plaid().
+				EventFunc(reloadNotesEvent).
+				Query("resource_id", resourceId).
+				Query("resource_type", resourceType).
+				Query("hide_resolved", fmt.Sprint(!hideResolved)).
+				Go()),
 	)
 
-	var notes []QorNote
-	db.Where("resource_type = ? and resource_id = ?", resourceType, resourceId).
-		Order("id DESC").Find(&notes)
+	// deleted notes are loaded to keep the context of their replies
+	var notes []*QorNote
+	db.Unscoped().Where("resource_type = ? and resource_id = ?", resourceType, resourceId).
+		Order("id ASC").Find(&notes)
+	t := &noteThreads{replies: make(map[uint][]*QorNote), logs: make(map[uint][]*QorNoteStatusLog), hideResolved: hideResolved}
+	var rootIDs []uint
+	for _, n := range notes {
+		if n.ParentID == 0 {
+			t.roots = append([]*QorNote{n}, t.roots...)
+			rootIDs = append(rootIDs, n.ID)
+		} else {
+			t.replies[n.ParentID] = append(t.replies[n.ParentID], n)
+		}
+	}
+	if len(rootIDs) > 0 {
+		var logs []*QorNoteStatusLog
+		db.Where("note_id IN (?)", rootIDs).Order("id ASC").Find(&logs)
+		for _, l := range logs {
+			t.logs[l.NoteID] = append(t.logs[l.NoteID], l)
+		}
+	}
 
 	var panels []h.HTMLComponent
-	for _, note := range notes {
+	for _, note := range t.roots {
+		if !t.visible(note) || (hideResolved && note.Status() == NoteStatusResolved) {
+			continue
+		}
+		header := msgr.NoteDeleted
+		if !note.DeletedAt.Valid {
+			header = fmt.Sprintf("%v - %v", note.Creator, presets.FormatTime(ctx.R, note.CreatedAt))
+		}
 		panels = append(panels, vuetify.VExpansionPanel(
-			vuetify.VExpansionPanelHeader(h.Span(fmt.Sprintf("%v - %v", note.Creator, note.CreatedAt.Format("2006-01-02 15:04:05 MST")))),
-			vuetify.VExpansionPanelContent(h.Text(note.Content)),
+			vuetify.VExpansionPanelHeader(h.Div(
+				h.Span(header),
+				h.If(note.Status() == NoteStatusResolved,
+					VChip(h.Text(msgr.Resolved)).Small(true).Color("success").Class("ml-2"),
+				),
+			)),
+			vuetify.VExpansionPanelContent(t.noteBody(ctx, msgr, note, resourceType, resourceId)),
 		))
 	}
 	c.AppendChildren(vuetify.VExpansionPanels(panels...).Attr("style", "padding:10px;"))
 	return c
 }
 
+type noteThreads struct {
+	roots        []*QorNote
+	replies      map[uint][]*QorNote
+	logs         map[uint][]*QorNoteStatusLog
+	hideResolved bool
+}
+
+// visible hides the deleted notes without replies to show
+func (t *noteThreads) visible(n *QorNote) bool {
+	if !n.DeletedAt.Valid {
+		return true
+	}
+	for _, r := range t.replies[n.ID] {
+		if t.visible(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *noteThreads) noteBody(ctx *web.EventContext, msgr *Messages, n *QorNote, resourceType, resourceId string) h.HTMLComponent {
+	body := h.Div()
+	if !n.DeletedAt.Valid {
+		userID, _ := GetUserData(ctx)
+		body.AppendChildren(
+			h.Text(n.Content),
+			attachmentComp(&n.Attachment),
+			h.Div(
+				VBtn(msgr.Reply).Text(true).Small(true).Attr("@click", "locals.replying = !locals.replying"),
+				h.If(userID != 0 && userID == n.UserID,
+					VBtn(msgr.Delete).Text(true).Small(true).Color("error").
+						Attr("@click", fmt.Sprintf("confirm(%q) && %s", msgr.DeleteNoteConfirm, web.Plaid().
+							EventFunc(deleteNoteEvent).
+							Query("note_id", n.ID).
+							Query("resource_id", resourceId).
+							Query("resource_type", resourceType).
+							Query("hide_resolved", fmt.Sprint(t.hideResolved)).
+							Go())),
+				),
+				h.If(n.ParentID == 0, t.resolveBtn(msgr, n, resourceType, resourceId)),
+			).Class("mt-1"),
+			h.Div(web.Scope(
+				VTextField().Attr(web.VFieldName("Content")...).Label(msgr.Reply).Dense(true).
+					Attr("@keyup.enter", web.Plaid().
+						EventFunc(createNoteEvent).
+						Query("parent_id", n.ID).
+						Query("resource_id", resourceId).
+						Query("resource_type", resourceType).
+						Query("hide_resolved", fmt.Sprint(t.hideResolved)).
+						Go()),
+			).VSlot("{plaidForm}")).Attr("v-if", "locals.replying"),
+		)
+		body.Attr(web.InitContextLocals, "{replying: false}")
+	}
+
+	for _, l := range t.logs[n.ID] {
+		msg := msgr.ReopenedBy
+		if l.Status == NoteStatusResolved {
+			msg = msgr.ResolvedBy
+		}
+		body.AppendChildren(h.Div(
+			h.Text(fmt.Sprintf("%s - %s", strings.ReplaceAll(msg, "{User}", l.Creator), presets.FormatTime(ctx.R, l.CreatedAt))),
+		).Class("text-caption grey--text mt-1"))
+	}
+
+	for _, r := range t.replies[n.ID] {
+		if !t.visible(r) {
+			continue
+		}
+		header := msgr.NoteDeleted
+		if !r.DeletedAt.Valid {
+			header = fmt.Sprintf("%v - %v", r.Creator, presets.FormatTime(ctx.R, r.CreatedAt))
+		}
+		body.AppendChildren(h.Div(
+			h.Div(h.Text(header)).Class("text-caption grey--text"),
+			t.noteBody(ctx, msgr, r, resourceType, resourceId),
+		).Class("mt-3 pl-3").Style("border-left: 2px solid #e0e0e0;"))
+	}
+	return body
+}
+
+func (t *noteThreads) resolveBtn(msgr *Messages, n *QorNote, resourceType, resourceId string) h.HTMLComponent {
+	label, status := msgr.Resolve, NoteStatusResolved
+	if n.Status() == NoteStatusResolved {
+		label, status = msgr.Reopen, NoteStatusOpen
+	}
+	return VBtn(label).Text(true).Small(true).
+		Attr("@click", web.Plaid().
+			EventFunc(resolveNoteEvent).
+			Query("note_id", n.ID).
+			Query("status", status).
+			Query("resource_id", resourceId).
+			Query("resource_type", resourceType).
+			Query("hide_resolved", fmt.Sprint(t.hideResolved)).
+			Go())
+}
+
+const attachmentField = "Attachment"
+
+func attachmentComp(mb *media_library.MediaBox) h.HTMLComponent {
+	if mb.IsEmpty() {
+		return nil
+	}
+	if mb.IsImage() {
+		return h.Div(
+			h.A(h.Img(mb.URL(media_library.QorPreviewSizeName)).Alt(mb.Description).Style("max-width: 100%;")).
+				Href(mb.URL()).Target("_blank"),
+		).Class("mt-2")
+	}
+	return h.Div(h.A(h.Text(mb.FileName)).Href(mb.URL()).Target("_blank")).Class("mt-2")
+}
+
 var AfterCreateFunc = func(db *gorm.DB) (err error) {
 	return
 }
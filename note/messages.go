@@ -5,6 +5,17 @@ type Messages struct {
 	Item                string
 	Notes               string
 	NewNote             string
+	Attachment          string
+	Reply               string
+	Delete              string
+	DeleteNoteConfirm   string
+	NoteDeleted         string
+	Resolve             string
+	Reopen              string
+	Resolved            string
+	HideResolved        string
+	ResolvedBy          string
+	ReopenedBy          string
 }
 
 var Messages_en_US = &Messages{
@@ -12,6 +23,17 @@ var Messages_en_US = &Messages{
 	Item:                "Item",
 	Notes:               "Notes",
 	NewNote:             "New Note",
+	Attachment:          "Attachment",
+	Reply:               "Reply",
+	Delete:              "Delete",
+	DeleteNoteConfirm:   "Are you sure you want to delete this note?",
+	NoteDeleted:         "This note was deleted",
+	Resolve:             "Resolve",
+	Reopen:              "Reopen",
+	Resolved:            "Resolved",
+	HideResolved:        "Hide resolved",
+	ResolvedBy:          "Resolved by {User}",
+	ReopenedBy:          "Reopened by {User}",
 }
 
 var Messages_zh_CN = &Messages{
@@ -19,6 +41,17 @@ var Messages_zh_CN = &Messages{
 	Item:                "记录",
 	Notes:               "备注",
 	NewNote:             "新建备注",
+	Attachment:          "附件",
+	Reply:               "回复",
+	Delete:              "删除",
+	DeleteNoteConfirm:   "确定要删除这条备注吗？",
+	NoteDeleted:         "该备注已删除",
+	Resolve:             "解决",
+	Reopen:              "重新打开",
+	Resolved:            "已解决",
+	HideResolved:        "隐藏已解决",
+	ResolvedBy:          "{User} 已解决",
+	ReopenedBy:          "{User} 重新打开",
 }
 
 var Messages_ja_JP = &Messages{
@@ -26,4 +59,15 @@ var Messages_ja_JP = &Messages{
 	Item:                "アイテム",
 	Notes:               "ノート",
 	NewNote:             "新規ノート",
+	Attachment:          "添付ファイル",
+	Reply:               "返信",
+	Delete:              "削除",
+	DeleteNoteConfirm:   "このノートを削除してもよろしいですか？",
+	NoteDeleted:         "このノートは削除されました",
+	Resolve:             "解決",
+	Reopen:              "再開",
+	Resolved:            "解決済み",
+	HideResolved:        "解決済みを非表示",
+	ResolvedBy:          "{User} が解決しました",
+	ReopenedBy:          "{User} が再開しました",
 }
@@ -3,7 +3,9 @@ package note
 import (
 	"errors"
 	"strings"
+	"time"
 
+	"github.com/qor5/admin/media/media_library"
 	"gorm.io/gorm"
 )
 
@@ -14,11 +16,41 @@ type QorNote struct {
 	Creator      string
 	ResourceType string `gorm:"index"`
 	ResourceID   string `gorm:"index"`
-	Content      string `sql:"size:5000"`
+	// ParentID is the note replied to, zero for the notes starting a thread
+	ParentID uint   `gorm:"index"`
+	Content  string `sql:"size:5000"`
+	// Attachment references a file of the media library, deleting the note keeps the file
+	Attachment media_library.MediaBox `sql:"type:text;"`
+	// ResolvedAt is set while the thread started by the note is resolved
+	ResolvedAt   *time.Time
+	ResolvedByID uint
+	ResolvedBy   string
+}
+
+const (
+	NoteStatusOpen     = "open"
+	NoteStatusResolved = "resolved"
+)
+
+func (this *QorNote) Status() string {
+	if this.ResolvedAt != nil {
+		return NoteStatusResolved
+	}
+	return NoteStatusOpen
+}
+
+// QorNoteStatusLog records each time a note is resolved or reopened
+type QorNoteStatusLog struct {
+	gorm.Model
+
+	NoteID  uint `gorm:"index"`
+	Status  string
+	UserID  uint
+	Creator string
 }
 
 func (this *QorNote) BeforeCreate(tx *gorm.DB) (err error) {
-	if strings.TrimSpace(this.Content) == "" {
+	if strings.TrimSpace(this.Content) == "" && this.Attachment.IsEmpty() {
 		err = errors.New("Note cannot be empty")
 	}
 
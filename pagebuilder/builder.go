@@ -3,6 +3,7 @@ package pagebuilder
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"path"
@@ -11,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/qor5/admin/activity"
 	"github.com/qor5/admin/l10n"
 	l10n_view "github.com/qor5/admin/l10n/views"
@@ -73,6 +75,10 @@ type Builder struct {
 	pageStyle         h.HTMLComponent
 	pageLayoutFunc    PageLayoutFunc
 	preview           http.Handler
+	sandbox           http.Handler
+	sandboxRender     http.Handler
+	sandboxOrigin     string
+	sandboxSecret     []byte
 	images            http.Handler
 	seoBuilder        *seo.Builder
 	imagesPrefix      string
@@ -80,6 +86,9 @@ type Builder struct {
 	publishBtnColor   string
 	duplicateBtnColor string
 	templateEnabled   bool
+	publisher         *publish.Builder
+	// categoryRepublisher republishes the pages of a category whose path changed
+	categoryRepublisher CategoryRepublisher
 }
 
 const (
@@ -112,6 +121,8 @@ func New(prefix string, db *gorm.DB, i18nB *i18n.Builder) *Builder {
 		&Container{},
 		&DemoContainer{},
 		&Category{},
+		&PageRedirect{},
+		&PageLock{},
 	)
 	if err != nil {
 		panic(err)
@@ -154,7 +165,12 @@ create unique index if not exists uidx_page_builder_demo_containers_model_name_l
 	r.ps.GetWebBuilder().RegisterEventFunc(MarkAsSharedContainerEvent, r.MarkAsSharedContainer)
 	r.ps.GetWebBuilder().RegisterEventFunc(RenameContainerDialogEvent, r.RenameContainerDialog)
 	r.ps.GetWebBuilder().RegisterEventFunc(RenameContainerEvent, r.RenameContainer)
+	r.ps.GetWebBuilder().RegisterEventFunc(refreshPageLockEvent, r.RefreshPageLock)
+	r.ps.GetWebBuilder().RegisterEventFunc(releasePageLockEvent, r.ReleasePageLock)
+	r.ps.GetWebBuilder().RegisterEventFunc(forceUnlockPageEvent, r.ForceUnlockPage)
 	r.preview = r.ps.GetWebBuilder().Page(r.Preview)
+	r.sandbox = r.ps.GetWebBuilder().Page(r.Sandbox)
+	r.sandboxRender = r.ps.GetWebBuilder().Page(r.SandboxRender)
 	return r
 }
 
@@ -493,7 +509,10 @@ function(e){
 	pm.RegisterEventFunc(createNoteEvent, createNote(db, pm))
 	pm.RegisterEventFunc(editSEODialogEvent, editSEODialog(db, pm, seoBuilder))
 	pm.RegisterEventFunc(updateSEOEvent, updateSEO(db, pm))
-	eb := pm.Editing("TemplateSelection", "Title", "CategoryID", "Slug")
+	pm.RegisterEventFunc(previewPublishUrlEvent, previewPublishUrl(db, l10nB))
+	pm.RegisterEventFunc(duplicatePageEvent, b.duplicatePage(db, pm, l10nB))
+	lb.RowMenu().RowMenuItem("Duplicate").ComponentFunc(duplicatePageRowMenuItem(pm))
+	eb := pm.Editing("TemplateSelection", "Title", "CategoryID", "Slug", "NotFound")
 	eb.ValidateFunc(func(obj interface{}, ctx *web.EventContext) (err web.ValidationErrors) {
 		c := obj.(*Page)
 		err = pageValidator(ctx.R.Context(), c, db, l10nB)
@@ -505,11 +524,16 @@ function(e){
 			vErr = *ve
 		}
 
-		return VTextField().
-			FieldName(field.Name).
-			Prefix("/").
-			Label(field.Label).Value(strings.TrimPrefix(field.Value(obj).(string), "/")).
-			ErrorMessages(vErr.GetFieldErrors("Page.Slug")...)
+		p := obj.(*Page)
+		return h.Div(
+			VTextField().
+				FieldName(field.Name).
+				Prefix("/").
+				Label(field.Label).Value(strings.TrimPrefix(field.Value(obj).(string), "/")).
+				ErrorMessages(vErr.GetFieldErrors("Page.Slug")...).
+				Attr("@input", previewPublishUrlQuery(p).FieldValue(field.Name, web.Var("$event")).Go()),
+			web.Portal(publishUrlPreview(p, db, l10nB, ctx)).Name(publishUrlPreviewPortal),
+		)
 	}).SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
 		m := obj.(*Page)
 		m.Slug = path.Join("/", m.Slug)
@@ -533,7 +557,14 @@ function(e){
 		return vx.VXAutocomplete().Label(msgr.Category).FieldName(field.Name).
 			Multiple(false).Chips(false).
 			Items(categories).Value(p.CategoryID).ItemText("Path").ItemValue("ID").
-			ErrorMessages(vErr.GetFieldErrors("Page.Category")...)
+			ErrorMessages(vErr.GetFieldErrors("Page.Category")...).
+			Attr("@change", previewPublishUrlQuery(p).FieldValue(field.Name, web.Var("$event")).Go())
+	})
+
+	eb.Field("NotFound").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
+		return VCheckbox().FieldName(field.Name).Label(msgr.NotFoundPage).
+			InputValue(obj.(*Page).NotFound)
 	})
 
 	eb.Field("TemplateSelection").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
@@ -574,11 +605,23 @@ function(e){
 			eb.Fetcher(&fromPage, id, ctx)
 			p.SEO = fromPage.SEO
 		}
+		if id != "" && funcName != pv.DuplicateVersionEvent && !strings.Contains(ctx.R.RequestURI, pv.SaveNewVersionEvent) {
+			if err = b.checkPageLock(ctx, p.ID, p.GetVersion(), p.GetLocale()); err != nil {
+				return
+			}
+		}
 
 		err = db.Transaction(func(tx *gorm.DB) (inerr error) {
 			if inerr = gorm2op.DataOperator(tx).Save(obj, id, ctx); inerr != nil {
 				return
 			}
+			// only one 404 page for each locale
+			if p.NotFound {
+				if inerr = tx.Model(&Page{}).Where("id <> ? AND locale_code = ? AND not_found = true", p.ID, p.LocaleCode).
+					Update("not_found", false).Error; inerr != nil {
+					return
+				}
+			}
 
 			if strings.Contains(ctx.R.RequestURI, pv.SaveNewVersionEvent) || strings.Contains(ctx.R.RequestURI, pv.DuplicateVersionEvent) {
 				if inerr = b.copyContainersToNewPageVersion(tx, int(p.ID), p.GetLocale(), p.ParentVersion, p.GetVersion()); inerr != nil {
@@ -640,6 +683,7 @@ function(e){
 		l10n_view.Configure(pb, db, l10nB, activityB, pm, demoContainerM, templateM, categoryM)
 	}
 	if publisher != nil {
+		b.publisher = publisher
 		publisher.WithPageBuilder(b)
 		pv.Configure(pb, db, activityB, publisher, pm)
 		pm.Editing().SidePanelFunc(nil).ActionsFunc(nil)
@@ -910,7 +954,22 @@ func (b *Builder) ConfigCategory(pb *presets.Builder, db *gorm.DB, l10nB *l10n.B
 	eb.SaveFunc(func(obj interface{}, id string, ctx *web.EventContext) (err error) {
 		c := obj.(*Category)
 		c.Path = path.Clean(c.Path)
-		err = db.Save(c).Error
+		var old Category
+		err = db.Transaction(func(tx *gorm.DB) (err error) {
+			if c.ID != 0 {
+				if err = tx.Where("id = ? AND locale_code = ?", c.ID, c.LocaleCode).Limit(1).Find(&old).Error; err != nil {
+					return
+				}
+			}
+			return tx.Save(c).Error
+		})
+		if err != nil {
+			return
+		}
+		// the pages are queued once the changed path is committed, so they are republished with it
+		if b.categoryRepublisher != nil && old.ID != 0 && old.Path != c.Path {
+			err = b.categoryRepublisher(ctx.R, c.ID, c.LocaleCode)
+		}
 		return
 	})
 
@@ -1147,10 +1206,10 @@ func schedulePublishDialog(db *gorm.DB, mb *presets.ModelBuilder) web.EventFunc
 
 		var start, end string
 		if s.GetScheduledStartAt() != nil {
-			start = s.GetScheduledStartAt().Format("2006-01-02 15:04")
+			start = presets.LocalTime(ctx.R, *s.GetScheduledStartAt()).Format("2006-01-02 15:04")
 		}
 		if s.GetScheduledEndAt() != nil {
-			end = s.GetScheduledEndAt().Format("2006-01-02 15:04")
+			end = presets.LocalTime(ctx.R, *s.GetScheduledEndAt()).Format("2006-01-02 15:04")
 		}
 
 		msgr := i18n.MustGetModuleMessages(ctx.R, pv.I18nPublishKey, Messages_en_US).(*pv.Messages)
@@ -1480,6 +1539,10 @@ func (b *Builder) ConfigSharedContainer(pb *presets.Builder, db *gorm.DB) (pm *p
 	listing := pm.Listing("DisplayName").SearchColumns("display_name")
 	listing.RowMenu("Rename").RowMenuItem("Rename").ComponentFunc(func(obj interface{}, id string, ctx *web.EventContext) h.HTMLComponent {
 		c := obj.(*Container)
+		cb, ok := b.LookupContainer(c.ModelName)
+		if !ok {
+			return nil
+		}
 		msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
 		return VListItem(
 			VListItemIcon(VIcon("edit_note")),
@@ -1487,7 +1550,7 @@ func (b *Builder) ConfigSharedContainer(pb *presets.Builder, db *gorm.DB) (pm *p
 			VListItemTitle(h.Text(msgr.Rename)),
 		).Attr("@click",
 			web.Plaid().
-				URL(b.ContainerByName(c.ModelName).mb.Info().ListingHref()).
+				URL(cb.mb.Info().ListingHref()).
 				EventFunc(RenameContainerDialogEvent).
 				Query(paramContainerID, c.PrimarySlug()).
 				Query(paramContainerName, c.DisplayName).
@@ -1541,11 +1604,15 @@ func (b *Builder) ConfigSharedContainer(pb *presets.Builder, db *gorm.DB) (pm *p
 	listing.CellWrapperFunc(func(cell h.MutableAttrHTMLComponent, id string, obj interface{}, dataTableID string) h.HTMLComponent {
 		tdbind := cell
 		c := obj.(*Container)
+		cb, ok := b.LookupContainer(c.ModelName)
+		if !ok {
+			return tdbind
+		}
 
 		tdbind.SetAttr("@click.self",
 			web.Plaid().
 				EventFunc(actions.Edit).
-				URL(b.ContainerByName(c.ModelName).GetModelBuilder().Info().ListingHref()).
+				URL(cb.GetModelBuilder().Info().ListingHref()).
 				Query(presets.ParamID, c.ModelID).
 				Query(paramOpenFromSharedContainer, 1).
 				Go()+fmt.Sprintf(`; vars.currEditingListItemID="%s-%d"`, dataTableID, c.ModelID))
@@ -1661,11 +1728,15 @@ func (b *Builder) ConfigDemoContainer(pb *presets.Builder, db *gorm.DB) (pm *pre
 	listing.CellWrapperFunc(func(cell h.MutableAttrHTMLComponent, id string, obj interface{}, dataTableID string) h.HTMLComponent {
 		tdbind := cell
 		c := obj.(*DemoContainer)
+		cb, ok := b.LookupContainer(c.ModelName)
+		if !ok {
+			return tdbind
+		}
 
 		tdbind.SetAttr("@click.self",
 			web.Plaid().
 				EventFunc(actions.Edit).
-				URL(b.ContainerByName(c.ModelName).GetModelBuilder().Info().ListingHref()).
+				URL(cb.GetModelBuilder().Info().ListingHref()).
 				Query(presets.ParamID, c.ModelID).
 				Go()+fmt.Sprintf(`; vars.currEditingListItemID="%s-%d"`, dataTableID, c.ModelID))
 
@@ -1799,6 +1870,7 @@ type ContainerBuilder struct {
 	modelType  reflect.Type
 	renderFunc RenderFunc
 	cover      string
+	required   map[string]bool
 }
 
 func (b *Builder) RegisterContainer(name string) (r *ContainerBuilder) {
@@ -1822,6 +1894,8 @@ func (b *ContainerBuilder) Model(m interface{}) *ContainerBuilder {
 	b.modelType = val.Elem().Type()
 
 	b.configureRelatedOnlinePagesTab()
+	b.configureSharedContainer()
+	b.configurePageLock()
 	return b
 }
 
@@ -1874,28 +1948,7 @@ func (b *ContainerBuilder) configureRelatedOnlinePagesTab() {
 			panic(err)
 		}
 
-		pages := []*Page{}
-		pageTable := (&Page{}).TableName()
-		containerTable := (&Container{}).TableName()
-		err = b.builder.db.Model(&Page{}).
-			Joins(fmt.Sprintf(`inner join %s on 
-        %s.id = %s.page_id
-        and %s.version = %s.page_version
-        and %s.locale_code = %s.locale_code`,
-				containerTable,
-				pageTable, containerTable,
-				pageTable, containerTable,
-				pageTable, containerTable,
-			)).
-			// FIXME: add container locale condition after container supports l10n
-			Where(fmt.Sprintf(`%s.status = ? and %s.model_id = ? and %s.model_name = ?`,
-				pageTable,
-				containerTable,
-				containerTable,
-			), publish.StatusOnline, id, b.name).
-			Group(fmt.Sprintf(`%s.id,%s.version,%s.locale_code`, pageTable, pageTable, pageTable)).
-			Find(&pages).
-			Error
+		pages, err := b.relatedOnlinePages(id)
 		if err != nil {
 			panic(err)
 		}
@@ -1944,6 +1997,112 @@ func (b *ContainerBuilder) configureRelatedOnlinePagesTab() {
 	})
 }
 
+func (b *ContainerBuilder) relatedOnlinePages(modelID interface{}) (pages []*Page, err error) {
+	pageTable := (&Page{}).TableName()
+	containerTable := (&Container{}).TableName()
+	err = b.builder.db.Model(&Page{}).
+		Joins(fmt.Sprintf(`inner join %s on 
+        %s.id = %s.page_id
+        and %s.version = %s.page_version
+        and %s.locale_code = %s.locale_code`,
+			containerTable,
+			pageTable, containerTable,
+			pageTable, containerTable,
+			pageTable, containerTable,
+		)).
+		// FIXME: add container locale condition after container supports l10n
+		Where(fmt.Sprintf(`%s.status = ? and %s.model_id = ? and %s.model_name = ?`,
+			pageTable,
+			containerTable,
+			containerTable,
+		), publish.StatusOnline, modelID, b.name).
+		Group(fmt.Sprintf(`%s.id,%s.version,%s.locale_code`, pageTable, pageTable, pageTable)).
+		Find(&pages).
+		Error
+	return
+}
+
+// configureSharedContainer marks the online pages using a shared container with ListUpdated after it is saved,
+// for RepublishListUpdatedPages to republish them, and blocks deleting a shared container that is still used by pages.
+// Set SaveFunc and DeleteFunc of the container editing before Model to keep them wrapped.
+func (b *ContainerBuilder) configureSharedContainer() {
+	eb := b.mb.Editing()
+	saver := eb.Saver
+	eb.SaveFunc(func(obj interface{}, id string, ctx *web.EventContext) (err error) {
+		if err = saver(obj, id, ctx); err != nil {
+			return
+		}
+		if id == "" {
+			return
+		}
+		return b.markSharedContainerPages(id)
+	})
+
+	deleter := eb.Deleter
+	eb.DeleteFunc(func(obj interface{}, id string, ctx *web.EventContext) (err error) {
+		var count int64
+		if err = b.builder.db.Model(&Container{}).Where("model_name = ? AND model_id = ? AND shared = true", b.name, id).Count(&count).Error; err != nil {
+			return
+		}
+		if count > 0 {
+			return errors.New(unableDeleteSharedContainerMsg)
+		}
+		return deleter(obj, id, ctx)
+	})
+}
+
+func (b *ContainerBuilder) markSharedContainerPages(modelID string) (err error) {
+	var count int64
+	if err = b.builder.db.Model(&Container{}).Where("model_name = ? AND model_id = ? AND shared = true", b.name, modelID).Count(&count).Error; err != nil {
+		return
+	}
+	if count == 0 {
+		return
+	}
+	pages, err := b.relatedOnlinePages(modelID)
+	if err != nil {
+		return
+	}
+	for _, p := range pages {
+		if err = b.builder.db.Model(p).UpdateColumn("list_updated", true).Error; err != nil {
+			return
+		}
+	}
+	return
+}
+
+// RepublishListUpdatedPages republishes the online pages marked with ListUpdated, like the ones using a shared container
+// that has been saved. Run it in the background, like the list publisher of publish.RunPublisher.
+// The mark is removed before a page is published, so a shared container saved meanwhile marks it again,
+// and restored if the publish fails.
+func (b *Builder) RepublishListUpdatedPages() (err error) {
+	if b.publisher == nil {
+		return
+	}
+	for {
+		var pages []*Page
+		if err = b.db.Where("list_updated = ? AND status = ?", true, publish.StatusOnline).
+			Order("id, version, locale_code").Limit(RepublishBatchSize).Find(&pages).Error; err != nil {
+			return
+		}
+		for _, p := range pages {
+			if err = b.db.Model(p).UpdateColumn("list_updated", false).Error; err != nil {
+				return
+			}
+			if err2 := b.publisher.Publish(p); err2 != nil {
+				log.Printf("republish page %d: %v", p.ID, err2)
+				err = multierror.Append(err, err2).ErrorOrNil()
+				if err2 = b.db.Model(p).UpdateColumn("list_updated", true).Error; err2 != nil {
+					return multierror.Append(err, err2).ErrorOrNil()
+				}
+			}
+		}
+		if len(pages) < RepublishBatchSize || err != nil {
+			return
+		}
+	}
+}
+
 func republishRelatedOnlinePages(pageURL string) web.EventFunc {
 	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
 		ids := strings.Split(ctx.R.FormValue("ids"), ",")
@@ -1970,6 +2129,16 @@ func (b *Builder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if b.sandboxOrigin != "" && strings.Index(r.RequestURI, b.prefix+sandboxRenderPath) >= 0 {
+		b.sandboxRender.ServeHTTP(w, r)
+		return
+	}
+
+	if b.sandboxOrigin != "" && strings.Index(r.RequestURI, b.prefix+sandboxPath) >= 0 {
+		b.sandbox.ServeHTTP(w, r)
+		return
+	}
+
 	if strings.Index(r.RequestURI, path.Join(b.prefix, b.imagesPrefix)) >= 0 {
 		b.images.ServeHTTP(w, r)
 		return
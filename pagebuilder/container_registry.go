@@ -0,0 +1,97 @@
+package pagebuilder
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	h "github.com/theplant/htmlgo"
+)
+
+// ContainerField describes an editable field of a container model
+type ContainerField struct {
+	Name     string
+	Type     string
+	Required bool
+}
+
+// ContainerTypes returns the registered containers in registration order, as listed in the add container dialog
+func (b *Builder) ContainerTypes() []*ContainerBuilder {
+	return b.containerBuilders
+}
+
+// LookupContainer returns the container registered with the name, it doesn't panic like ContainerByName
+// for containers stored in pages but no longer registered.
+func (b *Builder) LookupContainer(name string) (r *ContainerBuilder, ok bool) {
+	for _, cb := range b.containerBuilders {
+		if cb.name == name {
+			return cb, true
+		}
+	}
+	return nil, false
+}
+
+func (b *ContainerBuilder) Name() string {
+	return b.name
+}
+
+// Required marks fields that must not be zero, they are validated when the container is saved in the editor
+func (b *ContainerBuilder) Required(fields ...string) *ContainerBuilder {
+	if b.required == nil {
+		b.required = make(map[string]bool)
+	}
+	for _, f := range fields {
+		b.required[f] = true
+	}
+	b.mb.Editing().ValidateFunc(func(obj interface{}, ctx *web.EventContext) (err web.ValidationErrors) {
+		return b.Validate(obj, ctx)
+	})
+	return b
+}
+
+// Schema lists the exported fields of the container model except the ID
+func (b *ContainerBuilder) Schema() (r []*ContainerField) {
+	if b.modelType == nil {
+		return nil
+	}
+	for i := 0; i < b.modelType.NumField(); i++ {
+		f := b.modelType.Field(i)
+		if !f.IsExported() || f.Anonymous || f.Name == "ID" || f.Tag.Get("gorm") == "-" {
+			continue
+		}
+		r = append(r, &ContainerField{
+			Name:     f.Name,
+			Type:     f.Type.String(),
+			Required: b.required[f.Name],
+		})
+	}
+	return
+}
+
+// Validate checks the required fields of a container model
+func (b *ContainerBuilder) Validate(obj interface{}, ctx *web.EventContext) (err web.ValidationErrors) {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	for _, f := range b.Schema() {
+		if !f.Required {
+			continue
+		}
+		if fv := v.FieldByName(f.Name); !fv.IsValid() || fv.IsZero() {
+			err.FieldError(f.Name, msgr.FieldRequired)
+		}
+	}
+	return
+}
+
+// unknownContainer is rendered for a stored container whose type is not registered,
+// editors see a placeholder while live pages skip it.
+func unknownContainer(c *Container, input *RenderInput, ctx *web.EventContext) h.HTMLComponent {
+	if !input.IsEditor {
+		return nil
+	}
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
+	return h.Div(
+		h.Text(fmt.Sprintf("%s: %s", msgr.UnknownContainer, c.ModelName)),
+	).Style("border: 1px dashed #999; color: #777; padding: 24px; text-align: center;")
+}
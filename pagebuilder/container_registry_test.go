@@ -0,0 +1,50 @@
+package pagebuilder
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/qor5/web"
+)
+
+type testBlock struct {
+	ID      uint
+	Title   string
+	Body    string
+	Visible bool
+	ignored string
+}
+
+func TestContainerSchema(t *testing.T) {
+	b := &Builder{}
+	cb := b.RegisterContainer("Block")
+	cb.modelType = reflect.TypeOf(testBlock{})
+	cb.required = map[string]bool{"Title": true}
+
+	if _, ok := b.LookupContainer("Missing"); ok {
+		t.Error("unregistered container found")
+	}
+	if got, ok := b.LookupContainer("Block"); !ok || got != cb {
+		t.Error("registered container not found")
+	}
+
+	var names []string
+	for _, f := range cb.Schema() {
+		names = append(names, f.Name)
+		if f.Required != (f.Name == "Title") {
+			t.Errorf("%s required = %v", f.Name, f.Required)
+		}
+	}
+	if want := []string{"Title", "Body", "Visible"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("schema = %v, want %v", names, want)
+	}
+
+	ctx := &web.EventContext{R: httptest.NewRequest("GET", "/", nil)}
+	if errs := cb.Validate(&testBlock{}, ctx); errs.GetFieldErrors("Title") == nil {
+		t.Error("empty required field passed validation")
+	}
+	if errs := cb.Validate(&testBlock{Title: "t"}, ctx); errs.HaveErrors() {
+		t.Errorf("valid block failed validation: %v", errs.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package pagebuilder
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/qor5/admin/l10n"
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/admin/publish"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+)
+
+const duplicatePageEvent = "pageBuilder_DuplicatePageEvent"
+
+var errNoFreeSlug = errors.New("no free slug is found for the copy of the page")
+
+func duplicatePageRowMenuItem(pm *presets.ModelBuilder) func(obj interface{}, id string, ctx *web.EventContext) h.HTMLComponent {
+	return func(obj interface{}, id string, ctx *web.EventContext) h.HTMLComponent {
+		if pm.Info().Verifier().Do(presets.PermCreate).WithReq(ctx.R).IsAllowed() != nil {
+			return nil
+		}
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
+		return VListItem(
+			VListItemIcon(VIcon("content_copy")),
+			VListItemTitle(h.Text(msgr.Duplicate)),
+		).Attr("@click", web.Plaid().
+			EventFunc(duplicatePageEvent).
+			Query(presets.ParamID, id).
+			Go())
+	}
+}
+
+// duplicatePage copies the page with its containers as a new draft page with a free slug, then opens the copy.
+// Shared containers are referenced by the copy while the others are copied.
+func (b *Builder) duplicatePage(db *gorm.DB, pm *presets.ModelBuilder, l10nB *l10n.Builder) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		if err = pm.Info().Verifier().Do(presets.PermCreate).WithReq(ctx.R).IsAllowed(); err != nil {
+			return
+		}
+		obj, err := pm.Editing().Fetcher(pm.NewModel(), ctx.R.FormValue(presets.ParamID), ctx)
+		if err != nil {
+			return
+		}
+		from := obj.(*Page)
+
+		version := fmt.Sprintf("%s-v01", db.NowFunc().Format("2006-01-02"))
+		p := &Page{
+			Title:      from.Title,
+			CategoryID: from.CategoryID,
+			SEO:        from.SEO,
+			Status:     publish.Status{Status: publish.StatusDraft},
+			Version:    publish.Version{Version: version, VersionName: version},
+			Locale:     from.Locale,
+		}
+		if p.Slug, err = suggestSlug(p, from.Slug, db, l10nB); err != nil {
+			presets.ShowMessage(&r, err.Error(), "error")
+			err = nil
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(p).Error; err != nil {
+				return err
+			}
+			return b.copyContainersToAnotherPage(tx, int(from.ID), from.GetVersion(), from.GetLocale(), int(p.ID), p.GetVersion(), p.GetLocale())
+		})
+		if err != nil {
+			return
+		}
+
+		r.PushState = web.Location(nil).URL(pm.Info().DetailingHref(p.PrimarySlug()))
+		return
+	}
+}
+
+// suggestSlug returns the first of slug-copy, slug-copy-2, ... slug-copy-100 that is valid and doesn't conflict with
+// another page, or errNoFreeSlug if none is
+func suggestSlug(p *Page, slug string, db *gorm.DB, l10nB *l10n.Builder) (string, error) {
+	base := path.Clean(path.Join("/", slug))
+	if base == "/" {
+		base = "/copy"
+	} else {
+		base += "-copy"
+	}
+	p.Slug = base
+	for i := 2; ; i++ {
+		if _, err := checkPagePublishUrl(p, db, l10nB); !err.HaveErrors() {
+			return p.Slug, nil
+		}
+		if i > 100 {
+			return "", errNoFreeSlug
+		}
+		p.Slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
@@ -105,7 +105,18 @@ func (b *Builder) Editor(ctx *web.EventContext) (r web.PageResponse, err error)
 	r.PageTitle = fmt.Sprintf("Editor for %s: %s", id, p.Title)
 	device, _ = b.getDevice(ctx)
 
-	containerList, err = b.renderContainersList(ctx, p.ID, p.GetVersion(), p.GetLocale(), p.GetStatus() != publish.StatusDraft)
+	isReadonly := p.GetStatus() != publish.StatusDraft
+	var lockComp h.HTMLComponent
+	if !isTpl && !isReadonly {
+		var other *PageLock
+		if other, err = b.acquirePageLock(ctx, p.ID, p.GetVersion(), p.GetLocale()); err != nil {
+			return
+		}
+		lockComp = b.pageLockComp(ctx, p.ID, p.GetVersion(), p.GetLocale(), other)
+		isReadonly = other != nil
+	}
+
+	containerList, err = b.renderContainersList(ctx, p.ID, p.GetVersion(), p.GetLocale(), isReadonly)
 	if err != nil {
 		return
 	}
@@ -139,6 +150,7 @@ func (b *Builder) Editor(ctx *web.EventContext) (r web.PageResponse, err error)
 			App(true),
 
 		VMain(
+			lockComp,
 			VContainer(web.Portal(body).Name(editorPreviewContentPortal)).
 				Class("mt-6").
 				Fluid(true),
@@ -345,18 +357,23 @@ func (b *Builder) renderContainers(ctx *web.EventContext, p *Page, isEditor bool
 		if ec.container.Hidden {
 			continue
 		}
-		obj := ec.builder.NewModel()
-		err = b.db.FirstOrCreate(obj, "id = ?", ec.container.ModelID).Error
-		if err != nil {
-			return
-		}
-
 		input := RenderInput{
 			Page:       p,
 			IsEditor:   isEditor,
 			IsReadonly: isReadonly,
 			Device:     device,
 		}
+		if ec.builder == nil || ec.builder.renderFunc == nil {
+			r = append(r, unknownContainer(ec.container, &input, ctx))
+			continue
+		}
+
+		obj := ec.builder.NewModel()
+		err = b.db.FirstOrCreate(obj, "id = ?", ec.container.ModelID).Error
+		if err != nil {
+			return
+		}
+
 		pure := ec.builder.renderFunc(obj, &input, ctx)
 		r = append(r, pure)
 	}
@@ -389,6 +406,7 @@ func (b *Builder) renderContainersList(ctx *web.EventContext, pageID uint, pageV
 		return
 	}
 
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
 	var sorterData ContainerSorter
 	for i, c := range cons {
 		vicon := "visibility"
@@ -396,6 +414,12 @@ func (b *Builder) renderContainersList(ctx *web.EventContext, pageID uint, pageV
 			vicon = "visibility_off"
 		}
 		var displayName = i18n.T(ctx.R, presets.ModelsI18nModuleKey, c.DisplayName)
+		var url string
+		if cb, ok := b.LookupContainer(c.ModelName); ok {
+			url = cb.mb.Info().ListingHref()
+		} else {
+			displayName = fmt.Sprintf("%s (%s)", displayName, msgr.UnknownContainer)
+		}
 
 		sorterData.Items = append(sorterData.Items,
 			ContainerSorterItem{
@@ -405,7 +429,7 @@ func (b *Builder) renderContainersList(ctx *web.EventContext, pageID uint, pageV
 				ModelID:        strconv.Itoa(int(c.ModelID)),
 				DisplayName:    displayName,
 				ContainerID:    strconv.Itoa(int(c.ID)),
-				URL:            b.ContainerByName(c.ModelName).mb.Info().ListingHref(),
+				URL:            url,
 				Shared:         c.Shared,
 				VisibilityIcon: vicon,
 				ParamID:        c.PrimarySlug(),
@@ -413,7 +437,6 @@ func (b *Builder) renderContainersList(ctx *web.EventContext, pageID uint, pageV
 			},
 		)
 	}
-	msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
 
 	r = web.Scope(
 		VSheet(
@@ -528,6 +551,9 @@ func (b *Builder) AddContainer(ctx *web.EventContext) (r web.EventResponse, err
 	containerName := ctx.R.FormValue(paramContainerName)
 	sharedContainer := ctx.R.FormValue(paramSharedContainer)
 	modelID := ctx.QueryAsInt(paramModelID)
+	if err = b.checkPageLock(ctx, uint(pageID), pageVersion, locale); err != nil {
+		return
+	}
 	var newModelID uint
 	if sharedContainer == "true" {
 		err = b.AddSharedContainerToPage(pageID, pageVersion, locale, containerName, uint(modelID))
@@ -553,6 +579,11 @@ func (b *Builder) MoveContainer(ctx *web.EventContext) (r web.EventResponse, err
 	if err != nil {
 		return
 	}
+	if len(result) > 0 {
+		if err = b.checkContainerPageLock(ctx, result[0].ParamID); err != nil {
+			return
+		}
+	}
 	err = b.db.Transaction(func(tx *gorm.DB) (inerr error) {
 		for i, r := range result {
 			if inerr = tx.Model(&Container{}).Where("id = ? AND locale_code = ?", r.ContainerID, r.Locale).Update("display_order", i+1).Error; inerr != nil {
@@ -572,6 +603,9 @@ func (b *Builder) ToggleContainerVisibility(ctx *web.EventContext) (r web.EventR
 	cs := container.PrimaryColumnValuesBySlug(paramID)
 	containerID := cs["id"]
 	locale := cs["locale_code"]
+	if err = b.checkContainerPageLock(ctx, paramID); err != nil {
+		return
+	}
 
 	err = b.db.Exec("UPDATE page_builder_containers SET hidden = NOT(coalesce(hidden,FALSE)) WHERE id = ? AND locale_code = ?", containerID, locale).Error
 
@@ -622,6 +656,9 @@ func (b *Builder) DeleteContainer(ctx *web.EventContext) (r web.EventResponse, e
 	cs := container.PrimaryColumnValuesBySlug(paramID)
 	containerID := cs["id"]
 	locale := cs["locale_code"]
+	if err = b.checkContainerPageLock(ctx, paramID); err != nil {
+		return
+	}
 
 	err = b.db.Delete(&Container{}, "id = ? AND locale_code = ?", containerID, locale).Error
 	if err != nil {
@@ -983,7 +1020,10 @@ func (b *Builder) AddContainerDialog(ctx *web.EventContext) (r web.EventResponse
 
 	var sharedContainers []h.HTMLComponent
 	for _, sharedC := range cons {
-		c := b.ContainerByName(sharedC.ModelName)
+		c, ok := b.LookupContainer(sharedC.ModelName)
+		if !ok {
+			continue
+		}
 		cover := c.cover
 		if cover == "" {
 			cover = path.Join(b.prefix, b.imagesPrefix, strings.ReplaceAll(c.name, " ", "")+".png")
@@ -1053,16 +1093,14 @@ type editorContainer struct {
 	container *Container
 }
 
+// getContainerBuilders keeps containers of unknown types with a nil builder so they render a placeholder
 func (b *Builder) getContainerBuilders(cs []*Container) (r []*editorContainer) {
 	for _, c := range cs {
-		for _, cb := range b.containerBuilders {
-			if cb.name == c.ModelName {
-				r = append(r, &editorContainer{
-					builder:   cb,
-					container: c,
-				})
-			}
-		}
+		cb, _ := b.LookupContainer(c.ModelName)
+		r = append(r, &editorContainer{
+			builder:   cb,
+			container: c,
+		})
 	}
 	return
 }
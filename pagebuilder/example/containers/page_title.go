@@ -9,6 +9,7 @@ import (
 	"github.com/iancoleman/strcase"
 	"github.com/jinzhu/inflection"
 	"github.com/qor5/admin/media/media_library"
+	media_view "github.com/qor5/admin/media/views"
 	"github.com/qor5/admin/pagebuilder"
 	"github.com/qor5/web"
 	. "github.com/theplant/htmlgo"
@@ -64,12 +65,21 @@ func RegisterPageTitleContainer(pb *pagebuilder.Builder, db *gorm.DB) {
 		"HeroImage", "NavigationLink", "NavigationLinkText",
 		"HeadingIcon", "Heading", "Text", "Tags",
 	)
+	// the hero image usually has text in it
+	eb.Field("HeroImage").WithContextValue(media_view.MediaBoxConfig, &media_library.MediaBoxConfig{
+		AllowType: "image",
+		Locales:   []string{"China", "Japan"},
+	})
 
 	SetTagComponent(pb, eb)
 }
 
 func PageTitleBody(data *PageTitle, input *pagebuilder.RenderInput) (body HTMLComponent) {
-	image := Div().Class("container-page_title-background").Style(fmt.Sprintf("background-image: url(%s)", data.HeroImage.URL()))
+	heroImage := &data.HeroImage
+	if input.Page != nil {
+		heroImage = heroImage.ForLocale(input.Page.LocaleCode)
+	}
+	image := Div().Class("container-page_title-background").Style(fmt.Sprintf("background-image: url(%s)", heroImage.URL()))
 	wraper := Div(
 		Div().Class("container-page_title-corner"),
 		Div(
@@ -4,6 +4,7 @@ import (
 	"context"
 	"path"
 	"regexp"
+	"strings"
 
 	"github.com/qor5/admin/l10n"
 	"github.com/qor5/web"
@@ -12,6 +13,12 @@ import (
 
 var (
 	directoryRe = regexp.MustCompile(`^([\/]{1}[a-zA-Z0-9._-]+)+(\/?){1}$|^([\/]{1})$`)
+
+	// SlugRe is the pattern page slugs must match after being cleaned, like "/fruit/apple"
+	SlugRe = directoryRe
+	// ReservedSlugs can't be the first segment of a category path or of the url of a page under its category in any locale,
+	// they are compared case-insensitively
+	ReservedSlugs = []string{"admin", "api"}
 )
 
 const (
@@ -19,6 +26,7 @@ const (
 	SELECT pages.id AS id,
 	       pages.version AS version,
 	       pages.locale_code AS locale_code,
+	       pages.category_id AS category_id,
 	       categories.path AS category_path,
 	       pages.slug AS slug
 FROM page_builder_pages pages
@@ -27,28 +35,41 @@ WHERE pages.deleted_at IS NULL AND categories.deleted_at IS NULL
 `
 	invalidPathMsg  = "Invalid Path"
 	invalidSlugMsg  = "Invalid Slug"
+	reservedSlugMsg = "Reserved Slug"
 	conflictSlugMsg = "Conflicting Slug"
 	conflictPathMsg = "Conflicting Path"
 	existingPathMsg = "Existing Path"
 
-	unableDeleteCategoryMsg = "this category cannot be deleted because it has used with pages"
+	unableDeleteCategoryMsg        = "this category cannot be deleted because it has used with pages"
+	unableDeleteSharedContainerMsg = "this shared container cannot be deleted because it is used by pages"
 )
 
 type pagePathInfo struct {
 	ID           uint
 	Version      string
 	LocaleCode   string
+	CategoryID   uint
 	CategoryPath string
 	Slug         string
 }
 
 func pageValidator(ctx context.Context, p *Page, db *gorm.DB, l10nB *l10n.Builder) (err web.ValidationErrors) {
+	_, err = checkPagePublishUrl(p, db, l10nB)
+	return
+}
+
+// checkPagePublishUrl returns the publish url of the page and the slug error if it is invalid or conflicts with another page
+func checkPagePublishUrl(p *Page, db *gorm.DB, l10nB *l10n.Builder) (publishUrl string, err web.ValidationErrors) {
 	if p.Slug != "" {
 		pagePath := path.Clean(p.Slug)
-		if !directoryRe.MatchString(pagePath) {
+		if !SlugRe.MatchString(pagePath) {
 			err.FieldError("Page.Slug", invalidSlugMsg)
 			return
 		}
+		if isReservedSlug(pagePath) {
+			err.FieldError("Page.Slug", reservedSlugMsg)
+			return
+		}
 	}
 
 	var localePath string
@@ -58,9 +79,13 @@ func pageValidator(ctx context.Context, p *Page, db *gorm.DB, l10nB *l10n.Builde
 
 	currentPageCategory, inErr := p.GetCategory(db)
 	if inErr != nil {
-		panic(err)
+		panic(inErr)
+	}
+	if isReservedSlug(path.Join("/", currentPageCategory.Path, p.Slug)) {
+		err.FieldError("Page.Slug", reservedSlugMsg)
+		return
 	}
-	currentPagePublishUrl := p.getPublishUrl(localePath, currentPageCategory.Path)
+	publishUrl = p.getPublishUrl(localePath, currentPageCategory.Path)
 
 	var pagePathInfos []pagePathInfo
 	if err := db.Raw(queryLocaleCodeCategoryPathSlugSQL).Scan(&pagePathInfos).Error; err != nil {
@@ -76,7 +101,7 @@ func pageValidator(ctx context.Context, p *Page, db *gorm.DB, l10nB *l10n.Builde
 			localePath = l10nB.GetLocalePath(info.LocaleCode)
 		}
 
-		if generatePublishUrl(localePath, info.CategoryPath, info.Slug) == currentPagePublishUrl {
+		if generatePublishUrl(localePath, info.CategoryPath, info.Slug) == publishUrl {
 			err.FieldError("Page.Slug", conflictSlugMsg)
 			return
 		}
@@ -85,12 +110,26 @@ func pageValidator(ctx context.Context, p *Page, db *gorm.DB, l10nB *l10n.Builde
 	return
 }
 
+func isReservedSlug(slug string) bool {
+	first := strings.SplitN(strings.TrimPrefix(slug, "/"), "/", 2)[0]
+	for _, r := range ReservedSlugs {
+		if strings.EqualFold(first, strings.Trim(r, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
 func categoryValidator(category *Category, db *gorm.DB, l10nB *l10n.Builder) (err web.ValidationErrors) {
 	categoryPath := path.Clean(category.Path)
 	if !directoryRe.MatchString(categoryPath) {
 		err.FieldError("Category.Category", invalidPathMsg)
 		return
 	}
+	if isReservedSlug(categoryPath) {
+		err.FieldError("Category.Category", reservedSlugMsg)
+		return
+	}
 
 	var localePath string
 	if l10nB != nil {
@@ -117,6 +156,39 @@ func categoryValidator(category *Category, db *gorm.DB, l10nB *l10n.Builder) (er
 			return
 		}
 	}
+	if category.ID == 0 {
+		return
+	}
+
+	// the pages of the category are republished under the path, their urls must not conflict with the other pages
+	var pagePathInfos []pagePathInfo
+	if err := db.Raw(queryLocaleCodeCategoryPathSlugSQL).Scan(&pagePathInfos).Error; err != nil {
+		panic(err)
+	}
+	type pageKey struct {
+		id         uint
+		localeCode string
+	}
+	moved := make(map[string]pageKey)
+	others := make(map[string]pageKey)
+	for _, info := range pagePathInfos {
+		var localePath string
+		if l10nB != nil {
+			localePath = l10nB.GetLocalePath(info.LocaleCode)
+		}
+		k := pageKey{info.ID, info.LocaleCode}
+		if info.CategoryID == category.ID && info.LocaleCode == category.LocaleCode {
+			moved[generatePublishUrl(localePath, categoryPath, info.Slug)] = k
+		} else {
+			others[generatePublishUrl(localePath, info.CategoryPath, info.Slug)] = k
+		}
+	}
+	for u, k := range moved {
+		if other, ok := others[u]; ok && other != k {
+			err.FieldError("Category.Category", conflictPathMsg)
+			return
+		}
+	}
 
 	return
 }
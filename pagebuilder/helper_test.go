@@ -3,6 +3,10 @@ package pagebuilder
 import (
 	"path"
 	"testing"
+
+	"github.com/qor5/admin/publish"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestSlugReg(t *testing.T) {
@@ -72,3 +76,154 @@ func TestSlugReg(t *testing.T) {
 		}
 	}
 }
+
+func TestReservedSlug(t *testing.T) {
+	for _, c := range []string{"/admin", "/Admin", "/API/v1"} {
+		if !isReservedSlug(path.Clean(c)) {
+			t.Errorf("isReservedSlug(%q) = false, want true", c)
+		}
+	}
+	for _, c := range []string{"/", "/administration", "/news/admin"} {
+		if isReservedSlug(path.Clean(c)) {
+			t.Errorf("isReservedSlug(%q) = true, want false", c)
+		}
+	}
+}
+
+func TestReservedCategoryPath(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&Page{}, &Category{}); err != nil {
+		t.Fatal(err)
+	}
+	verr := categoryValidator(&Category{Name: "Admin", Path: "/Admin/news"}, db, nil)
+	if got := verr.GetFieldErrors("Category.Category"); len(got) != 1 || got[0] != reservedSlugMsg {
+		t.Errorf("errors = %v, want %q", got, reservedSlugMsg)
+	}
+
+	// a category saved before the path was reserved
+	admin := &Category{Model: gorm.Model{ID: 1}, Name: "Admin", Path: "/admin"}
+	if err = db.Create(admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, verr = checkPagePublishUrl(&Page{Title: "Hello", Slug: "/hello", CategoryID: admin.ID}, db, nil)
+	if got := verr.GetFieldErrors("Page.Slug"); len(got) != 1 || got[0] != reservedSlugMsg {
+		t.Errorf("errors = %v, want %q", got, reservedSlugMsg)
+	}
+}
+
+func TestSuggestSlug(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&Page{}, &Category{}); err != nil {
+		t.Fatal(err)
+	}
+	news := &Category{Model: gorm.Model{ID: 1}, Name: "News", Path: "/news"}
+	// a category saved before the path was reserved, none of the urls of its pages is free
+	admin := &Category{Model: gorm.Model{ID: 2}, Name: "Admin", Path: "/admin"}
+	for _, c := range []*Category{news, admin} {
+		if err = db.Create(c).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []*Page{
+		{Model: gorm.Model{ID: 1}, Title: "Hello", Slug: "/hello", CategoryID: news.ID},
+		{Model: gorm.Model{ID: 2}, Title: "Hello", Slug: "/hello-copy", CategoryID: news.ID},
+	} {
+		if err = db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	slug, err := suggestSlug(&Page{Title: "Hello", CategoryID: news.ID}, "/hello", db, nil)
+	if err != nil || slug != "/hello-copy-2" {
+		t.Errorf("suggestSlug = %q, %v, want /hello-copy-2", slug, err)
+	}
+	if _, err = suggestSlug(&Page{Title: "Hello", CategoryID: admin.ID}, "/hello", db, nil); err != errNoFreeSlug {
+		t.Errorf("err = %v, want %v", err, errNoFreeSlug)
+	}
+}
+
+func TestCategoryValidatorChecksTheURLsOfItsPages(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&Page{}, &Category{}); err != nil {
+		t.Fatal(err)
+	}
+	// the ids are set as sqlite doesn't increment them with the locale code in the primary key
+	news := &Category{Model: gorm.Model{ID: 1}, Name: "News", Path: "/news"}
+	blog := &Category{Model: gorm.Model{ID: 2}, Name: "Blog", Path: "/blog"}
+	for _, c := range []*Category{news, blog} {
+		if err = db.Create(c).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []*Page{
+		{Model: gorm.Model{ID: 1}, Title: "Hello", Slug: "/blog/hello", CategoryID: news.ID},
+		{Model: gorm.Model{ID: 2}, Title: "Hello", Slug: "/hello", CategoryID: blog.ID},
+		{Model: gorm.Model{ID: 3}, Title: "Bye", Slug: "/bye", CategoryID: blog.ID},
+	} {
+		if err = db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moved := *news
+	moved.Path = "/blog-archive"
+	if verr := categoryValidator(&moved, db, nil); verr.HaveErrors() {
+		t.Errorf("a free path is refused: %v", verr.GetFieldErrors("Category.Category"))
+	}
+	// /blog/hello of news would be the url of /hello of blog
+	news.Path = "/"
+	verr := categoryValidator(news, db, nil)
+	if got := verr.GetFieldErrors("Category.Category"); len(got) != 1 || got[0] != conflictPathMsg {
+		t.Errorf("errors = %v, want %q", got, conflictPathMsg)
+	}
+}
+
+func TestMarkSharedContainerPages(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&Page{}, &Container{}); err != nil {
+		t.Fatal(err)
+	}
+	online := publish.Status{Status: publish.StatusOnline}
+	for _, p := range []*Page{
+		{Model: gorm.Model{ID: 1}, Title: "Uses the footer", Status: online, Version: publish.Version{Version: "v1"}},
+		{Model: gorm.Model{ID: 2}, Title: "Draft", Status: publish.Status{Status: publish.StatusDraft}, Version: publish.Version{Version: "v1"}},
+		{Model: gorm.Model{ID: 3}, Title: "Other footer", Status: online, Version: publish.Version{Version: "v1"}},
+	} {
+		if err = db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []*Container{
+		{Model: gorm.Model{ID: 1}, PageID: 1, PageVersion: "v1", ModelName: "Footer", ModelID: 1, Shared: true},
+		{Model: gorm.Model{ID: 2}, PageID: 2, PageVersion: "v1", ModelName: "Footer", ModelID: 1, Shared: true},
+		{Model: gorm.Model{ID: 3}, PageID: 3, PageVersion: "v1", ModelName: "Footer", ModelID: 2, Shared: true},
+	} {
+		if err = db.Create(c).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cb := &ContainerBuilder{builder: &Builder{db: db}, name: "Footer"}
+	if err = cb.markSharedContainerPages("1"); err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint
+	if err = db.Model(&Page{}).Where("list_updated = ?", true).Order("id").Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("marked pages = %v, want [1]", ids)
+	}
+}
@@ -26,6 +26,13 @@ type Messages struct {
 	FilterTabOnlineVersion         string
 	FilterTabNamedVersions         string
 	Rename                         string
+	UnknownContainer               string
+	FieldRequired                  string
+	PublishUrl                     string
+	NotFoundPage                   string
+	PageLockedBy                   string
+	ForceUnlock                    string
+	ForceUnlockConfirm             string
 }
 
 var Messages_en_US = &Messages{
@@ -50,6 +57,13 @@ var Messages_en_US = &Messages{
 	FilterTabOnlineVersion:         "Online Version",
 	FilterTabNamedVersions:         "Named Versions",
 	Rename:                         "Rename",
+	UnknownContainer:               "Unknown container",
+	FieldRequired:                  "This field is required",
+	PublishUrl:                     "Publish URL",
+	NotFoundPage:                   "Use as 404 page of the locale",
+	PageLockedBy:                   "This page is being edited by {User}, your changes can't be saved",
+	ForceUnlock:                    "Take over",
+	ForceUnlockConfirm:             "The other editor will lose the changes not saved yet, take over the page?",
 }
 
 var Messages_zh_CN = &Messages{
@@ -74,6 +88,13 @@ var Messages_zh_CN = &Messages{
 	FilterTabOnlineVersion:         "在线版本",
 	FilterTabNamedVersions:         "已命名版本",
 	Rename:                         "重命名",
+	UnknownContainer:               "未知组件",
+	FieldRequired:                  "此项为必填项",
+	PublishUrl:                     "发布地址",
+	NotFoundPage:                   "用作该语言的 404 页面",
+	PageLockedBy:                   "{User} 正在编辑此页面，您的修改无法保存",
+	ForceUnlock:                    "接管编辑",
+	ForceUnlockConfirm:             "对方尚未保存的修改将会丢失，确定接管此页面吗？",
 }
 
 var Messages_ja_JP = &Messages{
@@ -97,4 +118,11 @@ var Messages_ja_JP = &Messages{
 	FilterTabOnlineVersion:         "オンラインバージョン",
 	FilterTabNamedVersions:         "名付け済みバージョン",
 	Rename:                         "名前の変更",
+	UnknownContainer:               "不明なコンテナ",
+	FieldRequired:                  "この項目は必須です",
+	PublishUrl:                     "公開URL",
+	NotFoundPage:                   "このロケールの404ページとして使用",
+	PageLockedBy:                   "このページは {User} が編集中のため、変更を保存できません",
+	ForceUnlock:                    "編集を引き継ぐ",
+	ForceUnlockConfirm:             "相手の未保存の変更は失われます。このページの編集を引き継ぎますか？",
 }
@@ -17,6 +17,11 @@ type Page struct {
 	Title      string
 	Slug       string
 	CategoryID uint
+	// NotFound marks the page served by LiveHandler for the unmatched paths of its locale
+	NotFound bool
+	// ListUpdated marks an online page to be republished by RepublishListUpdatedPages, like the ListUpdated of
+	// a publish.List, the page isn't a publish.ListInterface as it's not published in lists
+	ListUpdated bool
 
 	SEO seo.Setting
 	publish.Status
@@ -0,0 +1,89 @@
+package pagebuilder
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/qor/oss"
+	"github.com/qor5/admin/l10n"
+)
+
+func notFoundPublishUrl(localePath string) string {
+	return path.Join("/", localePath, "404.html")
+}
+
+// LiveHandler serves the pages published to storage, an unmatched path gets the 404 page
+// of the locale whose path it starts with, or the one published without locale, with a 404 status.
+func (b *Builder) LiveHandler(storage oss.StorageInterface, l10nB *l10n.Builder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := path.Clean("/" + r.URL.Path)
+		file := p
+		if path.Ext(p) == "" {
+			file = path.Join(p, "index.html")
+		}
+		if serveStorageFile(w, storage, file, http.StatusOK) {
+			return
+		}
+		if to := b.redirectURL(p); to != "" {
+			http.Redirect(w, r, mountPrefix(r)+to, http.StatusMovedPermanently)
+			return
+		}
+
+		var localePaths []string
+		if l10nB != nil {
+			for _, code := range l10nB.GetSupportLocaleCodes() {
+				localePaths = append(localePaths, l10nB.GetLocalePath(code))
+			}
+		}
+		if lp := matchLocalePath(p, localePaths); lp != "" && serveStorageFile(w, storage, notFoundPublishUrl(lp), http.StatusNotFound) {
+			return
+		}
+		if serveStorageFile(w, storage, notFoundPublishUrl(""), http.StatusNotFound) {
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+
+// mountPrefix returns the prefix stripped from the path of r, like by http.StripPrefix
+func mountPrefix(r *http.Request) string {
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, r.URL.Path)
+}
+
+// matchLocalePath returns the longest locale path that p is in
+func matchLocalePath(p string, localePaths []string) (r string) {
+	for _, lp := range localePaths {
+		if lp == "" || lp == "/" || len(lp) <= len(r) {
+			continue
+		}
+		if p == lp || strings.HasPrefix(p, lp+"/") {
+			r = lp
+		}
+	}
+	return
+}
+
+func serveStorageFile(w http.ResponseWriter, storage oss.StorageInterface, file string, status int) bool {
+	rc, err := storage.GetStream(file)
+	if err != nil {
+		return false
+	}
+	defer rc.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(file))
+	if contentType == "" {
+		contentType = "text/html; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	io.Copy(w, rc)
+	return true
+}
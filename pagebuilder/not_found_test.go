@@ -0,0 +1,31 @@
+package pagebuilder
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchLocalePath(t *testing.T) {
+	localePaths := []string{"/cn", "/jp", "/jp/tokyo", ""}
+	cases := map[string]string{
+		"/cn":             "/cn",
+		"/cn/about":       "/cn",
+		"/cnx/about":      "",
+		"/jp/tokyo/news":  "/jp/tokyo",
+		"/jp/osaka":       "/jp",
+		"/international/": "",
+	}
+	for p, want := range cases {
+		if got := matchLocalePath(p, localePaths); got != want {
+			t.Errorf("matchLocalePath(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestMountPrefix(t *testing.T) {
+	r := httptest.NewRequest("GET", "/live/news/hello?a=1", nil)
+	r.URL.Path = "/news/hello"
+	if got := mountPrefix(r); got != "/live" {
+		t.Errorf("mountPrefix = %q, want /live", got)
+	}
+}
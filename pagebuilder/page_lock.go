@@ -0,0 +1,208 @@
+package pagebuilder
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qor5/admin/note"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PageLockTimeout is how long the lock of a page lasts after its editor stops refreshing it, like when the browser is closed
+var PageLockTimeout = 5 * time.Minute
+
+// PermForceUnlock allows to take over a page locked by another editor
+const PermForceUnlock = "perm_page_builder_force_unlock"
+
+const (
+	refreshPageLockEvent = "pageBuilder_RefreshPageLockEvent"
+	releasePageLockEvent = "pageBuilder_ReleasePageLockEvent"
+	forceUnlockPageEvent = "pageBuilder_ForceUnlockPageEvent"
+)
+
+var ErrPageLocked = errors.New("page is being edited by another user")
+
+// PageLock is held by the user who has the editor of a page version open
+type PageLock struct {
+	PageID      uint   `gorm:"primaryKey;autoIncrement:false"`
+	PageVersion string `gorm:"primaryKey;size:128"`
+	LocaleCode  string `gorm:"primaryKey;size:64"`
+	UserID      uint
+	UserName    string
+	ExpiresAt   time.Time
+}
+
+func (*PageLock) TableName() string {
+	return "page_builder_page_locks"
+}
+
+func (l *PageLock) heldByOther(db *gorm.DB, userID uint) bool {
+	return l.UserID != userID && l.ExpiresAt.After(db.NowFunc())
+}
+
+// acquirePageLock locks the page for the current user unless another user holds an unexpired lock, which is returned.
+// The lock is taken by a single conditional upsert so two editors opening the page at the same time can't both get it.
+func (b *Builder) acquirePageLock(ctx *web.EventContext, pageID uint, version, locale string) (other *PageLock, err error) {
+	userID, userName := note.GetUserData(ctx)
+	now := b.db.NowFunc()
+	tx := b.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "page_id"}, {Name: "page_version"}, {Name: "locale_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "user_name", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "page_builder_page_locks.user_id = ? OR page_builder_page_locks.expires_at <= ?", Vars: []interface{}{userID, now}},
+		}},
+	}).Create(&PageLock{
+		PageID:      pageID,
+		PageVersion: version,
+		LocaleCode:  locale,
+		UserID:      userID,
+		UserName:    userName,
+		ExpiresAt:   now.Add(PageLockTimeout),
+	})
+	if err = tx.Error; err != nil || tx.RowsAffected > 0 {
+		return
+	}
+
+	var l PageLock
+	if err = b.db.Where("page_id = ? AND page_version = ? AND locale_code = ?", pageID, version, locale).
+		Limit(1).Find(&l).Error; err != nil {
+		return
+	}
+	other = &l
+	return
+}
+
+// checkPageLock returns ErrPageLocked when the page is locked by another user
+func (b *Builder) checkPageLock(ctx *web.EventContext, pageID uint, version, locale string) error {
+	userID, _ := note.GetUserData(ctx)
+	var l PageLock
+	if err := b.db.Where("page_id = ? AND page_version = ? AND locale_code = ?", pageID, version, locale).
+		Limit(1).Find(&l).Error; err != nil {
+		return err
+	}
+	if l.PageID != 0 && l.heldByOther(b.db, userID) {
+		return fmt.Errorf("%w: %s", ErrPageLocked, l.UserName)
+	}
+	return nil
+}
+
+// checkContainerPageLock checks the lock of the page of the container with the slug
+func (b *Builder) checkContainerPageLock(ctx *web.EventContext, containerSlug string) error {
+	var c Container
+	cs := c.PrimaryColumnValuesBySlug(containerSlug)
+	if err := b.db.Where("id = ? AND locale_code = ?", cs["id"], cs["locale_code"]).Limit(1).Find(&c).Error; err != nil || c.ID == 0 {
+		return err
+	}
+	return b.checkPageLock(ctx, c.PageID, c.PageVersion, c.LocaleCode)
+}
+
+// checkContainerModelPageLock checks the lock of the page using the container model with the id,
+// shared containers aren't checked as they don't belong to one page.
+func (b *ContainerBuilder) checkContainerModelPageLock(ctx *web.EventContext, modelID string) error {
+	var cs []Container
+	if err := b.builder.db.Where("model_name = ? AND model_id = ? AND coalesce(shared, false) = false", b.name, modelID).
+		Find(&cs).Error; err != nil {
+		return err
+	}
+	for _, c := range cs {
+		if err := b.builder.checkPageLock(ctx, c.PageID, c.PageVersion, c.LocaleCode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configurePageLock refuses to save the container content of a page locked by another user.
+// Set SaveFunc of the container editing before Model to keep it wrapped.
+func (b *ContainerBuilder) configurePageLock() {
+	eb := b.mb.Editing()
+	saver := eb.Saver
+	eb.SaveFunc(func(obj interface{}, id string, ctx *web.EventContext) (err error) {
+		if id != "" {
+			if err = b.checkContainerModelPageLock(ctx, id); err != nil {
+				return
+			}
+		}
+		return saver(obj, id, ctx)
+	})
+}
+
+func pageLockQueries(pageID uint, version, locale string) url.Values {
+	return url.Values{
+		paramPageID:      []string{fmt.Sprint(pageID)},
+		paramPageVersion: []string{version},
+		paramLocale:      []string{locale},
+	}
+}
+
+// pageLockComp keeps the lock of the current user refreshed while the editor is open and releases it when it's left,
+// or shows who holds the lock to the other users.
+func (b *Builder) pageLockComp(ctx *web.EventContext, pageID uint, version, locale string, other *PageLock) h.HTMLComponent {
+	queries := pageLockQueries(pageID, version, locale)
+	if other == nil {
+		releaseURL := fmt.Sprintf("%s/editors?%s=%s&%s", b.prefix, web.EventFuncIDName, releasePageLockEvent, queries.Encode())
+		ctx.Injector.TailHTML(fmt.Sprintf(`<script>window.addEventListener("pagehide", function () { navigator.sendBeacon(%q) })</script>`, releaseURL))
+		return h.Div(
+			web.Portal().
+				Loader(web.Plaid().URL(b.prefix+"/editors").EventFunc(refreshPageLockEvent).Queries(queries)).
+				AutoReloadInterval("vars.pageBuilder_pageLockInterval"),
+		).Attr(web.InitContextVars, fmt.Sprintf("{pageBuilder_pageLockInterval: %d}", PageLockTimeout.Milliseconds()/3))
+	}
+
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
+	alert := VAlert(
+		h.Text(strings.ReplaceAll(msgr.PageLockedBy, "{User}", other.UserName)),
+	).Type("warning").Dense(true).Class("mb-0")
+	if b.mb.Info().Verifier().Do(PermForceUnlock).WithReq(ctx.R).IsAllowed() == nil {
+		alert.Children(
+			VBtn(msgr.ForceUnlock).Small(true).Text(true).Class("ml-2").
+				Attr("@click", fmt.Sprintf("confirm(%q) && %s", msgr.ForceUnlockConfirm,
+					web.Plaid().URL(b.prefix+"/editors").EventFunc(forceUnlockPageEvent).Queries(queries).Go())),
+		)
+	}
+	return alert
+}
+
+func (b *Builder) RefreshPageLock(ctx *web.EventContext) (r web.EventResponse, err error) {
+	pageID := uint(ctx.QueryAsInt(paramPageID))
+	version, locale := ctx.R.FormValue(paramPageVersion), ctx.R.FormValue(paramLocale)
+	other, err := b.acquirePageLock(ctx, pageID, version, locale)
+	if err != nil {
+		return
+	}
+	if other != nil {
+		// the lock was taken over, reload the editor to show who holds it
+		r.PushState = web.Location(url.Values{})
+	}
+	return
+}
+
+func (b *Builder) ReleasePageLock(ctx *web.EventContext) (r web.EventResponse, err error) {
+	userID, _ := note.GetUserData(ctx)
+	err = b.db.Where("page_id = ? AND page_version = ? AND locale_code = ? AND user_id = ?",
+		ctx.QueryAsInt(paramPageID), ctx.R.FormValue(paramPageVersion), ctx.R.FormValue(paramLocale), userID).
+		Delete(&PageLock{}).Error
+	return
+}
+
+func (b *Builder) ForceUnlockPage(ctx *web.EventContext) (r web.EventResponse, err error) {
+	if err = b.mb.Info().Verifier().Do(PermForceUnlock).WithReq(ctx.R).IsAllowed(); err != nil {
+		return
+	}
+	err = b.db.Where("page_id = ? AND page_version = ? AND locale_code = ?",
+		ctx.QueryAsInt(paramPageID), ctx.R.FormValue(paramPageVersion), ctx.R.FormValue(paramLocale)).
+		Delete(&PageLock{}).Error
+	if err != nil {
+		return
+	}
+	r.PushState = web.Location(url.Values{})
+	return
+}
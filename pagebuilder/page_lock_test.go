@@ -0,0 +1,60 @@
+package pagebuilder
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qor5/admin/note"
+	"github.com/qor5/web"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func lockCtx(userID uint, name string) *web.EventContext {
+	r := httptest.NewRequest("GET", "/editors", nil)
+	c := context.WithValue(r.Context(), note.UserIDKey, userID)
+	c = context.WithValue(c, note.UserKey, name)
+	return &web.EventContext{R: r.WithContext(c)}
+}
+
+func TestAcquirePageLock(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&PageLock{}); err != nil {
+		t.Fatal(err)
+	}
+	b := &Builder{db: db}
+	alice, bob := lockCtx(1, "alice"), lockCtx(2, "bob")
+
+	if other, err := b.acquirePageLock(alice, 1, "v1", "en"); err != nil || other != nil {
+		t.Fatalf("first editor didn't get the lock: %v, %v", other, err)
+	}
+	other, err := b.acquirePageLock(bob, 1, "v1", "en")
+	if err != nil || other == nil || other.UserName != "alice" {
+		t.Fatalf("second editor should see the lock of alice, got %v, %v", other, err)
+	}
+	if err = b.checkPageLock(bob, 1, "v1", "en"); err == nil {
+		t.Fatal("saving of the second editor isn't refused")
+	}
+	if other, err = b.acquirePageLock(alice, 1, "v1", "en"); err != nil || other != nil {
+		t.Fatalf("the holder can't refresh the lock: %v, %v", other, err)
+	}
+	if other, err = b.acquirePageLock(bob, 1, "v2", "en"); err != nil || other != nil {
+		t.Fatalf("another version should be lockable: %v, %v", other, err)
+	}
+
+	if err = db.Model(&PageLock{}).Where("page_id = 1 AND page_version = 'v1'").
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if other, err = b.acquirePageLock(bob, 1, "v1", "en"); err != nil || other != nil {
+		t.Fatalf("an expired lock wasn't taken over: %v, %v", other, err)
+	}
+	if err = b.checkPageLock(alice, 1, "v1", "en"); err == nil {
+		t.Fatal("saving of the editor whose lock was taken over isn't refused")
+	}
+}
@@ -25,17 +25,7 @@ func (p *Page) GetPublishActions(db *gorm.DB, ctx context.Context, storage oss.S
 	if err != nil {
 		return
 	}
-	var localePath string
-	if l10nBuilder, ok := ctx.Value(publish.PublishContextKeyL10nBuilder).(*l10n.Builder); ok && l10nBuilder != nil && l10nON {
-		if eventCtx, ok := ctx.Value(publish.PublishContextKeyEventContext).(*web.EventContext); ok && eventCtx != nil {
-			if locale, ok := l10n.IsLocalizableFromCtx(eventCtx.R.Context()); ok {
-				localePath = l10nBuilder.GetLocalePath(locale)
-			}
-		}
-		if localeCode, err := reflectutils.Get(p, "LocaleCode"); err == nil {
-			localePath = l10nBuilder.GetLocalePath(localeCode.(string))
-		}
-	}
+	localePath := p.getLocalePath(ctx)
 
 	var category Category
 	category, err = p.GetCategory(db)
@@ -57,9 +47,22 @@ func (p *Page) GetPublishActions(db *gorm.DB, ctx context.Context, storage oss.S
 		}
 		lrdb.First(&liveRecord)
 	}
+	if p.NotFound {
+		objs = append(objs, &publish.PublishAction{
+			Url:      notFoundPublishUrl(localePath),
+			Content:  content,
+			IsDelete: false,
+		})
+	}
 	if liveRecord.ID == 0 {
 		return
 	}
+	if liveRecord.NotFound && !p.NotFound {
+		objs = append(objs, &publish.PublishAction{
+			Url:      notFoundPublishUrl(localePath),
+			IsDelete: true,
+		})
+	}
 
 	if liveRecord.GetOnlineUrl() != p.GetOnlineUrl() {
 		objs = append(objs, &publish.PublishAction{
@@ -76,6 +79,26 @@ func (p *Page) GetUnPublishActions(db *gorm.DB, ctx context.Context, storage oss
 		Url:      p.GetOnlineUrl(),
 		IsDelete: true,
 	})
+	if p.NotFound {
+		objs = append(objs, &publish.PublishAction{
+			Url:      notFoundPublishUrl(p.getLocalePath(ctx)),
+			IsDelete: true,
+		})
+	}
+	return
+}
+
+func (p *Page) getLocalePath(ctx context.Context) (localePath string) {
+	if l10nBuilder, ok := ctx.Value(publish.PublishContextKeyL10nBuilder).(*l10n.Builder); ok && l10nBuilder != nil && l10nON {
+		if eventCtx, ok := ctx.Value(publish.PublishContextKeyEventContext).(*web.EventContext); ok && eventCtx != nil {
+			if locale, ok := l10n.IsLocalizableFromCtx(eventCtx.R.Context()); ok {
+				localePath = l10nBuilder.GetLocalePath(locale)
+			}
+		}
+		if localeCode, err := reflectutils.Get(p, "LocaleCode"); err == nil {
+			localePath = l10nBuilder.GetLocalePath(localeCode.(string))
+		}
+	}
 	return
 }
 
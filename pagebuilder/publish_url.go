@@ -0,0 +1,72 @@
+package pagebuilder
+
+import (
+	"path"
+	"strconv"
+
+	"github.com/qor5/admin/l10n"
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	h "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+)
+
+const (
+	previewPublishUrlEvent  = "page_builder_previewPublishUrlEvent"
+	publishUrlPreviewPortal = "page_builder_publishUrlPreviewPortal"
+)
+
+// previewPublishUrlQuery is the event updating the publish url preview with the slug and category being edited
+func previewPublishUrlQuery(p *Page) *web.VueEventTagBuilder {
+	e := web.Plaid().EventFunc(previewPublishUrlEvent)
+	if p.ID != 0 {
+		e.Query(presets.ParamID, p.PrimarySlug())
+	}
+	return e
+}
+
+func publishUrlPreview(p *Page, db *gorm.DB, l10nB *l10n.Builder, ctx *web.EventContext) h.HTMLComponent {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nPageBuilderKey, Messages_en_US).(*Messages)
+
+	if p.LocaleCode == "" {
+		if locale, ok := l10n.IsLocalizableFromCtx(ctx.R.Context()); ok {
+			np := *p
+			np.LocaleCode = locale
+			p = &np
+		}
+	}
+	publishUrl, vErr := checkPagePublishUrl(p, db, l10nB)
+	if errs := vErr.GetFieldErrors("Page.Slug"); len(errs) > 0 {
+		return h.Div(
+			h.Text(errs[0]),
+		).Class("error--text text-caption mb-4")
+	}
+	return h.Div(
+		h.Text(msgr.PublishUrl+": "),
+		h.Span(p.getAccessUrl(publishUrl)).Class("font-weight-medium"),
+	).Class("grey--text text--darken-1 text-caption mb-4")
+}
+
+func previewPublishUrl(db *gorm.DB, l10nB *l10n.Builder) web.EventFunc {
+	return func(ctx *web.EventContext) (r web.EventResponse, err error) {
+		p := &Page{}
+		if id := ctx.R.FormValue(presets.ParamID); id != "" {
+			cs := p.PrimaryColumnValuesBySlug(id)
+			pageID, _ := strconv.Atoi(cs["id"])
+			p.ID = uint(pageID)
+			p.LocaleCode = cs["locale_code"]
+		}
+		if slug := ctx.R.FormValue("Slug"); slug != "" {
+			p.Slug = path.Clean(path.Join("/", slug))
+		}
+		categoryID, _ := strconv.Atoi(ctx.R.FormValue("CategoryID"))
+		p.CategoryID = uint(categoryID)
+
+		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+			Name: publishUrlPreviewPortal,
+			Body: publishUrlPreview(p, db, l10nB, ctx),
+		})
+		return
+	}
+}
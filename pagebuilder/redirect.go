@@ -0,0 +1,109 @@
+package pagebuilder
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/qor5/admin/publish"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RepublishBatchSize is the number of pages loaded at a time by RepublishCategoryPages
+var RepublishBatchSize = 100
+
+// PageRedirect sends the visitors of the old URL of a page to its new URL, like after the path of its category changed
+type PageRedirect struct {
+	ID        uint   `gorm:"primarykey"`
+	From      string `gorm:"uniqueIndex"`
+	To        string
+	CreatedAt time.Time
+}
+
+func (*PageRedirect) TableName() string {
+	return "page_builder_redirects"
+}
+
+// CategoryRepublisher queues RepublishCategoryPages for the category whose path changed, like in a worker job
+type CategoryRepublisher func(r *http.Request, categoryID uint, localeCode string) error
+
+// CategoryRepublisher sets the function queueing the online pages of a category to be republished,
+// it's called after a changed path of the category is saved
+func (b *Builder) CategoryRepublisher(v CategoryRepublisher) (r *Builder) {
+	b.categoryRepublisher = v
+	return b
+}
+
+// addRedirect redirects from to to, the redirects to from are moved to to so visitors are redirected once
+func addRedirect(db *gorm.DB, from string, to string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("\"from\" = ?", to).Delete(&PageRedirect{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&PageRedirect{}).Where("\"to\" = ?", from).Update("to", to).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "from"}},
+			DoUpdates: clause.AssignmentColumns([]string{"to", "created_at"}),
+		}).Create(&PageRedirect{From: from, To: to}).Error
+	})
+}
+
+// RepublishCategoryPages republishes the online pages of the category in batches, and redirects their previous URLs to the new ones,
+// progress is called with the percent done, like the SetProgress of a worker job.
+func (b *Builder) RepublishCategoryPages(categoryID uint, localeCode string, progress func(percent uint) error) (err error) {
+	if b.publisher == nil {
+		return
+	}
+	if progress == nil {
+		progress = func(uint) error { return nil }
+	}
+	var total int64
+	if err = b.db.Model(&Page{}).Where("category_id = ? AND locale_code = ? AND status = ?", categoryID, localeCode, publish.StatusOnline).
+		Count(&total).Error; err != nil {
+		return
+	}
+
+	var done int64
+	for offset := 0; int64(offset) < total; offset += RepublishBatchSize {
+		var infos []pagePathInfo
+		if err = b.db.Raw(queryLocaleCodeCategoryPathSlugSQL+` AND pages.category_id = ? AND pages.locale_code = ? AND pages.status = ?
+ORDER BY pages.id, pages.version LIMIT ? OFFSET ?`, categoryID, localeCode, publish.StatusOnline, RepublishBatchSize, offset).
+			Scan(&infos).Error; err != nil {
+			return
+		}
+		for _, info := range infos {
+			p := &Page{}
+			if err = b.db.Where("id = ? AND version = ? AND locale_code = ?", info.ID, info.Version, info.LocaleCode).First(p).Error; err != nil {
+				return
+			}
+			oldURL := p.getAccessUrl(p.GetOnlineUrl())
+			if err = b.publisher.Publish(p); err != nil {
+				return
+			}
+			if newURL := p.getAccessUrl(p.GetOnlineUrl()); oldURL != "." && oldURL != newURL {
+				if err = addRedirect(b.db, oldURL, newURL); err != nil {
+					return
+				}
+			}
+			done++
+			if err = progress(uint(done * 100 / total)); err != nil {
+				return
+			}
+		}
+		if len(infos) < RepublishBatchSize {
+			break
+		}
+	}
+	return progress(100)
+}
+
+// redirectURL returns where a visitor of the unpublished path p is redirected, "" when it's not redirected
+func (b *Builder) redirectURL(p string) string {
+	var r PageRedirect
+	if err := b.db.Where("\"from\" = ?", p).Limit(1).Find(&r).Error; err != nil {
+		return ""
+	}
+	return r.To
+}
@@ -0,0 +1,184 @@
+package pagebuilder
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qor5/admin/media/media_library"
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/web"
+	h "github.com/theplant/htmlgo"
+)
+
+const (
+	sandboxPath       = "/sandbox"
+	sandboxRenderPath = "/sandbox/render"
+	// SandboxTokenTTL is how long the form of the sandbox can be posted after it's opened
+	SandboxTokenTTL = time.Hour
+)
+
+var errSandboxToken = errors.New("invalid or expired sandbox token")
+
+// EnableSandbox serves the sandbox, it's meant for the development of containers only, don't enable it in production.
+// The containers are rendered in a sandboxed iframe from origin, like https://sandbox.example.com,
+// a host that also serves this builder but doesn't share the cookies of the admin.
+// secret signs the tokens the sample data is posted with.
+func (b *Builder) EnableSandbox(origin string, secret []byte) (r *Builder) {
+	if origin == "" || len(secret) == 0 {
+		panic("pagebuilder: the sandbox needs an origin and a secret")
+	}
+	b.sandboxOrigin = strings.TrimSuffix(origin, "/")
+	b.sandboxSecret = secret
+	return b
+}
+
+// Sandbox is the page of the sandbox at prefix + "/sandbox?container=Name", it posts the JSON of the container model
+// typed in with a token to SandboxRender on the sandbox origin and shows the result in a sandboxed iframe.
+func (b *Builder) Sandbox(ctx *web.EventContext) (r web.PageResponse, err error) {
+	name := ctx.R.FormValue("container")
+	cb, err := b.sandboxContainer(name)
+	if err != nil {
+		return
+	}
+	if err = cb.mb.Info().Verifier().Do(presets.PermUpdate).WithReq(ctx.R).IsAllowed(); err != nil {
+		return
+	}
+
+	sample, err := json.MarshalIndent(cb.NewModel(), "", "  ")
+	if err != nil {
+		return
+	}
+	frame := "sandbox-frame"
+	r.PageTitle = fmt.Sprintf("Sandbox: %s", name)
+	r.Body = h.Div(
+		h.Form(
+			h.Input("container").Type("hidden").Value(name),
+			h.Input("token").Type("hidden").Value(b.sandboxToken(name, time.Now().Add(SandboxTokenTTL))),
+			h.Textarea(string(sample)).Name("data").Attr("rows", "12").Style("width:100%;font-family:monospace"),
+			h.Button("Render").Type("submit"),
+		).Method(http.MethodPost).Action(b.sandboxOrigin+b.prefix+sandboxRenderPath).Target(frame),
+		h.Iframe().Name(frame).Attr("sandbox", "allow-scripts").Style("width:100%;height:80vh;border:1px solid #ddd"),
+	).Style("padding:16px")
+	return
+}
+
+// SandboxRender renders the container with the posted sample data on the sandbox origin, through the render func
+// of the container and the page layout like the preview. Media boxes with only the ID of a media library file are filled from the library.
+func (b *Builder) SandboxRender(ctx *web.EventContext) (r web.PageResponse, err error) {
+	if ctx.R.Method != http.MethodPost {
+		err = fmt.Errorf("the sandbox only renders posted data")
+		return
+	}
+	if u, perr := url.Parse(b.sandboxOrigin); perr != nil || u.Host != ctx.R.Host {
+		err = fmt.Errorf("the sandbox only renders on %s", b.sandboxOrigin)
+		return
+	}
+	name := ctx.R.FormValue("container")
+	if err = b.verifySandboxToken(name, ctx.R.FormValue("token"), time.Now()); err != nil {
+		return
+	}
+	cb, err := b.sandboxContainer(name)
+	if err != nil {
+		return
+	}
+
+	obj := cb.NewModel()
+	if data := ctx.R.FormValue("data"); data != "" {
+		if err = json.Unmarshal([]byte(data), obj); err != nil {
+			err = fmt.Errorf("invalid data for container %q: %w", name, err)
+			return
+		}
+	}
+	if err = b.resolveSandboxMedia(obj); err != nil {
+		return
+	}
+
+	// sandboxed even when it's opened outside of the iframe
+	ctx.W.Header().Set("Content-Security-Policy", "sandbox allow-scripts")
+	p := &Page{Title: fmt.Sprintf("Sandbox: %s", name)}
+	device, _ := b.getDevice(ctx)
+	body := cb.renderFunc(obj, &RenderInput{Page: p, Device: device}, ctx)
+	r.Body = b.pageLayoutFunc(h.Components(body), &PageLayoutInput{Page: p}, ctx)
+	ctx.Injector.HeadHTMLComponent("style", b.pageStyle, true)
+	r.PageTitle = p.Title
+	return
+}
+
+func (b *Builder) sandboxContainer(name string) (*ContainerBuilder, error) {
+	cb, ok := b.LookupContainer(name)
+	if !ok || cb.renderFunc == nil {
+		return nil, fmt.Errorf("container %q is not registered", name)
+	}
+	return cb, nil
+}
+
+// sandboxToken is the expiry and the signature of the container and the expiry
+func (b *Builder) sandboxToken(container string, expiry time.Time) string {
+	exp := strconv.FormatInt(expiry.Unix(), 10)
+	return exp + "." + b.sandboxSignature(container, exp)
+}
+
+func (b *Builder) verifySandboxToken(container string, token string, now time.Time) error {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return errSandboxToken
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.After(time.Unix(unix, 0)) {
+		return errSandboxToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(b.sandboxSignature(container, exp))) {
+		return errSandboxToken
+	}
+	return nil
+}
+
+func (b *Builder) sandboxSignature(container string, exp string) string {
+	mac := hmac.New(sha256.New, b.sandboxSecret)
+	mac.Write([]byte(container + "|" + exp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// resolveSandboxMedia fills the media boxes of the container model that have an ID but no URL with the media library file
+func (b *Builder) resolveSandboxMedia(obj interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Type().Field(i).IsExported() {
+			continue
+		}
+		mb, ok := v.Field(i).Addr().Interface().(*media_library.MediaBox)
+		if !ok || mb.ID.String() == "" || mb.Url != "" {
+			continue
+		}
+		id, err := strconv.ParseUint(mb.ID.String(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("media %s of field %s: %w", mb.ID, v.Type().Field(i).Name, err)
+		}
+		var m media_library.MediaLibrary
+		if err = b.db.Where("id = ?", id).First(&m).Error; err != nil {
+			return fmt.Errorf("media %s of field %s: %w", mb.ID, v.Type().Field(i).Name, err)
+		}
+		mb.Url = m.File.Url
+		mb.FileName = m.File.FileName
+		if mb.Description == "" {
+			mb.Description = m.File.Description
+		}
+		mb.FileSizes = m.File.FileSizes
+		mb.Width = m.File.Width
+		mb.Height = m.File.Height
+		mb.SeparateDerivatives = m.File.SeparateDerivatives
+		mb.SizeURL = m.File.SizeURL
+		mb.Version = m.UpdatedAt.UnixNano()
+	}
+	return nil
+}
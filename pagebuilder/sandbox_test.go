@@ -0,0 +1,31 @@
+package pagebuilder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSandboxToken(t *testing.T) {
+	b := (&Builder{}).EnableSandbox("https://sandbox.example.com/", []byte("secret"))
+	if b.sandboxOrigin != "https://sandbox.example.com" {
+		t.Errorf("sandboxOrigin = %q", b.sandboxOrigin)
+	}
+	now := time.Now()
+	token := b.sandboxToken("Heading", now.Add(SandboxTokenTTL))
+	if err := b.verifySandboxToken("Heading", token, now); err != nil {
+		t.Errorf("valid token: %v", err)
+	}
+	if err := b.verifySandboxToken("ListContent", token, now); err == nil {
+		t.Error("token of another container is accepted")
+	}
+	if err := b.verifySandboxToken("Heading", token, now.Add(2*SandboxTokenTTL)); err == nil {
+		t.Error("expired token is accepted")
+	}
+	other := (&Builder{}).EnableSandbox("https://sandbox.example.com", []byte("other"))
+	if err := other.verifySandboxToken("Heading", token, now); err == nil {
+		t.Error("token signed with another secret is accepted")
+	}
+	if err := b.verifySandboxToken("Heading", "", now); err == nil {
+		t.Error("empty token is accepted")
+	}
+}
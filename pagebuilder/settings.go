@@ -37,10 +37,10 @@ func settings(db *gorm.DB, pm *presets.ModelBuilder) presets.FieldComponentFunc
 		)
 		var start, end, se string
 		if p.GetScheduledStartAt() != nil {
-			start = p.GetScheduledStartAt().Format("2006-01-02 15:04")
+			start = presets.LocalTime(ctx.R, *p.GetScheduledStartAt()).Format("2006-01-02 15:04")
 		}
 		if p.GetScheduledEndAt() != nil {
-			end = p.GetScheduledEndAt().Format("2006-01-02 15:04")
+			end = presets.LocalTime(ctx.R, *p.GetScheduledEndAt()).Format("2006-01-02 15:04")
 		}
 		if start != "" || end != "" {
 			se = start + " ~ " + end
@@ -80,7 +80,7 @@ func settings(db *gorm.DB, pm *presets.ModelBuilder) presets.FieldComponentFunc
 			s := VContainer()
 			for _, n := range notes {
 				s.AppendChildren(VRow(VCardText(h.Text(n.Content)).Class("pb-0")))
-				s.AppendChildren(VRow(VCardText(h.Text(fmt.Sprintf("%v - %v", n.Creator, n.CreatedAt.Format("2006-01-02 15:04:05 MST")))).Class("pt-0")))
+				s.AppendChildren(VRow(VCardText(h.Text(fmt.Sprintf("%v - %v", n.Creator, presets.FormatTime(ctx.R, n.CreatedAt)))).Class("pt-0")))
 			}
 			notesSetcion = s
 		}
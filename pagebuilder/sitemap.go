@@ -0,0 +1,174 @@
+package pagebuilder
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/qor5/admin/l10n"
+	"github.com/qor5/admin/publish"
+)
+
+// DefaultSitemapMaxURLs is the limit of urls in one sitemap file defined by sitemaps.org
+const DefaultSitemapMaxURLs = 50000
+
+// SitemapBuilder serves /sitemap.xml of the online pages with the other locales of a page as hreflang alternates,
+// when there are more than maxURLs pages it serves a sitemap index of /sitemap.xml?page=N instead.
+type SitemapBuilder struct {
+	b            *Builder
+	l10nB        *l10n.Builder
+	baseURL      string
+	maxURLs      int
+	hreflangFunc func(localeCode string) string
+}
+
+func (b *Builder) Sitemap(baseURL string) *SitemapBuilder {
+	return &SitemapBuilder{
+		b:       b,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		maxURLs: DefaultSitemapMaxURLs,
+	}
+}
+
+func (s *SitemapBuilder) L10n(v *l10n.Builder) *SitemapBuilder {
+	s.l10nB = v
+	return s
+}
+
+func (s *SitemapBuilder) MaxURLs(v int) *SitemapBuilder {
+	s.maxURLs = v
+	return s
+}
+
+// HreflangFunc maps a locale code to the hreflang value, like "ja-JP" or "x-default", the locale code is used by default
+func (s *SitemapBuilder) HreflangFunc(v func(localeCode string) string) *SitemapBuilder {
+	s.hreflangFunc = v
+	return s
+}
+
+func (s *SitemapBuilder) URL() string {
+	return s.baseURL + "/sitemap.xml"
+}
+
+func (s *SitemapBuilder) MountTo(mux *http.ServeMux) {
+	mux.Handle("/sitemap.xml", s)
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	XHTML   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string             `xml:"loc"`
+	Alternates []sitemapAlternate `xml:"xhtml:link"`
+}
+
+type sitemapAlternate struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+const sitemapXmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+func (s *SitemapBuilder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var infos []pagePathInfo
+	if err := s.b.db.Raw(queryLocaleCodeCategoryPathSlugSQL+` AND pages.status = ? AND pages.not_found = false ORDER BY pages.id, pages.locale_code`, publish.StatusOnline).
+		Scan(&infos).Error; err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	urls := s.urls(infos)
+
+	var v interface{}
+	if s.maxURLs > 0 && len(urls) > s.maxURLs {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			index := sitemapIndex{Xmlns: sitemapXmlns}
+			for i := 1; (i-1)*s.maxURLs < len(urls); i++ {
+				index.Sitemaps = append(index.Sitemaps, sitemapLoc{Loc: fmt.Sprintf("%s?page=%d", s.URL(), i)})
+			}
+			v = index
+		} else {
+			start, end := (page-1)*s.maxURLs, page*s.maxURLs
+			if page < 0 || start >= len(urls) {
+				http.NotFound(w, r)
+				return
+			}
+			if end > len(urls) {
+				end = len(urls)
+			}
+			urls = urls[start:end]
+		}
+	}
+	if v == nil {
+		v = sitemapURLSet{Xmlns: sitemapXmlns, XHTML: "http://www.w3.org/1999/xhtml", URLs: urls}
+	}
+
+	body, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml;charset=UTF-8")
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
+
+// urls makes a url for every page locale, infos must be ordered by page id
+func (s *SitemapBuilder) urls(infos []pagePathInfo) (r []sitemapURL) {
+	for i := 0; i < len(infos); {
+		j := i
+		for j < len(infos) && infos[j].ID == infos[i].ID {
+			j++
+		}
+		group := infos[i:j]
+		i = j
+
+		var alternates []sitemapAlternate
+		if len(group) > 1 {
+			for _, info := range group {
+				alternates = append(alternates, sitemapAlternate{
+					Rel:      "alternate",
+					Hreflang: s.hreflang(info.LocaleCode),
+					Href:     s.loc(info),
+				})
+			}
+		}
+		for _, info := range group {
+			r = append(r, sitemapURL{Loc: s.loc(info), Alternates: alternates})
+		}
+	}
+	return
+}
+
+func (s *SitemapBuilder) loc(info pagePathInfo) string {
+	var localePath string
+	if s.l10nB != nil {
+		localePath = s.l10nB.GetLocalePath(info.LocaleCode)
+	}
+	p := &Page{Slug: info.Slug}
+	return s.baseURL + p.getAccessUrl(p.getPublishUrl(localePath, info.CategoryPath))
+}
+
+func (s *SitemapBuilder) hreflang(localeCode string) string {
+	if s.hreflangFunc != nil {
+		return s.hreflangFunc(localeCode)
+	}
+	return localeCode
+}
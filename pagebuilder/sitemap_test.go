@@ -0,0 +1,42 @@
+package pagebuilder
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/qor5/admin/l10n"
+)
+
+func TestSitemapURLs(t *testing.T) {
+	l10nB := l10n.New().
+		RegisterLocales("International", "international", "International").
+		RegisterLocales("Japan", "jp", "Japan")
+	s := (&Builder{}).Sitemap("https://example.com/").L10n(l10nB)
+
+	urls := s.urls([]pagePathInfo{
+		{ID: 1, LocaleCode: "International", CategoryPath: "/news", Slug: "/hello"},
+		{ID: 1, LocaleCode: "Japan", CategoryPath: "/news", Slug: "/hello"},
+		{ID: 2, LocaleCode: "Japan", Slug: "/"},
+	})
+	if len(urls) != 3 {
+		t.Fatalf("got %d urls", len(urls))
+	}
+	if urls[0].Loc != "https://example.com/international/news/hello" || urls[2].Loc != "https://example.com/jp" {
+		t.Errorf("wrong locs: %s, %s", urls[0].Loc, urls[2].Loc)
+	}
+	if len(urls[1].Alternates) != 2 || urls[1].Alternates[1].Hreflang != "Japan" {
+		t.Errorf("wrong alternates: %+v", urls[1].Alternates)
+	}
+	if len(urls[2].Alternates) != 0 {
+		t.Errorf("page with one locale should have no alternates: %+v", urls[2].Alternates)
+	}
+
+	body, err := xml.Marshal(sitemapURLSet{Xmlns: sitemapXmlns, URLs: urls[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `<xhtml:link rel="alternate" hreflang="Japan" href="https://example.com/jp/news/hello"></xhtml:link>`) {
+		t.Errorf("unexpected xml: %s", body)
+	}
+}
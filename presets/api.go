@@ -57,6 +57,17 @@ type SearchParams struct {
 	Page           int64
 	OrderBy        string
 	PageURL        *url.URL
+	// Cursor is set instead of Page for a listing with CursorPagination
+	Cursor *CursorParams
+}
+
+// CursorParams are the cursors of a listing with CursorPagination, the searcher loads the page after After
+// or before Before, newest first, and sets NextCursor and PrevCursor to the cursors of the pages around it
+type CursorParams struct {
+	After      string
+	Before     string
+	NextCursor string
+	PrevCursor string
 }
 
 type SlugDecoder interface {
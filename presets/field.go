@@ -135,6 +135,14 @@ func (b *FieldBuilder) WithContextValue(key interface{}, val interface{}) (r *Fi
 	return b
 }
 
+// ContextValue returns the value set with WithContextValue for key, or nil
+func (b *FieldBuilder) ContextValue(key interface{}) interface{} {
+	if b.context == nil {
+		return nil
+	}
+	return b.context.Value(key)
+}
+
 type NestedConfig interface {
 	nested()
 }
@@ -321,6 +329,7 @@ func (b *FieldsBuilder) SetObjectFields(fromObj interface{}, toObj interface{},
 			FormKey:   keyPath,
 			Name:      f.name,
 			Label:     b.getLabel(f.NameLabel),
+			Context:   f.context,
 		}, ctx)
 		if err1 != nil {
 			vErr.FieldError(f.name, err1.Error())
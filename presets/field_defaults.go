@@ -171,7 +171,21 @@ func (b *FieldDefaults) fieldTypeByTypeOrCreate(tv reflect.Type) (r *FieldDefaul
 }
 
 func cfTextTd(obj interface{}, field *FieldContext, ctx *web.EventContext) h.HTMLComponent {
-	return h.Td(h.Text(field.StringValue(obj)))
+	return h.Td(h.Text(localStringValue(obj, field, ctx)))
+}
+
+// localStringValue is the StringValue of the field with times formatted in the time zone and language of the user
+func localStringValue(obj interface{}, field *FieldContext, ctx *web.EventContext) string {
+	switch vt := field.Value(obj).(type) {
+	case time.Time:
+		return FormatTime(ctx.R, vt)
+	case *time.Time:
+		if vt == nil {
+			return ""
+		}
+		return FormatTime(ctx.R, *vt)
+	}
+	return field.StringValue(obj)
 }
 
 func cfCheckbox(obj interface{}, field *FieldContext, ctx *web.EventContext) h.HTMLComponent {
@@ -199,9 +213,13 @@ func cfTime(obj interface{}, field *FieldContext, ctx *web.EventContext) h.HTMLC
 	if v := field.Value(obj); v != nil {
 		switch vt := v.(type) {
 		case time.Time:
-			val = vt.Format("2006-01-02 15:04")
+			if !vt.IsZero() {
+				val = LocalTime(ctx.R, vt).Format("2006-01-02 15:04")
+			}
 		case *time.Time:
-			val = vt.Format("2006-01-02 15:04")
+			if vt != nil {
+				val = LocalTime(ctx.R, *vt).Format("2006-01-02 15:04")
+			}
 		default:
 			panic(fmt.Sprintf("unknown time type: %T\n", v))
 		}
@@ -223,7 +241,7 @@ func cfTimeSetter(obj interface{}, field *FieldContext, ctx *web.EventContext) (
 	if v == "" {
 		return reflectutils.Set(obj, field.Name, nil)
 	}
-	t, err := time.ParseInLocation("2006-01-02 15:04", v, time.Local)
+	t, err := ParseLocalTime(ctx.R, "2006-01-02 15:04", v)
 	if err != nil {
 		return err
 	}
@@ -243,7 +261,7 @@ func cfTextField(obj interface{}, field *FieldContext, ctx *web.EventContext) h.
 func cfReadonlyText(obj interface{}, field *FieldContext, ctx *web.EventContext) h.HTMLComponent {
 	return vuetifyx.VXReadonlyField().
 		Label(field.Label).
-		Value(field.StringValue(obj))
+		Value(localStringValue(obj, field, ctx))
 }
 
 func cfReadonlyCheckbox(obj interface{}, field *FieldContext, ctx *web.EventContext) h.HTMLComponent {
@@ -283,6 +301,11 @@ func (b *FieldDefaults) builtInFieldTypes() {
 			b.FieldType(v).
 				ComponentFunc(cfReadonlyText)
 		}
+
+		for _, v := range timeVals {
+			b.FieldType(v).
+				ComponentFunc(cfReadonlyText)
+		}
 		return
 	}
 
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/qor5/admin/presets"
+	"github.com/qor5/admin/utils"
 	"github.com/qor5/web"
 	"gorm.io/gorm"
 )
@@ -46,6 +47,18 @@ func (op *DataOperatorBuilder) Search(obj interface{}, params *presets.SearchPar
 		wh = wh.Where(strings.Replace(cond.Query, " ILIKE ", " "+ilike+" ", -1), cond.Args...)
 	}
 
+	if params.Cursor != nil {
+		// the cursors replace the count and the offset, so the order is the one of the cursors
+		var cp utils.CursorPage
+		if cp, err = utils.CursorPaginate(wh, params.Cursor.After, params.Cursor.Before, int(params.PerPage), true, obj); err != nil {
+			return
+		}
+		params.Cursor.NextCursor, params.Cursor.PrevCursor = cp.NextCursor, cp.PrevCursor
+		r = reflect.ValueOf(obj).Elem().Interface()
+		totalCount = reflect.ValueOf(r).Len()
+		return
+	}
+
 	var c int64
 	err = wh.Count(&c).Error
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -49,6 +50,8 @@ type ListingBuilder struct {
 	// 2. the perPage will actually be ignored.
 	// 3. all data will be returned in one page.
 	disablePagination bool
+	// cursorPagination pages with the cursor and before query params instead of the page numbers
+	cursorPagination bool
 
 	orderBy           string
 	orderableFields   []*OrderableField
@@ -100,6 +103,14 @@ func (b *ListingBuilder) DisablePagination(v bool) (r *ListingBuilder) {
 	return b
 }
 
+// CursorPagination pages the listing with cursors on created_at and id instead of page numbers, newest first,
+// it stays fast deep into large tables and records inserted meanwhile don't shift the pages.
+// The searcher gets the cursors in SearchParams.Cursor, the gorm2op one loads the pages with utils.CursorPaginate.
+func (b *ListingBuilder) CursorPagination(v bool) (r *ListingBuilder) {
+	b.cursorPagination = v
+	return b
+}
+
 func (b *ListingBuilder) SearchFunc(v SearchFunc) (r *ListingBuilder) {
 	b.Searcher = v
 	return b
@@ -1160,6 +1171,9 @@ func (b *ListingBuilder) getTableComponents(
 	if searchParams.Page == 0 {
 		searchParams.Page = 1
 	}
+	if b.cursorPagination && !b.disablePagination {
+		searchParams.Cursor = &CursorParams{After: qs.Get("cursor"), Before: qs.Get("before")}
+	}
 
 	var fd vx.FilterData
 	if b.filterDataFunc != nil {
@@ -1327,6 +1341,14 @@ func (b *ListingBuilder) getTableComponents(
 		// the pagination component and the no-record message to page.
 		return
 	}
+	if searchParams.Cursor != nil {
+		var count int
+		if v := reflect.ValueOf(objs); v.Kind() == reflect.Slice {
+			count = v.Len()
+		}
+		datatableAdditions = b.cursorPager(ctx, msgr, searchParams.Cursor, count, inDialog)
+		return
+	}
 	if totalCount > 0 {
 		tpb := vx.VXTablePagination().
 			Total(int64(totalCount)).
@@ -1364,6 +1386,30 @@ func (b *ListingBuilder) getTableComponents(
 	return
 }
 
+func (b *ListingBuilder) cursorPager(ctx *web.EventContext, msgr *Messages, cp *CursorParams, count int, inDialog bool) h.HTMLComponent {
+	if count == 0 && cp.PrevCursor == "" {
+		return h.Div(h.Text(msgr.ListingNoRecordToShow)).Class("mt-10 text-center grey--text text--darken-2")
+	}
+	link := func(after string, before string) string {
+		e := web.Plaid().
+			Query("cursor", after).
+			Query("before", before).
+			MergeQuery(true)
+		if inDialog {
+			return e.URL(ctx.R.RequestURI).EventFunc(actions.UpdateListingDialog).Go()
+		}
+		return e.PushState(true).Go()
+	}
+	return h.Div(
+		VBtn("").Icon(true).Disabled(cp.PrevCursor == "").
+			Children(VIcon("chevron_left")).
+			Attr("@click", link("", cp.PrevCursor)),
+		VBtn("").Icon(true).Disabled(cp.NextCursor == "").
+			Children(VIcon("chevron_right")).
+			Attr("@click", link(cp.NextCursor, "")),
+	).Class("d-flex justify-end mt-2")
+}
+
 func (b *ListingBuilder) reloadList(ctx *web.EventContext) (r web.EventResponse, err error) {
 	dataTable, dataTableAdditions := b.getTableComponents(ctx, false)
 	r.UpdatePortals = append(r.UpdatePortals,
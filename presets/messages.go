@@ -55,6 +55,7 @@ type Messages struct {
 	Language                                   string
 	Colon                                      string
 	NotFoundPageNotice                         string
+	TimeFormat                                 string
 }
 
 func (msgr *Messages) DeleteConfirmationText(id string) string {
@@ -141,6 +142,7 @@ var Messages_en_US = &Messages{
 	Language:                                   "Language",
 	Colon:                                      ":",
 	NotFoundPageNotice:                         "Sorry, the requested page cannot be found. Please check the URL.",
+	TimeFormat:                                 "2006-01-02 15:04:05 MST",
 }
 
 var Messages_zh_CN = &Messages{
@@ -194,6 +196,7 @@ var Messages_zh_CN = &Messages{
 	Language:                                   "语言",
 	Colon:                                      "：",
 	NotFoundPageNotice:                         "很抱歉，所请求的页面不存在，请检查URL。",
+	TimeFormat:                                 "2006年01月02日 15:04:05 MST",
 }
 
 var Messages_ja_JP = &Messages{
@@ -246,4 +249,5 @@ var Messages_ja_JP = &Messages{
 	Language:                                   "言語",
 	Colon:                                      ":",
 	NotFoundPageNotice:                         "申し訳ありませんが、リクエストされたページは見つかりませんでした。URLを確認してください。",
+	TimeFormat:                                 "2006年01月02日 15:04:05 MST",
 }
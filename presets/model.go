@@ -21,6 +21,7 @@ type ModelBuilder struct {
 	modelType           reflect.Type
 	menuGroupName       string
 	notInMenu           bool
+	menuVisibleFunc     func(r *http.Request) bool
 	menuIcon            string
 	uriName             string
 	defaultURLQueryFunc func(*http.Request) url.Values
@@ -204,6 +205,20 @@ func (mb *ModelBuilder) InMenu(v bool) (r *ModelBuilder) {
 	return mb
 }
 
+// MenuVisibleFunc hides the menu item of the model when v returns false, for models whose access is checked
+// by their own permissions. The item is also hidden when listing the model is not permitted.
+func (mb *ModelBuilder) MenuVisibleFunc(v func(r *http.Request) bool) (r *ModelBuilder) {
+	mb.menuVisibleFunc = v
+	return mb
+}
+
+func (mb *ModelBuilder) menuItemAllowed(r *http.Request) bool {
+	if mb.Info().Verifier().Do(PermList).WithReq(r).IsAllowed() != nil {
+		return false
+	}
+	return mb.menuVisibleFunc == nil || mb.menuVisibleFunc(r)
+}
+
 func (mb *ModelBuilder) MenuIcon(v string) (r *ModelBuilder) {
 	mb.menuIcon = v
 	return mb
@@ -314,6 +314,11 @@ func (b *Builder) Model(v interface{}) (r *ModelBuilder) {
 	return r
 }
 
+// GetModels returns the model builders in the order they were added
+func (b *Builder) GetModels() []*ModelBuilder {
+	return b.models
+}
+
 func (b *Builder) DataOperator(v DataOperator) (r *Builder) {
 	b.dataOperator = v
 	return b
@@ -544,7 +549,7 @@ func (b *Builder) CreateMenus(ctx *web.EventContext) (r h.HTMLComponent) {
 				if m.notInMenu {
 					continue
 				}
-				if m.Info().Verifier().Do(PermList).WithReq(ctx.R).IsAllowed() != nil {
+				if !m.menuItemAllowed(ctx.R) {
 					continue
 				}
 				subMenus = append(subMenus, b.menuItem(ctx, m, true))
@@ -573,7 +578,7 @@ func (b *Builder) CreateMenus(ctx *web.EventContext) (r h.HTMLComponent) {
 			if m == nil {
 				continue
 			}
-			if m.Info().Verifier().Do(PermList).WithReq(ctx.R).IsAllowed() != nil {
+			if !m.menuItemAllowed(ctx.R) {
 				continue
 			}
 
@@ -592,7 +597,7 @@ func (b *Builder) CreateMenus(ctx *web.EventContext) (r h.HTMLComponent) {
 			continue
 		}
 
-		if m.Info().Verifier().Do(PermList).WithReq(ctx.R).IsAllowed() != nil {
+		if !m.menuItemAllowed(ctx.R) {
 			continue
 		}
 
@@ -1082,6 +1087,7 @@ func (b *Builder) InjectAssets(ctx *web.EventContext) {
 			</style>
 		`, "{{prefix}}", b.prefix, -1))
 
+	ctx.Injector.HeadHTML(timezoneScript)
 	b.InjectExtraAssets(ctx)
 
 	if len(os.Getenv("DEV_PRESETS")) > 0 {
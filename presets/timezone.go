@@ -0,0 +1,79 @@
+package presets
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// TimezoneCookieName is the cookie the layout saves the IANA time zone of the browser in, like "Asia/Tokyo"
+const TimezoneCookieName = "qor5_timezone"
+
+// TimezoneFunc returns the time zone timestamps are shown in to the user of the request,
+// set it to use the time zone of the user profile. The default is BrowserTimezone.
+var TimezoneFunc = BrowserTimezone
+
+// BrowserTimezone returns the time zone of the browser saved in TimezoneCookieName, or time.Local before it's saved
+func BrowserTimezone(r *http.Request) *time.Location {
+	if c, err := r.Cookie(TimezoneCookieName); err == nil {
+		name, _ := url.QueryUnescape(c.Value)
+		if loc, err := loadLocation(name); err == nil && name != "" {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// locations caches the time zones loaded by loadLocation, only the valid names are kept so the cookie can't grow it
+// past the time zone database
+var locations sync.Map
+
+// loadLocation is time.LoadLocation without reading the time zone database again for the names already loaded
+func loadLocation(name string) (*time.Location, error) {
+	if v, ok := locations.Load(name); ok {
+		return v.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// LocalTime converts t to the time zone of the user, the daylight saving offset is the one of the date of t
+func LocalTime(r *http.Request, t time.Time) time.Time {
+	if r == nil {
+		return t.Local()
+	}
+	return t.In(TimezoneFunc(r))
+}
+
+// FormatTime formats t in the time zone of the user with the time format of the user's language
+func FormatTime(r *http.Request, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	layout := Messages_en_US.TimeFormat
+	if r != nil && MustGetMessages(r).TimeFormat != "" {
+		layout = MustGetMessages(r).TimeFormat
+	}
+	return LocalTime(r, t).Format(layout)
+}
+
+// ParseLocalTime parses v in the time zone of the user, like the value of a date time picker
+func ParseLocalTime(r *http.Request, layout string, v string) (time.Time, error) {
+	return time.ParseInLocation(layout, v, TimezoneFunc(r))
+}
+
+const timezoneScript = `<script>
+(function () {
+	try {
+		var tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+		if (tz) {
+			document.cookie = "` + TimezoneCookieName + `=" + encodeURIComponent(tz) + "; path=/; max-age=31536000; samesite=lax";
+		}
+	} catch (e) {}
+})();
+</script>`
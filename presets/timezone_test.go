@@ -0,0 +1,46 @@
+package presets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLocalTime(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: TimezoneCookieName, Value: "Asia%2FTokyo"})
+
+	if loc := BrowserTimezone(r); loc.String() != "Asia/Tokyo" {
+		t.Fatalf("got %s", loc)
+	}
+	utc := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	if got := LocalTime(r, utc).Format("2006-01-02 15:04"); got != "2023-01-03 00:00" {
+		t.Errorf("got %s", got)
+	}
+	parsed, err := ParseLocalTime(r, "2006-01-02 15:04", "2023-01-03 00:00")
+	if err != nil || !parsed.Equal(utc) {
+		t.Errorf("got %s, %v", parsed, err)
+	}
+
+	if loc := BrowserTimezone(httptest.NewRequest("GET", "/", nil)); loc != time.Local {
+		t.Errorf("got %s without cookie", loc)
+	}
+}
+
+func TestLoadLocationIsCached(t *testing.T) {
+	a, err := loadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := loadLocation("Europe/Paris")
+	if a != b {
+		t.Error("the location is loaded again")
+	}
+	if _, err = loadLocation("Not/AZone"); err == nil {
+		t.Error("an invalid name is loaded")
+	}
+	if _, ok := locations.Load("Not/AZone"); ok {
+		t.Error("an invalid name is cached")
+	}
+}
@@ -1,12 +1,20 @@
 package publish
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
 	"reflect"
 	"strings"
 	"sync"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/iancoleman/strcase"
 	"github.com/qor/oss"
 	"github.com/qor5/admin/utils"
@@ -59,19 +67,19 @@ func (b *Builder) Context() context.Context {
 }
 
 // 幂等
+// The status is updated in a transaction that commits only after the content is written to the storage,
+// if either fails the storage writes are reverted and the previously published version is left intact.
 func (b *Builder) Publish(record interface{}) (err error) {
-	err = utils.Transact(b.db, func(tx *gorm.DB) (err error) {
-		// publish content
-		if r, ok := record.(PublishInterface); ok {
-			var objs []*PublishAction
-			objs, err = r.GetPublishActions(b.db, b.context, b.storage)
-			if err != nil {
-				return
-			}
-			if err = UploadOrDelete(objs, b.storage); err != nil {
-				return
-			}
+	var objs []*PublishAction
+	if r, ok := record.(PublishInterface); ok {
+		objs, err = r.GetPublishActions(b.db, b.context, b.storage)
+		if err != nil {
+			return
 		}
+	}
+
+	var st *storageTx
+	err = utils.Transact(b.db, func(tx *gorm.DB) (err error) {
 
 		// update status
 		if r, ok := record.(StatusInterface); ok {
@@ -82,7 +90,7 @@ func (b *Builder) Publish(record interface{}) (err error) {
 				if err != nil {
 					return
 				}
-				scope := SetPrimaryKeysConditionWithoutVersion(b.db.Model(reflect.New(modelSchema.ModelType).Interface()), record, modelSchema).Where("version <> ? AND status = ?", version.GetVersion(), StatusOnline)
+				scope := SetPrimaryKeysConditionWithoutVersion(tx.Model(reflect.New(modelSchema.ModelType).Interface()), record, modelSchema).Where("version <> ? AND status = ?", version.GetVersion(), StatusOnline)
 				var count int64
 				if err = scope.Count(&count).Error; err != nil {
 					return
@@ -117,35 +125,42 @@ func (b *Builder) Publish(record interface{}) (err error) {
 			}
 			updateMap["status"] = StatusOnline
 			updateMap["online_url"] = r.GetOnlineUrl()
-			if err = b.db.Model(record).Updates(updateMap).Error; err != nil {
+			if err = tx.Model(record).Updates(updateMap).Error; err != nil {
 				return
 			}
 		}
 
+		// publish content
+		if st, err = uploadOrDelete(objs, b.storage); err != nil {
+			return
+		}
+
 		// publish callback
 		if r, ok := record.(AfterPublishInterface); ok {
-			if err = r.AfterPublish(b.db, b.storage, b.context); err != nil {
+			if err = r.AfterPublish(tx, b.storage, b.context); err != nil {
 				return
 			}
 		}
 		return
 	})
+	if err != nil && st != nil {
+		st.rollback()
+	}
 	return
 }
 
+// UnPublish reverts the storage like Publish when it fails
 func (b *Builder) UnPublish(record interface{}) (err error) {
-	err = utils.Transact(b.db, func(tx *gorm.DB) (err error) {
-		// unpublish content
-		if r, ok := record.(UnPublishInterface); ok {
-			var objs []*PublishAction
-			objs, err = r.GetUnPublishActions(b.db, b.context, b.storage)
-			if err != nil {
-				return
-			}
-			if err = UploadOrDelete(objs, b.storage); err != nil {
-				return
-			}
+	var objs []*PublishAction
+	if r, ok := record.(UnPublishInterface); ok {
+		objs, err = r.GetUnPublishActions(b.db, b.context, b.storage)
+		if err != nil {
+			return
 		}
+	}
+
+	var st *storageTx
+	err = utils.Transact(b.db, func(tx *gorm.DB) (err error) {
 
 		// update status
 		if _, ok := record.(StatusInterface); ok {
@@ -161,19 +176,27 @@ func (b *Builder) UnPublish(record interface{}) (err error) {
 				updateMap["list_deleted"] = true
 			}
 			updateMap["status"] = StatusOffline
-			if err = b.db.Model(record).Updates(updateMap).Error; err != nil {
+			if err = tx.Model(record).Updates(updateMap).Error; err != nil {
 				return
 			}
 		}
 
+		// unpublish content
+		if st, err = uploadOrDelete(objs, b.storage); err != nil {
+			return
+		}
+
 		// unpublish callback
 		if r, ok := record.(AfterUnPublishInterface); ok {
-			if err = r.AfterUnPublish(b.db, b.storage, b.context); err != nil {
+			if err = r.AfterUnPublish(tx, b.storage, b.context); err != nil {
 				return
 			}
 		}
 		return
 	})
+	if err != nil && st != nil {
+		st.rollback()
+	}
 	return
 }
 
@@ -181,7 +204,34 @@ func (b *Builder) Sync(models ...interface{}) error {
 	return nil
 }
 
+// UploadOrDelete applies the actions in order, when one fails the applied ones are reverted
 func UploadOrDelete(objs []*PublishAction, storage oss.StorageInterface) (err error) {
+	_, err = uploadOrDelete(objs, storage)
+	return
+}
+
+// storageTx keeps what the storage had at the urls before the actions so they can be reverted
+type storageTx struct {
+	storage oss.StorageInterface
+	backups []storageBackup
+}
+
+type storageBackup struct {
+	url     string
+	content []byte
+	existed bool
+}
+
+// uploadOrDelete returns the storageTx of the applied actions to revert them when a later step fails,
+// it reverts them itself when an action fails. What the storage has at the urls is read before anything is written,
+// so a storage that can't be read fails before any change.
+func uploadOrDelete(objs []*PublishAction, storage oss.StorageInterface) (st *storageTx, err error) {
+	st = &storageTx{storage: storage}
+	for _, obj := range objs {
+		if err = st.backup(obj.Url); err != nil {
+			return nil, err
+		}
+	}
 	for _, obj := range objs {
 		if obj.IsDelete {
 			fmt.Printf("deleting %s \n", obj.Url)
@@ -191,12 +241,67 @@ func UploadOrDelete(objs []*PublishAction, storage oss.StorageInterface) (err er
 			_, err = storage.Put(obj.Url, strings.NewReader(obj.Content))
 		}
 		if err != nil {
-			return
+			st.rollback()
+			return nil, err
 		}
 	}
+	return st, nil
+}
+
+func (st *storageTx) backup(url string) error {
+	for _, b := range st.backups {
+		if b.url == url {
+			return nil
+		}
+	}
+	b := storageBackup{url: url}
+	r, err := st.storage.GetStream(url)
+	switch {
+	case err == nil:
+		b.content, err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("publish: backup %s: %w", url, err)
+		}
+		b.existed = true
+	case !isNotFound(err):
+		return fmt.Errorf("publish: backup %s: %w", url, err)
+	}
+	st.backups = append(st.backups, b)
 	return nil
 }
 
+// isNotFound reports whether the error of GetStream means nothing is stored at the url,
+// for the file system and the S3 storages
+func isNotFound(err error) bool {
+	if errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	var aerr awserr.RequestFailure
+	if errors.As(err, &aerr) && aerr.StatusCode() == http.StatusNotFound {
+		return true
+	}
+	var cerr awserr.Error
+	return errors.As(err, &cerr) && cerr.Code() == s3.ErrCodeNoSuchKey
+}
+
+// rollback restores the urls in reverse order, errors are logged since the original error is returned
+func (st *storageTx) rollback() {
+	for i := len(st.backups) - 1; i >= 0; i-- {
+		b := st.backups[i]
+		var err error
+		if b.existed {
+			_, err = st.storage.Put(b.url, bytes.NewReader(b.content))
+		} else {
+			err = st.storage.Delete(b.url)
+		}
+		if err != nil {
+			log.Printf("publish: failed to restore %s: %v\n", b.url, err)
+		}
+	}
+	st.backups = nil
+}
+
 func SetPrimaryKeysConditionWithoutVersion(db *gorm.DB, record interface{}, s *schema.Schema) *gorm.DB {
 	querys := []string{}
 	args := []interface{}{}
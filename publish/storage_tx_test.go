@@ -0,0 +1,92 @@
+package publish
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/qor/oss"
+	"github.com/qor/oss/filesystem"
+)
+
+type failingStorage struct {
+	oss.StorageInterface
+	failURL string
+}
+
+func (s *failingStorage) Put(path string, reader io.Reader) (*oss.Object, error) {
+	if path == s.failURL {
+		return nil, errors.New("put failed")
+	}
+	return s.StorageInterface.Put(path, reader)
+}
+
+func TestUploadOrDeleteRollback(t *testing.T) {
+	dir, err := os.MkdirTemp("", "publish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	storage := &failingStorage{StorageInterface: filesystem.New(dir), failURL: "/c.html"}
+	UploadOrDelete([]*PublishAction{{Url: "/a.html", Content: "old a"}, {Url: "/d.html", Content: "old d"}}, storage)
+
+	err = UploadOrDelete([]*PublishAction{
+		{Url: "/a.html", Content: "new a"},
+		{Url: "/b.html", Content: "new b"},
+		{Url: "/d.html", IsDelete: true},
+		{Url: "/c.html", Content: "new c"},
+	}, storage)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for url, want := range map[string]string{"/a.html": "old a", "/d.html": "old d"} {
+		r, err := storage.GetStream(url)
+		if err != nil {
+			t.Fatalf("%s: %v", url, err)
+		}
+		got, _ := io.ReadAll(r)
+		r.Close()
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", url, got, want)
+		}
+	}
+	if _, err := storage.GetStream("/b.html"); err == nil {
+		t.Error("/b.html should be removed")
+	}
+}
+
+type unreadableStorage struct {
+	oss.StorageInterface
+}
+
+func (s *unreadableStorage) GetStream(path string) (io.ReadCloser, error) {
+	return nil, errors.New("read failed")
+}
+
+func TestUploadOrDeleteFailsBeforeWritingWhenBackupFails(t *testing.T) {
+	dir, err := os.MkdirTemp("", "publish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fs := filesystem.New(dir)
+	if err = UploadOrDelete([]*PublishAction{{Url: "/a.html", Content: "old a"}}, fs); err != nil {
+		t.Fatal(err)
+	}
+	if err = UploadOrDelete([]*PublishAction{{Url: "/a.html", Content: "new a"}}, &unreadableStorage{fs}); err == nil {
+		t.Fatal("expected error")
+	}
+	r, err := fs.GetStream("/a.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if string(got) != "old a" {
+		t.Errorf("/a.html = %q, want the old content", got)
+	}
+}
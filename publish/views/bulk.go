@@ -0,0 +1,79 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qor5/admin/activity"
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/admin/publish"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	h "github.com/theplant/htmlgo"
+)
+
+const (
+	BulkPublishAction   = "Publish"
+	BulkUnpublishAction = "Unpublish"
+)
+
+func configureBulkActions(mb *presets.ModelBuilder, publisher *publish.Builder, ab *activity.ActivityBuilder) {
+	lb := mb.Listing()
+	lb.BulkAction(BulkPublishAction).
+		ComponentFunc(bulkConfirm).
+		UpdateFunc(bulkPublishUpdate(mb, ab, ActivityPublish, func(obj interface{}, ctx *web.EventContext) error {
+			publisher.WithEventContext(ctx)
+			return publisher.Publish(obj)
+		}))
+	lb.BulkAction(BulkUnpublishAction).
+		ComponentFunc(bulkConfirm).
+		UpdateFunc(bulkPublishUpdate(mb, ab, ActivityUnPublish, func(obj interface{}, ctx *web.EventContext) error {
+			return publisher.UnPublish(obj)
+		}))
+}
+
+func bulkConfirm(selectedIds []string, ctx *web.EventContext) h.HTMLComponent {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nPublishKey, Messages_en_US).(*Messages)
+	return VCardText(h.Text(fmt.Sprintf("%s (%d)", msgr.Areyousure, len(selectedIds))))
+}
+
+// bulkPublishUpdate runs do on the selected records one by one, without a transaction around them, so a record
+// failing the validation of the editing or do is skipped without undoing the others, the failures are reported
+// together after the others are done.
+func bulkPublishUpdate(mb *presets.ModelBuilder, ab *activity.ActivityBuilder, actionName string, do func(obj interface{}, ctx *web.EventContext) error) presets.BulkActionUpdateFunc {
+	return func(selectedIds []string, ctx *web.EventContext) (err error) {
+		var failed []string
+		for _, id := range selectedIds {
+			obj, err := mb.Editing().Fetcher(mb.NewModel(), id, ctx)
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", id, err))
+				continue
+			}
+			if v := mb.Editing().Validator; v != nil {
+				if vErr := v(obj, ctx); vErr.HaveErrors() {
+					failed = append(failed, fmt.Sprintf("%s: %s", id, vErr.Error()))
+					continue
+				}
+			}
+			if err = do(obj, ctx); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", id, err))
+				continue
+			}
+			if ab != nil {
+				if _, exist := ab.GetModelBuilder(obj); exist {
+					ab.AddCustomizedRecord(actionName, false, ctx.R.Context(), obj)
+				}
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nPublishKey, Messages_en_US).(*Messages)
+		vErr := &web.ValidationErrors{}
+		vErr.GlobalError(fmt.Sprintf("%s (%d/%d): %s", msgr.BulkFailed, len(failed), len(selectedIds), strings.Join(failed, "; ")))
+		ctx.Flash = vErr
+		return nil
+	}
+}
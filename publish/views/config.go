@@ -165,6 +165,9 @@ func Configure(b *presets.Builder, db *gorm.DB, ab *activity.ActivityBuilder, pu
 		}
 
 		registerEventFuncs(db, m, publisher, ab)
+		if _, ok := obj.(publish.StatusInterface); ok {
+			configureBulkActions(m, publisher, ab)
+		}
 	}
 
 	b.FieldDefaults(presets.LIST).
@@ -32,6 +32,7 @@ type Messages struct {
 	AllVersions             string
 	NamedVersions           string
 	RenameVersion           string
+	BulkFailed              string
 }
 
 var Messages_en_US = &Messages{
@@ -62,6 +63,7 @@ var Messages_en_US = &Messages{
 	AllVersions:             "All versions",
 	NamedVersions:           "Named versions",
 	RenameVersion:           "Rename Version",
+	BulkFailed:              "These records were skipped",
 }
 
 var Messages_zh_CN = &Messages{
@@ -92,6 +94,7 @@ var Messages_zh_CN = &Messages{
 	AllVersions:             "所有版本",
 	NamedVersions:           "已命名版本",
 	RenameVersion:           "命名版本",
+	BulkFailed:              "以下记录已跳过",
 }
 
 var Messages_ja_JP = &Messages{
@@ -122,6 +125,7 @@ var Messages_ja_JP = &Messages{
 	AllVersions:             "全てのバージョン",
 	NamedVersions:           "名付け済みバージョン",
 	RenameVersion:           "バージョンの名前を変更する",
+	BulkFailed:              "次のレコードはスキップされました",
 }
 
 func GetStatusText(status string, msgr *Messages) string {
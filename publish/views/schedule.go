@@ -2,8 +2,8 @@ package views
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
-	"time"
 
 	"github.com/qor5/admin/presets"
 	"github.com/qor5/admin/publish"
@@ -26,18 +26,18 @@ func ScheduleEditFunc() presets.FieldComponentFunc {
 
 		var start, end string
 		if s.GetScheduledStartAt() != nil {
-			start = s.GetScheduledStartAt().Format("2006-01-02 15:04")
+			start = presets.LocalTime(ctx.R, *s.GetScheduledStartAt()).Format("2006-01-02 15:04")
 		}
 		if s.GetScheduledEndAt() != nil {
-			end = s.GetScheduledEndAt().Format("2006-01-02 15:04")
+			end = presets.LocalTime(ctx.R, *s.GetScheduledEndAt()).Format("2006-01-02 15:04")
 		}
 
 		publishedAt, unpublishedAt := "", ""
 		if s.GetPublishedAt() != nil {
-			publishedAt = s.GetPublishedAt().Format("2006-01-02 15:04")
+			publishedAt = presets.LocalTime(ctx.R, *s.GetPublishedAt()).Format("2006-01-02 15:04")
 		}
 		if s.GetUnPublishedAt() != nil {
-			unpublishedAt = s.GetUnPublishedAt().Format("2006-01-02 15:04")
+			unpublishedAt = presets.LocalTime(ctx.R, *s.GetUnPublishedAt()).Format("2006-01-02 15:04")
 		}
 		return h.Div(
 			VCard(
@@ -119,7 +119,7 @@ func ScheduleEditSetterFunc(obj interface{}, field *presets.FieldContext, ctx *w
 	_, exist := ctx.R.Form["ScheduledStartAt"]
 	if exist {
 		s := ctx.R.FormValue("ScheduledStartAt")
-		if err = setTime(obj, "ScheduledStartAt", s); err != nil {
+		if err = setTime(ctx.R, obj, "ScheduledStartAt", s); err != nil {
 			return
 		}
 	}
@@ -127,7 +127,7 @@ func ScheduleEditSetterFunc(obj interface{}, field *presets.FieldContext, ctx *w
 	_, exist = ctx.R.Form["ScheduledEndAt"]
 	if exist {
 		e := ctx.R.FormValue("ScheduledEndAt")
-		if err = setTime(obj, "ScheduledEndAt", e); err != nil {
+		if err = setTime(ctx.R, obj, "ScheduledEndAt", e); err != nil {
 			return
 		}
 
@@ -138,11 +138,11 @@ func ScheduleEditSetterFunc(obj interface{}, field *presets.FieldContext, ctx *w
 
 var timeFormat = "2006-01-02 15:04:05"
 
-func setTime(obj interface{}, fieldName string, val string) (err error) {
+func setTime(r *http.Request, obj interface{}, fieldName string, val string) (err error) {
 	if val == "" {
 		err = reflectutils.Set(obj, fieldName, nil)
 	} else {
-		startAt, err1 := time.ParseInLocation(timeFormat, fmt.Sprintf("%v:00", val), time.Local)
+		startAt, err1 := presets.ParseLocalTime(r, timeFormat, fmt.Sprintf("%v:00", val))
 		if err1 == nil && !startAt.IsZero() {
 			err = reflectutils.Set(obj, fieldName, startAt)
 		}
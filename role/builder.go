@@ -21,6 +21,8 @@ type Builder struct {
 	// editorSubject is the subject that has permission to edit roles
 	// empty value means anyone can edit roles
 	editorSubject string
+	userModel     interface{}
+	permB         *perm.Builder
 }
 
 func New(db *gorm.DB) *Builder {
@@ -86,6 +88,7 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 		)
 	}
 
+	b.permB = pb.GetPermission()
 	role := pb.Model(&Role{})
 
 	ed := role.Editing(
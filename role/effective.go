@@ -0,0 +1,138 @@
+package role
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/qor5/x/perm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	ErrNoUserModel  = errors.New("role: UserModel is not set")
+	ErrNoPermission = errors.New("role: the presets builder does not have a permission builder")
+)
+
+// Permission is the effect of an action on a resource for a user, Roles are the roles giving the effect
+type Permission struct {
+	Action   string
+	Resource string
+	Effect   string
+	Roles    []string
+}
+
+// UserModel is the model of the users, the roles of the users are read from its many2many relation to Role,
+// so the join table is the one of the relation
+func (b *Builder) UserModel(v interface{}) (r *Builder) {
+	b.userModel = v
+	return b
+}
+
+// EffectivePermissions checks the actions and resources of the role editor, and of the policies of the roles of the
+// user, with the permission builder of Configure, so the policies defined in code are included.
+// Like the permission checks, an action is allowed when it's allowed for any of the roles, Roles are then the roles
+// allowing it, or all the roles of the user when it's denied. The conditions of the policies are given the context of r.
+func (b *Builder) EffectivePermissions(r *http.Request, userID uint) (ps []Permission, err error) {
+	if b.permB == nil {
+		return nil, ErrNoPermission
+	}
+	roles, err := b.userRoles(userID)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, ro := range roles {
+		names = append(names, ro.Name)
+	}
+
+	type key struct{ action, resource string }
+	var keys []key
+	seen := make(map[key]bool)
+	add := func(action string, resources ...string) {
+		for _, res := range resources {
+			for _, v := range strings.Split(res, ",") {
+				k := key{action, v}
+				if v == "" || seen[k] {
+					continue
+				}
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	for _, a := range b.actions {
+		for _, res := range b.resources {
+			add(fmt.Sprint(a.Value), fmt.Sprint(res.Value))
+		}
+	}
+	for _, ro := range roles {
+		for _, p := range ro.Permissions {
+			for _, a := range p.Actions {
+				add(a, p.Resources...)
+			}
+		}
+	}
+
+	for _, k := range keys {
+		p := Permission{Action: k.action, Resource: k.resource, Effect: perm.Denied}
+		if len(names) == 0 {
+			if b.isAllowed(r, perm.Anonymous, k.action, k.resource) {
+				p.Effect = perm.Allowed
+			}
+		}
+		for _, name := range names {
+			if b.isAllowed(r, name, k.action, k.resource) {
+				p.Effect = perm.Allowed
+				p.Roles = append(p.Roles, name)
+			}
+		}
+		if p.Effect == perm.Denied {
+			p.Roles = names
+		}
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Resource != ps[j].Resource {
+			return ps[i].Resource < ps[j].Resource
+		}
+		return ps[i].Action < ps[j].Action
+	})
+	return
+}
+
+func (b *Builder) isAllowed(r *http.Request, subject, action, resource string) bool {
+	// the resource patterns are checked as they are, without the module of the verifier
+	return perm.NewVerifier("", b.permB).Do(action).RemoveOn(1).On(resource).From(subject).WithReq(r).IsAllowed() == nil
+}
+
+func (b *Builder) userRoles(userID uint) (roles []*Role, err error) {
+	if b.userModel == nil {
+		return nil, ErrNoUserModel
+	}
+	stmt := &gorm.Statement{DB: b.db}
+	if err = stmt.Parse(b.userModel); err != nil {
+		return
+	}
+	var rel *schema.Relationship
+	for _, v := range stmt.Schema.Relationships.Many2Many {
+		if v.FieldSchema.ModelType == reflect.TypeOf(Role{}) {
+			rel = v
+			break
+		}
+	}
+	if rel == nil {
+		return nil, fmt.Errorf("role: %s has no many2many relation to Role", stmt.Schema.Name)
+	}
+
+	u := reflect.New(stmt.Schema.ModelType)
+	if err = stmt.Schema.PrioritizedPrimaryField.Set(stmt.Context, u.Elem(), userID); err != nil {
+		return
+	}
+	err = b.db.Preload("Permissions").Model(u.Interface()).Association(rel.Name).Find(&roles)
+	return
+}
@@ -0,0 +1,99 @@
+package role
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/qor5/admin/presets"
+	"github.com/qor5/ui/vuetify"
+	"github.com/qor5/x/perm"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type effectiveUser struct {
+	gorm.Model
+	Roles []Role `gorm:"many2many:member_roles;"`
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&Role{}, &perm.DefaultDBPolicy{}, &effectiveUser{}); err != nil {
+		t.Fatal(err)
+	}
+	editor := Role{Name: "editor", Permissions: []*perm.DefaultDBPolicy{
+		{Subject: "editor", Effect: perm.Allowed, Actions: []string{presets.PermUpdate}, Resources: []string{"*:posts:*"}},
+	}}
+	viewer := Role{Name: "viewer"}
+	u := &effectiveUser{Roles: []Role{editor, viewer}}
+	if err = db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	noRoles := &effectiveUser{}
+	if err = db.Create(noRoles).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	permB := perm.New().Policies(
+		perm.PolicyFor(perm.Anybody).WhoAre(perm.Allowed).ToDo(presets.PermList).On(perm.Anything),
+		perm.PolicyFor(perm.Anybody).WhoAre(perm.Denied).ToDo(presets.PermDelete).On("*:posts:*"),
+		perm.PolicyFor("viewer").WhoAre(perm.Denied).ToDo(presets.PermUpdate).On(perm.Anything),
+	)
+	var dbPolicies []perm.DefaultDBPolicy
+	db.Find(&dbPolicies)
+	for _, p := range dbPolicies {
+		permB.CreatePolicies(p.ToPolicy())
+	}
+
+	b := New(db).Actions([]*vuetify.DefaultOptionItem{
+		{Text: "List", Value: presets.PermList},
+		{Text: "Update", Value: presets.PermUpdate},
+		{Text: "Delete", Value: presets.PermDelete},
+	}).Resources([]*vuetify.DefaultOptionItem{
+		{Text: "Posts", Value: "*:posts:*"},
+		{Text: "Orders", Value: "*:orders:*,*:order_management:"},
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	if _, err = b.EffectivePermissions(r, u.ID); err != ErrNoPermission {
+		t.Errorf("err = %v, want %v", err, ErrNoPermission)
+	}
+	b.Configure(presets.New().Permission(permB))
+	if _, err = b.EffectivePermissions(r, u.ID); err != ErrNoUserModel {
+		t.Errorf("err = %v, want %v", err, ErrNoUserModel)
+	}
+	b.UserModel(&effectiveUser{})
+
+	ps, err := b.EffectivePermissions(r, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	both := []string{"editor", "viewer"}
+	want := []Permission{
+		{presets.PermDelete, "*:order_management:", perm.Denied, both},
+		{presets.PermList, "*:order_management:", perm.Allowed, both},
+		{presets.PermUpdate, "*:order_management:", perm.Denied, both},
+		{presets.PermDelete, "*:orders:*", perm.Denied, both},
+		{presets.PermList, "*:orders:*", perm.Allowed, both},
+		{presets.PermUpdate, "*:orders:*", perm.Denied, both},
+		{presets.PermDelete, "*:posts:*", perm.Denied, both},
+		{presets.PermList, "*:posts:*", perm.Allowed, both},
+		{presets.PermUpdate, "*:posts:*", perm.Allowed, []string{"editor"}},
+	}
+	if !reflect.DeepEqual(ps, want) {
+		t.Errorf("permissions = %+v, want %+v", ps, want)
+	}
+
+	ps, err = b.EffectivePermissions(r, noRoles.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range ps {
+		if allowed := p.Action == presets.PermList; (p.Effect == perm.Allowed) != allowed || len(p.Roles) != 0 {
+			t.Errorf("the policies of anybody aren't applied to the user without roles: %+v", p)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorPage tells the cursors of the pages around the one loaded by CursorPaginate,
+// they are empty when there is no such page
+type CursorPage struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// CursorPaginate loads a page of limit records of db into dest ordered by created_at and id, newest first when desc,
+// it replaces the order of db. Unlike an offset it stays fast deep into large tables with an index on (created_at, id),
+// and records inserted meanwhile don't shift the pages. after is the NextCursor and before the PrevCursor of a page
+// for the following or preceding one, both empty for the first page. A limit of zero loads all the records after the cursor.
+// dest is a pointer to a slice of structs or their pointers with the CreatedAt and ID fields, like those embedding gorm.Model.
+func CursorPaginate(db *gorm.DB, after string, before string, limit int, desc bool, dest interface{}) (p CursorPage, err error) {
+	backward := before != ""
+	cursor := after
+	if backward {
+		cursor = before
+	}
+
+	tx := db.Session(&gorm.Session{}).Clauses()
+	delete(tx.Statement.Clauses, "ORDER BY")
+	cmp, order := ">", "ASC"
+	if desc != backward {
+		cmp, order = "<", "DESC"
+	}
+	if cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return p, err
+		}
+		tx = tx.Where(fmt.Sprintf("(created_at %s ? OR (created_at = ? AND id %s ?))", cmp, cmp), createdAt, createdAt, id)
+	}
+	tx = tx.Order("created_at " + order).Order("id " + order)
+	if limit > 0 {
+		tx = tx.Limit(limit + 1)
+	}
+	if err = tx.Find(dest).Error; err != nil {
+		return
+	}
+
+	rv := reflect.ValueOf(dest).Elem()
+	n := rv.Len()
+	hasMore := limit > 0 && n > limit
+	if hasMore {
+		n = limit
+		rv.Set(rv.Slice(0, n))
+	}
+	if backward {
+		swap := reflect.Swapper(rv.Interface())
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	if n == 0 {
+		return
+	}
+
+	first, err := EncodeCursor(rv.Index(0).Interface())
+	if err != nil {
+		return
+	}
+	last, err := EncodeCursor(rv.Index(n - 1).Interface())
+	if err != nil {
+		return
+	}
+	if backward {
+		p.NextCursor = last
+		if hasMore {
+			p.PrevCursor = first
+		}
+		return
+	}
+	if hasMore {
+		p.NextCursor = last
+	}
+	if after != "" {
+		p.PrevCursor = first
+	}
+	return
+}
+
+// EncodeCursor returns the cursor of a record with the CreatedAt and ID fields,
+// CursorPaginate with it as after loads the records following it. The ID can be an integer or a string.
+func EncodeCursor(record interface{}) (string, error) {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	if v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("cursor of %T: not a struct", record)
+	}
+	createdAt, ok := fieldValue(v, "CreatedAt").(time.Time)
+	if !ok {
+		return "", fmt.Errorf("cursor of %T: no CreatedAt time", record)
+	}
+
+	var id string
+	idv := v.FieldByName("ID")
+	switch idv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		id = strconv.FormatUint(idv.Uint(), 10)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		id = strconv.FormatInt(idv.Int(), 10)
+	case reflect.String:
+		// prefixed so a numeric string is still compared as a string
+		id = "s" + idv.String()
+	default:
+		return "", fmt.Errorf("cursor of %T: unsupported ID type %s", record, idv.Kind())
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d_%s", createdAt.UnixNano(), id))), nil
+}
+
+func fieldValue(v reflect.Value, name string) interface{} {
+	f := v.FieldByName(name)
+	if !f.IsValid() || !f.CanInterface() {
+		return nil
+	}
+	return f.Interface()
+}
+
+func decodeCursor(cursor string) (createdAt time.Time, id interface{}, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return createdAt, nil, ErrInvalidCursor
+	}
+	ts, ids, ok := strings.Cut(string(b), "_")
+	if !ok || ids == "" {
+		return createdAt, nil, ErrInvalidCursor
+	}
+	ns, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return createdAt, nil, ErrInvalidCursor
+	}
+	if strings.HasPrefix(ids, "s") {
+		return time.Unix(0, ns), ids[1:], nil
+	}
+	if id, err = strconv.ParseInt(ids, 10, 64); err != nil {
+		if id, err = strconv.ParseUint(ids, 10, 64); err != nil {
+			return createdAt, nil, ErrInvalidCursor
+		}
+	}
+	return time.Unix(0, ns), id, nil
+}
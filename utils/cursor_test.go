@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type cursorRecord struct {
+	ID        uint
+	CreatedAt time.Time
+}
+
+func cursorPageIDs(t *testing.T, db *gorm.DB, after string, before string) (ids []uint, p CursorPage) {
+	var records []*cursorRecord
+	p, err := CursorPaginate(db.Model(&cursorRecord{}), after, before, 2, true, &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		ids = append(ids, r.ID)
+	}
+	return
+}
+
+func TestCursorPaginate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&cursorRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	// 2 and 3 are created at the same time, the id keeps their order
+	now := time.Now()
+	for i, d := range []time.Duration{0, time.Second, time.Second, 2 * time.Second, 3 * time.Second} {
+		if err = db.Create(&cursorRecord{ID: uint(i + 1), CreatedAt: now.Add(d)}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages := [][]uint{{5, 4}, {3, 2}, {1}}
+	var cursors []CursorPage
+	after := ""
+	for i, want := range pages {
+		ids, p := cursorPageIDs(t, db, after, "")
+		if !equalIDs(ids, want) {
+			t.Fatalf("page %d = %v, want %v", i, ids, want)
+		}
+		if (p.PrevCursor == "") != (i == 0) || (p.NextCursor == "") != (i == len(pages)-1) {
+			t.Fatalf("page %d cursors = %+v", i, p)
+		}
+		cursors = append(cursors, p)
+		after = p.NextCursor
+	}
+
+	// a record inserted meanwhile doesn't shift the pages
+	if err = db.Create(&cursorRecord{ID: 6, CreatedAt: now.Add(4 * time.Second)}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// backward from the last page
+	ids, p := cursorPageIDs(t, db, "", cursors[2].PrevCursor)
+	if !equalIDs(ids, pages[1]) {
+		t.Fatalf("previous page of the last one = %v, want %v", ids, pages[1])
+	}
+	if p.NextCursor == "" || p.PrevCursor == "" {
+		t.Fatalf("cursors of the middle page = %+v", p)
+	}
+	ids, p = cursorPageIDs(t, db, "", p.PrevCursor)
+	if !equalIDs(ids, []uint{5, 4}) {
+		t.Fatalf("previous page of the middle one = %v", ids)
+	}
+	ids, p = cursorPageIDs(t, db, "", p.PrevCursor)
+	if !equalIDs(ids, []uint{6}) || p.PrevCursor != "" {
+		t.Fatalf("the new record is the first page, got %v, %+v", ids, p)
+	}
+
+	if _, err = CursorPaginate(db.Model(&cursorRecord{}), "broken", "", 2, true, &[]*cursorRecord{}); err != ErrInvalidCursor {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestEncodeCursor(t *testing.T) {
+	now := time.Now()
+	for _, record := range []interface{}{
+		&cursorRecord{ID: 1, CreatedAt: now},
+		struct {
+			ID        int
+			CreatedAt time.Time
+		}{-1, now},
+		struct {
+			ID        string
+			CreatedAt time.Time
+		}{"42", now},
+	} {
+		c, err := EncodeCursor(record)
+		if err != nil {
+			t.Fatalf("EncodeCursor(%+v): %v", record, err)
+		}
+		createdAt, _, err := decodeCursor(c)
+		if err != nil || !createdAt.Equal(time.Unix(0, now.UnixNano())) {
+			t.Errorf("decodeCursor(%q) = %v, %v", c, createdAt, err)
+		}
+	}
+	if _, id, _ := decodeCursor(mustEncodeCursor(t, struct {
+		ID        string
+		CreatedAt time.Time
+	}{"42", now})); id != "42" {
+		t.Errorf("string id decoded as %#v", id)
+	}
+
+	for _, record := range []interface{}{
+		struct {
+			ID        float64
+			CreatedAt time.Time
+		}{1, now},
+		struct{ ID uint }{1},
+		1,
+	} {
+		if _, err := EncodeCursor(record); err == nil {
+			t.Errorf("EncodeCursor(%+v) accepted", record)
+		}
+	}
+}
+
+func mustEncodeCursor(t *testing.T, record interface{}) string {
+	c, err := EncodeCursor(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func equalIDs(a []uint, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"runtime/debug"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -33,3 +36,15 @@ func Transact(db *gorm.DB, f func(tx *gorm.DB) error) (err error) {
 	err = f(tx)
 	return
 }
+
+// RequestDB binds db to the context of the request, so its queries are cancelled when the request is,
+// and after timeout when it's above zero. Call cancel once the queries are done.
+func RequestDB(db *gorm.DB, r *http.Request, timeout time.Duration) (rdb *gorm.DB, cancel context.CancelFunc) {
+	var ctx context.Context
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(r.Context(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(r.Context())
+	}
+	return db.WithContext(ctx), cancel
+}
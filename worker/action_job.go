@@ -7,6 +7,7 @@ import (
 	"github.com/qor5/admin/presets"
 	"github.com/qor5/ui/vuetify"
 	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
 	h "github.com/theplant/htmlgo"
 )
 
@@ -277,11 +278,16 @@ func (b *Builder) eventActionJobProgressing(ctx *web.EventContext) (er web.Event
 		return er, err
 	}
 
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
+	eta := b.etaText(msgr, inst)
 	er.Body = h.Div(
 		h.Div(vuetify.VProgressLinear(
 			h.Strong(fmt.Sprintf("%d%%", inst.Progress)),
 		).Value(int(inst.Progress)).Height(20)).Class("mb-5"),
-		h.If(config.displayLog, actionJobLog(*config.b, inst)),
+		h.If(eta != "",
+			h.Div(h.Text(eta)).Class("text-caption grey--text mt-n4 mb-5"),
+		),
+		h.If(config.displayLog, actionJobLog(config.b, inst)),
 		h.If(inst.ProgressText != "",
 			h.Div().Class("mb-3").Children(
 				h.RawHTML(inst.ProgressText),
@@ -297,7 +303,7 @@ func (b *Builder) eventActionJobProgressing(ctx *web.EventContext) (er web.Event
 	return er, nil
 }
 
-func actionJobLog(b Builder, inst *QorJobInstance) h.HTMLComponent {
+func actionJobLog(b *Builder, inst *QorJobInstance) h.HTMLComponent {
 	var logLines []h.HTMLComponent
 	logs := make([]string, 0, 100)
 
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// QorJobCheckpoint is how far Batch has got in a job, it's shared by the reruns of the job
+type QorJobCheckpoint struct {
+	QorJobID  uint `gorm:"primarykey;autoIncrement:false"`
+	Offset    int
+	UpdatedAt time.Time
+}
+
+// BatchFunc processes the records from offset to offset+limit
+type BatchFunc func(ctx context.Context, offset int, limit int) error
+
+// Batch calls fn for every batchSize records of total, sets the progress of the job after each batch
+// and saves the offset done as a checkpoint. A rerun of a crashed or aborted job continues from the checkpoint,
+// the checkpoint is removed once all the batches are done. It stops with the error of ctx once ctx is done.
+func Batch(ctx context.Context, job QorJobInterface, total int, batchSize int, fn BatchFunc) error {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	cp, _ := job.(checkpointer)
+
+	offset := 0
+	if cp != nil {
+		var err error
+		if offset, err = cp.loadCheckpoint(); err != nil {
+			return err
+		}
+		if offset > 0 && offset < total {
+			job.AddLogf("continue from checkpoint %d/%d", offset, total)
+		}
+	}
+
+	for offset < total {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		limit := batchSize
+		if offset+limit > total {
+			limit = total - offset
+		}
+		if err := fn(ctx, offset, limit); err != nil {
+			return err
+		}
+		offset += limit
+		if cp != nil {
+			if err := cp.saveCheckpoint(offset); err != nil {
+				return err
+			}
+		}
+		if err := job.SetProgress(uint(offset * 100 / total)); err != nil {
+			return err
+		}
+	}
+
+	if cp != nil {
+		return cp.clearCheckpoint()
+	}
+	return nil
+}
+
+type checkpointer interface {
+	loadCheckpoint() (offset int, err error)
+	saveCheckpoint(offset int) error
+	clearCheckpoint() error
+}
+
+var _ checkpointer = (*QorJobInstance)(nil)
+
+func (job *QorJobInstance) loadCheckpoint() (offset int, err error) {
+	var cp QorJobCheckpoint
+	err = job.jb.b.db.Where("qor_job_id = ?", job.QorJobID).Limit(1).Find(&cp).Error
+	return cp.Offset, err
+}
+
+func (job *QorJobInstance) saveCheckpoint(offset int) error {
+	return job.jb.b.db.Save(&QorJobCheckpoint{QorJobID: job.QorJobID, Offset: offset}).Error
+}
+
+func (job *QorJobInstance) clearCheckpoint() error {
+	return job.jb.b.db.Where("qor_job_id = ?", job.QorJobID).Delete(&QorJobCheckpoint{}).Error
+}
@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestJobInstance(t *testing.T, b *Builder, qorJobID uint) *QorJobInstance {
+	inst := &QorJobInstance{QorJobID: qorJobID, Job: "batch", Status: JobStatusRunning, jb: &JobBuilder{b: b}}
+	if err := b.db.Create(inst).Error; err != nil {
+		t.Fatal(err)
+	}
+	return inst
+}
+
+func TestBatchResumesFromCheckpoint(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, nil)
+	qorJob := &QorJob{Job: "batch", Status: JobStatusRunning}
+	if err = db.Create(qorJob).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var done []int
+	crash := errors.New("worker crashed")
+	err = Batch(context.Background(), newTestJobInstance(t, b, qorJob.ID), 10, 3, func(ctx context.Context, offset int, limit int) error {
+		if offset == 6 {
+			return crash
+		}
+		done = append(done, offset)
+		return nil
+	})
+	if !errors.Is(err, crash) {
+		t.Fatalf("expected the crash error, got %v", err)
+	}
+
+	var cp QorJobCheckpoint
+	if err = db.First(&cp, "qor_job_id = ?", qorJob.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if cp.Offset != 6 {
+		t.Fatalf("expected checkpoint 6, got %d", cp.Offset)
+	}
+
+	// the rerun is a new instance of the same job
+	err = Batch(context.Background(), newTestJobInstance(t, b, qorJob.ID), 10, 3, func(ctx context.Context, offset int, limit int) error {
+		done = append(done, offset)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 4 || done[2] != 6 || done[3] != 9 {
+		t.Fatalf("expected batches 0, 3, 6, 9 each done once, got %v", done)
+	}
+
+	var count int64
+	if err = db.Model(&QorJobCheckpoint{}).Where("qor_job_id = ?", qorJob.ID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatal("expected the checkpoint to be cleared")
+	}
+}
@@ -10,10 +10,14 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/qor/oss"
 	"github.com/qor5/admin/activity"
 	"github.com/qor5/admin/presets"
+	"github.com/qor5/admin/presets/actions"
+	"github.com/qor5/admin/utils"
 	. "github.com/qor5/ui/vuetify"
 	"github.com/qor5/ui/vuetifyx"
 	"github.com/qor5/web"
@@ -33,6 +37,19 @@ type Builder struct {
 	mb                   *presets.ModelBuilder
 	getCurrentUserIDFunc func(r *http.Request) string
 	ab                   *activity.ActivityBuilder
+	retention            *RetentionPolicy
+	fieldPermission      bool
+	dbTimeout            time.Duration
+	logStorage           oss.StorageInterface
+	logDownloadPath      string
+	listeners            int
+	retentionStopC       chan struct{}
+
+	pauseM         sync.Mutex
+	paused         bool
+	listened       bool
+	stoppingC      chan struct{} // closed when the listeners stopped by Pause are stopped
+	pauseSyncStopC chan struct{}
 }
 
 func New(db *gorm.DB) *Builder {
@@ -48,10 +65,14 @@ func newWithConfigs(db *gorm.DB, q Queue) *Builder {
 		panic("db can not be nil")
 	}
 
-	err := db.AutoMigrate(&QorJob{}, &QorJobInstance{}, &QorJobLog{}, &GoQueError{})
+	err := db.AutoMigrate(&QorJob{}, &QorJobInstance{}, &QorJobLog{}, &QorJobTag{}, &QorJobCheckpoint{}, &QorJobQueueState{}, &GoQueError{})
 	if err != nil {
 		panic(err)
 	}
+	// for the cursor pagination of the listing
+	if err = db.Exec("CREATE INDEX IF NOT EXISTS idx_qor_jobs_created_at_id ON qor_jobs (created_at, id)").Error; err != nil {
+		panic(err)
+	}
 
 	r := &Builder{
 		db:  db,
@@ -79,6 +100,20 @@ func (b *Builder) Activity(ab *activity.ActivityBuilder) *Builder {
 	return b
 }
 
+// FieldPermission enables permission checks on job args fields with resources like "presets:job_name:f_field_name",
+// deny presets.PermGet to hide a field, deny presets.PermCreate and presets.PermUpdate to make it read-only.
+// Fields that are not editable keep their default(or previous) values on save.
+func (b *Builder) FieldPermission(v bool) *Builder {
+	b.fieldPermission = v
+	return b
+}
+
+// DBTimeout cancels the queries of the job progress and logs events taking longer, they are always cancelled with their request
+func (b *Builder) DBTimeout(v time.Duration) *Builder {
+	b.dbTimeout = v
+	return b
+}
+
 func (b *Builder) NewJob(name string) *JobBuilder {
 	for _, jb := range b.jbs {
 		if jb.name == name {
@@ -135,6 +170,9 @@ var permVerifier *perm.Verifier
 func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 	b.pb = pb
 	permVerifier = perm.NewVerifier("workers", pb.GetPermission())
+	if b.fieldPermission {
+		b.jpb.Permission(pb.GetPermission())
+	}
 	pb.I18n().
 		RegisterForModule(language.English, I18nWorkerKey, Messages_en_US).
 		RegisterForModule(language.SimplifiedChinese, I18nWorkerKey, Messages_zh_CN)
@@ -142,10 +180,12 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 	mb := pb.Model(&QorJob{}).
 		Label("Workers").
 		URIName("workers").
-		MenuIcon("smart_toy")
+		MenuIcon("smart_toy").
+		MenuVisibleFunc(b.anyJobViewable)
 
 	b.mb = mb
 	mb.RegisterEventFunc("worker_selectJob", b.eventSelectJob)
+	mb.RegisterEventFunc("worker_abortJobConfirmation", b.eventAbortJobConfirmation)
 	mb.RegisterEventFunc("worker_abortJob", b.eventAbortJob)
 	mb.RegisterEventFunc("worker_rerunJob", b.eventRerunJob)
 	mb.RegisterEventFunc("worker_updateJob", b.eventUpdateJob)
@@ -156,15 +196,34 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 	mb.RegisterEventFunc(ActionJobResponse, b.eventActionJobResponse)
 	mb.RegisterEventFunc(ActionJobClose, b.eventActionJobClose)
 	mb.RegisterEventFunc(ActionJobProgressing, b.eventActionJobProgressing)
+	mb.RegisterEventFunc("worker_togglePause", b.eventTogglePause)
 
-	lb := mb.Listing("ID", "Job", "Status", "CreatedAt")
+	lb := mb.Listing("ID", "Job", "Status", "Tags", "CreatedAt")
 	lb.RowMenu().Empty()
+	lb.CursorPagination(true)
+	searcher := lb.Searcher
+	lb.SearchFunc(func(model interface{}, params *presets.SearchParams, ctx *web.EventContext) (r interface{}, totalCount int, err error) {
+		if hidden := b.hiddenJobNames(ctx.R); len(hidden) > 0 {
+			params.SQLConditions = append(params.SQLConditions, &presets.SQLCondition{
+				Query: "job NOT IN (?)",
+				Args:  []interface{}{hidden},
+			})
+		}
+		if r, totalCount, err = searcher(model, params, ctx); err != nil {
+			return
+		}
+		if jobs, ok := r.([]*QorJob); ok {
+			err = b.loadJobTags(jobs)
+		}
+		return
+	})
+	lb.Action("PauseQueue").ButtonCompFunc(b.pauseButton)
 	lb.FilterDataFunc(func(ctx *web.EventContext) vuetifyx.FilterData {
 		msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
 		return []*vuetifyx.FilterItem{
 			{
 				Key:          "status",
-				Label:        "Status",
+				Label:        msgr.FilterStatus,
 				ItemType:     vuetifyx.ItemTypeSelect,
 				SQLCondition: `status %s ?`,
 				Options: []*vuetifyx.SelectItem{
@@ -175,13 +234,15 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 					{Text: msgr.StatusDone, Value: JobStatusDone},
 					{Text: msgr.StatusException, Value: JobStatusException},
 					{Text: msgr.StatusKilled, Value: JobStatusKilled},
+					{Text: msgr.StatusTimedOut, Value: JobStatusTimedOut},
 				},
 			},
+			b.tagFilterItem(msgr.FilterTag),
 		}
 	})
 	lb.FilterTabsFunc(func(ctx *web.EventContext) []*presets.FilterTab {
 		msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
-		return []*presets.FilterTab{
+		return append([]*presets.FilterTab{
 			{
 				Label: msgr.FilterTabAll,
 				Query: url.Values{"all": []string{"1"}},
@@ -202,7 +263,7 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 				Label: msgr.FilterTabErrors,
 				Query: url.Values{"status": []string{JobStatusException}},
 			},
-		}
+		}, tagFilterTabs(b.declaredTags())...)
 	})
 	lb.Field("Job").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
 		qorJob := obj.(*QorJob)
@@ -213,6 +274,7 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 		qorJob := obj.(*QorJob)
 		return Td(Text(getTStatus(msgr, qorJob.Status)))
 	})
+	lb.Field("Tags").ComponentFunc(b.tagsCell)
 
 	eb := mb.Editing("Job", "Args")
 
@@ -231,7 +293,23 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 		Value string
 	}
 	eb.Field("Job").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
 		qorJob := obj.(*QorJob)
+		if qorJob.Job == "" {
+			if job, _, err := b.getCloneSource(ctx); err != nil {
+				return Text(err.Error())
+			} else if job != "" {
+				if jb := b.getJobBuilder(job); jb == nil || editIsAllowed(ctx.R, job) != nil {
+					return Div(
+						VAlert().Dense(true).Type("warning").Children(
+							Text(msgr.NoticeJobCannotBeCloned),
+						),
+						web.Portal(b.jobSelectList(ctx, "")).Name("worker_jobSelectList"),
+					)
+				}
+				qorJob.Job = job
+			}
+		}
 		return web.Portal(b.jobSelectList(ctx, qorJob.Job)).Name("worker_jobSelectList")
 	})
 	eb.Field("Args").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
@@ -251,6 +329,11 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 		}
 
 		qorJob := obj.(*QorJob)
+		if qorJob.Job != "" && qorJob.Args == nil && ctx.R.FormValue(paramCloneJobID) != "" {
+			if job, args, err := b.getCloneSource(ctx); err == nil && job == qorJob.Job {
+				qorJob.Args = args
+			}
+		}
 		return web.Portal(b.jobEditingContent(ctx, qorJob.Job, qorJob.Args)).Name("worker_jobEditingContent")
 	})
 
@@ -269,7 +352,18 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 		return
 	})
 
-	mb.Detailing("DetailingPage").Field("DetailingPage").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+	dp := mb.Detailing("DetailingPage")
+	fetcher := dp.GetFetchFunc()
+	dp.FetchFunc(func(obj interface{}, id string, ctx *web.EventContext) (r interface{}, err error) {
+		if r, err = fetcher(obj, id, ctx); err != nil {
+			return
+		}
+		if err = viewIsAllowed(ctx.R, r.(*QorJob).Job); err != nil {
+			return nil, err
+		}
+		return
+	})
+	dp.Field("DetailingPage").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
 		msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
 
 		qorJob := obj.(*QorJob)
@@ -297,7 +391,7 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 							VBtn(msgr.ActionCancelJob).Color("error").Class("mr-2").
 								Attr("@click", web.Plaid().
 									URL(eURL).
-									EventFunc("worker_abortJob").
+									EventFunc("worker_abortJobConfirmation").
 									Query("jobID", fmt.Sprintf("%d", qorJob.ID)).
 									Query("job", qorJob.Job).
 									Go()),
@@ -307,6 +401,7 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 									EventFunc("worker_updateJob").
 									Query("jobID", fmt.Sprintf("%d", qorJob.ID)).
 									Query("job", qorJob.Job).
+									Query("instanceID", fmt.Sprint(inst.ID)).
 									Go()),
 						),
 					),
@@ -322,7 +417,12 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 		}
 
 		return Div(
-			Div(Text(getTJob(ctx.R, qorJob.Job))).Class("mb-3 text-h6 font-weight-regular"),
+			Div(
+				Text(getTJob(ctx.R, qorJob.Job)),
+				If(b.isDryRunInstance(inst),
+					VChip(Text(msgr.DryRun)).Small(true).Color("warning").Class("ml-2"),
+				),
+			).Class("mb-3 text-h6 font-weight-regular"),
 			If(inst.Status == JobStatusScheduled,
 				scheduledJobDetailing...,
 			).Else(
@@ -337,6 +437,7 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 				).Attr(web.InitContextVars, "{worker_updateJobProgressingInterval: 2000}"),
 			),
 			web.Portal().Name("worker_snackbar"),
+			web.Portal().Name("worker_abortJobConfirmation"),
 		)
 	})
 
@@ -369,12 +470,78 @@ func (b *Builder) Configure(pb *presets.Builder) *presets.ModelBuilder {
 	return mb
 }
 
+const paramCloneJobID = "cloneJobID"
+
+// getCloneSource returns the job name and args of the job to be cloned in the new job form
+func (b *Builder) getCloneSource(ctx *web.EventContext) (job string, args interface{}, err error) {
+	id := ctx.R.FormValue(paramCloneJobID)
+	if id == "" {
+		return "", nil, nil
+	}
+	var inst QorJobInstance
+	err = b.db.Where("qor_job_id = ?", id).Order("created_at desc").First(&inst).Error
+	if err != nil {
+		return "", nil, err
+	}
+	jb := b.getJobBuilder(inst.Job)
+	if jb == nil {
+		return inst.Job, nil, nil
+	}
+	args, err = jb.parseArgs(inst.Args)
+	if err != nil {
+		return "", nil, err
+	}
+	return inst.Job, args, nil
+}
+
+func (b *Builder) isDryRunInstance(inst *QorJobInstance) bool {
+	jb := b.getJobBuilder(inst.Job)
+	if jb == nil {
+		return false
+	}
+	args, err := jb.parseArgs(inst.Args)
+	if err != nil {
+		return false
+	}
+	return isDryRunArgs(args)
+}
+
+// progressText is the progress text of the job instance, or the number of records reported by ReportDryRun
+func progressText(msgr *Messages, inst *QorJobInstance) string {
+	if inst.DryRunAffected == nil {
+		return inst.ProgressText
+	}
+	return strings.ReplaceAll(msgr.DryRunAffected, "{Count}", fmt.Sprint(*inst.DryRunAffected))
+}
+
 func (b *Builder) Listen() {
+	paused, err := b.loadPaused()
+	if err != nil {
+		panic(err)
+	}
+	b.pauseM.Lock()
+	b.listened = true
+	b.paused = paused
+	if b.paused {
+		b.stoppingC = make(chan struct{})
+		close(b.stoppingC)
+	} else {
+		b.listen()
+	}
+	b.pauseM.Unlock()
+	b.runRetention()
+	b.runPauseSync()
+}
+
+func (b *Builder) listen() {
 	var jds []*QorJobDefinition
 	for _, jb := range b.jbs {
 		jds = append(jds, &QorJobDefinition{
-			Name:    jb.name,
-			Handler: jb.h,
+			Name:          jb.name,
+			Handler:       jb.h,
+			Timeout:       jb.timeout,
+			MaxRetries:    jb.maxRetries,
+			RetryInterval: jb.retryInterval,
 		})
 	}
 	err := b.q.Listen(jds, func(qorJobID uint) (QueJobInterface, error) {
@@ -391,9 +558,18 @@ func (b *Builder) Listen() {
 	if err != nil {
 		panic(err)
 	}
+	b.listeners = len(jds)
 }
 
 func (b *Builder) Shutdown(ctx context.Context) error {
+	if b.retentionStopC != nil {
+		close(b.retentionStopC)
+		b.retentionStopC = nil
+	}
+	if b.pauseSyncStopC != nil {
+		close(b.pauseSyncStopC)
+		b.pauseSyncStopC = nil
+	}
 	return b.q.Shutdown(ctx)
 }
 
@@ -405,7 +581,7 @@ func (b *Builder) createJob(ctx *web.EventContext, qorJob *QorJob) (j *QorJob, e
 	jb := b.mustGetJobBuilder(qorJob.Job)
 
 	// encode args
-	args, vErr := jb.unmarshalForm(ctx)
+	args, vErr := jb.unmarshalForm(ctx, "")
 	if vErr.HaveErrors() {
 		errM := make(map[string][]string)
 		argsT := reflect.TypeOf(jb.r).Elem()
@@ -414,7 +590,12 @@ func (b *Builder) createJob(ctx *web.EventContext, qorJob *QorJob) (j *QorJob, e
 			errM[fName] = vErr.GetFieldErrors(fName)
 		}
 		bErrM, _ := json.Marshal(errM)
-		err = errors.New(string(bErrM))
+		ve := &web.ValidationErrors{}
+		ve.FieldError("Args", string(bErrM))
+		for _, ge := range vErr.GetGlobalErrors() {
+			ve.GlobalError(ge)
+		}
+		err = ve
 		return
 	}
 
@@ -430,25 +611,81 @@ func (b *Builder) createJob(ctx *web.EventContext, qorJob *QorJob) (j *QorJob, e
 		}
 	}
 
+	return b.doCreateJob(ctx.R, jb, args, context, nil)
+}
+
+func (b *Builder) doCreateJob(r *http.Request, jb *JobBuilder, args interface{}, context map[string]interface{}, tags []string) (j *QorJob, err error) {
+	var inst *QorJobInstance
 	err = b.db.Transaction(func(tx *gorm.DB) error {
 		j = &QorJob{
-			Job:    qorJob.Job,
+			Job:    jb.name,
 			Status: JobStatusNew,
+			Tags:   normalizeTags(append(append([]string{}, jb.tags...), tags...)),
 		}
-		err = b.db.Create(j).Error
+		err = tx.Create(j).Error
 		if err != nil {
 			return err
 		}
-		var inst *QorJobInstance
-		inst, err = jb.newJobInstance(ctx.R, j.ID, qorJob.Job, args, context)
-		if err != nil {
+		if err = createJobTags(tx, j); err != nil {
 			return err
 		}
-		return b.q.Add(ctx.R.Context(), inst)
+		inst, err = jb.newJobInstance(tx, r, j.ID, jb.name, args, context)
+		return err
 	})
+	if err != nil {
+		return
+	}
+	// added after the commit, or a worker could pick up the job before its rows are visible
+	if err = b.q.Add(r.Context(), inst); err != nil {
+		inst.SetProgressText(err.Error())
+		inst.SetStatus(JobStatusException)
+	}
 	return
 }
 
+// EnqueueJob creates a job with args and adds it to the queue without the admin UI,
+// args should be the same type as the job resource
+func (b *Builder) EnqueueJob(name string, args interface{}) (jobID uint, err error) {
+	return b.EnqueueJobWithContext(context.Background(), name, args)
+}
+
+// EnqueueJobWithContext is like EnqueueJob, the request passed to GetCurrentUserIDFunc carries ctx
+func (b *Builder) EnqueueJobWithContext(ctx context.Context, name string, args interface{}) (jobID uint, err error) {
+	return b.enqueueJob(ctx, name, args, nil)
+}
+
+func (b *Builder) enqueueJob(ctx context.Context, name string, args interface{}, tags []string) (jobID uint, err error) {
+	if b.pb == nil {
+		return 0, errors.New("worker is not configured, call Configure before EnqueueJob")
+	}
+	jb := b.getJobBuilder(name)
+	if jb == nil {
+		return 0, fmt.Errorf("no job %s", name)
+	}
+	if jb.r != nil {
+		if args == nil {
+			args = jb.newResourceObject()
+		} else if reflect.TypeOf(args) != reflect.TypeOf(jb.r) {
+			return 0, fmt.Errorf("job %s args should be %T, got %T", name, jb.r, args)
+		}
+	}
+	if jb.validateFunc != nil {
+		if err = jb.validateFunc(args); err != nil {
+			return 0, err
+		}
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+	if err != nil {
+		return 0, err
+	}
+	j, err := b.doCreateJob(r, jb, args, map[string]interface{}{}, tags)
+	if err != nil {
+		return 0, err
+	}
+	return j.ID, nil
+}
+
 func (b *Builder) eventSelectJob(ctx *web.EventContext) (er web.EventResponse, err error) {
 	job := ctx.R.FormValue("jobName")
 	er.UpdatePortals = append(er.UpdatePortals,
@@ -465,16 +702,70 @@ func (b *Builder) eventSelectJob(ctx *web.EventContext) (er web.EventResponse, e
 	return
 }
 
+func (b *Builder) eventAbortJobConfirmation(ctx *web.EventContext) (er web.EventResponse, err error) {
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
+	presetsMsgr := presets.MustGetMessages(ctx.R)
+
+	qorJobName := ctx.R.FormValue("job")
+	if pErr := editIsAllowed(ctx.R, qorJobName); pErr != nil {
+		return er, pErr
+	}
+
+	er.UpdatePortals = append(er.UpdatePortals, &web.PortalUpdate{
+		Name: "worker_abortJobConfirmation",
+		Body: VDialog(
+			VCard(
+				VCardTitle(Text(msgr.AbortJobConfirmation)),
+				VCardText(
+					VTextarea().FieldName("AbortReason").Label(msgr.AbortReason).Rows(3),
+				),
+				VCardActions(
+					VSpacer(),
+					VBtn(presetsMsgr.Cancel).
+						Depressed(true).
+						Class("ml-2").
+						On("click", "vars.worker_abortJobConfirmation = false"),
+					VBtn(presetsMsgr.OK).
+						Color("error").
+						Depressed(true).
+						Dark(true).
+						Attr("@click", web.Plaid().
+							URL(ctx.R.URL.Path).
+							EventFunc("worker_abortJob").
+							Queries(ctx.Queries()).
+							Go()),
+				),
+			),
+		).MaxWidth("600px").
+			Attr("v-model", "vars.worker_abortJobConfirmation").
+			Attr(web.InitContextVars, `{worker_abortJobConfirmation: false}`),
+	})
+	er.VarsScript = "setTimeout(function(){ vars.worker_abortJobConfirmation = true }, 100)"
+	return
+}
+
 func (b *Builder) eventAbortJob(ctx *web.EventContext) (er web.EventResponse, err error) {
 	msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
 
 	qorJobID := uint(ctx.QueryAsInt("jobID"))
 	qorJobName := ctx.R.FormValue("job")
 
+	// check again in case the permission is changed after the confirmation is opened
 	if pErr := editIsAllowed(ctx.R, qorJobName); pErr != nil {
 		return er, pErr
 	}
 
+	reason := strings.TrimSpace(ctx.R.FormValue("AbortReason"))
+	if reason == "" {
+		er.UpdatePortals = append(er.UpdatePortals, &web.PortalUpdate{
+			Name: "worker_snackbar",
+			Body: VSnackbar().Value(true).Timeout(3000).Color("warning").Children(
+				Text(msgr.NoticeAbortReasonRequired),
+			),
+		})
+		return er, nil
+	}
+
 	jb := b.mustGetJobBuilder(qorJobName)
 	inst, err := jb.getJobInstance(qorJobID)
 	if err != nil {
@@ -494,6 +785,12 @@ func (b *Builder) eventAbortJob(ctx *web.EventContext) (er web.EventResponse, er
 				Text(msgr.NoticeJobCannotBeAborted),
 			),
 		})
+	} else {
+		operator := "unknown"
+		if b.getCurrentUserIDFunc != nil {
+			operator = b.getCurrentUserIDFunc(ctx.R)
+		}
+		inst.AddLogf("Aborted by %s, reason: %s", operator, reason)
 	}
 
 	er.Reload = true
@@ -548,11 +845,12 @@ func (b *Builder) eventRerunJob(ctx *web.EventContext) (er web.EventResponse, er
 	if err != nil {
 		return er, err
 	}
-	if old.Status != JobStatusDone {
-		return er, errors.New("job is not done")
+	// a job that failed or was killed continues from its checkpoint, a done one starts over
+	if !utils.Contains(finishedJobStatuses, old.Status) {
+		return er, errors.New("job is not finished")
 	}
 
-	inst, err := jb.newJobInstance(ctx.R, qorJobID, qorJobName, old.Args, old.Context)
+	inst, err := jb.newJobInstance(b.db, ctx.R, qorJobID, qorJobName, old.Args, old.Context)
 	if err != nil {
 		return er, err
 	}
@@ -589,7 +887,22 @@ func (b *Builder) eventUpdateJob(ctx *web.EventContext) (er web.EventResponse, e
 	}
 
 	jb := b.mustGetJobBuilder(qorJobName)
-	newArgs, argsVErr := jb.unmarshalForm(ctx)
+	old, err := jb.getJobInstance(qorJobID)
+	if err != nil {
+		return er, err
+	}
+	// someone else has updated the job since the page was loaded
+	if v := ctx.R.FormValue("instanceID"); v != "" && v != fmt.Sprint(old.ID) {
+		er.UpdatePortals = append(er.UpdatePortals, &web.PortalUpdate{
+			Name: "worker_snackbar",
+			Body: VSnackbar().Value(true).Timeout(3000).Color("warning").Children(
+				Text(msgr.NoticeJobWasUpdated),
+			),
+		})
+		er.Reload = true
+		return er, nil
+	}
+	newArgs, argsVErr := jb.unmarshalForm(ctx, old.Args)
 	if argsVErr.HaveErrors() {
 		return er, errors.New("invalid arguments")
 	}
@@ -604,10 +917,6 @@ func (b *Builder) eventUpdateJob(ctx *web.EventContext) (er web.EventResponse, e
 		}
 	}
 
-	old, err := jb.getJobInstance(qorJobID)
-	if err != nil {
-		return er, err
-	}
 	oldArgs, _ := jb.parseArgs(old.Args)
 	err = b.doAbortJob(ctx.R.Context(), old)
 	if err != nil {
@@ -625,7 +934,7 @@ func (b *Builder) eventUpdateJob(ctx *web.EventContext) (er web.EventResponse, e
 		return er, nil
 	}
 
-	newInst, err := jb.newJobInstance(ctx.R, qorJobID, qorJobName, newArgs, contexts)
+	newInst, err := jb.newJobInstance(b.db, ctx.R, qorJobID, qorJobName, newArgs, contexts)
 	if err != nil {
 		return er, err
 	}
@@ -662,42 +971,28 @@ func (b *Builder) eventUpdateJobProgressing(ctx *web.EventContext) (er web.Event
 	qorJobID := uint(ctx.QueryAsInt("jobID"))
 	qorJobName := ctx.R.FormValue("job")
 
-	inst, err := getModelQorJobInstance(b.db, qorJobID)
+	db, cancel := utils.RequestDB(b.db, ctx.R, b.dbTimeout)
+	defer cancel()
+	inst, err := getModelQorJobInstance(db, qorJobID)
 	if err != nil {
 		return er, err
 	}
+	if err = viewIsAllowed(ctx.R, inst.Job); err != nil {
+		return er, err
+	}
 
 	canEdit := editIsAllowed(ctx.R, qorJobName) == nil
 	logs := make([]string, 0, 100)
-	hasMoreLogs := false
-	{
-		var count int64
-		err = b.db.Model(&QorJobLog{}).
-			Where("qor_job_instance_id = ?", inst.ID).
-			Count(&count).
-			Error
-		if err != nil {
-			return er, err
-		}
-		if count > 100 {
-			hasMoreLogs = true
-		}
-		if count > 0 {
-			var mLogs []*QorJobLog
-			err = b.db.Where("qor_job_instance_id = ?", inst.ID).
-				Order("created_at desc").
-				Limit(100).
-				Find(&mLogs).
-				Error
-			if err != nil {
-				return er, err
-			}
-			for i := len(mLogs) - 1; i >= 0; i-- {
-				logs = append(logs, mLogs[i].Log)
-			}
-		}
+	// the latest 100 logs, the older ones are loaded after the cursor by eventLoadHiddenLogs
+	var mLogs []*QorJobLog
+	page, err := utils.CursorPaginate(db.Where("qor_job_instance_id = ?", inst.ID), "", "", 100, true, &mLogs)
+	if err != nil {
+		return er, err
+	}
+	for i := len(mLogs) - 1; i >= 0; i-- {
+		logs = append(logs, mLogs[i].Log)
 	}
-	er.Body = b.jobProgressing(canEdit, msgr, qorJobID, qorJobName, inst.Status, inst.Progress, logs, hasMoreLogs, inst.ProgressText)
+	er.Body = b.jobProgressing(canEdit, msgr, qorJobID, qorJobName, inst.Status, inst.Progress, b.etaText(msgr, inst), logs, page.NextCursor, progressText(msgr, inst), b.isDryRunInstance(inst))
 	if inst.Status != JobStatusNew && inst.Status != JobStatusRunning && inst.Status != JobStatusKilled {
 		er.VarsScript = "vars.worker_updateJobProgressingInterval = 0"
 	} else {
@@ -708,19 +1003,19 @@ func (b *Builder) eventUpdateJobProgressing(ctx *web.EventContext) (er web.Event
 
 func (b *Builder) eventLoadHiddenLogs(ctx *web.EventContext) (er web.EventResponse, err error) {
 	qorJobID := uint(ctx.QueryAsInt("jobID"))
-	currentCount := ctx.QueryAsInt("currentCount")
 
-	inst, err := getModelQorJobInstance(b.db, qorJobID)
+	db, cancel := utils.RequestDB(b.db, ctx.R, b.dbTimeout)
+	defer cancel()
+	inst, err := getModelQorJobInstance(db, qorJobID)
 	if err != nil {
 		return er, err
 	}
+	if err = viewIsAllowed(ctx.R, inst.Job); err != nil {
+		return er, err
+	}
 
 	var logs []*QorJobLog
-	err = b.db.Where("qor_job_instance_id = ?", inst.ID).
-		Order("created_at desc").
-		Offset(currentCount).
-		Find(&logs).
-		Error
+	_, err = utils.CursorPaginate(db.Where("qor_job_instance_id = ?", inst.ID), ctx.R.FormValue("cursor"), "", 0, true, &logs)
 	if err != nil {
 		return er, err
 	}
@@ -746,16 +1041,18 @@ func (b *Builder) jobProgressing(
 	job string,
 	status string,
 	progress uint,
+	eta string,
 	logs []string,
-	hasMoreLogs bool,
+	hiddenLogsCursor string,
 	progressText string,
+	isDryRun bool,
 ) HTMLComponent {
 	logLines := make([]HTMLComponent, 0, len(logs)+1)
-	if hasMoreLogs {
+	if hiddenLogsCursor != "" {
 		logLines = append(logLines, web.Portal(
-			VBtn("Load hidden logs").Attr("@click", web.Plaid().EventFunc("worker_loadHiddenLogs").
+			VBtn(msgr.LoadHiddenLogs).Attr("@click", web.Plaid().EventFunc("worker_loadHiddenLogs").
 				Query("jobID", id).
-				Query("currentCount", len(logs)).Go()).
+				Query("cursor", hiddenLogsCursor).Go()).
 				Small(true).
 				Depressed(true).
 				Class("mb-3"),
@@ -776,7 +1073,14 @@ func (b *Builder) jobProgressing(
 	}
 	inRefresh := status == JobStatusNew || status == JobStatusRunning
 	eURL := path.Join(b.mb.Info().ListingHref(), fmt.Sprint(id))
+	rerunLabel := msgr.ActionRerunJob
+	if isDryRun {
+		rerunLabel = msgr.ActionRerunDryRun
+	}
 	return Div(
+		If(isDryRun,
+			VAlert(Text(msgr.NoticeDryRun)).Dense(true).Type("info"),
+		),
 		Div(Text(msgr.DetailTitleStatus)).Class("text-caption"),
 		Div().Class("d-flex align-center mb-5").Children(
 			Div().Style("width: 120px").Children(
@@ -784,8 +1088,16 @@ func (b *Builder) jobProgressing(
 			),
 			VProgressLinear().Value(int(progress)),
 		),
+		If(eta != "",
+			Div(Text(eta)).Class("text-caption grey--text mt-n4 mb-5"),
+		),
 
-		Div(Text(msgr.DetailTitleLog)).Class("text-caption"),
+		Div(
+			Text(msgr.DetailTitleLog),
+			If(b.logDownloadURL(id) != "",
+				A(Text(msgr.DownloadFullLog)).Href(b.logDownloadURL(id)).Attr("download", true).Class("ml-2"),
+			),
+		).Class("text-caption"),
 		Div().Class("mb-3").Style(fmt.Sprintf(`
 		background-color: #222;
 		color: #fff;
@@ -814,13 +1126,21 @@ func (b *Builder) jobProgressing(
 					VBtn(msgr.ActionAbortJob).Color("error").
 						Attr("@click", web.Plaid().
 							URL(eURL).
-							EventFunc("worker_abortJob").
+							EventFunc("worker_abortJobConfirmation").
 							Query("jobID", fmt.Sprintf("%d", id)).
 							Query("job", job).
 							Go()),
 				),
+				If(utils.Contains(finishedJobStatuses, status),
+					VBtn(msgr.ActionCloneJob).Color("primary").Outlined(true).Class("mr-2").
+						Attr("@click", web.Plaid().
+							URL(b.mb.Info().ListingHref()).
+							EventFunc(actions.New).
+							Query(paramCloneJobID, fmt.Sprintf("%d", id)).
+							Go()),
+				),
 				If(status == JobStatusDone,
-					VBtn(msgr.ActionRerunJob).Color("primary").
+					VBtn(rerunLabel).Color("primary").
 						Attr("@click", web.Plaid().
 							URL(eURL).
 							EventFunc("worker_rerunJob").
@@ -861,6 +1181,12 @@ func (b *Builder) jobSelectList(
 					),
 				))),
 			)
+		} else if viewIsAllowed(ctx.R, jb.name) == nil {
+			items = append(items,
+				VListItem(VListItemContent(VListItemTitle(
+					Text(label),
+				))).Disabled(true),
+			)
 		}
 	}
 
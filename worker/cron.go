@@ -31,6 +31,15 @@ func (job cronJob) ToString() string {
 	return fmt.Sprintf("## BEGIN QOR JOB %v # %v\n%v\n## END QOR JOB\n", job.JobID, string(marshal), job.Command)
 }
 
+// killedBySignalError is returned by cron.run when the process got a SIGINT or SIGTERM while running the job
+type killedBySignalError struct {
+	sig os.Signal
+}
+
+func (e *killedBySignalError) Error() string {
+	return fmt.Sprintf("Worker killed by signal %s", e.sig.String())
+}
+
 // Cron implemented a worker Queue based on cronjob
 type cron struct {
 	Jobs     []*cronJob
@@ -146,17 +155,34 @@ func (c *cron) run(ctx context.Context, qorJob QueJobInterface) (err error) {
 		panic(fmt.Sprintf("job %v no handler", jobInfo.JobName))
 	}
 
-	go func() {
-		sigint := make(chan os.Signal, 1)
+	hctx, cf := context.WithCancel(ctx)
+	defer cf()
+	hDoneC := make(chan struct{})
+	sigC := make(chan os.Signal, 1)
+	gotSigC := make(chan os.Signal, 1)
 
-		// interrupt signal sent from terminal
-		signal.Notify(sigint, syscall.SIGINT)
-		// sigterm signal sent from kubernetes
-		signal.Notify(sigint, syscall.SIGTERM)
+	// interrupt signal sent from terminal, sigterm signal sent from kubernetes
+	signal.Notify(sigC, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigC)
 
-		i := <-sigint
+	go func() {
+		var i os.Signal
+		select {
+		case <-hDoneC:
+			return
+		case i = <-sigC:
+		}
 
-		qorJob.SetProgressText(fmt.Sprintf("Worker killed by signal %s", i.String()))
+		// give the handler a chance to stop gracefully, doRunJob saves the status once it returns
+		gotSigC <- i
+		cf()
+		select {
+		case <-hDoneC:
+			return
+		case <-time.After(AbortGracePeriod):
+		}
+
+		qorJob.SetProgressText((&killedBySignalError{sig: i}).Error())
 		qorJob.SetStatus(JobStatusKilled)
 
 		qorJob.StopRefresh()
@@ -166,7 +192,13 @@ func (c *cron) run(ctx context.Context, qorJob QueJobInterface) (err error) {
 	qorJob.StartRefresh()
 	defer qorJob.StopRefresh()
 
-	err = h(ctx, qorJob)
+	err = h(hctx, qorJob)
+	close(hDoneC)
+	select {
+	case i := <-gotSigC:
+		return &killedBySignalError{sig: i}
+	default:
+	}
 	if err == nil {
 		c.parseJobs()
 		defer c.writeCronJob()
@@ -192,7 +224,12 @@ func (c *cron) Kill(ctx context.Context, job QueJobInterface) (err error) {
 	for _, cronJob := range c.Jobs {
 		if cronJob.JobID == jobInfo.JobID {
 			if process, err := os.FindProcess(cronJob.Pid); err == nil {
-				if err = process.Kill(); err == nil {
+				if err = process.Signal(syscall.SIGTERM); err == nil {
+					// force kill the process if it is still running after the grace period
+					go func() {
+						time.Sleep(AbortGracePeriod)
+						process.Kill()
+					}()
 					cronJob.Delete = true
 					return job.SetStatus(JobStatusKilled)
 				}
@@ -246,6 +283,10 @@ func (c *cron) Listen(_ []*QorJobDefinition, getJob func(qorJobID uint) (QueJobI
 			os.Exit(0)
 		} else {
 			fmt.Println(err)
+			var ke *killedBySignalError
+			if errors.As(err, &ke) {
+				os.Exit(int(reflect.ValueOf(ke.sig).Int()))
+			}
 			os.Exit(1)
 		}
 	}
@@ -267,11 +308,18 @@ func (c *cron) doRunJob(ctx context.Context, job QueJobInterface) error {
 	}
 
 	if err := job.SetStatus(JobStatusRunning); err == nil {
-		if err := c.run(ctx, job); err == nil {
+		defer observeJobDuration(job, time.Now())
+		err = c.run(ctx, job)
+		if err == nil {
 			return job.SetStatus(JobStatusDone)
 		}
 
 		job.SetProgressText(err.Error())
+		var ke *killedBySignalError
+		if errors.As(err, &ke) {
+			job.SetStatus(JobStatusKilled)
+			return err
+		}
 		job.SetStatus(JobStatusException)
 	}
 
@@ -0,0 +1,34 @@
+package worker
+
+import "errors"
+
+// ErrJobTimedOut is the error a job is expired with when it runs longer than JobBuilder.Timeout
+var ErrJobTimedOut = errors.New("job timed out")
+
+// PermanentError is returned by a job handler for a failure that retrying won't fix, like invalid args,
+// the job goes straight to JobStatusException. Any other error is retried as set by JobBuilder.Retry.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err into a PermanentError, nil stays nil
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanentError reports whether err or any error it wraps is a PermanentError
+func IsPermanentError(err error) bool {
+	var pe *PermanentError
+	return errors.As(err, &pe)
+}
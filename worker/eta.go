@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// etaMinElapsed is how long a job runs before its remaining time is estimated
+const etaMinElapsed = 5 * time.Second
+
+// estimateRemaining estimates the remaining time of a running job from its average speed since it started,
+// it only depends on the instance, so any process polling the job gets the same estimate.
+// False means it's too early to estimate.
+func estimateRemaining(startedAt *time.Time, progress uint, now time.Time) (time.Duration, bool) {
+	if startedAt == nil || progress == 0 || progress >= 100 {
+		return 0, false
+	}
+	elapsed := now.Sub(*startedAt)
+	if elapsed < etaMinElapsed {
+		return 0, false
+	}
+	rate := float64(progress) / elapsed.Seconds()
+	return time.Duration(float64(100-progress) / rate * float64(time.Second)), true
+}
+
+func (b *Builder) etaText(msgr *Messages, inst *QorJobInstance) string {
+	if inst.Status != JobStatusRunning {
+		return ""
+	}
+	remaining, ok := estimateRemaining(inst.StartedAt, inst.Progress, time.Now())
+	if !ok {
+		return msgr.ETACalculating
+	}
+	return strings.ReplaceAll(msgr.ETARemaining, "{Time}", formatRemaining(msgr, remaining))
+}
+
+func formatRemaining(msgr *Messages, d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return strings.ReplaceAll(msgr.ETASeconds, "{Seconds}", fmt.Sprint(int(d.Seconds())+1))
+	case d < time.Hour:
+		return strings.ReplaceAll(msgr.ETAMinutes, "{Minutes}", fmt.Sprint(int(d.Minutes()+0.5)))
+	}
+	return strings.NewReplacer("{Hours}", fmt.Sprint(int(d.Hours())), "{Minutes}", fmt.Sprint(int(d.Minutes())%60)).
+		Replace(msgr.ETAHoursMinutes)
+}
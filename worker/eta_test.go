@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestEstimateRemaining(t *testing.T) {
+	now := time.Now()
+	startedAt := now.Add(-time.Minute)
+	cases := []struct {
+		name      string
+		startedAt *time.Time
+		progress  uint
+		want      time.Duration
+		ok        bool
+	}{
+		{name: "not started", progress: 50},
+		{name: "no progress", startedAt: &startedAt},
+		{name: "done", startedAt: &startedAt, progress: 100},
+		{name: "too early", startedAt: &now, progress: 50},
+		{name: "quarter", startedAt: &startedAt, progress: 25, want: 3 * time.Minute, ok: true},
+		{name: "half", startedAt: &startedAt, progress: 50, want: time.Minute, ok: true},
+	}
+	for _, c := range cases {
+		got, ok := estimateRemaining(c.startedAt, c.progress, now)
+		if ok != c.ok || got.Round(time.Second) != c.want {
+			t.Errorf("%s: got %v %v, want %v %v", c.name, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		10 * time.Second:               "11s",
+		5*time.Minute + 40*time.Second: "6m",
+		2*time.Hour + 5*time.Minute:    "2h5m",
+	} {
+		if got := formatRemaining(Messages_en_US, d); got != want {
+			t.Errorf("%v: got %q, want %q", d, got, want)
+		}
+	}
+	if got := formatRemaining(Messages_zh_CN, 2*time.Hour+5*time.Minute); got != "2小时5分钟" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRetryResetsStartedAt(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, nil)
+	qorJob := &QorJob{Job: "batch", Status: JobStatusRunning}
+	if err = db.Create(qorJob).Error; err != nil {
+		t.Fatal(err)
+	}
+	inst := newTestJobInstance(t, b, qorJob.ID)
+	startedAt := time.Now().Add(-time.Hour)
+	inst.StartedAt = &startedAt
+
+	if err = inst.SetStatus(JobStatusNew); err != nil {
+		t.Fatal(err)
+	}
+	if err = inst.SetStatus(JobStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if inst.StartedAt == nil || time.Since(*inst.StartedAt) > time.Minute {
+		t.Errorf("the retry should start at now, got %v", inst.StartedAt)
+	}
+}
+
+func TestETAText(t *testing.T) {
+	b := &Builder{}
+	startedAt := time.Now().Add(-time.Minute)
+	if got := b.etaText(Messages_en_US, &QorJobInstance{Status: JobStatusDone, StartedAt: &startedAt, Progress: 50}); got != "" {
+		t.Errorf("a finished job shows %q", got)
+	}
+	if got := b.etaText(Messages_en_US, &QorJobInstance{Status: JobStatusRunning, StartedAt: &startedAt}); got != Messages_en_US.ETACalculating {
+		t.Errorf("a job without progress shows %q", got)
+	}
+	if got := b.etaText(Messages_en_US, &QorJobInstance{Status: JobStatusRunning, StartedAt: &startedAt, Progress: 50}); got != "About 1m remaining" {
+		t.Errorf("a half done job shows %q", got)
+	}
+}
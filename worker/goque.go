@@ -7,7 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"runtime/debug"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/tnclong/go-que"
@@ -19,6 +22,7 @@ import (
 type goque struct {
 	q   que.Queue
 	db  *gorm.DB
+	mu  sync.Mutex // for wks, Pause and Resume call Shutdown and Listen from other goroutines
 	wks []*que.Worker
 }
 
@@ -56,9 +60,13 @@ func (q *goque) Add(ctx context.Context, job QueJobInterface) error {
 		runAt = scheduler.GetScheduleTime().In(time.Local)
 		job.SetStatus(JobStatusScheduled)
 	}
+	var priority int
+	if p, ok := jobInfo.Argument.(Prioritizer); ok {
+		priority = p.GetPriority()
+	}
 
 	_, err = q.q.Enqueue(ctx, nil, que.Plan{
-		Queue: "worker_" + jobInfo.JobName,
+		Queue: priorityQueue("worker_"+jobInfo.JobName, priority),
 		Args:  que.Args(jobInfo.JobID, jobInfo.Argument),
 		RunAt: runAt,
 	})
@@ -76,6 +84,70 @@ func (q *goque) run(ctx context.Context, job QueJobInterface) error {
 	return job.GetHandler()(ctx, job)
 }
 
+// runUntilAborted runs the job handler and cancels its context once the job is aborted or runs longer than timeout.
+// It always waits for the handler to return, a handler still running AbortGracePeriod after the abort keeps
+// its worker busy, so the jobs running never exceed MaxConcurrentPerformCount.
+// A panic of the handler is a PermanentError, the job is not retried.
+// A job aborted for running longer than timeout returns an ErrJobTimedOut.
+func (q *goque) runUntilAborted(ctx context.Context, job QueJobInterface, timeout time.Duration) (isAborted bool, err error) {
+	hctx, cf := context.WithCancel(ctx)
+	defer cf()
+
+	hErrC := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				job.AddLog(string(debug.Stack()))
+				hErrC <- Permanent(fmt.Errorf("job handler panicked: %v", r))
+			}
+		}()
+		hErrC <- q.run(hctx, job)
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	var graceC <-chan time.Time
+	var timedOut bool
+	for {
+		select {
+		case err = <-hErrC:
+			if timedOut {
+				err = fmt.Errorf("%w after %s", ErrJobTimedOut, timeout)
+			}
+			return isAborted, err
+		case <-timeoutC:
+			if isAborted {
+				continue
+			}
+			isAborted, timedOut = true, true
+			job.AddLog(fmt.Sprintf("job timed out after %s", timeout))
+			job.SetProgressText(fmt.Sprintf("Timed out after %s", timeout))
+			job.SetStatus(JobStatusTimedOut)
+			cf()
+			graceC = time.After(AbortGracePeriod)
+		case <-ticker.C:
+			if isAborted {
+				continue
+			}
+			status, _ := job.FetchAndSetStatus()
+			if status == JobStatusKilled {
+				isAborted = true
+				cf()
+				graceC = time.After(AbortGracePeriod)
+			}
+		case <-graceC:
+			graceC = nil
+			job.AddLog(fmt.Sprintf("job handler did not exit within %s after abort, waiting for it to return", AbortGracePeriod))
+		}
+	}
+}
+
 func (q *goque) Kill(ctx context.Context, job QueJobInterface) error {
 	return job.SetStatus(JobStatusKilled)
 }
@@ -92,94 +164,21 @@ func (q *goque) Listen(jobDefs []*QorJobDefinition, getJob func(qorJobID uint) (
 		}
 		worker, err := que.NewWorker(que.WorkerOptions{
 			Queue:                     "worker_" + jd.Name,
-			Mutex:                     q.q.Mutex(),
+			Mutex:                     &priorityMutex{Mutex: q.q.Mutex(), db: q.db},
 			MaxLockPerSecond:          10,
 			MaxBufferJobsCount:        0,
 			MaxPerformPerSecond:       2,
 			MaxConcurrentPerformCount: 1,
-			Perform: func(ctx context.Context, qj que.Job) (err error) {
-				var job QueJobInterface
-				{
-					var sid string
-					err = q.parseArgs(qj.Plan().Args, &sid)
-					if err != nil {
-						return err
-					}
-					id, err := strconv.Atoi(sid)
-					if err != nil {
-						return err
-					}
-					job, err = getJob(uint(id))
-					if err != nil {
-						return err
-					}
-				}
-
-				defer func() {
-					if r := recover(); r != nil {
-						job.AddLog(string(debug.Stack()))
-						job.SetProgressText(fmt.Sprint(r))
-						job.SetStatus(JobStatusException)
-						panic(r)
-					}
-				}()
-
-				if job.GetStatus() == JobStatusCancelled {
-					return qj.Expire(ctx, errors.New("job is cancelled"))
-				}
-				if job.GetStatus() != JobStatusNew && job.GetStatus() != JobStatusScheduled {
-					job.SetStatus(JobStatusKilled)
-					return errors.New("invalid job status, current status: " + job.GetStatus())
-				}
-
-				err = job.SetStatus(JobStatusRunning)
-				if err != nil {
-					return err
-				}
-
-				hctx, cf := context.WithCancel(ctx)
-				hDoneC := make(chan struct{})
-				isAborted := false
-				go func() {
-					timer := time.NewTicker(time.Second)
-					for {
-						select {
-						case <-hDoneC:
-							return
-						case <-timer.C:
-							status, _ := job.FetchAndSetStatus()
-							if status == JobStatusKilled {
-								isAborted = true
-								cf()
-								return
-							}
-						}
-					}
-				}()
-				err = q.run(hctx, job)
-				if !isAborted {
-					hDoneC <- struct{}{}
-				}
-				if err != nil {
-					job.SetProgressText(err.Error())
-					job.SetStatus(JobStatusException)
-					return err
-				}
-				if isAborted {
-					return qj.Expire(ctx, errors.New("manually aborted"))
-				}
-
-				err = job.SetStatus(JobStatusDone)
-				if err != nil {
-					return err
-				}
-				return qj.Done(ctx)
+			Perform: func(ctx context.Context, qj que.Job) error {
+				return q.perform(ctx, jd, qj, getJob)
 			},
 		})
 		if err != nil {
 			panic(err)
 		}
+		q.mu.Lock()
 		q.wks = append(q.wks, worker)
+		q.mu.Unlock()
 		go func() {
 			if err := worker.Run(); err != nil {
 				q.db.Create(&GoQueError{
@@ -192,9 +191,92 @@ func (q *goque) Listen(jobDefs []*QorJobDefinition, getJob func(qorJobID uint) (
 	return nil
 }
 
+// perform runs the job of qj, a failed job is retried up to the MaxRetries of jd unless the error is a PermanentError,
+// and a timed out or aborted job is expired
+func (q *goque) perform(ctx context.Context, jd *QorJobDefinition, qj que.Job, getJob func(qorJobID uint) (QueJobInterface, error)) (err error) {
+	var job QueJobInterface
+	{
+		var sid string
+		err = q.parseArgs(qj.Plan().Args, &sid)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(sid)
+		if err != nil {
+			return err
+		}
+		job, err = getJob(uint(id))
+		if err != nil {
+			return err
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			job.AddLog(string(debug.Stack()))
+			job.SetProgressText(fmt.Sprint(r))
+			job.SetStatus(JobStatusException)
+			panic(r)
+		}
+	}()
+
+	if job.GetStatus() == JobStatusCancelled {
+		return qj.Expire(ctx, errors.New("job is cancelled"))
+	}
+	if job.GetStatus() == JobStatusRunning {
+		// the queue locks a job to one worker, so the worker that was running it is gone,
+		// it's run again and continues from its checkpoint
+		job.AddLog("the worker running the job stopped, resuming the job")
+	} else if job.GetStatus() != JobStatusNew && job.GetStatus() != JobStatusScheduled {
+		job.SetStatus(JobStatusKilled)
+		return errors.New("invalid job status, current status: " + job.GetStatus())
+	}
+
+	err = job.SetStatus(JobStatusRunning)
+	if err != nil {
+		return err
+	}
+	defer observeJobDuration(job, time.Now())
+
+	isAborted, err := q.runUntilAborted(ctx, job, jd.Timeout)
+	if isAborted {
+		if errors.Is(err, ErrJobTimedOut) {
+			return qj.Expire(ctx, err)
+		}
+		return qj.Expire(ctx, errors.New("manually aborted"))
+	}
+	if err != nil {
+		if !IsPermanentError(err) && int(qj.RetryCount()) < jd.MaxRetries {
+			interval := jd.RetryInterval
+			if interval <= 0 {
+				interval = 10 * time.Second
+			}
+			job.AddLog(fmt.Sprintf("attempt %d failed, retrying in %s: %s", qj.RetryCount()+1, interval, err))
+			if err := job.SetStatus(JobStatusNew); err != nil {
+				return err
+			}
+			return qj.RetryAfter(ctx, interval, err)
+		}
+		job.SetProgressText(err.Error())
+		job.SetStatus(JobStatusException)
+		return err
+	}
+
+	err = job.SetStatus(JobStatusDone)
+	if err != nil {
+		return err
+	}
+	return qj.Done(ctx)
+}
+
 func (q *goque) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	wks := q.wks
+	q.wks = nil
+	q.mu.Unlock()
+
 	var errs error
-	for _, wk := range q.wks {
+	for _, wk := range wks {
 		if err := wk.Stop(ctx); err != nil {
 			errs = multierr.Append(errs, err)
 		}
@@ -202,6 +284,54 @@ func (q *goque) Shutdown(ctx context.Context) error {
 	return errs
 }
 
+// priorityQueueSep separates the queue of a job and the priority of the queue of its jobs of a priority
+const priorityQueueSep = ":p"
+
+// priorityQueue is the queue of the jobs of priority of queue, the jobs of priority 0 or below stay in queue
+func priorityQueue(queue string, priority int) string {
+	if priority <= 0 {
+		return queue
+	}
+	return fmt.Sprintf("%s%s%d", queue, priorityQueueSep, priority)
+}
+
+// priorityMutex locks the due jobs of the highest priority queue of a queue first,
+// the jobs of a priority queue are locked by their run_at like the ones of any queue
+type priorityMutex struct {
+	que.Mutex
+	db *gorm.DB
+}
+
+func (m *priorityMutex) Lock(ctx context.Context, queue string, count int) ([]que.Job, error) {
+	var queues []string
+	err := m.db.WithContext(ctx).Table("goque_jobs").Distinct("queue").
+		Where("queue LIKE ? AND run_at <= ? AND done_at IS NULL AND expired_at IS NULL", queue+priorityQueueSep+"%", time.Now()).
+		Pluck("queue", &queues).Error
+	if err != nil {
+		return nil, err
+	}
+	type prioritized struct {
+		name     string
+		priority int
+	}
+	var pqs []prioritized
+	prefix := queue + priorityQueueSep
+	for _, name := range queues {
+		if p, err := strconv.Atoi(strings.TrimPrefix(name, prefix)); err == nil && strings.HasPrefix(name, prefix) {
+			pqs = append(pqs, prioritized{name, p})
+		}
+	}
+	sort.Slice(pqs, func(i, j int) bool { return pqs[i].priority > pqs[j].priority })
+
+	for _, pq := range pqs {
+		jobs, err := m.Mutex.Lock(ctx, pq.name, count)
+		if err != nil || len(jobs) > 0 {
+			return jobs, err
+		}
+	}
+	return m.Mutex.Lock(ctx, queue, count)
+}
+
 func (q *goque) parseArgs(data []byte, args ...interface{}) error {
 	d := json.NewDecoder(bytes.NewReader(data))
 	if _, err := d.Token(); err != nil {
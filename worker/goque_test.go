@@ -0,0 +1,243 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tnclong/go-que"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestRunningJob(t *testing.T, h JobHandler) *QorJobInstance {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, nil)
+	qorJob := &QorJob{Job: "test", Status: JobStatusRunning}
+	if err = db.Create(qorJob).Error; err != nil {
+		t.Fatal(err)
+	}
+	inst := newTestJobInstance(t, b, qorJob.ID)
+	inst.jb.h = h
+	return inst
+}
+
+func TestRunUntilAbortedWaitsForTheHandler(t *testing.T) {
+	defer func(v time.Duration) { AbortGracePeriod = v }(AbortGracePeriod)
+	AbortGracePeriod = 10 * time.Millisecond
+
+	var returned int32
+	job := newTestRunningJob(t, func(ctx context.Context, job QorJobInterface) error {
+		// ignores the cancelled context for a while
+		time.Sleep(200 * time.Millisecond)
+		atomic.StoreInt32(&returned, 1)
+		return nil
+	})
+
+	isAborted, err := (&goque{}).runUntilAborted(context.Background(), job, 20*time.Millisecond)
+	if !isAborted || !errors.Is(err, ErrJobTimedOut) {
+		t.Fatalf("expected the job to time out, got %v, %v", isAborted, err)
+	}
+	if job.GetStatus() != JobStatusTimedOut {
+		t.Fatalf("expected status %s, got %s", JobStatusTimedOut, job.GetStatus())
+	}
+	if atomic.LoadInt32(&returned) != 1 {
+		t.Fatal("returned before the handler, the worker slot was given up while the handler runs")
+	}
+}
+
+func TestRunUntilAbortedPanicIsPermanent(t *testing.T) {
+	job := newTestRunningJob(t, func(ctx context.Context, job QorJobInterface) error {
+		panic("boom")
+	})
+
+	_, err := (&goque{}).runUntilAborted(context.Background(), job, 0)
+	if err == nil || !IsPermanentError(err) {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+}
+
+// lockingMutex locks a job of the queues of jobs, and records the queues it's asked to lock
+type lockingMutex struct {
+	que.Mutex
+	jobs  map[string]bool
+	asked []string
+}
+
+func (m *lockingMutex) Lock(ctx context.Context, queue string, count int) ([]que.Job, error) {
+	m.asked = append(m.asked, queue)
+	if m.jobs[queue] {
+		return []que.Job{nil}, nil
+	}
+	return nil, nil
+}
+
+func TestPriorityMutex(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Exec("CREATE TABLE goque_jobs (id integer primary key, queue text, run_at datetime, done_at datetime, expired_at datetime)").Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, j := range []struct {
+		queue string
+		runAt time.Time
+	}{
+		{"worker_a", now.Add(-time.Minute)},
+		{priorityQueue("worker_a", 1), now.Add(-time.Minute)},
+		{priorityQueue("worker_a", 10), now.Add(-time.Minute)},
+		{priorityQueue("worker_a", 2), now.Add(-time.Minute)},
+		// not due yet
+		{priorityQueue("worker_a", 20), now.Add(time.Hour)},
+		{priorityQueue("worker_ab", 30), now.Add(-time.Minute)},
+	} {
+		if err = db.Exec("INSERT INTO goque_jobs (queue, run_at) VALUES (?, ?)", j.queue, j.runAt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inner := &lockingMutex{jobs: map[string]bool{"worker_a": true, "worker_a:p1": true, "worker_a:p2": true}}
+	m := &priorityMutex{Mutex: inner, db: db}
+	jobs, err := m.Lock(context.Background(), "worker_a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("%d jobs locked", len(jobs))
+	}
+	// the job of priority 10 is taken by another worker
+	if want := []string{"worker_a:p10", "worker_a:p2"}; !reflect.DeepEqual(inner.asked, want) {
+		t.Errorf("queues locked in %v, want %v", inner.asked, want)
+	}
+
+	inner.asked, inner.jobs = nil, map[string]bool{"worker_a": true}
+	if _, err = m.Lock(context.Background(), "worker_a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"worker_a:p10", "worker_a:p2", "worker_a:p1", "worker_a"}; !reflect.DeepEqual(inner.asked, want) {
+		t.Errorf("queues locked in %v, want %v", inner.asked, want)
+	}
+}
+
+// recordingQueJob is the que.Job of a job instance, recording how the job is finished
+type recordingQueJob struct {
+	que.Job
+	retryCount int32
+	retryAfter time.Duration
+	expired    error
+	done       bool
+}
+
+func (j *recordingQueJob) Plan() que.Plan    { return que.Plan{Args: que.Args("1")} }
+func (j *recordingQueJob) RetryCount() int32 { return j.retryCount }
+
+func (j *recordingQueJob) RetryAfter(ctx context.Context, interval time.Duration, cerr error) error {
+	j.retryAfter = interval
+	return nil
+}
+
+func (j *recordingQueJob) Expire(ctx context.Context, cerr error) error {
+	j.expired = cerr
+	return nil
+}
+
+func (j *recordingQueJob) Done(ctx context.Context) error {
+	j.done = true
+	return nil
+}
+
+func performTestJob(job *QorJobInstance, jd *QorJobDefinition, qj *recordingQueJob) error {
+	return (&goque{}).perform(context.Background(), jd, qj, func(qorJobID uint) (QueJobInterface, error) {
+		return job, nil
+	})
+}
+
+func TestPerformRetriesTheFailedJobs(t *testing.T) {
+	job := newTestRunningJob(t, func(ctx context.Context, job QorJobInterface) error {
+		return errors.New("failed")
+	})
+	job.Status = JobStatusNew
+	jd := &QorJobDefinition{MaxRetries: 2, RetryInterval: time.Minute}
+
+	qj := &recordingQueJob{retryCount: 1}
+	if err := performTestJob(job, jd, qj); err != nil {
+		t.Fatal(err)
+	}
+	if qj.retryAfter != time.Minute || job.GetStatus() != JobStatusNew {
+		t.Fatalf("the second attempt should be retried in a minute as %s, got %v as %s", JobStatusNew, qj.retryAfter, job.GetStatus())
+	}
+
+	qj = &recordingQueJob{retryCount: 2}
+	if err := performTestJob(job, jd, qj); err == nil {
+		t.Fatal("expected the error of the last attempt")
+	}
+	if qj.retryAfter != 0 || job.GetStatus() != JobStatusException {
+		t.Fatalf("the last attempt shouldn't be retried, got %v as %s", qj.retryAfter, job.GetStatus())
+	}
+}
+
+func TestPerformDoesNotRetryPermanentErrors(t *testing.T) {
+	job := newTestRunningJob(t, func(ctx context.Context, job QorJobInterface) error {
+		return Permanent(errors.New("bad argument"))
+	})
+	job.Status = JobStatusNew
+
+	qj := &recordingQueJob{}
+	if err := performTestJob(job, &QorJobDefinition{MaxRetries: 3}, qj); err == nil {
+		t.Fatal("expected the permanent error")
+	}
+	if qj.retryAfter != 0 || job.GetStatus() != JobStatusException {
+		t.Fatalf("a permanent error shouldn't be retried, got %v as %s", qj.retryAfter, job.GetStatus())
+	}
+}
+
+func TestPerformExpiresTheTimedOutJobs(t *testing.T) {
+	job := newTestRunningJob(t, func(ctx context.Context, job QorJobInterface) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job.Status = JobStatusNew
+
+	qj := &recordingQueJob{}
+	if err := performTestJob(job, &QorJobDefinition{Timeout: 20 * time.Millisecond, MaxRetries: 3}, qj); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(qj.expired, ErrJobTimedOut) || qj.retryAfter != 0 {
+		t.Fatalf("a timed out job should be expired without a retry, got %v, %v", qj.expired, qj.retryAfter)
+	}
+	if job.GetStatus() != JobStatusTimedOut {
+		t.Fatalf("expected status %s, got %s", JobStatusTimedOut, job.GetStatus())
+	}
+}
+
+func TestPerformResumesTheJobOfAStoppedWorker(t *testing.T) {
+	var resumed bool
+	job := newTestRunningJob(t, func(ctx context.Context, job QorJobInterface) error {
+		resumed = true
+		return nil
+	})
+
+	qj := &recordingQueJob{}
+	if err := performTestJob(job, &QorJobDefinition{}, qj); err != nil {
+		t.Fatal(err)
+	}
+	if !resumed || !qj.done || job.GetStatus() != JobStatusDone {
+		t.Fatalf("the job left running should be run again, got %v, %v, %s", resumed, qj.done, job.GetStatus())
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	for priority, want := range map[int]string{-1: "worker_a", 0: "worker_a", 5: "worker_a:p5"} {
+		if got := priorityQueue("worker_a", priority); got != want {
+			t.Errorf("priorityQueue(%d) = %q, want %q", priority, got, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	HealthStatusIdle  = "idle"
+	HealthStatusBusy  = "busy"
+	HealthStatusStuck = "stuck"
+)
+
+// HealthStuckThreshold is how long running jobs can go without progress
+// or new jobs can wait to be picked up before the queue is reported as stuck
+var HealthStuckThreshold = time.Minute
+
+type Health struct {
+	Status         string           `json:"status"`
+	Counts         map[string]int64 `json:"counts"`
+	Listeners      int              `json:"listeners"`
+	Paused         bool             `json:"paused"`
+	LastHeartbeat  *time.Time       `json:"last_heartbeat"`
+	LastProgressAt *time.Time       `json:"last_progress_at"`
+	OldestNewJobAt *time.Time       `json:"oldest_new_job_at"`
+}
+
+// GetHealth reports job counts by status and whether the queue is idle, busy or stuck.
+// Running jobs save themselves every few seconds, the latest save is the heartbeat, it only tells their process is alive.
+// The queue is stuck when no running job changed its progress, progress text or logs within HealthStuckThreshold,
+// or when the oldest new job has waited longer than it.
+func (b *Builder) GetHealth() (h *Health, err error) {
+	h = &Health{
+		Counts:    make(map[string]int64),
+		Listeners: b.listeners,
+		Paused:    b.IsPaused(),
+	}
+
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err = b.db.Model(&QorJob{}).Select("status, count(*) as count").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		h.Counts[r.Status] = r.Count
+	}
+
+	if h.Counts[JobStatusRunning] > 0 {
+		var inst QorJobInstance
+		err = b.db.Where("status = ?", JobStatusRunning).Order("updated_at desc").Limit(1).Find(&inst).Error
+		if err != nil {
+			return nil, err
+		}
+		if inst.ID > 0 {
+			h.LastHeartbeat = &inst.UpdatedAt
+		}
+
+		inst = QorJobInstance{}
+		err = b.db.Where("status = ? AND progress_updated_at IS NOT NULL", JobStatusRunning).
+			Order("progress_updated_at desc").Limit(1).Find(&inst).Error
+		if err != nil {
+			return nil, err
+		}
+		if inst.ID > 0 {
+			h.LastProgressAt = inst.ProgressUpdatedAt
+		} else {
+			// the instances saved before ProgressUpdatedAt only have the heartbeat
+			h.LastProgressAt = h.LastHeartbeat
+		}
+	}
+	if h.Counts[JobStatusNew] > 0 {
+		var j QorJob
+		err = b.db.Where("status = ?", JobStatusNew).Order("created_at asc").Limit(1).Find(&j).Error
+		if err != nil {
+			return nil, err
+		}
+		if j.ID > 0 {
+			h.OldestNewJobAt = &j.CreatedAt
+		}
+	}
+
+	switch {
+	case h.Paused && h.Counts[JobStatusRunning] == 0:
+		h.Status = HealthStatusIdle
+	case h.LastProgressAt != nil && time.Since(*h.LastProgressAt) > HealthStuckThreshold:
+		h.Status = HealthStatusStuck
+	case !h.Paused && h.OldestNewJobAt != nil && time.Since(*h.OldestNewJobAt) > HealthStuckThreshold:
+		h.Status = HealthStatusStuck
+	case h.Counts[JobStatusRunning] > 0 || h.Counts[JobStatusNew] > 0:
+		h.Status = HealthStatusBusy
+	default:
+		h.Status = HealthStatusIdle
+	}
+	return h, nil
+}
+
+// HealthHandler serves GetHealth as JSON, responds 503 when the queue is stuck
+func (b *Builder) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, err := b.GetHealth()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if h.Status == HealthStatusStuck {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(h)
+	})
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGetHealth(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, nil)
+	qorJob := &QorJob{Job: "test", Status: JobStatusRunning}
+	if err = db.Create(qorJob).Error; err != nil {
+		t.Fatal(err)
+	}
+	inst := newTestJobInstance(t, b, qorJob.ID)
+	status := func() string {
+		h, err := b.GetHealth()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h.Status
+	}
+
+	if err = inst.SetProgress(10); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != HealthStatusBusy {
+		t.Errorf("a job making progress is %s", got)
+	}
+
+	// the refresh keeps saving the hanging job
+	hungAt := time.Now().Add(-2 * HealthStuckThreshold)
+	inst.ProgressUpdatedAt = &hungAt
+	if err = db.Save(inst).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != HealthStatusStuck {
+		t.Errorf("a job without progress is %s", got)
+	}
+
+	if err = inst.AddLog("still working"); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Save(inst).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != HealthStatusBusy {
+		t.Errorf("a job adding logs is %s", got)
+	}
+
+	// the new jobs pile up while a job runs
+	newJob := &QorJob{Job: "test", Status: JobStatusNew}
+	if err = db.Create(newJob).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Model(newJob).UpdateColumn("created_at", hungAt).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != HealthStatusStuck {
+		t.Errorf("a queue with a new job waiting is %s", got)
+	}
+}
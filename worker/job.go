@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/qor5/admin/presets"
+	. "github.com/qor5/ui/vuetify"
 	vx "github.com/qor5/ui/vuetifyx"
 	"github.com/qor5/web"
 	"github.com/qor5/x/i18n"
@@ -28,7 +29,12 @@ type JobBuilder struct {
 	rmb            *presets.ModelBuilder
 	h              JobHandler
 	contextHandler func(*web.EventContext) map[string]interface{} //optional
+	validateFunc   func(args interface{}) error                   //optional
 	global         bool
+	tags           []string
+	timeout        time.Duration
+	maxRetries     int
+	retryInterval  time.Duration
 }
 
 func newJob(b *Builder, name string) *JobBuilder {
@@ -61,7 +67,8 @@ func (jb *JobBuilder) Resource(r interface{}) *JobBuilder {
 	}
 
 	jb.r = r
-	jb.rmb = jb.b.jpb.Model(r)
+	// job name as uri name to check field permissions on resource like "presets:job_name:f_field_name"
+	jb.rmb = jb.b.jpb.Model(r).URIName(jb.name)
 
 	if _, ok := r.(Scheduler); ok {
 		jb.rmb.Editing().Field("ScheduleTime").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
@@ -69,7 +76,7 @@ func (jb *JobBuilder) Resource(r interface{}) *JobBuilder {
 			t := obj.(Scheduler).GetScheduleTime()
 			var v string
 			if t != nil {
-				v = t.Local().Format("2006-01-02 15:04")
+				v = presets.LocalTime(ctx.R, *t).Format("2006-01-02 15:04")
 			}
 			return vx.VXDateTimePicker().FieldName(field.Name).Label(msgr.ScheduleTime).
 				Value(v).
@@ -83,7 +90,7 @@ func (jb *JobBuilder) Resource(r interface{}) *JobBuilder {
 			if v == "" {
 				return nil
 			}
-			t, err := time.ParseInLocation("2006-01-02 15:04", v, time.Local)
+			t, err := presets.ParseLocalTime(ctx.R, "2006-01-02 15:04", v)
 			if err != nil {
 				return err
 			}
@@ -91,6 +98,13 @@ func (jb *JobBuilder) Resource(r interface{}) *JobBuilder {
 			return nil
 		})
 	}
+	if _, ok := r.(DryRunner); ok {
+		jb.rmb.Editing().Field("DryRun").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
+			return VCheckbox().FieldName(field.Name).Label(msgr.DryRun).
+				InputValue(obj.(DryRunner).IsDryRun())
+		})
+	}
 	return jb
 }
 
@@ -103,11 +117,32 @@ func (jb *JobBuilder) Handler(h JobHandler) *JobBuilder {
 	return jb
 }
 
+// Timeout kills the job like an abort when it runs longer than d, every run of the job has its own clock
+func (jb *JobBuilder) Timeout(d time.Duration) *JobBuilder {
+	jb.timeout = d
+	return jb
+}
+
+// Retry runs a failed job again up to maxRetries times, interval after the failure (10 seconds if 0).
+// Handlers return a PermanentError for failures that retrying won't fix.
+func (jb *JobBuilder) Retry(maxRetries int, interval time.Duration) *JobBuilder {
+	jb.maxRetries = maxRetries
+	jb.retryInterval = interval
+	return jb
+}
+
 func (jb *JobBuilder) ContextHandler(handler func(*web.EventContext) map[string]interface{}) *JobBuilder {
 	jb.contextHandler = handler
 	return jb
 }
 
+// Validate sets the func to validate job args before the job is created,
+// return *web.ValidationErrors to show the errors on the form fields
+func (jb *JobBuilder) Validate(v func(args interface{}) error) *JobBuilder {
+	jb.validateFunc = v
+	return jb
+}
+
 func (jb *JobBuilder) newResourceObject() interface{} {
 	if jb.r == nil {
 		return nil
@@ -115,12 +150,37 @@ func (jb *JobBuilder) newResourceObject() interface{} {
 	return reflect.New(reflect.TypeOf(jb.r).Elem()).Interface()
 }
 
-func (jb *JobBuilder) unmarshalForm(ctx *web.EventContext) (args interface{}, vErr web.ValidationErrors) {
+// unmarshalForm sets the form values to a copy of base args (the resource defaults if base is empty),
+// so fields the user is not permitted to edit keep their values
+func (jb *JobBuilder) unmarshalForm(ctx *web.EventContext, base string) (args interface{}, vErr web.ValidationErrors) {
 	args = jb.newResourceObject()
 	if args != nil {
+		if base == "" {
+			bArgs, err := json.Marshal(jb.r)
+			if err == nil {
+				base = string(bArgs)
+			}
+		}
+		if base != "" {
+			if err := json.Unmarshal([]byte(base), args); err != nil {
+				vErr.GlobalError(err.Error())
+				return args, vErr
+			}
+		}
 		vErr = jb.rmb.Editing().RunSetterFunc(ctx, false, args)
 	}
+	if vErr.HaveErrors() || jb.validateFunc == nil {
+		return args, vErr
+	}
 
+	if err := jb.validateFunc(args); err != nil {
+		var ve *web.ValidationErrors
+		if errors.As(err, &ve) {
+			vErr = *ve
+		} else {
+			vErr.GlobalError(err.Error())
+		}
+	}
 	return args, vErr
 }
 
@@ -155,7 +215,11 @@ func getModelQorJobInstance(db *gorm.DB, qorJobID uint) (*QorJobInstance, error)
 }
 
 func (jb *JobBuilder) getJobInstance(qorJobID uint) (*QorJobInstance, error) {
-	inst, err := getModelQorJobInstance(jb.b.db, qorJobID)
+	return jb.getJobInstanceFromDB(jb.b.db, qorJobID)
+}
+
+func (jb *JobBuilder) getJobInstanceFromDB(db *gorm.DB, qorJobID uint) (*QorJobInstance, error) {
+	inst, err := getModelQorJobInstance(db, qorJobID)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +229,9 @@ func (jb *JobBuilder) getJobInstance(qorJobID uint) (*QorJobInstance, error) {
 	return inst, nil
 }
 
+// newJobInstance creates the instance with db, which is the transaction when it's created with the job
 func (jb *JobBuilder) newJobInstance(
+	db *gorm.DB,
 	r *http.Request,
 	qorJobID uint,
 	qorJobName string,
@@ -204,12 +270,12 @@ func (jb *JobBuilder) newJobInstance(
 	if jb.b.getCurrentUserIDFunc != nil {
 		inst.Operator = jb.b.getCurrentUserIDFunc(r)
 	}
-	err := jb.b.db.Create(&inst).Error
+	err := db.Create(&inst).Error
 	if err != nil {
 		return nil, err
 	}
 
-	return jb.getJobInstance(qorJobID)
+	return jb.getJobInstanceFromDB(db, qorJobID)
 }
 
 type QueJobInterface interface {
@@ -303,6 +369,17 @@ func (job *QorJobInstance) SetStatus(status string) error {
 	if status == JobStatusDone {
 		job.Progress = 100
 	}
+	// a retry starts again, its remaining time is estimated from when it starts running
+	if status == JobStatusNew {
+		job.StartedAt = nil
+	}
+	if status == JobStatusRunning {
+		now := time.Now()
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+		job.ProgressUpdatedAt = &now
+	}
 
 	if job.shouldCallSave() {
 		return job.callSave()
@@ -319,6 +396,7 @@ func (job *QorJobInstance) SetProgress(progress uint) error {
 		progress = 100
 	}
 	job.Progress = progress
+	job.progressUpdated()
 
 	if job.shouldCallSave() {
 		return job.callSave()
@@ -332,6 +410,7 @@ func (job *QorJobInstance) SetProgressText(s string) error {
 	defer job.mutex.Unlock()
 
 	job.ProgressText = s
+	job.progressUpdated()
 	if job.shouldCallSave() {
 		return job.callSave()
 	}
@@ -339,17 +418,33 @@ func (job *QorJobInstance) SetProgressText(s string) error {
 	return nil
 }
 
-func (job *QorJobInstance) AddLog(log string) error {
-	if err := job.jb.b.db.Create(&QorJobLog{
-		QorJobInstanceID: job.ID,
-		Log:              log,
-	}).Error; err != nil {
-		return err
+// progressUpdated sets ProgressUpdatedAt, the health check tells a job hanging in a live process from the saves
+// of its refresh with it, job.mutex must be held
+func (job *QorJobInstance) progressUpdated() {
+	now := time.Now()
+	job.ProgressUpdatedAt = &now
+}
+
+func (job *QorJobInstance) setDryRunAffected(n int64) error {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	job.DryRunAffected = &n
+	if job.shouldCallSave() {
+		return job.callSave()
 	}
 
 	return nil
 }
 
+func (job *QorJobInstance) AddLog(log string) error {
+	return job.bufferLog(&QorJobLog{
+		QorJobInstanceID: job.ID,
+		CreatedAt:        time.Now(),
+		Log:              log,
+	})
+}
+
 func (job *QorJobInstance) AddLogf(format string, a ...interface{}) error {
 	return job.AddLog(fmt.Sprintf(format, a...))
 }
@@ -377,6 +472,7 @@ func (job *QorJobInstance) StopRefresh() {
 	}
 
 	job.stopRefresh = true
+	job.flushLogs()
 }
 
 func (job *QorJobInstance) GetHandler() JobHandler {
@@ -413,6 +509,7 @@ func (job *QorJobInstance) refresh() {
 	if err != nil {
 		log.Println(err)
 	}
+	job.flushLogs()
 
 	if job.stopRefresh {
 		job.inRefresh = false
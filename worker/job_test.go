@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type addFailQueue struct {
+	listenCountQueue
+}
+
+func (q *addFailQueue) Add(ctx context.Context, job QueJobInterface) error {
+	return errors.New("queue is down")
+}
+
+func TestCreateJobFailedToQueue(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, &addFailQueue{})
+	jb := b.NewJob("test").Handler(func(ctx context.Context, job QorJobInterface) error { return nil })
+
+	j, err := b.doCreateJob(httptest.NewRequest("POST", "/", nil), jb, nil, map[string]interface{}{}, nil)
+	if err == nil {
+		t.Fatal("expected the queue error")
+	}
+	inst, err := getModelQorJobInstance(db, j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.Status != JobStatusException {
+		t.Fatalf("a job that failed to queue should be %s to be rerun, got %s", JobStatusException, inst.Status)
+	}
+}
+
+func TestCreateJobTagsInTheTransaction(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:create_job_tags?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, &listenCountQueue{})
+	jb := b.NewJob("test").Tags("import").Handler(func(ctx context.Context, job QorJobInterface) error { return nil })
+
+	j, err := b.doCreateJob(httptest.NewRequest("POST", "/", nil), jb, nil, map[string]interface{}{}, []string{"daily"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	if err = db.Model(&QorJobTag{}).Where("qor_job_id = ?", j.ID).Order("name").Pluck("name", &names).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "daily" || names[1] != "import" {
+		t.Fatalf("unexpected tags %v", names)
+	}
+}
+
+func TestLoadJobTags(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, nil)
+	jobs := []*QorJob{{Job: "a", Tags: []string{"nightly", "export"}}, {Job: "b"}, {Job: "c", Tags: []string{"import"}}}
+	for _, j := range jobs {
+		if err = db.Create(j).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err = createJobTags(db, j); err != nil {
+			t.Fatal(err)
+		}
+		j.Tags = nil
+	}
+	if err = b.loadJobTags(jobs); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(jobs[0].Tags, jobs[1].Tags, jobs[2].Tags); got != "[export nightly] [] [import]" {
+		t.Errorf("loaded tags %s", got)
+	}
+}
+
+func TestReportDryRunIsTranslated(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, nil)
+	qorJob := &QorJob{Job: "dry-run", Status: JobStatusRunning}
+	if err = db.Create(qorJob).Error; err != nil {
+		t.Fatal(err)
+	}
+	inst := newTestJobInstance(t, b, qorJob.ID)
+	if err = ReportDryRun(inst, 12); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := getModelQorJobInstance(db, qorJob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Progress != 100 || got.ProgressText != "" {
+		t.Errorf("progress = %d, progress text = %q", got.Progress, got.ProgressText)
+	}
+	if v := progressText(Messages_en_US, got); v != "Dry run: would affect 12 records" {
+		t.Errorf("en progress text = %q", v)
+	}
+	if v := progressText(Messages_zh_CN, got); v != "试运行：将影响 12 条记录" {
+		t.Errorf("zh progress text = %q", v)
+	}
+}
@@ -0,0 +1,148 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qor/oss"
+	"gorm.io/gorm"
+)
+
+// errNoLogArchive is returned by archiveLogs when neither LogArchiver nor the log storage is set
+var errNoLogArchive = errors.New("no log archive")
+
+// LogStorage keeps the log lines pruned from the database for exceeding MaxLogLines in s, as the log attachment
+// of their job instance, it's downloaded with the lines left in the database by LogDownloads
+func (b *Builder) LogStorage(s oss.StorageInterface) *Builder {
+	b.logStorage = s
+	return b
+}
+
+// LogDownloadPath is the path the LogDownloads handler is mounted at, the full log links are only shown once it's set
+func (b *Builder) LogDownloadPath(v string) *Builder {
+	b.logDownloadPath = v
+	return b
+}
+
+// logAttachmentDir is the folder of the parts of the log attachment of a job instance
+func logAttachmentDir(jobInstanceID uint) string {
+	return fmt.Sprintf("/worker/logs/%d/", jobInstanceID)
+}
+
+// archiveLogs keeps the lines with LogArchiver, or appends them to the log attachment as a new part named after
+// the first line, so the parts are in the order of the lines
+func (b *Builder) archiveLogs(jobInstanceID uint, logs []*QorJobLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if LogArchiver != nil {
+		return LogArchiver(jobInstanceID, logs)
+	}
+	if b.logStorage == nil {
+		return errNoLogArchive
+	}
+	var sb strings.Builder
+	for _, l := range logs {
+		sb.WriteString(l.Log)
+		sb.WriteString("\n")
+	}
+	_, err := b.logStorage.Put(fmt.Sprintf("%s%020d.log", logAttachmentDir(jobInstanceID), logs[0].ID), strings.NewReader(sb.String()))
+	return err
+}
+
+// logAttachmentParts lists the parts of the log attachment of a job instance in the order of their lines
+func (b *Builder) logAttachmentParts(jobInstanceID uint) ([]*oss.Object, error) {
+	if b.logStorage == nil {
+		return nil, nil
+	}
+	objs, err := b.logStorage.List(logAttachmentDir(jobInstanceID))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Name < objs[j].Name })
+	return objs, nil
+}
+
+// deleteLogAttachment deletes the log attachment of a job instance
+func (b *Builder) deleteLogAttachment(jobInstanceID uint) error {
+	objs, err := b.logAttachmentParts(jobInstanceID)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := b.logStorage.Delete(obj.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFullLog writes the log attachment of a job instance followed by its lines in the database
+func (b *Builder) writeFullLog(w io.Writer, jobInstanceID uint) error {
+	objs, err := b.logAttachmentParts(jobInstanceID)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		rc, err := b.logStorage.GetStream(obj.Path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	var logs []*QorJobLog
+	return b.db.Where("qor_job_instance_id = ?", jobInstanceID).Order("created_at, id").
+		FindInBatches(&logs, 1000, func(tx *gorm.DB, batch int) error {
+			for _, l := range logs {
+				if _, err := io.WriteString(w, l.Log+"\n"); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// LogDownloads returns the handler of the full log links of the jobs, mount it at the LogDownloadPath
+func (b *Builder) LogDownloads() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qorJobID, err := strconv.ParseUint(r.FormValue("jobID"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		inst, err := getModelQorJobInstance(b.db, uint(qorJobID))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err = viewIsAllowed(r, inst.Job); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d.log"`, inst.Job, qorJobID))
+		if err = b.writeFullLog(w, inst.ID); err != nil {
+			// the headers are sent already
+			fmt.Fprintf(w, "\n%s\n", err)
+		}
+	})
+}
+
+// logDownloadURL returns the full log link of a job, "" without a LogDownloadPath
+func (b *Builder) logDownloadURL(qorJobID uint) string {
+	if b.logDownloadPath == "" {
+		return ""
+	}
+	return b.logDownloadPath + "?" + url.Values{"jobID": {fmt.Sprint(qorJobID)}}.Encode()
+}
@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"log"
+	"time"
+)
+
+var (
+	// LogFlushInterval is how often the log lines of a running job are written to the database
+	LogFlushInterval = 2 * time.Second
+	// LogFlushLines writes the log lines of a running job earlier when this many are waiting
+	LogFlushLines = 500
+	// MaxLogLines is how many of the latest log lines of a job instance are kept in the database, 0 keeps all
+	MaxLogLines = 10000
+	// LogArchiver receives the oldest log lines before they are deleted for exceeding MaxLogLines,
+	// to keep the full log elsewhere instead of the log attachment of Builder.LogStorage.
+	// The lines are kept in the database if it fails, and passed to it again by the next flush.
+	LogArchiver func(jobInstanceID uint, logs []*QorJobLog) error
+)
+
+// bufferLog adds the line to the lines waiting to be written,
+// they are written at once when the job isn't running, as nothing would flush them later
+func (job *QorJobInstance) bufferLog(l *QorJobLog) error {
+	job.mutex.Lock()
+	running := job.inRefresh && !job.stopRefresh
+	job.progressUpdated()
+	job.mutex.Unlock()
+
+	job.logMutex.Lock()
+	defer job.logMutex.Unlock()
+	job.logBuf = append(job.logBuf, l)
+	if running && len(job.logBuf) < LogFlushLines && time.Since(job.lastLogFlush) < LogFlushInterval {
+		return nil
+	}
+	return job.flushLogsLocked()
+}
+
+func (job *QorJobInstance) flushLogs() {
+	job.logMutex.Lock()
+	defer job.logMutex.Unlock()
+	if err := job.flushLogsLocked(); err != nil {
+		log.Println(err)
+	}
+}
+
+func (job *QorJobInstance) flushLogsLocked() error {
+	job.lastLogFlush = time.Now()
+	if len(job.logBuf) == 0 {
+		return nil
+	}
+	db := job.jb.b.db
+	if err := db.CreateInBatches(job.logBuf, 500).Error; err != nil {
+		return err
+	}
+	job.logCount += int64(len(job.logBuf))
+	job.logBuf = nil
+	return job.pruneLogs()
+}
+
+// pruneLogs archives and then deletes the oldest lines exceeding MaxLogLines, so the latest ones are always kept.
+// The lines are kept in the database until they are archived.
+func (job *QorJobInstance) pruneLogs() error {
+	if MaxLogLines <= 0 {
+		return nil
+	}
+	db := job.jb.b.db
+	if !job.logCounted {
+		if err := db.Model(&QorJobLog{}).Where("qor_job_instance_id = ?", job.ID).Count(&job.logCount).Error; err != nil {
+			return err
+		}
+		job.logCounted = true
+	}
+	if job.logCount <= int64(MaxLogLines) {
+		return nil
+	}
+
+	var logs []*QorJobLog
+	err := db.Where("qor_job_instance_id = ?", job.ID).
+		Order("created_at, id").
+		Limit(int(job.logCount) - MaxLogLines).
+		Find(&logs).
+		Error
+	if err != nil {
+		return err
+	}
+	if err = job.jb.b.archiveLogs(job.ID, logs); err != nil {
+		if err == errNoLogArchive {
+			return nil
+		}
+		return err
+	}
+	ids := make([]uint, 0, len(logs))
+	for _, l := range logs {
+		ids = append(ids, l.ID)
+	}
+	res := db.Where("id IN ?", ids).Delete(&QorJobLog{})
+	job.logCount -= res.RowsAffected
+	return res.Error
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/qor/oss/filesystem"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPruneLogsArchivesBeforeDeleting(t *testing.T) {
+	defer func(v int) { MaxLogLines = v }(MaxLogLines)
+	MaxLogLines = 3
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, nil)
+	qorJob := &QorJob{Job: "logs", Status: JobStatusDone}
+	if err = db.Create(qorJob).Error; err != nil {
+		t.Fatal(err)
+	}
+	inst := newTestJobInstance(t, b, qorJob.ID)
+	inst.Status = JobStatusDone
+	dbLines := func() (n int64) {
+		db.Model(&QorJobLog{}).Where("qor_job_instance_id = ?", inst.ID).Count(&n)
+		return
+	}
+
+	// without an archive the lines are kept
+	for i := 1; i <= 5; i++ {
+		if err = inst.AddLog(fmt.Sprint("line ", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := dbLines(); n != 5 {
+		t.Fatalf("%d lines in the database, the lines aren't archived and should be kept", n)
+	}
+
+	// a failed archive keeps the lines too
+	defer func(v func(uint, []*QorJobLog) error) { LogArchiver = v }(LogArchiver)
+	LogArchiver = func(uint, []*QorJobLog) error { return errors.New("archive failed") }
+	if err = inst.AddLog("line 6"); err == nil {
+		t.Error("the archive error is not returned")
+	}
+	if n := dbLines(); n != 6 {
+		t.Fatalf("%d lines in the database, the lines failed to be archived should be kept", n)
+	}
+	LogArchiver = nil
+
+	b.LogStorage(filesystem.New(t.TempDir()))
+	for i := 7; i <= 8; i++ {
+		if err = inst.AddLog(fmt.Sprint("line ", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := dbLines(); n != int64(MaxLogLines) || inst.logCount != n {
+		t.Fatalf("%d lines in the database, %d counted, want %d", n, inst.logCount, MaxLogLines)
+	}
+
+	var full bytes.Buffer
+	if err = b.writeFullLog(&full, inst.ID); err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := 1; i <= 8; i++ {
+		want = append(want, fmt.Sprint("line ", i))
+	}
+	if got := strings.TrimSpace(full.String()); got != strings.Join(want, "\n") {
+		t.Errorf("full log = %q", got)
+	}
+
+	if err = b.deleteLogAttachment(inst.ID); err != nil {
+		t.Fatal(err)
+	}
+	if parts, _ := b.logAttachmentParts(inst.ID); len(parts) != 0 {
+		t.Errorf("%d parts of the log attachment are left", len(parts))
+	}
+}
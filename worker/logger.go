@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// LogOutput receives the JSON lines of the job loggers, set it to nil to only write the job log
+var LogOutput io.Writer = os.Stdout
+
+var logOutputMutex sync.Mutex
+
+// JobLogger writes readable lines to the job log shown in the admin,
+// and JSON lines tagged with the job ID as correlation ID to LogOutput
+type JobLogger struct {
+	job     QorJobInterface
+	jobID   string
+	jobName string
+	fields  []interface{}
+}
+
+// Logger return a structured logger of the job, to be used in job handlers
+func Logger(job QorJobInterface) *JobLogger {
+	l := &JobLogger{job: job}
+	if info, err := job.GetJobInfo(); err == nil {
+		l.jobID = info.JobID
+		l.jobName = info.JobName
+	}
+	return l
+}
+
+// With return a logger adding the key/value pairs to every line
+func (l *JobLogger) With(keyvals ...interface{}) *JobLogger {
+	nl := *l
+	nl.fields = append(append([]interface{}{}, l.fields...), keyvals...)
+	return &nl
+}
+
+func (l *JobLogger) Info(msg string, keyvals ...interface{}) error {
+	return l.log(LogLevelInfo, msg, keyvals)
+}
+
+func (l *JobLogger) Warn(msg string, keyvals ...interface{}) error {
+	return l.log(LogLevelWarn, msg, keyvals)
+}
+
+func (l *JobLogger) Error(msg string, keyvals ...interface{}) error {
+	return l.log(LogLevelError, msg, keyvals)
+}
+
+func (l *JobLogger) log(level string, msg string, keyvals []interface{}) error {
+	keyvals = append(append([]interface{}{}, l.fields...), keyvals...)
+
+	if LogOutput != nil {
+		entry := map[string]interface{}{
+			"time":   time.Now().Format(time.RFC3339Nano),
+			"level":  level,
+			"job_id": l.jobID,
+			"job":    l.jobName,
+			"msg":    msg,
+		}
+		for _, kv := range pairs(keyvals) {
+			k := kv.key
+			if _, ok := entry[k]; ok {
+				k = "field." + k
+			}
+			entry[k] = kv.value
+		}
+		if line, err := json.Marshal(entry); err == nil {
+			logOutputMutex.Lock()
+			LogOutput.Write(append(line, '\n'))
+			logOutputMutex.Unlock()
+		}
+	}
+
+	return l.job.AddLog(formatLogLine(level, msg, keyvals))
+}
+
+// formatLogLine formats the readable line of the job log, e.g. [WARN] skipped row=3 reason="no sku"
+func formatLogLine(level string, msg string, keyvals []interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", strings.ToUpper(level), msg)
+	for _, kv := range pairs(keyvals) {
+		v := fmt.Sprint(kv.value)
+		if strings.ContainsAny(v, " \t\n\"=") || v == "" {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %s=%s", kv.key, v)
+	}
+	return b.String()
+}
+
+type logField struct {
+	key   string
+	value interface{}
+}
+
+// pairs groups the key/values, a missing value of the last key is logged as "!MISSING"
+func pairs(keyvals []interface{}) (r []logField) {
+	for i := 0; i < len(keyvals); i += 2 {
+		f := logField{key: fmt.Sprint(keyvals[i]), value: "!MISSING"}
+		if i+1 < len(keyvals) {
+			f.value = keyvals[i+1]
+		}
+		if err, ok := f.value.(error); ok {
+			f.value = err.Error()
+		}
+		r = append(r, f)
+	}
+	return
+}
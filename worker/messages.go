@@ -3,91 +3,163 @@ package worker
 import (
 	"net/http"
 
-	"github.com/qor5/x/i18n"
 	"github.com/qor5/admin/presets"
+	"github.com/qor5/x/i18n"
 )
 
 const I18nWorkerKey i18n.ModuleKey = "I18nWorkerKey"
 
 type Messages struct {
-	StatusNew                string
-	StatusScheduled          string
-	StatusRunning            string
-	StatusCancelled          string
-	StatusDone               string
-	StatusException          string
-	StatusKilled             string
-	FilterTabAll             string
-	FilterTabRunning         string
-	FilterTabScheduled       string
-	FilterTabDone            string
-	FilterTabErrors          string
-	ActionCancelJob          string
-	ActionAbortJob           string
-	ActionUpdateJob          string
-	ActionRerunJob           string
-	DetailTitleStatus        string
-	DetailTitleLog           string
-	NoticeJobCannotBeAborted string
-	NoticeJobWontBeExecuted  string
-	ScheduleTime             string
-	DateTimePickerClearText  string
-	DateTimePickerOkText     string
-	PleaseSelectJob          string
+	StatusNew                 string
+	StatusScheduled           string
+	StatusRunning             string
+	StatusCancelled           string
+	StatusDone                string
+	StatusException           string
+	StatusKilled              string
+	StatusTimedOut            string
+	FilterTabAll              string
+	FilterTabRunning          string
+	FilterTabScheduled        string
+	FilterTabDone             string
+	FilterTabErrors           string
+	ActionCancelJob           string
+	ActionAbortJob            string
+	ActionUpdateJob           string
+	ActionRerunJob            string
+	DetailTitleStatus         string
+	DetailTitleLog            string
+	NoticeJobCannotBeAborted  string
+	NoticeJobWasUpdated       string
+	NoticeJobWontBeExecuted   string
+	ScheduleTime              string
+	DateTimePickerClearText   string
+	DateTimePickerOkText      string
+	PleaseSelectJob           string
+	DryRun                    string
+	ActionRerunDryRun         string
+	NoticeDryRun              string
+	DryRunAffected            string
+	ActionCloneJob            string
+	NoticeJobCannotBeCloned   string
+	FilterStatus              string
+	FilterTag                 string
+	LoadHiddenLogs            string
+	DownloadFullLog           string
+	AbortJobConfirmation      string
+	AbortReason               string
+	NoticeAbortReasonRequired string
+	ActionPauseQueue          string
+	ActionResumeQueue         string
+	NoticeQueuePaused         string
+	NoticeQueueResumed        string
+	ETACalculating            string
+	ETARemaining              string
+	ETASeconds                string
+	ETAMinutes                string
+	ETAHoursMinutes           string
 }
 
 var Messages_en_US = &Messages{
-	StatusNew:                "New",
-	StatusScheduled:          "Scheduled",
-	StatusRunning:            "Running",
-	StatusCancelled:          "Cancelled",
-	StatusDone:               "Done",
-	StatusException:          "Exception",
-	StatusKilled:             "Killed",
-	FilterTabAll:             "All Jobs",
-	FilterTabRunning:         "Running",
-	FilterTabScheduled:       "Scheduled",
-	FilterTabDone:            "Done",
-	FilterTabErrors:          "Errors",
-	ActionCancelJob:          "Cancel Job",
-	ActionAbortJob:           "Abort Job",
-	ActionUpdateJob:          "Update Job",
-	ActionRerunJob:           "Rerun Job",
-	DetailTitleStatus:        "Status",
-	DetailTitleLog:           "Log",
-	NoticeJobCannotBeAborted: "This job cannot be aborted/canceled/updated due to its status change",
-	NoticeJobWontBeExecuted:  "This job won't be executed due to code being deleted/modified",
-	ScheduleTime:             "Schedule Time",
-	DateTimePickerClearText:  "Clear",
-	DateTimePickerOkText:     "OK",
-	PleaseSelectJob:          "Please select job",
+	StatusNew:                 "New",
+	StatusScheduled:           "Scheduled",
+	StatusRunning:             "Running",
+	StatusCancelled:           "Cancelled",
+	StatusDone:                "Done",
+	StatusException:           "Exception",
+	StatusKilled:              "Killed",
+	StatusTimedOut:            "Timed Out",
+	FilterTabAll:              "All Jobs",
+	FilterTabRunning:          "Running",
+	FilterTabScheduled:        "Scheduled",
+	FilterTabDone:             "Done",
+	FilterTabErrors:           "Errors",
+	FilterTag:                 "Tag",
+	ActionCancelJob:           "Cancel Job",
+	ActionAbortJob:            "Abort Job",
+	ActionUpdateJob:           "Update Job",
+	ActionRerunJob:            "Rerun Job",
+	DetailTitleStatus:         "Status",
+	DetailTitleLog:            "Log",
+	NoticeJobCannotBeAborted:  "This job cannot be aborted/canceled/updated due to its status change",
+	NoticeJobWasUpdated:       "This job has been updated by someone else, please check the latest arguments and update again",
+	NoticeJobWontBeExecuted:   "This job won't be executed due to code being deleted/modified",
+	ScheduleTime:              "Schedule Time",
+	DateTimePickerClearText:   "Clear",
+	DateTimePickerOkText:      "OK",
+	PleaseSelectJob:           "Please select job",
+	DryRun:                    "Dry Run",
+	ActionRerunDryRun:         "Rerun Dry Run",
+	NoticeDryRun:              "This is a dry run, no changes will be made",
+	DryRunAffected:            "Dry run: would affect {Count} records",
+	ActionCloneJob:            "Clone Job",
+	NoticeJobCannotBeCloned:   "This job cannot be cloned due to code being deleted/modified",
+	FilterStatus:              "Status",
+	LoadHiddenLogs:            "Load hidden logs",
+	DownloadFullLog:           "Download full log",
+	AbortJobConfirmation:      "Are you sure you want to abort this job?",
+	AbortReason:               "Reason",
+	NoticeAbortReasonRequired: "Please enter the reason",
+	ActionPauseQueue:          "Pause Queue",
+	ActionResumeQueue:         "Resume Queue",
+	NoticeQueuePaused:         "The queue is paused, running jobs will finish but new jobs won't start until it's resumed",
+	NoticeQueueResumed:        "The queue is resumed",
+	ETACalculating:            "Calculating time remaining…",
+	ETARemaining:              "About {Time} remaining",
+	ETASeconds:                "{Seconds}s",
+	ETAMinutes:                "{Minutes}m",
+	ETAHoursMinutes:           "{Hours}h{Minutes}m",
 }
 
 var Messages_zh_CN = &Messages{
-	StatusNew:                "新建",
-	StatusScheduled:          "计划",
-	StatusRunning:            "运行中",
-	StatusCancelled:          "取消",
-	StatusDone:               "完成",
-	StatusException:          "错误",
-	StatusKilled:             "中止",
-	FilterTabAll:             "全部",
-	FilterTabRunning:         "运行中",
-	FilterTabScheduled:       "计划",
-	FilterTabDone:            "完成",
-	FilterTabErrors:          "错误",
-	ActionCancelJob:          "取消Job",
-	ActionAbortJob:           "中止Job",
-	ActionUpdateJob:          "更新Job",
-	ActionRerunJob:           "重跑Job",
-	DetailTitleStatus:        "状态",
-	DetailTitleLog:           "日志",
-	NoticeJobCannotBeAborted: "Job状态已经改变，不能被中止/取消/更新",
-	NoticeJobWontBeExecuted:  "Job代码被删除/修改, 这个Job不会被执行",
-	ScheduleTime:             "执行时间",
-	DateTimePickerClearText:  "清空",
-	DateTimePickerOkText:     "确定",
-	PleaseSelectJob:          "请选择Job",
+	StatusNew:                 "新建",
+	StatusScheduled:           "计划",
+	StatusRunning:             "运行中",
+	StatusCancelled:           "取消",
+	StatusDone:                "完成",
+	StatusException:           "错误",
+	StatusKilled:              "中止",
+	StatusTimedOut:            "超时",
+	FilterTabAll:              "全部",
+	FilterTabRunning:          "运行中",
+	FilterTabScheduled:        "计划",
+	FilterTag:                 "标签",
+	FilterTabDone:             "完成",
+	FilterTabErrors:           "错误",
+	ActionCancelJob:           "取消Job",
+	ActionAbortJob:            "中止Job",
+	ActionUpdateJob:           "更新Job",
+	ActionRerunJob:            "重跑Job",
+	DetailTitleStatus:         "状态",
+	DetailTitleLog:            "日志",
+	NoticeJobCannotBeAborted:  "Job状态已经改变，不能被中止/取消/更新",
+	NoticeJobWasUpdated:       "Job已被其他人更新，请确认最新参数后重新更新",
+	NoticeJobWontBeExecuted:   "Job代码被删除/修改, 这个Job不会被执行",
+	ScheduleTime:              "执行时间",
+	DateTimePickerClearText:   "清空",
+	DateTimePickerOkText:      "确定",
+	PleaseSelectJob:           "请选择Job",
+	DryRun:                    "试运行",
+	ActionRerunDryRun:         "重跑试运行",
+	NoticeDryRun:              "这是一次试运行，不会做任何更改",
+	DryRunAffected:            "试运行：将影响 {Count} 条记录",
+	ActionCloneJob:            "克隆Job",
+	NoticeJobCannotBeCloned:   "Job代码被删除/修改, 这个Job不能被克隆",
+	FilterStatus:              "状态",
+	LoadHiddenLogs:            "加载隐藏的日志",
+	DownloadFullLog:           "下载完整日志",
+	AbortJobConfirmation:      "你确定要中止这个Job吗?",
+	AbortReason:               "原因",
+	NoticeAbortReasonRequired: "请输入原因",
+	ActionPauseQueue:          "暂停队列",
+	ActionResumeQueue:         "恢复队列",
+	NoticeQueuePaused:         "队列已暂停，运行中的Job会继续完成，新的Job在恢复前不会开始",
+	NoticeQueueResumed:        "队列已恢复",
+	ETACalculating:            "正在计算剩余时间…",
+	ETARemaining:              "预计剩余 {Time}",
+	ETASeconds:                "{Seconds}秒",
+	ETAMinutes:                "{Minutes}分钟",
+	ETAHoursMinutes:           "{Hours}小时{Minutes}分钟",
 }
 
 func getTStatus(msgr *Messages, status string) string {
@@ -106,6 +178,8 @@ func getTStatus(msgr *Messages, status string) string {
 		return msgr.StatusException
 	case JobStatusKilled:
 		return msgr.StatusKilled
+	case JobStatusTimedOut:
+		return msgr.StatusTimedOut
 	}
 	return status
 }
@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -13,6 +14,8 @@ type QorJob struct {
 	Job    string
 	Status string      `sql:"default:'new'"`
 	Args   interface{} `sql:"-" gorm:"-"`
+	// Tags are saved as QorJobTag when the job is created
+	Tags []string `sql:"-" gorm:"-"`
 }
 
 type QorJobInstance struct {
@@ -29,11 +32,24 @@ type QorJobInstance struct {
 
 	Progress     uint
 	ProgressText string
+	// StartedAt is when the instance started running
+	StartedAt *time.Time
+	// ProgressUpdatedAt is when the running instance last changed its progress, progress text or logs
+	ProgressUpdatedAt *time.Time
+	// DryRunAffected is the number of records reported by ReportDryRun, the job detail shows it in the language of the viewer
+	DryRunAffected *int64
 
 	jb          *JobBuilder `sql:"-"`
 	mutex       sync.Mutex  `sql:"-"`
 	stopRefresh bool        `sql:"-"`
 	inRefresh   bool        `sql:"-"`
+
+	logMutex     sync.Mutex   `sql:"-"`
+	logBuf       []*QorJobLog `sql:"-"`
+	lastLogFlush time.Time    `sql:"-"`
+	// logCount is the count of the log lines in the database once logCounted, kept by the flushes
+	logCount   int64 `sql:"-"`
+	logCounted bool  `sql:"-"`
 }
 
 type QorJobLog struct {
@@ -68,6 +84,67 @@ func (schedule *Schedule) SetScheduleTime(t *time.Time) {
 	schedule.ScheduleTime = t
 }
 
+type Prioritizer interface {
+	GetPriority() int
+}
+
+// JobPriority could be embedded as job argument to run the job ahead of the due jobs of lower priority of the same job,
+// jobs of the same priority are run in the order of their run time
+type JobPriority struct {
+	Priority int
+}
+
+func (p *JobPriority) GetPriority() int {
+	return p.Priority
+}
+
+type DryRunner interface {
+	IsDryRun() bool
+}
+
+// DryRunMode could be embedded as job argument, then the job can be run in dry-run mode,
+// the handler should check IsDryRun and report what it would do by ReportDryRun without making changes
+type DryRunMode struct {
+	DryRun bool
+}
+
+func (d *DryRunMode) IsDryRun() bool {
+	return d.DryRun
+}
+
+// IsDryRun reports whether the job is running in dry-run mode
+func IsDryRun(job QorJobInterface) bool {
+	jobInfo, err := job.GetJobInfo()
+	if err != nil {
+		return false
+	}
+	return isDryRunArgs(jobInfo.Argument)
+}
+
+func isDryRunArgs(args interface{}) bool {
+	d, ok := args.(DryRunner)
+	return ok && d.IsDryRun()
+}
+
+// ReportDryRun keeps the number of records the job would affect, the job detail shows it with the DryRunAffected message
+func ReportDryRun(job QorJobInterface, affected int64) error {
+	if err := job.SetProgress(100); err != nil {
+		return err
+	}
+	inst, ok := job.(*QorJobInstance)
+	if !ok {
+		return job.SetProgressText(fmt.Sprint(affected))
+	}
+	return inst.setDryRunAffected(affected)
+}
+
+// QorJobQueueState is the state of the queue shared by all the processes, Paused is set by Pause and Resume
+type QorJobQueueState struct {
+	ID        uint `gorm:"primarykey"`
+	Paused    bool
+	UpdatedAt time.Time
+}
+
 type GoQueError struct {
 	gorm.Model
 	Error string
@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/qor5/admin/presets"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/web"
+	"github.com/qor5/x/i18n"
+	. "github.com/theplant/htmlgo"
+)
+
+// PauseSyncInterval is how often a process checks whether the queue was paused or resumed by another one
+var PauseSyncInterval = 10 * time.Second
+
+// Pause stops the listeners from starting new jobs, the running jobs go on until they finish.
+// The queued jobs stay in the queue, including the scheduled ones that become due, and are run after Resume.
+// The pause is saved in the db, so it's kept across restarts and the other processes pause within PauseSyncInterval.
+func (b *Builder) Pause() error {
+	if err := b.savePaused(true); err != nil {
+		return err
+	}
+	b.pause()
+	return nil
+}
+
+// Resume starts the listeners stopped by Pause again, once the jobs running when it was paused finished
+func (b *Builder) Resume() error {
+	if err := b.savePaused(false); err != nil {
+		return err
+	}
+	b.resume()
+	return nil
+}
+
+func (b *Builder) pause() {
+	b.pauseM.Lock()
+	defer b.pauseM.Unlock()
+	if b.paused {
+		return
+	}
+	b.paused = true
+	if !b.listened || b.stoppingC != nil {
+		return
+	}
+
+	stoppingC := make(chan struct{})
+	b.stoppingC = stoppingC
+	go func() {
+		defer close(stoppingC)
+		if err := b.q.Shutdown(context.Background()); err != nil {
+			b.db.Create(&GoQueError{
+				Error: fmt.Sprintf("worker Pause() error: %s", err.Error()),
+			})
+		}
+	}()
+}
+
+func (b *Builder) resume() {
+	b.pauseM.Lock()
+	defer b.pauseM.Unlock()
+	if !b.paused {
+		return
+	}
+	b.paused = false
+	stoppingC := b.stoppingC
+	if stoppingC == nil {
+		return
+	}
+
+	go func() {
+		<-stoppingC
+		b.pauseM.Lock()
+		defer b.pauseM.Unlock()
+		if b.paused || b.stoppingC != stoppingC {
+			return
+		}
+		b.stoppingC = nil
+		b.listen()
+	}()
+}
+
+// IsPaused reports whether the queue is paused by Pause
+func (b *Builder) IsPaused() bool {
+	b.pauseM.Lock()
+	defer b.pauseM.Unlock()
+	return b.paused
+}
+
+func (b *Builder) loadPaused() (bool, error) {
+	var s QorJobQueueState
+	err := b.db.Where("id = ?", 1).Limit(1).Find(&s).Error
+	return s.Paused, err
+}
+
+func (b *Builder) savePaused(paused bool) error {
+	return b.db.Save(&QorJobQueueState{ID: 1, Paused: paused}).Error
+}
+
+// runPauseSync follows the pauses and resumes of the other processes
+func (b *Builder) runPauseSync() {
+	stopC := make(chan struct{})
+	b.pauseSyncStopC = stopC
+	go func() {
+		ticker := time.NewTicker(PauseSyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopC:
+				return
+			case <-ticker.C:
+				paused, err := b.loadPaused()
+				if err != nil {
+					log.Println(err)
+					continue
+				}
+				if paused && !b.IsPaused() {
+					b.pause()
+				} else if !paused && b.IsPaused() {
+					b.resume()
+				}
+			}
+		}
+	}()
+}
+
+func pauseIsAllowed(r *http.Request) error {
+	return permVerifier.Do(PermPause).WithReq(r).IsAllowed()
+}
+
+func (b *Builder) pauseButton(ctx *web.EventContext) HTMLComponent {
+	if pauseIsAllowed(ctx.R) != nil {
+		return nil
+	}
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
+	label, icon := msgr.ActionPauseQueue, "pause"
+	if b.IsPaused() {
+		label, icon = msgr.ActionResumeQueue, "play_arrow"
+	}
+	return VBtn("").Depressed(true).Class("ml-2").
+		Children(VIcon(icon).Left(true), Text(label)).
+		Attr("@click", web.Plaid().EventFunc("worker_togglePause").Go())
+}
+
+func (b *Builder) eventTogglePause(ctx *web.EventContext) (er web.EventResponse, err error) {
+	if err = pauseIsAllowed(ctx.R); err != nil {
+		return
+	}
+	msgr := i18n.MustGetModuleMessages(ctx.R, I18nWorkerKey, Messages_en_US).(*Messages)
+	if b.IsPaused() {
+		if err = b.Resume(); err != nil {
+			return
+		}
+		presets.ShowMessage(&er, msgr.NoticeQueueResumed, "")
+	} else {
+		if err = b.Pause(); err != nil {
+			return
+		}
+		presets.ShowMessage(&er, msgr.NoticeQueuePaused, "warning")
+	}
+	er.Reload = true
+	return
+}
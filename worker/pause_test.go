@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type listenCountQueue struct {
+	mu        sync.Mutex
+	listening bool
+}
+
+func (q *listenCountQueue) Add(ctx context.Context, job QueJobInterface) error    { return nil }
+func (q *listenCountQueue) Kill(ctx context.Context, job QueJobInterface) error   { return nil }
+func (q *listenCountQueue) Remove(ctx context.Context, job QueJobInterface) error { return nil }
+
+func (q *listenCountQueue) Listen(jobDefs []*QorJobDefinition, getJob func(qorJobID uint) (QueJobInterface, error)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.listening = true
+	return nil
+}
+
+func (q *listenCountQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.listening = false
+	return nil
+}
+
+func (q *listenCountQueue) isListening() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.listening
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPauseIsSharedByTheProcesses(t *testing.T) {
+	defer func(v time.Duration) { PauseSyncInterval = v }(PauseSyncInterval)
+	PauseSyncInterval = 10 * time.Millisecond
+
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q1, q2 := &listenCountQueue{}, &listenCountQueue{}
+	b1, b2 := NewWithQueue(db, q1), NewWithQueue(db, q2)
+	b1.Listen()
+	b2.Listen()
+	defer b1.Shutdown(context.Background())
+	defer b2.Shutdown(context.Background())
+
+	if err = b1.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the other process to pause", func() bool { return b2.IsPaused() && !q2.isListening() })
+
+	// a process started during the pause doesn't listen
+	q3 := &listenCountQueue{}
+	b3 := NewWithQueue(db, q3)
+	b3.Listen()
+	defer b3.Shutdown(context.Background())
+	if !b3.IsPaused() || q3.isListening() {
+		t.Fatal("a restarted process isn't paused")
+	}
+
+	if err = b2.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "all the processes to resume", func() bool {
+		return !b1.IsPaused() && !b3.IsPaused() && q1.isListening() && q2.isListening() && q3.isListening()
+	})
+}
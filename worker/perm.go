@@ -6,9 +6,36 @@ import "net/http"
 // permPolicy.On("*")
 // permPolicy.On("workers:upload_posts")
 const (
+	// PermEdit is to create, abort, update and rerun the jobs of a job name
 	PermEdit = "perm_worker_edit"
+	// PermView is to see the jobs of a job name and their history, PermEdit includes it
+	PermView = "perm_worker_view"
+	// PermPause is to pause and resume the queue, on the "workers" resource
+	PermPause = "perm_worker_pause"
 )
 
 func editIsAllowed(r *http.Request, jobName string) error {
 	return permVerifier.Do(PermEdit).SnakeOn(jobName).WithReq(r).IsAllowed()
 }
+
+func viewIsAllowed(r *http.Request, jobName string) error {
+	if err := permVerifier.Do(PermView).SnakeOn(jobName).WithReq(r).IsAllowed(); err == nil {
+		return nil
+	}
+	return editIsAllowed(r, jobName)
+}
+
+// hiddenJobNames returns the names of the jobs the user isn't permitted to see
+func (b *Builder) hiddenJobNames(r *http.Request) (names []string) {
+	for _, jb := range b.jbs {
+		if viewIsAllowed(r, jb.name) != nil {
+			names = append(names, jb.name)
+		}
+	}
+	return
+}
+
+// anyJobViewable hides the Workers menu item from the users who can't see any job
+func (b *Builder) anyJobViewable(r *http.Request) bool {
+	return len(b.hiddenJobNames(r)) < len(b.jbs)
+}
@@ -1,12 +1,26 @@
 package worker
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/qor5/admin/metrics"
+)
 
 //go:generate moq -pkg mock -out mock/queue.go . Queue
 
+// AbortGracePeriod is how long an aborted job handler has to return after its context is cancelled,
+// the go-que queue logs a handler still running after it and the cron queue kills its process
+var AbortGracePeriod = 10 * time.Second
+
 type QorJobDefinition struct {
 	Name    string
 	Handler JobHandler
+	// Timeout is the max runtime of a job, 0 means no limit
+	Timeout time.Duration
+	// MaxRetries is how many times a job failed with an error other than PermanentError is run again
+	MaxRetries    int
+	RetryInterval time.Duration
 }
 
 type Queue interface {
@@ -16,3 +30,11 @@ type Queue interface {
 	Listen(jobDefs []*QorJobDefinition, getJob func(qorJobID uint) (QueJobInterface, error)) error
 	Shutdown(ctx context.Context) error
 }
+
+func observeJobDuration(job QueJobInterface, start time.Time) {
+	var name string
+	if jobInfo, err := job.GetJobInfo(); err == nil {
+		name = jobInfo.JobName
+	}
+	metrics.ObserveSince("qor5_worker_job_duration_seconds", metrics.Labels{"job": name, "status": job.GetStatus()}, start)
+}
@@ -0,0 +1,143 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const RetentionCleanupJobName = "Cleanup Job History"
+
+// RetentionPolicy defines how long finished jobs are kept, running and scheduled jobs are never pruned
+type RetentionPolicy struct {
+	// MaxAge prunes finished jobs last updated before MaxAge ago, 0 means no limit
+	MaxAge time.Duration
+	// MaxCount keeps the latest MaxCount finished jobs per job name, 0 means no limit
+	MaxCount int
+	// Interval is how often the cleanup runs automatically after Listen, default is 24 hours
+	Interval time.Duration
+}
+
+var finishedJobStatuses = []string{JobStatusDone, JobStatusException, JobStatusKilled, JobStatusTimedOut, JobStatusCancelled}
+
+// Retention sets the retention policy and registers the built-in cleanup job
+func (b *Builder) Retention(p RetentionPolicy) *Builder {
+	if p.Interval <= 0 {
+		p.Interval = 24 * time.Hour
+	}
+	if b.retention == nil {
+		b.NewJob(RetentionCleanupJobName).
+			Handler(func(ctx context.Context, job QorJobInterface) error {
+				n, err := b.PruneJobs(ctx)
+				if err != nil {
+					return err
+				}
+				return job.AddLogf("%d jobs pruned", n)
+			})
+	}
+	b.retention = &p
+	return b
+}
+
+// PruneJobs deletes the finished jobs exceeding the retention policy together with their instances and logs,
+// including the log attachments of LogStorage
+func (b *Builder) PruneJobs(ctx context.Context) (n int64, err error) {
+	if b.retention == nil {
+		return 0, nil
+	}
+	db := b.db.WithContext(ctx)
+
+	var ids []uint
+	if b.retention.MaxAge > 0 {
+		err = db.Model(&QorJob{}).
+			Where("status IN ? AND updated_at < ?", finishedJobStatuses, time.Now().Add(-b.retention.MaxAge)).
+			Pluck("id", &ids).
+			Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	if b.retention.MaxCount > 0 {
+		var names []string
+		err = db.Model(&QorJob{}).Distinct("job").Pluck("job", &names).Error
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range names {
+			var nameIDs []uint
+			err = db.Model(&QorJob{}).
+				Where("job = ? AND status IN ?", name, finishedJobStatuses).
+				Order("id desc").
+				Offset(b.retention.MaxCount).
+				Limit(-1).
+				Pluck("id", &nameIDs).
+				Error
+			if err != nil {
+				return 0, err
+			}
+			ids = append(ids, nameIDs...)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var instIDs []uint
+	if b.logStorage != nil {
+		err = db.Unscoped().Model(&QorJobInstance{}).Where("qor_job_id IN ?", ids).Pluck("id", &instIDs).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		instIDs := tx.Unscoped().Model(&QorJobInstance{}).Select("id").Where("qor_job_id IN ?", ids)
+		if err := tx.Where("qor_job_instance_id IN (?)", instIDs).Delete(&QorJobLog{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("qor_job_id IN ?", ids).Delete(&QorJobInstance{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("qor_job_id IN ?", ids).Delete(&QorJobTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("qor_job_id IN ?", ids).Delete(&QorJobCheckpoint{}).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Where("id IN ? AND status IN ?", ids, finishedJobStatuses).Delete(&QorJob{})
+		n = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return n, err
+	}
+	// the log attachments are deleted once their instances are, a failed deletion leaves the rest of the parts
+	for _, id := range instIDs {
+		if err = b.deleteLogAttachment(id); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (b *Builder) runRetention() {
+	if b.retention == nil {
+		return
+	}
+	b.retentionStopC = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(b.retention.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.retentionStopC:
+				return
+			case <-ticker.C:
+				if _, err := b.PruneJobs(context.Background()); err != nil {
+					log.Println(err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qor/oss/filesystem"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPruneJobsDeletesTheLogAttachments(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithQueue(db, nil).
+		LogStorage(filesystem.New(t.TempDir())).
+		Retention(RetentionPolicy{MaxCount: 1})
+
+	var insts []*QorJobInstance
+	for i := 0; i < 2; i++ {
+		qorJob := &QorJob{Job: "logs", Status: JobStatusDone}
+		if err = db.Create(qorJob).Error; err != nil {
+			t.Fatal(err)
+		}
+		inst := newTestJobInstance(t, b, qorJob.ID)
+		if err = b.archiveLogs(inst.ID, []*QorJobLog{{Log: "line"}}); err != nil {
+			t.Fatal(err)
+		}
+		insts = append(insts, inst)
+	}
+
+	n, err := b.PruneJobs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("%d jobs pruned, want 1", n)
+	}
+	if parts, _ := b.logAttachmentParts(insts[0].ID); len(parts) != 0 {
+		t.Errorf("%d parts of the log attachment of the pruned job are left", len(parts))
+	}
+	if parts, _ := b.logAttachmentParts(insts[1].ID); len(parts) != 1 {
+		t.Errorf("the log attachment of the kept job has %d parts, want 1", len(parts))
+	}
+}
@@ -15,4 +15,6 @@ const (
 	JobStatusException = "exception"
 	// JobStatusKilled job status killed
 	JobStatusKilled = "killed"
+	// JobStatusTimedOut job status timed out, the job ran longer than JobBuilder.Timeout
+	JobStatusTimedOut = "timed_out"
 )
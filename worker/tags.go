@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/qor5/admin/presets"
+	. "github.com/qor5/ui/vuetify"
+	"github.com/qor5/ui/vuetifyx"
+	"github.com/qor5/web"
+	. "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+)
+
+// QorJobTag is a tag of a job, like "export" or "nightly", to filter the workers list by
+type QorJobTag struct {
+	ID       uint   `gorm:"primarykey"`
+	QorJobID uint   `gorm:"uniqueIndex:idx_qor_job_tags_job_name"`
+	Name     string `gorm:"uniqueIndex:idx_qor_job_tags_job_name;index"`
+}
+
+// Tags sets the tags of the jobs created by the builder, EnqueueJobWithTags adds more for a job
+func (jb *JobBuilder) Tags(tags ...string) *JobBuilder {
+	jb.tags = tags
+	return jb
+}
+
+// EnqueueJobWithTags is like EnqueueJobWithContext, the job is tagged with the tags of the job builder and tags
+func (b *Builder) EnqueueJobWithTags(ctx context.Context, name string, args interface{}, tags ...string) (jobID uint, err error) {
+	return b.enqueueJob(ctx, name, args, tags)
+}
+
+// normalizeTags trims and dedupes the tags, empty ones are dropped
+func normalizeTags(tags []string) (r []string) {
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		r = append(r, t)
+	}
+	return
+}
+
+func createJobTags(tx *gorm.DB, j *QorJob) error {
+	for _, t := range j.Tags {
+		if err := tx.Create(&QorJobTag{QorJobID: j.ID, Name: t}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declaredTags returns the tags set by JobBuilder.Tags, in the order the jobs are registered
+func (b *Builder) declaredTags() []string {
+	var tags []string
+	for _, jb := range b.jbs {
+		tags = append(tags, jb.tags...)
+	}
+	return normalizeTags(tags)
+}
+
+func (b *Builder) tagFilterItem(label string) *vuetifyx.FilterItem {
+	var names []string
+	b.db.Model(&QorJobTag{}).Distinct("name").Order("name").Pluck("name", &names)
+	var options []*vuetifyx.SelectItem
+	for _, n := range names {
+		options = append(options, &vuetifyx.SelectItem{Text: n, Value: n})
+	}
+	return &vuetifyx.FilterItem{
+		Key:          "tag",
+		Label:        label,
+		ItemType:     vuetifyx.ItemTypeSelect,
+		SQLCondition: `id IN (SELECT qor_job_id FROM qor_job_tags WHERE name %s ?)`,
+		Options:      options,
+	}
+}
+
+func tagFilterTabs(tags []string) (tabs []*presets.FilterTab) {
+	for _, t := range tags {
+		tabs = append(tabs, &presets.FilterTab{
+			Label: t,
+			Query: url.Values{"tag": []string{t}},
+		})
+	}
+	return
+}
+
+// loadJobTags sets the Tags of the jobs of a listing page with one query
+func (b *Builder) loadJobTags(jobs []*QorJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*QorJob, len(jobs))
+	ids := make([]uint, 0, len(jobs))
+	for _, j := range jobs {
+		byID[j.ID] = j
+		ids = append(ids, j.ID)
+	}
+	var tags []*QorJobTag
+	if err := b.db.Where("qor_job_id IN (?)", ids).Order("name").Find(&tags).Error; err != nil {
+		return err
+	}
+	for _, t := range tags {
+		if j := byID[t.QorJobID]; j != nil {
+			j.Tags = append(j.Tags, t.Name)
+		}
+	}
+	return nil
+}
+
+// tagsCell renders the Tags loaded by loadJobTags
+func (b *Builder) tagsCell(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+	var chips []HTMLComponent
+	for _, n := range obj.(*QorJob).Tags {
+		chips = append(chips, VChip(Text(n)).XSmall(true).Class("mr-1"))
+	}
+	return Td(chips...)
+}